@@ -0,0 +1,131 @@
+package bedrock
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strconv"
+
+	"github.com/kzs0/bedrock/attr"
+	"github.com/kzs0/bedrock/metric"
+	"github.com/kzs0/bedrock/trace"
+)
+
+// customSampleRate is the stored trace sample rate while a sampler other than a
+// ratio, such as Config.TraceSampler, is in use.
+var customSampleRate = math.NaN()
+
+// TraceSampleRate returns the fraction of new traces that are sampled. ok is false if
+// Config.TraceSampler is set, as its rate isn't known.
+func (b *Bedrock) TraceSampleRate() (rate float64, ok bool) {
+	rate = math.Float64frombits(b.sampleRate.Load())
+	if math.IsNaN(rate) {
+		return 0, false
+	}
+	return rate, true
+}
+
+// SetTraceSampleRate changes the fraction of new traces that are sampled at runtime,
+// such as to trace every request while debugging an incident, replacing any
+// Config.TraceSampler. The rate is clamped to [0, 1]. Spans with a sampled parent
+// are recorded as before.
+func (b *Bedrock) SetTraceSampleRate(rate float64) {
+	if b.isNoop || math.IsNaN(rate) {
+		return
+	}
+	rate = min(max(rate, 0), 1)
+	b.tracer.SetSampler(rateSampler(rate))
+	b.sampleRate.Store(math.Float64bits(rate))
+}
+
+// rateSampler returns the sampler for a trace sample rate in [0, 1].
+func rateSampler(rate float64) trace.Sampler {
+	switch {
+	case rate >= 1:
+		return trace.AlwaysSampler{}
+	case rate <= 0:
+		return trace.NeverSampler{}
+	}
+	return trace.NewRatioSampler(rate)
+}
+
+// Flush exports the spans that have ended but not yet been exported and refreshes the
+// runtime metrics, without shutting anything down, so the telemetry of an incident can
+// be inspected before the next export or collection interval.
+func (b *Bedrock) Flush(ctx context.Context) error {
+	if b.isNoop {
+		return nil
+	}
+
+	var errs []error
+	if err := b.tracer.Flush(ctx); err != nil {
+		errs = append(errs, err)
+	}
+	if b.batchProcessor != nil {
+		if err := b.batchProcessor.ForceFlush(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if b.runtimeCollector != nil {
+		if err := b.runtimeCollector.Collect(func(metric.MetricFamily) {}); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// configInfoCollector exposes a config_info gauge with a constant value of 1, labeled by
+// the settings that can be changed at runtime: log_level and trace_sample_rate. Only the
+// current settings are exposed, so a change shows as a new series replacing the old one.
+type configInfoCollector struct {
+	b    *Bedrock
+	desc metric.Desc
+}
+
+// newConfigInfoCollector creates the config_info collector for b.
+func newConfigInfoCollector(b *Bedrock) *configInfoCollector {
+	name := "config_info"
+	if b.config.MetricPrefix != "" {
+		name = b.config.MetricPrefix + "_" + name
+	}
+	return &configInfoCollector{
+		b: b,
+		desc: metric.Desc{
+			Name: name,
+			Help: "Runtime configuration of the observability settings",
+			Type: metric.TypeGauge,
+		},
+	}
+}
+
+// Describe reports the config_info metric family.
+func (c *configInfoCollector) Describe(describe func(metric.Desc)) {
+	describe(c.desc)
+}
+
+// Collect emits config_info with the current settings.
+func (c *configInfoCollector) Collect(emit func(metric.MetricFamily)) error {
+	sampleRate := "custom"
+	if rate, ok := c.b.TraceSampleRate(); ok {
+		sampleRate = strconv.FormatFloat(rate, 'g', -1, 64)
+	}
+
+	// Collected families aren't sanitized by the registry, so the static keys are here
+	_, static := c.b.staticLabels()
+	labels := make([]attr.Attr, 0, len(static)+2)
+	for _, label := range static {
+		labels = append(labels, label.WithKey(metric.SanitizeLabelName(label.Key)))
+	}
+	labels = append(labels,
+		attr.String("log_level", c.b.LogLevel().String()),
+		attr.String("trace_sample_rate", sampleRate),
+	)
+
+	emit(metric.MetricFamily{
+		Name:    c.desc.Name,
+		Help:    c.desc.Help,
+		Type:    c.desc.Type,
+		Metrics: []metric.Metric{{Labels: attr.NewSet(labels...), Value: 1}},
+	})
+	return nil
+}
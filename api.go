@@ -2,10 +2,17 @@ package bedrock
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"os"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/kzs0/bedrock/attr"
+	blog "github.com/kzs0/bedrock/log"
 	"github.com/kzs0/bedrock/metric"
 	"github.com/kzs0/bedrock/server"
 	"github.com/kzs0/bedrock/trace"
@@ -18,85 +25,160 @@ type Op struct {
 
 // Src is a handle to a source.
 type Src struct {
-	bedrock *Bedrock
-	name    string
-	config  *sourceConfig
+	bedrock   *Bedrock
+	name      string
+	config    *sourceConfig
+	aggLabels []string // aggregate label names, filtered by the label allowlist/denylist
+	startTime time.Time
+	span      *trace.Span      // covers the source's lifetime, if traced
+	up        *GaugeWithStatic // <name>_up, set to 1 at start and 0 at Done
+
+	mu         sync.Mutex
+	aggregates map[string]*sourceAggregate // lifetime totals by aggregate key
+	doneOnce   sync.Once
+}
+
+// sourceAggregate accumulates an aggregate over a source's lifetime.
+type sourceAggregate struct {
+	kind  string // "sum", "gauge", or "histogram"
+	value float64
+	count int
+}
+
+// record accumulates v.
+func (a *sourceAggregate) record(v float64) {
+	a.count++
+	if a.kind == "gauge" {
+		a.value = v
+	} else {
+		a.value += v
+	}
+}
+
+// summary returns the aggregate's lifetime value for the final log line.
+func (a *sourceAggregate) summary() any {
+	if a.kind == "histogram" {
+		return map[string]any{"count": a.count, "sum": a.value}
+	}
+	return a.value
 }
 
 // CounterWithStatic wraps a metric.Counter and automatically includes static labels.
 type CounterWithStatic struct {
-	counter      *metric.Counter
-	staticLabels []attr.Attr
+	counter *metric.Counter
+	b       *Bedrock
+
+	// static is the series with only the current static labels, resolved on use
+	static atomic.Pointer[staticSeries[*metric.CounterVec]]
 }
 
 // With returns a CounterVec with the given label values plus static labels.
 func (c *CounterWithStatic) With(labels ...attr.Attr) *metric.CounterVec {
-	allLabels := append(c.staticLabels, labels...)
-	return c.counter.With(allLabels...)
+	_, static := c.b.staticLabels()
+	return c.counter.With(append(static, labels...)...)
+}
+
+// staticVec returns the cached series with only the current static labels.
+func (c *CounterWithStatic) staticVec() *metric.CounterVec {
+	return currentSeries(c.b, &c.static, c.counter.With)
 }
 
 // Inc increments the counter by 1 with static labels.
 func (c *CounterWithStatic) Inc() {
-	c.counter.With(c.staticLabels...).Inc()
+	c.staticVec().Inc()
 }
 
 // Add adds the given value to the counter with static labels.
 func (c *CounterWithStatic) Add(v float64) {
-	c.counter.With(c.staticLabels...).Add(v)
+	c.staticVec().Add(v)
 }
 
 // GaugeWithStatic wraps a metric.Gauge and automatically includes static labels.
 type GaugeWithStatic struct {
-	gauge        *metric.Gauge
-	staticLabels []attr.Attr
+	gauge *metric.Gauge
+	b     *Bedrock
+
+	// static is the series with only the current static labels, resolved on use
+	static atomic.Pointer[staticSeries[*metric.GaugeVec]]
 }
 
 // With returns a GaugeVec with the given label values plus static labels.
 func (g *GaugeWithStatic) With(labels ...attr.Attr) *metric.GaugeVec {
-	allLabels := append(g.staticLabels, labels...)
-	return g.gauge.With(allLabels...)
+	_, static := g.b.staticLabels()
+	return g.gauge.With(append(static, labels...)...)
+}
+
+// staticVec returns the cached series with only the current static labels.
+func (g *GaugeWithStatic) staticVec() *metric.GaugeVec {
+	return currentSeries(g.b, &g.static, g.gauge.With)
 }
 
 // Set sets the gauge to the given value with static labels.
 func (g *GaugeWithStatic) Set(v float64) {
-	g.gauge.With(g.staticLabels...).Set(v)
+	g.staticVec().Set(v)
 }
 
 // Inc increments the gauge by 1 with static labels.
 func (g *GaugeWithStatic) Inc() {
-	g.gauge.With(g.staticLabels...).Inc()
+	g.staticVec().Inc()
 }
 
 // Dec decrements the gauge by 1 with static labels.
 func (g *GaugeWithStatic) Dec() {
-	g.gauge.With(g.staticLabels...).Dec()
+	g.staticVec().Dec()
 }
 
 // Add adds the given value to the gauge with static labels.
 func (g *GaugeWithStatic) Add(v float64) {
-	g.gauge.With(g.staticLabels...).Add(v)
+	g.staticVec().Add(v)
 }
 
 // Sub subtracts the given value from the gauge with static labels.
 func (g *GaugeWithStatic) Sub(v float64) {
-	g.gauge.With(g.staticLabels...).Sub(v)
+	g.staticVec().Sub(v)
 }
 
 // HistogramWithStatic wraps a metric.Histogram and automatically includes static labels.
 type HistogramWithStatic struct {
-	histogram    *metric.Histogram
-	staticLabels []attr.Attr
+	histogram *metric.Histogram
+	b         *Bedrock
+
+	// static is the series with only the current static labels, resolved on use
+	static atomic.Pointer[staticSeries[*metric.HistogramVec]]
 }
 
 // With returns a HistogramVec with the given label values plus static labels.
 func (h *HistogramWithStatic) With(labels ...attr.Attr) *metric.HistogramVec {
-	allLabels := append(h.staticLabels, labels...)
-	return h.histogram.With(allLabels...)
+	_, static := h.b.staticLabels()
+	return h.histogram.With(append(static, labels...)...)
+}
+
+// staticVec returns the cached series with only the current static labels.
+func (h *HistogramWithStatic) staticVec() *metric.HistogramVec {
+	return currentSeries(h.b, &h.static, h.histogram.With)
 }
 
 // Observe records an observation with static labels.
 func (h *HistogramWithStatic) Observe(v float64) {
-	h.histogram.With(h.staticLabels...).Observe(v)
+	h.staticVec().Observe(v)
+}
+
+// staticSeries is a metric series with the static labels of a staticState.
+type staticSeries[V any] struct {
+	state *staticState
+	vec   V
+}
+
+// currentSeries returns the series in cache if it has b's current static labels,
+// or resolves and caches it with with.
+func currentSeries[V any](b *Bedrock, cache *atomic.Pointer[staticSeries[V]], with func(...attr.Attr) V) V {
+	state := b.static.Load()
+	if series := cache.Load(); series != nil && series.state == state {
+		return series.vec
+	}
+	vec := with(state.labels...)
+	cache.Store(&staticSeries[V]{state: state, vec: vec})
+	return vec
 }
 
 // Init initializes bedrock in the context and returns a context with bedrock attached
@@ -133,6 +215,12 @@ func Init(ctx context.Context, opts ...InitOption) (context.Context, func()) {
 	var obsServer *server.Server
 	if cfg.config.ServerEnabled {
 		serverCfg := cfg.config.serverConfig()
+		serverCfg.NamedMetrics = b.lookupNamedMetrics
+		serverCfg.LogLevel = b.logLevel
+		serverCfg.Admin = b
+		serverCfg.Readiness = b.readiness
+		serverCfg.Liveness = b.liveness
+		serverCfg.Version = b.versionInfo()
 		obsServer = server.New(b.metrics, serverCfg)
 		go func() {
 			if err := obsServer.ListenAndServe(); err != nil {
@@ -144,23 +232,47 @@ func Init(ctx context.Context, opts ...InitOption) (context.Context, func()) {
 		}()
 	}
 
+	var cleanupOnce sync.Once
 	cleanup := func() {
-		shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.config.ShutdownTimeout)
-		defer cancel()
+		cleanupOnce.Do(func() { shutdown(b, obsServer, cfg.config.ShutdownTimeout) })
+	}
+	b.cleanup = cleanup
 
-		// Shutdown obs server first if it exists
-		if obsServer != nil {
-			if err := obsServer.Shutdown(shutdownCtx); err != nil {
-				b.logger.Error("failed to shutdown observability server", slog.Any("error", err))
-			}
-		}
+	return ctx, cleanup
+}
+
+// versionInfo returns the information served at /version: the build information of the
+// binary, with the version and revision static attributes, if set, taking precedence so
+// /version reports the version telemetry is labeled with.
+func (b *Bedrock) versionInfo() *server.VersionInfo {
+	info := server.NewVersionInfo(b.config.Service)
+	info.StartTime = b.startTime
 
-		if err := b.Shutdown(shutdownCtx); err != nil {
-			b.logger.Error("failed to shutdown bedrock", slog.Any("error", err))
+	static := b.StaticAttrs()
+	if v, ok := static.Get("version"); ok && v.String() != "" {
+		info.Version = v.String()
+	}
+	if v, ok := static.Get("revision"); ok && v.String() != "" {
+		info.Revision = v.String()
+	}
+	return &info
+}
+
+// shutdown stops the observability server, if any, then flushes and shuts down bedrock.
+func shutdown(b *Bedrock, obsServer *server.Server, timeout time.Duration) {
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	// Shutdown obs server first if it exists
+	if obsServer != nil {
+		if err := obsServer.Shutdown(shutdownCtx); err != nil {
+			b.logger.Error("failed to shutdown observability server", slog.Any("error", err))
 		}
 	}
 
-	return ctx, cleanup
+	if err := b.Shutdown(shutdownCtx); err != nil {
+		b.logger.Error("failed to shutdown bedrock", slog.Any("error", err))
+	}
 }
 
 // Operation starts a new operation and returns the operation handle and updated context.
@@ -182,15 +294,13 @@ func Operation(ctx context.Context, name string, opts ...OperationOption) (*Op,
 	// Check for parent operation
 	parent := operationStateFromContext(ctx)
 
-	// Check for source config and merge attributes/labels if present
+	// Check for source config and merge attributes/labels if present.
+	// Sets are immutable, so an operation without attributes of its own shares its
+	// source's set, and the span shares the operation's.
+	attrs := attr.EmptySet
 	if source := sourceConfigFromContext(ctx); source != nil {
 		// Merge source attributes
-		sourceAttrs := make([]attr.Attr, 0)
-		source.attrs.Range(func(a attr.Attr) bool {
-			sourceAttrs = append(sourceAttrs, a)
-			return true
-		})
-		cfg.attrs = append(sourceAttrs, cfg.attrs...)
+		attrs = source.attrs
 
 		// Use source metric labels if operation doesn't define any
 		if len(cfg.metricLabels) == 0 {
@@ -198,8 +308,10 @@ func Operation(ctx context.Context, name string, opts ...OperationOption) (*Op,
 		}
 
 		// Prefix operation name with source name
-		cfg.name = source.name + "." + name
+		cfg.name = source.operationName(name)
 	}
+	// Request-scoped values override source attributes; the operation's own override both
+	attrs = attrs.MergeSet(valuesFromContext(ctx)).Merge(cfg.attrs...)
 
 	// Inherit no-trace mode from context or check if explicitly set
 	noTrace := cfg.noTrace || isNoTrace(ctx)
@@ -220,28 +332,85 @@ func Operation(ctx context.Context, name string, opts ...OperationOption) (*Op,
 		}
 
 		// Build span options
-		spanOpts := []trace.StartSpanOption{trace.WithAttrs(cfg.attrs...)}
+		spanOpts := []trace.StartSpanOption{trace.WithAttrSet(attrs)}
 
 		// Add remote parent if provided (from W3C Trace Context)
 		if cfg.remoteParent != nil && cfg.remoteParent.IsValid() {
 			spanOpts = append(spanOpts, trace.WithRemoteParent(*cfg.remoteParent))
 		}
 
+		if cfg.traceSampler != nil {
+			spanOpts = append(spanOpts, trace.WithSampler(cfg.traceSampler))
+		}
+
+		// Keep the span of an operation with an SLO, so it can be sampled if it breaches
+		if cfg.slo > 0 && b.config.OperationSLOSampleBreaches {
+			spanOpts = append(spanOpts, trace.WithRecordUnsampled())
+		}
+
 		newCtx, span = b.tracer.Start(parentCtx, cfg.name, spanOpts...)
 	}
 
 	// Create operation state
-	state := newOperationState(b, span, cfg.name, cfg, parent)
+	state := newOperationState(ctx, b, span, cfg.name, attrs, cfg, parent)
 
 	// Store operation state in context
 	newCtx = withOperationState(newCtx, state)
+	op := &Op{state: state}
+
+	for _, intercept := range b.config.OperationInterceptors {
+		if done := intercept(newCtx, op); done != nil {
+			state.onDone = append(state.onDone, done)
+		}
+	}
 
 	// Return operation handle
-	return &Op{state: state}, newCtx
+	return op, newCtx
+}
+
+// OperationFromContext returns the innermost operation in ctx, so code deep in the call
+// stack can register attributes on it without being passed the *Op. It returns false
+// if ctx has no operation.
+//
+// Usage:
+//
+//	if op, ok := bedrock.OperationFromContext(ctx); ok {
+//	    op.Register(ctx, attr.Int("cache.hits", hits))
+//	}
+func OperationFromContext(ctx context.Context) (*Op, bool) {
+	state := operationStateFromContext(ctx)
+	if state == nil {
+		return nil, false
+	}
+	return &Op{state: state}, true
+}
+
+// TraceID returns the hex-encoded ID of the current trace in ctx, or "" if ctx has no
+// span, such as outside an operation or within a NoTrace one. Use it to give users
+// a reference to the trace, such as in error responses or support tickets.
+//
+// Usage:
+//
+//	http.Error(w, "internal error (ref "+bedrock.TraceID(ctx)+")", http.StatusInternalServerError)
+func TraceID(ctx context.Context) string {
+	if span := trace.SpanFromContext(ctx); span != nil {
+		return span.TraceID().String()
+	}
+	return ""
+}
+
+// SpanID returns the hex-encoded ID of the current span in ctx, or "" if ctx has no span.
+func SpanID(ctx context.Context) string {
+	if span := trace.SpanFromContext(ctx); span != nil {
+		return span.SpanID().String()
+	}
+	return ""
 }
 
 // Source registers a source in the context and returns the source handle.
 // Sources are for long-running processes that spawn operations.
+// The source sets a <name>_up gauge to 1 and starts a span covering its lifetime;
+// both end with Done.
 //
 // Usage:
 //
@@ -255,11 +424,27 @@ func Source(ctx context.Context, name string, opts ...SourceOption) (*Src, conte
 
 	b := bedrockFromContext(ctx)
 
-	return &Src{
-		bedrock: b,
-		name:    name,
-		config:  &cfg,
-	}, ctx
+	src := &Src{
+		bedrock:    b,
+		name:       name,
+		config:     &cfg,
+		aggLabels:  b.config.filterMetricLabels(cfg.aggLabels),
+		startTime:  time.Now(),
+		aggregates: make(map[string]*sourceAggregate),
+	}
+	if b.isNoop {
+		return src, ctx
+	}
+
+	src.up = b.gauge(name+"_up", "Whether "+name+" is running")
+	src.up.Set(1)
+
+	// The span isn't put in ctx: operations from a long-lived source keep their own traces
+	if !isNoTrace(ctx) {
+		_, src.span = b.tracer.Start(ctx, name, trace.WithAttrSet(cfg.attrs))
+	}
+
+	return src, ctx
 }
 
 // Step creates a lightweight step within an operation for tracing without full operation metrics.
@@ -299,10 +484,8 @@ func (op *Op) Register(ctx context.Context, items ...attr.Registrable) {
 		case attr.Attr:
 			attrs = append(attrs, v)
 		case attr.Event:
-			// Register as trace event
-			if op.state.span != nil {
-				op.state.span.AddEvent(v.Name, v.Attrs...)
-			}
+			// Register as trace event, also kept for the canonical log
+			op.state.addEvent(v.Name, v.Attrs...)
 		}
 	}
 
@@ -311,11 +494,278 @@ func (op *Op) Register(ctx context.Context, items ...attr.Registrable) {
 	}
 }
 
+// Fail marks the operation as failed with err, without registering it as an attribute.
+// The span records err as an exception with an error status, and the canonical log line
+// gets the original error value. A nil err marks the operation as failed with no error.
+//
+// Usage:
+//
+//	if err != nil {
+//	    op.Fail(err)
+//	    return err
+//	}
+func (op *Op) Fail(err error) {
+	if op.state == nil {
+		return
+	}
+	op.state.fail(err)
+}
+
+// Error categories returned by ClassifyError. A custom Config.ErrorClassifier may
+// return these or its own, such as "validation" or "not_found"; it should return
+// only a handful of distinct values, since they become metric label values.
+const (
+	ErrorTypeCanceled = "canceled"
+	ErrorTypeTimeout  = "timeout"
+	ErrorTypeInternal = "internal"
+)
+
+// ClassifyError is the default Config.ErrorClassifier. It returns ErrorTypeCanceled
+// for context.Canceled, ErrorTypeTimeout for context.DeadlineExceeded and errors with
+// a Timeout() bool method that returns true (such as net.Error), and ErrorTypeInternal
+// otherwise.
+//
+// Custom classifiers can wrap it:
+//
+//	cfg.ErrorClassifier = func(err error) string {
+//	    if errors.Is(err, ErrInvalidInput) {
+//	        return "validation"
+//	    }
+//	    return bedrock.ClassifyError(err)
+//	}
+func ClassifyError(err error) string {
+	if errors.Is(err, context.Canceled) {
+		return ErrorTypeCanceled
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ErrorTypeTimeout
+	}
+	var timeout interface{ Timeout() bool }
+	if errors.As(err, &timeout) && timeout.Timeout() {
+		return ErrorTypeTimeout
+	}
+	return ErrorTypeInternal
+}
+
+// Name returns the operation's name, including any source prefix.
+func (op *Op) Name() string {
+	if op.state == nil {
+		return ""
+	}
+	return op.state.name
+}
+
+// Succeeded reports whether the operation is currently successful.
+func (op *Op) Succeeded() bool {
+	if op.state == nil {
+		return true
+	}
+	op.state.mu.Lock()
+	defer op.state.mu.Unlock()
+	return op.state.success
+}
+
+// Succeed marks the operation as successful, overriding an earlier failure,
+// such as one registered before a retry succeeded. The span status is set to OK.
+func (op *Op) Succeed() {
+	if op.state == nil {
+		return
+	}
+	op.state.succeed()
+}
+
 // Done completes the operation and records all automatic metrics.
-func (op *Op) Done() {
+// EndSuccess and EndFailure set the outcome first, as Succeed and Fail do;
+// EndFailure with a nil error leaves it unchanged, so a deferred Done can
+// report a named error result:
+//
+//	func process(ctx context.Context) (err error) {
+//	    op, ctx := bedrock.Operation(ctx, "process")
+//	    defer func() { op.Done(bedrock.EndFailure(err)) }()
+//	    ...
+//	}
+func (op *Op) Done(opts ...EndOption) {
+	if op.state == nil {
+		return
+	}
+
+	op.done(applyEndOptions(opts))
+}
+
+// DoneRecover completes the operation like Done, recovering a panic in the deferring
+// function. It must be deferred directly. A recovered panic fails the operation with
+// its value and stack, recorded on the span, in the failure metrics, and in an
+// Error-level log record. With Repanic, it then panics again with the same value.
+//
+// Usage:
+//
+//	op, ctx := bedrock.Operation(ctx, "job")
+//	defer op.DoneRecover()
+func (op *Op) DoneRecover(opts ...EndOption) {
+	r := recover()
 	if op.state == nil {
+		if r != nil {
+			panic(r)
+		}
+		return
+	}
+
+	cfg := applyEndOptions(opts)
+	if r == nil {
+		op.done(cfg)
 		return
 	}
+
+	op.donePanicked(r, cfg)
+	if cfg.repanic {
+		panic(r)
+	}
+}
+
+// goroutinePanic is a panic recovered on another goroutine and re-panicked on the
+// operation's, with the stack of the goroutine that panicked.
+type goroutinePanic struct {
+	value any
+	stack attr.Stack
+}
+
+// donePanicked completes the operation as failed by the recovered panic value r,
+// logging it with its stack, and returns the failure.
+func (op *Op) donePanicked(r any, cfg endConfig) error {
+	stack := attr.CaptureStack(2)
+	if p, ok := r.(*goroutinePanic); ok {
+		r, stack = p.value, p.stack
+	}
+
+	var err error
+	if e, ok := r.(error); ok {
+		err = fmt.Errorf("panic: %w", e)
+	} else {
+		err = fmt.Errorf("panic: %v", r)
+	}
+	se := attr.NewStackError(err, stack)
+
+	ctx := withOperationState(context.Background(), op.state)
+	if op.state.span != nil {
+		ctx = trace.ContextWithSpan(ctx, op.state.span)
+	}
+	op.state.bedrock.logBridge.Error(ctx, "operation panicked", attr.String("error", err.Error()), attr.String("stack", se.Stack().String()))
+
+	cfg.hasOpts = true
+	cfg.success, cfg.failure = false, se
+	op.done(cfg)
+	return se
+}
+
+// Run runs fn as an operation named name: it starts the operation, passes fn its
+// context, fails the operation with fn's error, if any, and completes it. A panic in fn
+// is recovered as DoneRecover does and returned as an error.
+//
+// Usage:
+//
+//	err := bedrock.Run(ctx, "process_user", func(ctx context.Context) error {
+//	    return process(ctx, user)
+//	}, bedrock.Attrs(attr.String("user_id", user.ID)))
+func Run(ctx context.Context, name string, fn func(context.Context) error, opts ...OperationOption) (err error) {
+	op, ctx := Operation(ctx, name, opts...)
+	defer func() {
+		if r := recover(); r != nil {
+			err = op.donePanicked(r, endConfig{})
+			return
+		}
+		op.Done(EndFailure(err))
+	}()
+
+	return fn(ctx)
+}
+
+// Go runs fn in a new goroutine as an operation named name, a child of the operation
+// in ctx, as Run does. The goroutine's context carries ctx's values, including its
+// bedrock instance and trace, but not its cancellation, so fire-and-forget work can
+// outlive the request that started it. fn's error or panic fails the operation and
+// is otherwise dropped.
+//
+// Usage:
+//
+//	bedrock.Go(ctx, "send_welcome_email", func(ctx context.Context) error {
+//	    return mailer.SendWelcome(ctx, user)
+//	})
+func Go(ctx context.Context, name string, fn func(context.Context) error, opts ...OperationOption) {
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		_ = Run(ctx, name, fn, opts...)
+	}()
+}
+
+// WithTimeout runs fn as an operation named name, as Run does, with a context that
+// times out after d. If fn hasn't returned by then, WithTimeout returns
+// context.DeadlineExceeded without waiting for it; fn's eventual result is dropped.
+// A timed-out operation fails with an "error.type" attribute of "timeout", and every
+// operation gets "timeout.deadline_ms" and "timeout.elapsed_ms" attributes comparing
+// the configured timeout to the actual duration.
+//
+// Usage:
+//
+//	err := bedrock.WithTimeout(ctx, "inventory.lookup", 200*time.Millisecond, func(ctx context.Context) error {
+//	    return inventory.Lookup(ctx, sku)
+//	})
+func WithTimeout(ctx context.Context, name string, d time.Duration, fn func(context.Context) error, opts ...OperationOption) error {
+	return Run(ctx, name, func(ctx context.Context) error {
+		op, _ := OperationFromContext(ctx)
+		ctx, cancel := context.WithTimeout(ctx, d)
+		defer cancel()
+
+		type result struct {
+			err   error
+			panic *goroutinePanic
+		}
+		done := make(chan result, 1)
+		start := time.Now()
+		go func() {
+			defer func() {
+				if r := recover(); r != nil {
+					done <- result{panic: &goroutinePanic{value: r, stack: attr.CaptureStack(0)}}
+				}
+			}()
+			done <- result{err: fn(ctx)}
+		}()
+
+		var err error
+		select {
+		case r := <-done:
+			if r.panic != nil {
+				panic(r.panic)
+			}
+			err = r.err
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+
+		op.Register(ctx,
+			attr.Int64("timeout.deadline_ms", d.Milliseconds()),
+			attr.Int64("timeout.elapsed_ms", time.Since(start).Milliseconds()),
+		)
+		if errors.Is(err, context.DeadlineExceeded) {
+			op.Register(ctx, attr.String("error.type", ErrorTypeTimeout))
+		}
+		return err
+	}, opts...)
+}
+
+// done sets the outcome from cfg, if any, finalizes it, runs the interceptors' done
+// functions, and completes the operation.
+func (op *Op) done(cfg endConfig) {
+	if cfg.hasOpts {
+		if cfg.success {
+			op.state.succeed()
+		} else if cfg.failure != nil {
+			op.state.fail(cfg.failure)
+		}
+	}
+	op.state.finalize()
+	for i := len(op.state.onDone) - 1; i >= 0; i-- {
+		op.state.onDone[i]()
+	}
 	op.state.end()
 }
 
@@ -330,45 +780,122 @@ func (op *Op) Done() {
 //	    attr.Gauge("queue_depth", 42),
 //	    attr.Histogram("latency_ms", 123.45),
 //	)
+//
+// Aggregations can carry values for labels registered with SourceAggregateLabels,
+// attached with attr.Labeled:
+//
+//	source, ctx := bedrock.Source(ctx, "consumer", bedrock.SourceAggregateLabels("topic"))
+//	source.Aggregate(ctx, attr.Labeled(attr.Sum("messages", 1), attr.String("topic", topic)))
 func (src *Src) Aggregate(ctx context.Context, items ...attr.Aggregation) {
 	if src.bedrock.isNoop {
 		return
 	}
 
 	for _, item := range items {
-		switch v := item.(type) {
-		case attr.SumAttr:
-			// Record as counter
-			counter := Counter(
-				ctx,
-				src.name+"_"+v.Key,
-				"Aggregated "+v.Key+" for "+src.name,
-			)
-			counter.Add(v.Value)
-		case attr.GaugeAttr:
-			// Record as gauge
-			gauge := Gauge(
-				ctx,
-				src.name+"_"+v.Key,
-				"Aggregated "+v.Key+" for "+src.name,
-			)
-			gauge.Set(v.Value)
-		case attr.HistogramAttr:
-			// Record as histogram
-			histogram := Histogram(
-				ctx,
-				src.name+"_"+v.Key,
-				"Aggregated "+v.Key+" for "+src.name,
-				nil, // use default buckets
-			)
-			histogram.Observe(v.Value)
+		src.aggregate(ctx, item, attr.Set{})
+	}
+}
+
+// aggregate records a single aggregation with the given label values.
+func (src *Src) aggregate(ctx context.Context, item attr.Aggregation, labels attr.Set) {
+	switch v := item.(type) {
+	case attr.LabeledAttr:
+		src.aggregate(ctx, v.Aggregation, v.Labels)
+	case attr.SumAttr:
+		src.accumulate("sum", v.Key, v.Value)
+		// Record as counter
+		counter := src.bedrock.counter(
+			src.name+"_"+v.Key,
+			"Aggregated "+v.Key+" for "+src.name,
+			src.aggLabels...,
+		)
+		counter.With(src.aggregateLabels(labels)...).Add(v.Value)
+	case attr.GaugeAttr:
+		src.accumulate("gauge", v.Key, v.Value)
+		// Record as gauge
+		gauge := src.bedrock.gauge(
+			src.name+"_"+v.Key,
+			"Aggregated "+v.Key+" for "+src.name,
+			src.aggLabels...,
+		)
+		gauge.With(src.aggregateLabels(labels)...).Set(v.Value)
+	case attr.HistogramAttr:
+		src.accumulate("histogram", v.Key, v.Value)
+		// Record as histogram
+		histogram := src.bedrock.histogram(
+			src.name+"_"+v.Key,
+			"Aggregated "+v.Key+" for "+src.name,
+			nil, // use default buckets
+			src.aggLabels...,
+		)
+		histogram.With(src.aggregateLabels(labels)...).Observe(v.Value)
+	}
+}
+
+// aggregateLabels returns a value for each registered aggregate label, taken from
+// labels, or "_" if labels has none.
+func (src *Src) aggregateLabels(labels attr.Set) []attr.Attr {
+	if len(src.aggLabels) == 0 {
+		return nil
+	}
+
+	values := make([]attr.Attr, 0, len(src.aggLabels))
+	for _, name := range src.aggLabels {
+		if v, ok := labels.Get(name); ok {
+			values = append(values, attr.Attr{Key: name, Value: v})
+		} else {
+			values = append(values, attr.String(name, "_"))
 		}
 	}
+	return values
+}
+
+// accumulate adds v to the lifetime aggregate for key.
+func (src *Src) accumulate(kind, key string, v float64) {
+	src.mu.Lock()
+	defer src.mu.Unlock()
+
+	agg, ok := src.aggregates[key]
+	if !ok {
+		agg = &sourceAggregate{kind: kind}
+		src.aggregates[key] = agg
+	}
+	agg.record(v)
 }
 
-// Done is a no-op for sources (they don't complete).
+// Done stops the source: it sets the <name>_up gauge to 0, ends the source's span,
+// and logs a final line with the source's uptime and lifetime aggregates.
+// Calls after the first are no-ops.
 func (src *Src) Done() {
-	// Sources don't complete, this is just for API consistency
+	if src.bedrock.isNoop {
+		return
+	}
+
+	src.doneOnce.Do(func() {
+		src.up.Set(0)
+
+		ctx := context.Background()
+		if src.span != nil {
+			src.span.End()
+			ctx = trace.ContextWithSpan(ctx, src.span)
+		}
+
+		src.mu.Lock()
+		aggregates := make(map[string]any, len(src.aggregates))
+		for key, agg := range src.aggregates {
+			aggregates[key] = agg.summary()
+		}
+		src.mu.Unlock()
+
+		logFields := []any{
+			"source", src.name,
+			"uptime_ms", time.Since(src.startTime).Milliseconds(),
+		}
+		if len(aggregates) > 0 {
+			logFields = append(logFields, "aggregates", aggregates)
+		}
+		src.bedrock.logger.Log(ctx, slog.LevelInfo, "source done", logFields...)
+	})
 }
 
 // InitOption configures initialization.
@@ -393,6 +920,19 @@ func WithStaticAttrs(attrs ...attr.Attr) InitOption {
 	}
 }
 
+// WithBuildInfoAttrs adds the binary's version and VCS revision as static attributes.
+// The values are read from runtime/debug.ReadBuildInfo, so services don't need to
+// pass their version in manually.
+//
+// Usage:
+//
+//	ctx, close := bedrock.Init(ctx, bedrock.WithBuildInfoAttrs())
+func WithBuildInfoAttrs() InitOption {
+	return func(c *initConfig) {
+		c.staticAttrs = append(c.staticAttrs, metric.ReadBuildInfo().Attrs()...)
+	}
+}
+
 // WithLogLevel sets the log level for the bedrock instance.
 // Valid levels: "debug", "info", "warn", "error"
 // This is a convenience wrapper that modifies the config.
@@ -422,6 +962,8 @@ func applyInitOptions(opts []InitOption) initConfig {
 
 // Counter creates or retrieves a counter metric from the bedrock instance in context.
 // Static labels are automatically included when recording values.
+// If Config.MetricStrictNames is set, invalid metric or label names are logged once and
+// the metric's series are dropped.
 //
 // Usage:
 //
@@ -431,32 +973,29 @@ func applyInitOptions(opts []InitOption) initConfig {
 //	counter.Inc() // automatically includes static labels
 func Counter(ctx context.Context, name, help string, labelNames ...string) *CounterWithStatic {
 	b := bedrockFromContext(ctx)
+	if !b.config.MetricStrictNames {
+		return b.counter(name, help, labelNames...)
+	}
 
 	// Include static label names
-	staticLabelNames := make([]string, 0, b.staticAttr.Len())
-	b.staticAttr.Range(func(a attr.Attr) bool {
-		staticLabelNames = append(staticLabelNames, a.Key)
-		return true
-	})
-
-	// Get static label values
-	staticLabels := make([]attr.Attr, 0, b.staticAttr.Len())
-	b.staticAttr.Range(func(a attr.Attr) bool {
-		staticLabels = append(staticLabels, a)
-		return true
-	})
-
-	allLabelNames := append(staticLabelNames, labelNames...)
-	counter := b.metrics.Counter(name, help, allLabelNames...)
+	staticLabelNames, _ := b.staticLabels()
+	allLabelNames := append(sanitizeLabelNames(staticLabelNames), labelNames...)
+	counter, err := b.metrics.RegisterCounter(metric.Opts{Name: name, Help: help, LabelNames: allLabelNames})
+	if err != nil {
+		b.dropInvalidMetric(name, err)
+		counter = b.discardMetrics.Counter(name, help, allLabelNames...)
+	}
 
 	return &CounterWithStatic{
-		counter:      counter,
-		staticLabels: staticLabels,
+		counter: counter,
+		b:       b,
 	}
 }
 
 // Gauge creates or retrieves a gauge metric from the bedrock instance in context.
 // Static labels are automatically included when recording values.
+// If Config.MetricStrictNames is set, invalid metric or label names are logged once and
+// the metric's series are dropped.
 //
 // Usage:
 //
@@ -464,33 +1003,30 @@ func Counter(ctx context.Context, name, help string, labelNames ...string) *Coun
 //	gauge.Set(42) // automatically includes static labels
 func Gauge(ctx context.Context, name, help string, labelNames ...string) *GaugeWithStatic {
 	b := bedrockFromContext(ctx)
+	if !b.config.MetricStrictNames {
+		return b.gauge(name, help, labelNames...)
+	}
 
 	// Include static label names
-	staticLabelNames := make([]string, 0, b.staticAttr.Len())
-	b.staticAttr.Range(func(a attr.Attr) bool {
-		staticLabelNames = append(staticLabelNames, a.Key)
-		return true
-	})
-
-	// Get static label values
-	staticLabels := make([]attr.Attr, 0, b.staticAttr.Len())
-	b.staticAttr.Range(func(a attr.Attr) bool {
-		staticLabels = append(staticLabels, a)
-		return true
-	})
-
-	allLabelNames := append(staticLabelNames, labelNames...)
-	gauge := b.metrics.Gauge(name, help, allLabelNames...)
+	staticLabelNames, _ := b.staticLabels()
+	allLabelNames := append(sanitizeLabelNames(staticLabelNames), labelNames...)
+	gauge, err := b.metrics.RegisterGauge(metric.Opts{Name: name, Help: help, LabelNames: allLabelNames})
+	if err != nil {
+		b.dropInvalidMetric(name, err)
+		gauge = b.discardMetrics.Gauge(name, help, allLabelNames...)
+	}
 
 	return &GaugeWithStatic{
-		gauge:        gauge,
-		staticLabels: staticLabels,
+		gauge: gauge,
+		b:     b,
 	}
 }
 
 // Histogram creates or retrieves a histogram metric from the bedrock instance in context.
 // Uses default buckets if buckets is nil.
 // Static labels are automatically included when recording values.
+// If Config.MetricStrictNames is set, invalid metric or label names are logged once and
+// the metric's series are dropped.
 //
 // Usage:
 //
@@ -500,30 +1036,78 @@ func Gauge(ctx context.Context, name, help string, labelNames ...string) *GaugeW
 //	hist.Observe(123.45) // automatically includes static labels
 func Histogram(ctx context.Context, name, help string, buckets []float64, labelNames ...string) *HistogramWithStatic {
 	b := bedrockFromContext(ctx)
+	if !b.config.MetricStrictNames {
+		return b.histogram(name, help, buckets, labelNames...)
+	}
 
 	// Include static label names
-	staticLabelNames := make([]string, 0, b.staticAttr.Len())
-	b.staticAttr.Range(func(a attr.Attr) bool {
-		staticLabelNames = append(staticLabelNames, a.Key)
-		return true
-	})
+	staticLabelNames, _ := b.staticLabels()
+	allLabelNames := append(sanitizeLabelNames(staticLabelNames), labelNames...)
+	histogram, err := b.metrics.RegisterHistogram(metric.Opts{Name: name, Help: help, Buckets: buckets, LabelNames: allLabelNames})
+	if err != nil {
+		b.dropInvalidMetric(name, err)
+		histogram = b.discardMetrics.Histogram(name, help, buckets, allLabelNames...)
+	}
 
-	// Get static label values
-	staticLabels := make([]attr.Attr, 0, b.staticAttr.Len())
-	b.staticAttr.Range(func(a attr.Attr) bool {
-		staticLabels = append(staticLabels, a)
-		return true
-	})
+	return &HistogramWithStatic{
+		histogram: histogram,
+		b:         b,
+	}
+}
+
+// counter creates or retrieves a counter with the static labels, sanitizing its names
+// as the registry does for operation metrics. It's for metrics bedrock names after
+// user-provided names, such as a source's, which MetricStrictNames doesn't reject.
+func (b *Bedrock) counter(name, help string, labelNames ...string) *CounterWithStatic {
+	staticLabelNames, _ := b.staticLabels()
+	return &CounterWithStatic{
+		counter: b.metrics.Counter(name, help, append(staticLabelNames, labelNames...)...),
+		b:       b,
+	}
+}
 
-	allLabelNames := append(staticLabelNames, labelNames...)
-	histogram := b.metrics.Histogram(name, help, buckets, allLabelNames...)
+// gauge creates or retrieves a gauge with the static labels, as counter does.
+func (b *Bedrock) gauge(name, help string, labelNames ...string) *GaugeWithStatic {
+	staticLabelNames, _ := b.staticLabels()
+	return &GaugeWithStatic{
+		gauge: b.metrics.Gauge(name, help, append(staticLabelNames, labelNames...)...),
+		b:     b,
+	}
+}
 
+// histogram creates or retrieves a histogram with the static labels, as counter does.
+func (b *Bedrock) histogram(name, help string, buckets []float64, labelNames ...string) *HistogramWithStatic {
+	staticLabelNames, _ := b.staticLabels()
 	return &HistogramWithStatic{
-		histogram:    histogram,
-		staticLabels: staticLabels,
+		histogram: b.metrics.Histogram(name, help, buckets, append(staticLabelNames, labelNames...)...),
+		b:         b,
 	}
 }
 
+// dropInvalidMetric logs, the first time for each metric name, that a metric was rejected
+// in strict mode. The caller creates it in discardMetrics instead, which is never exposed,
+// so recording to it is harmless and its series are dropped.
+func (b *Bedrock) dropInvalidMetric(name string, err error) {
+	if _, logged := b.invalidMetrics.LoadOrStore(name, struct{}{}); logged {
+		return
+	}
+	b.logger.Error("invalid metric name, dropping its series",
+		slog.String("metric", name),
+		slog.Any("error", err),
+	)
+}
+
+// sanitizeLabelNames returns the static label names as registered in strict mode:
+// sanitized as in every other mode, since attribute keys such as "service.version" aren't
+// valid label names. Only the names passed to Counter, Gauge, and Histogram are validated.
+func sanitizeLabelNames(names []string) []string {
+	sanitized := make([]string, len(names), len(names)+4)
+	for i, name := range names {
+		sanitized[i] = metric.SanitizeLabelName(name)
+	}
+	return sanitized
+}
+
 // Debug logs a debug message with the given attributes.
 // Uses the bedrock logger from context, which includes static attributes.
 //
@@ -568,6 +1152,138 @@ func Error(ctx context.Context, msg string, attrs ...attr.Attr) {
 	b.logBridge.Error(ctx, msg, attrs...)
 }
 
+// LevelFatal is the level of records logged by Fatal. It is rendered as "FATAL".
+const LevelFatal = blog.LevelFatal
+
+// exit terminates the process; replaced in tests.
+var exit = os.Exit
+
+// Fatal logs a message at LevelFatal, then flushes telemetry through the same
+// shutdown path as the cleanup function returned by Init and exits with status 1.
+// The spans of the current operation and its parents are ended with the message
+// as their error, so they are exported rather than lost.
+//
+// Usage:
+//
+//	bedrock.Fatal(ctx, "failed to load config", attr.Error(err))
+func Fatal(ctx context.Context, msg string, attrs ...attr.Attr) {
+	b := bedrockFromContext(ctx)
+	b.logBridge.Log(ctx, LevelFatal, msg, attrs...)
+
+	for op := operationStateFromContext(ctx); op != nil; op = op.parent {
+		if op.span != nil {
+			op.span.RecordError(errors.New(msg))
+			op.span.End()
+		}
+	}
+
+	if b.cleanup != nil {
+		b.cleanup()
+	} else if !b.isNoop {
+		shutdown(b, nil, b.config.ShutdownTimeout)
+	}
+	exit(1)
+}
+
+// Audit records a security-relevant event, such as a login or permission change, in the
+// audit log. The audit log is a separate pipeline configured by the Audit* fields of Config:
+// events are never sampled, deduplicated, or filtered by level, and are not mixed into the
+// application log. Each event includes the service name, static attributes, trace context,
+// and the attributes from WithLogAttrs.
+//
+// Audit returns an error if the event lacks any of Config.AuditRequiredAttrs (it is still
+// written) or if it could not be written.
+//
+// Usage:
+//
+//	err := bedrock.Audit(ctx, "user.role_changed",
+//	    attr.String("actor", admin), attr.String("user_id", id), attr.String("role", "owner"))
+func Audit(ctx context.Context, event string, attrs ...attr.Attr) error {
+	b := bedrockFromContext(ctx)
+	if b.audit == nil {
+		return nil
+	}
+
+	var missing []string
+	if len(b.config.AuditRequiredAttrs) > 0 {
+		logAttrs := logAttrsFromContext(ctx)
+		for _, key := range b.config.AuditRequiredAttrs {
+			_, ok := logAttrs.Get(key)
+			if !ok && !slices.ContainsFunc(attrs, func(a attr.Attr) bool { return a.Key == key }) {
+				missing = append(missing, key)
+			}
+		}
+	}
+
+	r := slog.NewRecord(time.Now(), slog.LevelInfo, event, 0)
+	r.AddAttrs(blog.AttrsToSlog(attrs)...)
+	if len(missing) > 0 {
+		r.AddAttrs(slog.Any("audit.missing", missing))
+	}
+	if err := b.audit.Handle(ctx, r); err != nil {
+		return fmt.Errorf("bedrock: audit %s: %w", event, err)
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("bedrock: audit %s: missing required attributes %v", event, missing)
+	}
+	return nil
+}
+
+// WithLogAttrs returns a context whose log records include the given attributes,
+// in addition to any added by enclosing calls. Attributes passed to a log call
+// take precedence over these.
+//
+// Usage:
+//
+//	ctx = bedrock.WithLogAttrs(ctx, attr.String("tenant_id", tenant), attr.String("request_id", id))
+//	bedrock.Info(ctx, "loaded settings") // includes tenant_id and request_id
+func WithLogAttrs(ctx context.Context, attrs ...attr.Attr) context.Context {
+	if len(attrs) == 0 {
+		return ctx
+	}
+	return withLogAttrs(ctx, attrs...)
+}
+
+// SetValue returns a context carrying a request-scoped value, a typed alternative to
+// context.WithValue for request metadata. The value is registered on the current
+// operation, added to the attributes of operations started from the returned context,
+// and included in its log records as with WithLogAttrs.
+//
+// Usage:
+//
+//	// In auth middleware
+//	ctx = bedrock.SetValue(ctx, "user_id", attr.StringValue(claims.Subject))
+//	next.ServeHTTP(w, r.WithContext(ctx))
+//
+//	// In a handler
+//	if v, ok := bedrock.Value(ctx, "user_id"); ok {
+//	    userID := v.AsString()
+//	}
+func SetValue(ctx context.Context, key string, value attr.Value) context.Context {
+	a := attr.Attr{Key: key, Value: value}
+	if state := operationStateFromContext(ctx); state != nil {
+		state.setAttr(a)
+	}
+	return withLogAttrs(withValue(ctx, a), a)
+}
+
+// Value returns the request-scoped value for key set with SetValue.
+func Value(ctx context.Context, key string) (attr.Value, bool) {
+	return valuesFromContext(ctx).Get(key)
+}
+
+// Values returns all request-scoped values set with SetValue.
+func Values(ctx context.Context) attr.Set {
+	return valuesFromContext(ctx)
+}
+
+// AttrName returns the name bedrock records the attribute key under: its OpenTelemetry
+// semantic convention name if Config.SemConv is set, as from semconv.Name, or key itself.
+// Integrations use it to name their attributes as bedrock's own HTTP attributes are.
+func AttrName(ctx context.Context, key string) string {
+	return bedrockFromContext(ctx).attrName(key)
+}
+
 // Log logs a message at the given level with attributes.
 // Uses the bedrock logger from context, which includes static attributes.
 //
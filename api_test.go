@@ -3,10 +3,22 @@ package bedrock
 import (
 	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"log/slog"
+	"net"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
 	"testing"
+	"time"
+	"unicode/utf8"
 
 	"github.com/kzs0/bedrock/attr"
+	"github.com/kzs0/bedrock/log/logtest"
+	"github.com/kzs0/bedrock/trace"
 )
 
 func TestCounter(t *testing.T) {
@@ -362,6 +374,36 @@ func TestMetricsSameName(t *testing.T) {
 	}
 }
 
+func TestStaticLabelHandleCached(t *testing.T) {
+	ctx, close := Init(context.Background(),
+		WithConfig(Config{Service: "test-service"}),
+		WithStaticAttrs(attr.String("env", "test")),
+	)
+	defer close()
+
+	counter := Counter(ctx, "cached_total", "Cached counter")
+	counter.Inc()
+	counter.Add(2)
+
+	if counter.staticVec() != counter.staticVec() {
+		t.Error("expected static series handle to be cached")
+	}
+
+	for _, fam := range FromContext(ctx).Metrics().Gather() {
+		if fam.Name != "cached_total" {
+			continue
+		}
+		if len(fam.Metrics) != 1 || fam.Metrics[0].Value != 3 {
+			t.Errorf("expected one series with value 3, got %+v", fam.Metrics)
+		}
+		if v, ok := fam.Metrics[0].Labels.Get("env"); !ok || v.AsString() != "test" {
+			t.Error("expected static label env=test")
+		}
+		return
+	}
+	t.Error("expected cached_total to be gathered")
+}
+
 func TestStaticAttributesInLogs(t *testing.T) {
 	var buf bytes.Buffer
 	ctx, close := Init(context.Background(),
@@ -426,6 +468,1007 @@ func TestWithLogLevel(t *testing.T) {
 	}
 }
 
+func TestSetLogLevel(t *testing.T) {
+	var buf bytes.Buffer
+	ctx, close := Init(context.Background(),
+		WithConfig(Config{
+			Service:   "test-service",
+			LogLevel:  "info",
+			LogOutput: &buf,
+		}),
+	)
+	defer close()
+
+	b := FromContext(ctx)
+	logger := b.Logger() // created before the change
+
+	Debug(ctx, "hidden message")
+	if bytes.Contains(buf.Bytes(), []byte("hidden message")) {
+		t.Error("expected debug log to be dropped at info level")
+	}
+
+	b.SetLogLevel(slog.LevelDebug)
+	if b.LogLevel() != slog.LevelDebug {
+		t.Errorf("expected level debug, got %v", b.LogLevel())
+	}
+
+	logger.Debug("visible message")
+	if !bytes.Contains(buf.Bytes(), []byte("visible message")) {
+		t.Error("expected debug log to be output after SetLogLevel")
+	}
+}
+
+func TestLogSampling(t *testing.T) {
+	var buf bytes.Buffer
+	ctx, close := Init(context.Background(),
+		WithConfig(Config{
+			Service:             "test-service",
+			LogOutput:           &buf,
+			LogSampleInitial:    2,
+			LogSampleThereafter: 5,
+		}),
+	)
+	defer close()
+
+	for range 12 {
+		Error(ctx, "tight loop")
+	}
+	Info(ctx, "other message")
+
+	// 2 initial records, then the 5th and 10th of the remaining 10
+	if n := bytes.Count(buf.Bytes(), []byte("tight loop")); n != 4 {
+		t.Errorf("expected 4 sampled records, got %d", n)
+	}
+	if !bytes.Contains(buf.Bytes(), []byte("other message")) {
+		t.Error("expected other messages to have their own budget")
+	}
+
+	for _, fam := range FromContext(ctx).Metrics().Gather() {
+		if fam.Name != "log_dropped_total" {
+			continue
+		}
+		if len(fam.Metrics) != 1 || fam.Metrics[0].Value != 8 {
+			t.Errorf("expected 8 dropped ERROR records, got %+v", fam.Metrics)
+		}
+		return
+	}
+	t.Error("expected log_dropped_total to be gathered")
+}
+
+func TestLogVolumeMetrics(t *testing.T) {
+	var buf, debugBuf bytes.Buffer
+	ctx, close := Init(context.Background(),
+		WithConfig(Config{
+			Service:   "test-service",
+			LogLevel:  "info",
+			LogOutput: &buf,
+			LogDestinations: []LogDestination{
+				{Output: &debugBuf, Level: "debug"},
+			},
+		}),
+	)
+	defer close()
+
+	Debug(ctx, "debug")
+	Info(ctx, "info")
+	Error(ctx, "first")
+	Error(ctx, "second")
+
+	messages := map[string]float64{}
+	var written float64
+	for _, fam := range FromContext(ctx).Metrics().Gather() {
+		switch fam.Name {
+		case "log_messages_total":
+			for _, m := range fam.Metrics {
+				level, _ := m.Labels.Get("level")
+				messages[level.AsString()] = m.Value
+			}
+		case "log_bytes_total":
+			written = fam.Metrics[0].Value
+		}
+	}
+
+	want := map[string]float64{"DEBUG": 1, "INFO": 1, "WARN": 0, "ERROR": 2}
+	for level, n := range want {
+		if messages[level] != n {
+			t.Errorf("expected %v %s records, got %v", n, level, messages[level])
+		}
+	}
+	if total := float64(buf.Len() + debugBuf.Len()); written != total {
+		t.Errorf("expected %v bytes written across outputs, got %v", total, written)
+	}
+}
+
+// syncBuffer is a bytes.Buffer safe for use by background log writers.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestLogDedup(t *testing.T) {
+	var buf syncBuffer
+	ctx, close := Init(context.Background(),
+		WithConfig(Config{
+			Service:        "test-service",
+			LogFormat:      "text",
+			LogOutput:      &buf,
+			LogDedupWindow: 50 * time.Millisecond,
+		}),
+	)
+	defer close()
+
+	for range 5 {
+		Error(ctx, "crash")
+	}
+	Info(ctx, "other")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected first record, summary, and other record, got %q", lines)
+	}
+	if !strings.Contains(lines[0], "msg=crash") {
+		t.Errorf("expected first record logged as is, got %q", lines[0])
+	}
+	if !strings.Contains(lines[1], `msg="crash (repeated 4 times in`) || !strings.Contains(lines[1], "level=ERROR") {
+		t.Errorf("expected summary of suppressed records before the next record, got %q", lines[1])
+	}
+	if !strings.Contains(lines[2], "msg=other") {
+		t.Errorf("expected other record, got %q", lines[2])
+	}
+
+	// A burst still pending when the window ends is summarized by the timer
+	for range 3 {
+		Warn(ctx, "retrying")
+	}
+	deadline := time.Now().Add(time.Second)
+	for !strings.Contains(buf.String(), "retrying (repeated 2 times in") {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected summary when the window ends, got %q", buf.String())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestPrettyLogFormat(t *testing.T) {
+	t.Setenv("NO_COLOR", "1")
+
+	var buf bytes.Buffer
+	ctx, close := Init(context.Background(),
+		WithConfig(Config{
+			Service:           "test-service",
+			LogFormat:         "pretty",
+			LogOutput:         &buf,
+			LogAddSource:      true,
+			LogOperationAttrs: true,
+		}),
+	)
+	defer close()
+
+	op, ctx := Operation(ctx, "checkout")
+	Warn(ctx, "card declined", attr.String("reason", "insufficient funds"), attr.Int("attempt", 2))
+	op.Done()
+
+	line := strings.TrimSpace(buf.String())
+	traceID := operationStateFromContext(ctx).span.TraceID().String()
+
+	if !regexp.MustCompile(`^\d{2}:\d{2}:\d{2}\.\d{3} WRN \[` + traceID[:8] + `\] card declined +`).MatchString(line) {
+		t.Errorf("expected short time, level, and inline trace ID, got %q", line)
+	}
+	if !strings.Contains(line, `reason="insufficient funds"`) || !strings.Contains(line, "attempt=2") {
+		t.Errorf("expected key=value attributes, got %q", line)
+	}
+	if !strings.Contains(line, "operation=checkout") {
+		t.Errorf("expected operation attributes, got %q", line)
+	}
+	if !strings.Contains(line, "api_test.go:") {
+		t.Errorf("expected source location, got %q", line)
+	}
+	if strings.Contains(line, "\x1b[") {
+		t.Errorf("expected no colors with NO_COLOR set, got %q", line)
+	}
+}
+
+func TestGCPLogFormat(t *testing.T) {
+	var buf bytes.Buffer
+	ctx, close := Init(context.Background(),
+		WithConfig(Config{
+			Service:       "test-service",
+			LogFormat:     "gcp",
+			LogGCPProject: "my-project",
+			LogOutput:     &buf,
+			LogAddSource:  true,
+		}),
+	)
+	defer close()
+
+	op, ctx := Operation(ctx, "checkout")
+	Warn(ctx, "card declined")
+	op.Done()
+
+	var rec map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+		t.Fatal(err)
+	}
+
+	span := operationStateFromContext(ctx).span
+	want := map[string]any{
+		"severity":                      "WARNING",
+		"message":                       "card declined",
+		"logging.googleapis.com/trace":  "projects/my-project/traces/" + span.TraceID().String(),
+		"logging.googleapis.com/spanId": span.SpanID().String(),
+	}
+	for k, v := range want {
+		if rec[k] != v {
+			t.Errorf("expected %s=%v, got %v", k, v, rec[k])
+		}
+	}
+	if _, ok := rec["time"]; !ok {
+		t.Error("expected time field")
+	}
+	for _, k := range []string{"level", "msg", "trace_id", "span_id", "source"} {
+		if _, ok := rec[k]; ok {
+			t.Errorf("expected %s to be renamed, got %v", k, rec)
+		}
+	}
+
+	loc, ok := rec["logging.googleapis.com/sourceLocation"].(map[string]any)
+	if !ok {
+		t.Fatalf("expected sourceLocation, got %v", rec)
+	}
+	if file, _ := loc["file"].(string); !strings.HasSuffix(file, "api_test.go") {
+		t.Errorf("expected source file, got %v", loc)
+	}
+	if line, _ := loc["line"].(string); line == "" || line == "0" {
+		t.Errorf("expected source line, got %v", loc)
+	}
+}
+
+func TestLogMaxRecordSize(t *testing.T) {
+	var buf bytes.Buffer
+	ctx, close := Init(context.Background(),
+		WithConfig(Config{
+			Service:          "test-service",
+			LogOutput:        &buf,
+			LogMaxRecordSize: 1024,
+		}),
+	)
+	defer close()
+
+	Info(ctx, "request body",
+		attr.String("user_id", "42"),
+		attr.String("body", strings.Repeat("é", 1<<20)),
+		attr.Any("headers", strings.Repeat("x", 4096)),
+	)
+	Info(ctx, "small", attr.String("body", "ok"))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 log records, got %d", len(lines))
+	}
+	if len(lines[0]) > 1500 {
+		t.Errorf("expected record to be truncated to about 1KB, got %d bytes", len(lines[0]))
+	}
+
+	var rec map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &rec); err != nil {
+		t.Fatal(err)
+	}
+	if rec["user_id"] != "42" || rec["msg"] != "request body" {
+		t.Errorf("expected short values to be kept, got %v", rec)
+	}
+	for _, k := range []string{"body", "headers"} {
+		v, _ := rec[k].(string)
+		if !strings.HasSuffix(v, "...[truncated]") || !utf8.ValidString(v) {
+			t.Errorf("expected %s to be truncated, got %q", k, v)
+		}
+	}
+
+	if err := json.Unmarshal([]byte(lines[1]), &rec); err != nil {
+		t.Fatal(err)
+	}
+	if rec["body"] != "ok" {
+		t.Errorf("expected small record to be unchanged, got %v", rec)
+	}
+}
+
+func TestLogFieldNames(t *testing.T) {
+	var buf bytes.Buffer
+	ctx, close := Init(context.Background(),
+		WithConfig(Config{
+			Service:       "test-service",
+			LogOutput:     &buf,
+			LogTimeKey:    "timestamp",
+			LogLevelKey:   "severity",
+			LogMessageKey: "message",
+			LogTimeFormat: "unixmilli",
+		}),
+	)
+	defer close()
+
+	before := time.Now().UnixMilli()
+	Info(ctx, "hello")
+
+	var rec map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+		t.Fatal(err)
+	}
+	if rec["severity"] != "INFO" || rec["message"] != "hello" {
+		t.Errorf("expected renamed level and message, got %v", rec)
+	}
+	ts, ok := rec["timestamp"].(float64)
+	if !ok || int64(ts) < before || int64(ts) > time.Now().UnixMilli() {
+		t.Errorf("expected epoch millis timestamp, got %v", rec["timestamp"])
+	}
+	for _, k := range []string{"level", "msg"} {
+		if _, ok := rec[k]; ok {
+			t.Errorf("expected %s to be renamed, got %v", k, rec)
+		}
+	}
+}
+
+func TestECSLogFormat(t *testing.T) {
+	var buf bytes.Buffer
+	ctx, close := Init(context.Background(),
+		WithConfig(Config{
+			Service:      "test-service",
+			LogFormat:    "ecs",
+			LogOutput:    &buf,
+			LogAddSource: true,
+		}),
+	)
+	defer close()
+
+	op, ctx := Operation(ctx, "checkout")
+	Error(ctx, "charge failed", attr.Error(errors.New("card declined")))
+	op.Done()
+
+	var rec map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+		t.Fatal(err)
+	}
+
+	span := operationStateFromContext(ctx).span
+	want := map[string]any{
+		"log.level":    "error",
+		"message":      "charge failed",
+		"service.name": "test-service",
+		"trace.id":     span.TraceID().String(),
+		"span.id":      span.SpanID().String(),
+	}
+	for k, v := range want {
+		if rec[k] != v {
+			t.Errorf("expected %s=%v, got %v", k, v, rec[k])
+		}
+	}
+	if _, ok := rec["@timestamp"]; !ok {
+		t.Error("expected @timestamp field")
+	}
+	for _, k := range []string{"time", "level", "msg", "trace_id", "span_id", "source"} {
+		if _, ok := rec[k]; ok {
+			t.Errorf("expected %s to be renamed, got %v", k, rec)
+		}
+	}
+
+	if e, _ := rec["error"].(map[string]any); e["message"] != "card declined" {
+		t.Errorf("expected error.message, got %v", rec["error"])
+	}
+	origin, _ := rec["log.origin"].(map[string]any)
+	file, _ := origin["file"].(map[string]any)
+	if name, _ := file["name"].(string); !strings.HasSuffix(name, "api_test.go") {
+		t.Errorf("expected log.origin.file.name, got %v", rec["log.origin"])
+	}
+}
+
+func TestLogHandler(t *testing.T) {
+	rec := logtest.NewRecorder()
+	ctx, close := Init(context.Background(),
+		WithConfig(Config{
+			Service:    "test-service",
+			LogLevel:   "info",
+			LogHandler: rec,
+		}),
+		WithStaticAttrs(attr.String("env", "test")),
+	)
+	defer close()
+
+	op, ctx := Operation(ctx, "checkout")
+	Debug(ctx, "filtered")
+	Info(ctx, "forwarded", attr.Int("items", 3))
+	op.Done()
+
+	records := rec.Records()
+	if len(records) != 1 || records[0].Message != "forwarded" {
+		t.Fatalf("expected only the INFO record, got %+v", records)
+	}
+	if !rec.HasRecord(slog.LevelInfo, "forwarded", attr.String("env", "test"), attr.Int("items", 3)) {
+		t.Errorf("expected static and call site attributes, got %v", records[0].Attrs)
+	}
+	if traceID, ok := records[0].Attr("trace_id"); !ok || traceID.String() == "" {
+		t.Errorf("expected trace context, got %v", records[0].Attrs)
+	}
+	if rec.HasRecord(slog.LevelInfo, "forwarded", attr.Int("items", 4)) {
+		t.Error("expected attribute values to be compared")
+	}
+
+	slog.New(rec).WithGroup("http").Info("grouped", "method", "GET")
+	if !rec.HasRecord(slog.LevelInfo, "grouped", attr.String("http.method", "GET")) {
+		t.Errorf("expected dotted group keys, got %+v", rec.Records())
+	}
+}
+
+func TestSyslogOutput(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("udp not available: %v", err)
+	}
+	defer func() { _ = conn.Close() }()
+
+	ctx, close := Init(context.Background(),
+		WithConfig(Config{
+			Service:       "test-service",
+			LogFormat:     "syslog",
+			LogSyslogAddr: "udp://" + conn.LocalAddr().String(),
+		}),
+	)
+	defer close()
+
+	Warn(ctx, "disk almost full", attr.Int("percent", 95))
+
+	buf := make([]byte, 4096)
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := string(buf[:n])
+
+	// facility user (1) * 8 + severity warning (4)
+	if !strings.HasPrefix(msg, "<12>1 ") {
+		t.Errorf("expected RFC 5424 header with priority 12, got %q", msg)
+	}
+	if !strings.Contains(msg, " test-service ") {
+		t.Errorf("expected app name test-service, got %q", msg)
+	}
+	if !strings.Contains(msg, "disk almost full") || !strings.Contains(msg, "percent=95") {
+		t.Errorf("expected record in message, got %q", msg)
+	}
+}
+
+func TestLogDestinations(t *testing.T) {
+	var textBuf, jsonBuf bytes.Buffer
+	ctx, close := Init(context.Background(),
+		WithConfig(Config{
+			Service:   "test-service",
+			LogLevel:  "info",
+			LogFormat: "text",
+			LogOutput: &textBuf,
+			LogDestinations: []LogDestination{
+				{Output: &jsonBuf, Format: "json", Level: "debug"},
+			},
+		}),
+		WithStaticAttrs(attr.String("env", "test")),
+	)
+	defer close()
+
+	Debug(ctx, "debug message")
+	Info(ctx, "info message")
+
+	text := textBuf.String()
+	if strings.Contains(text, "debug message") {
+		t.Errorf("expected text output to filter DEBUG, got %q", text)
+	}
+	if !strings.Contains(text, "msg=\"info message\"") {
+		t.Errorf("expected INFO record in text format, got %q", text)
+	}
+
+	lines := strings.Split(strings.TrimSpace(jsonBuf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 JSON records, got %d: %q", len(lines), jsonBuf.String())
+	}
+	for i, want := range []string{"debug message", "info message"} {
+		var rec map[string]any
+		if err := json.Unmarshal([]byte(lines[i]), &rec); err != nil {
+			t.Fatalf("expected JSON record, got %q: %v", lines[i], err)
+		}
+		if rec["msg"] != want {
+			t.Errorf("expected msg %q, got %v", want, rec["msg"])
+		}
+		if rec["env"] != "test" {
+			t.Errorf("expected static attributes on every destination, got %v", rec)
+		}
+	}
+}
+
+func TestLogOperationAttrs(t *testing.T) {
+	var buf bytes.Buffer
+	ctx, close := Init(context.Background(),
+		WithConfig(Config{
+			Service:           "test-service",
+			LogOutput:         &buf,
+			LogOperationAttrs: true,
+		}),
+	)
+	defer close()
+
+	op, opCtx := Operation(ctx, "checkout", Attrs(attr.String("user_id", "123")))
+	op.Register(opCtx, attr.String("cart", "abc"), attr.String("region", "eu"))
+	Info(opCtx, "charging card", attr.String("region", "us"))
+	op.Done()
+
+	Info(ctx, "outside")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 log records, got %d: %q", len(lines), buf.String())
+	}
+
+	var rec map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &rec); err != nil {
+		t.Fatal(err)
+	}
+	if rec["operation"] != "checkout" || rec["user_id"] != "123" || rec["cart"] != "abc" {
+		t.Errorf("expected operation name and attributes in record, got %v", rec)
+	}
+	if rec["region"] != "us" {
+		t.Errorf("expected call site attribute to take precedence, got %v", rec["region"])
+	}
+	if strings.Count(lines[0], `"region"`) != 1 {
+		t.Errorf("expected region once, got %s", lines[0])
+	}
+
+	rec = nil
+	if err := json.Unmarshal([]byte(lines[1]), &rec); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := rec["operation"]; ok {
+		t.Errorf("expected no operation outside an operation, got %v", rec)
+	}
+}
+
+func TestLogRedaction(t *testing.T) {
+	var buf bytes.Buffer
+	ctx, close := Init(context.Background(),
+		WithConfig(Config{
+			Service:      "test-service",
+			LogOutput:    &buf,
+			RedactKeys:   []string{"password", "*_secret"},
+			RedactValues: []string{`\d{3}-\d{2}-\d{4}`},
+		}),
+		WithStaticAttrs(attr.String("api_secret", "s3cr3t")),
+	)
+	defer close()
+
+	Info(ctx, "signup",
+		attr.String("password", "hunter2"),
+		attr.String("note", "ssn 123-45-6789"),
+		attr.String("user", "alice"),
+	)
+
+	out := buf.String()
+	for _, leaked := range []string{"hunter2", "s3cr3t", "123-45-6789"} {
+		if strings.Contains(out, leaked) {
+			t.Errorf("expected %q to be redacted, got %s", leaked, out)
+		}
+	}
+	if !strings.Contains(out, `"note":"ssn [REDACTED]"`) || !strings.Contains(out, `"user":"alice"`) {
+		t.Errorf("expected partial redaction and untouched attributes, got %s", out)
+	}
+}
+
+func TestInvalidRedactionPattern(t *testing.T) {
+	_, err := New(Config{RedactValues: []string{"("}})
+	if err == nil {
+		t.Error("expected error for invalid redaction pattern")
+	}
+}
+
+func TestWithLogAttrs(t *testing.T) {
+	var buf bytes.Buffer
+	ctx, close := Init(context.Background(),
+		WithConfig(Config{
+			Service:           "test-service",
+			LogOutput:         &buf,
+			LogOperationAttrs: true,
+		}),
+	)
+	defer close()
+
+	reqCtx := WithLogAttrs(ctx, attr.String("tenant_id", "acme"), attr.String("request_id", "r1"))
+	reqCtx = WithLogAttrs(reqCtx, attr.String("request_id", "r2"))
+	op, opCtx := Operation(reqCtx, "checkout", Attrs(attr.String("tenant_id", "op-tenant")))
+
+	Info(opCtx, "scoped")
+	Info(opCtx, "overridden", attr.String("request_id", "call"))
+	op.Done()
+	Info(ctx, "unscoped")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 log records, got %q", lines)
+	}
+
+	var recs []map[string]any
+	for _, line := range lines {
+		var rec map[string]any
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			t.Fatal(err)
+		}
+		recs = append(recs, rec)
+	}
+
+	if recs[0]["tenant_id"] != "acme" || recs[0]["request_id"] != "r2" || recs[0]["operation"] != "checkout" {
+		t.Errorf("expected context log attributes to be included and merged, got %v", recs[0])
+	}
+	if strings.Count(lines[0], `"tenant_id"`) != 1 {
+		t.Errorf("expected context log attributes to win over operation attributes once, got %s", lines[0])
+	}
+	if recs[1]["request_id"] != "call" {
+		t.Errorf("expected call site attribute to take precedence, got %v", recs[1]["request_id"])
+	}
+	if _, ok := recs[2]["tenant_id"]; ok {
+		t.Errorf("expected no context log attributes on the parent context, got %v", recs[2])
+	}
+}
+
+func TestSetValue(t *testing.T) {
+	var buf bytes.Buffer
+	ctx, close := Init(context.Background(),
+		WithConfig(Config{
+			Service:   "test-service",
+			LogOutput: &buf,
+		}),
+	)
+	defer close()
+
+	req, reqCtx := Operation(ctx, "http.request")
+	reqCtx = SetValue(reqCtx, "user_id", attr.StringValue("u1"))
+
+	if v, ok := Value(reqCtx, "user_id"); !ok || v.AsString() != "u1" {
+		t.Errorf("expected user_id u1, got %v (found %v)", v, ok)
+	}
+	if _, ok := Value(ctx, "user_id"); ok {
+		t.Error("expected no value on the parent context")
+	}
+	if v, _ := req.state.attrs.Get("user_id"); v.AsString() != "u1" {
+		t.Errorf("expected value registered on the current operation, got %q", v.AsString())
+	}
+
+	child, childCtx := Operation(reqCtx, "load_user", Attrs(attr.String("tenant_id", "acme")))
+	if v, _ := child.state.attrs.Get("user_id"); v.AsString() != "u1" {
+		t.Errorf("expected value on child operation, got %q", v.AsString())
+	}
+	if v, _ := child.state.span.Attrs().Get("user_id"); v.AsString() != "u1" {
+		t.Errorf("expected value on child span, got %q", v.AsString())
+	}
+	Info(childCtx, "loaded")
+	child.Done()
+	req.Done()
+
+	if Values(reqCtx).Len() != 1 {
+		t.Errorf("expected 1 value, got %d", Values(reqCtx).Len())
+	}
+	if !strings.Contains(buf.String(), `"user_id":"u1"`) {
+		t.Errorf("expected value in log record, got %s", buf.String())
+	}
+}
+
+func TestAudit(t *testing.T) {
+	var logs, audit bytes.Buffer
+	ctx, close := Init(context.Background(),
+		WithConfig(Config{
+			Service:             "test-service",
+			LogOutput:           &logs,
+			LogSampleInitial:    1,
+			LogSampleThereafter: 0,
+			AuditOutput:         &audit,
+			AuditRequiredAttrs:  []string{"actor"},
+			RedactKeys:          []string{"password"},
+		}),
+	)
+	defer close()
+
+	op, opCtx := Operation(WithLogAttrs(ctx, attr.String("actor", "admin")), "update_user")
+	defer op.Done()
+
+	// Audit events are not sampled alongside application logs
+	for range 2 {
+		if err := Audit(opCtx, "user.password_changed", attr.String("user_id", "42"), attr.String("password", "hunter2")); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	err := Audit(ctx, "user.deleted", attr.String("user_id", "42"))
+	if err == nil || !strings.Contains(err.Error(), "actor") {
+		t.Errorf("expected missing actor error, got %v", err)
+	}
+
+	if logs.Len() != 0 {
+		t.Errorf("expected no audit events in the application log, got %q", logs.String())
+	}
+
+	lines := strings.Split(strings.TrimSpace(audit.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 audit events, got %q", audit.String())
+	}
+	var rec map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &rec); err != nil {
+		t.Fatal(err)
+	}
+	if rec["msg"] != "user.password_changed" || rec["service"] != "test-service" || rec["actor"] != "admin" || rec["user_id"] != "42" {
+		t.Errorf("unexpected audit event: %v", rec)
+	}
+	if rec["password"] != attr.Redacted {
+		t.Errorf("expected password to be redacted, got %v", rec["password"])
+	}
+	if rec["trace_id"] != op.state.span.TraceID().String() {
+		t.Errorf("expected trace_id of the operation, got %v", rec["trace_id"])
+	}
+
+	if err := json.Unmarshal([]byte(lines[2]), &rec); err != nil {
+		t.Fatal(err)
+	}
+	if missing, _ := rec["audit.missing"].([]any); len(missing) != 1 || missing[0] != "actor" {
+		t.Errorf("expected audit.missing [actor], got %v", rec["audit.missing"])
+	}
+}
+
+func TestAuditNoop(t *testing.T) {
+	if err := Audit(context.Background(), "user.login"); err != nil {
+		t.Errorf("expected no error without bedrock, got %v", err)
+	}
+}
+
+func TestNoTrace(t *testing.T) {
+	ctx, close := Init(context.Background(),
+		WithConfig(Config{Service: "test-service"}),
+	)
+	defer close()
+
+	op, opCtx := Operation(ctx, "hot_path", NoTrace())
+	if op.state.span != nil {
+		t.Error("expected no span for a NoTrace operation")
+	}
+
+	// Children inherit NoTrace
+	child, _ := Operation(opCtx, "child")
+	if child.state.span != nil {
+		t.Error("expected no span for a child of a NoTrace operation")
+	}
+	child.Done()
+	step := Step(opCtx, "step")
+	if step.span != nil {
+		t.Error("expected no span for a step of a NoTrace operation")
+	}
+	step.Done()
+	op.Done()
+
+	if s := Step(ctx, "hot_step", NoTrace()); s.span != nil {
+		t.Error("expected no span for a NoTrace step")
+	}
+
+	// Metrics are still recorded
+	found := false
+	for _, fam := range FromContext(ctx).Metrics().Gather() {
+		if fam.Name == "hot_path_count" {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("expected hot_path_count to be recorded without tracing")
+	}
+}
+
+func TestOperationFromContext(t *testing.T) {
+	ctx, close := Init(context.Background(),
+		WithConfig(Config{Service: "test-service"}),
+	)
+	defer close()
+
+	if _, ok := OperationFromContext(ctx); ok {
+		t.Error("expected no operation outside of one")
+	}
+
+	op, opCtx := Operation(ctx, "checkout")
+	defer op.Done()
+
+	// Library code registers on the enclosing operation without the *Op
+	lookup := func(ctx context.Context) {
+		if op, ok := OperationFromContext(ctx); ok {
+			op.Register(ctx, attr.String("cache", "hit"))
+		}
+	}
+	lookup(opCtx)
+
+	if v, _ := op.state.attrs.Get("cache"); v.AsString() != "hit" {
+		t.Errorf("expected attribute registered through OperationFromContext, got %q", v.AsString())
+	}
+
+	got, ok := OperationFromContext(opCtx)
+	if !ok || got.state != op.state {
+		t.Error("expected the enclosing operation")
+	}
+}
+
+func TestOperationInterceptors(t *testing.T) {
+	var calls []string
+	errInjected := errors.New("injected")
+	record := func(id string) OperationInterceptor {
+		return func(ctx context.Context, op *Op) func() {
+			calls = append(calls, id+" start "+op.Name())
+			if _, ok := OperationFromContext(ctx); !ok {
+				t.Error("expected the operation's context")
+			}
+			return func() {
+				calls = append(calls, fmt.Sprintf("%s done %s %v", id, op.Name(), op.Succeeded()))
+			}
+		}
+	}
+	inject := func(ctx context.Context, op *Op) func() {
+		if op.Name() != "flaky" {
+			return nil
+		}
+		return func() {
+			op.Register(ctx, attr.Bool("fault.injected", true))
+			op.Fail(errInjected)
+		}
+	}
+
+	ctx, close := Init(context.Background(),
+		WithConfig(Config{
+			Service:               "test-service",
+			OperationInterceptors: []OperationInterceptor{record("outer"), inject, record("inner")},
+		}),
+	)
+	defer close()
+
+	op, _ := Operation(ctx, "checkout")
+	op.Done()
+
+	flaky, flakyCtx := Operation(ctx, "flaky")
+	flaky.Done()
+	state := operationStateFromContext(flakyCtx)
+	if state.success || state.failure != errInjected {
+		t.Errorf("expected the injected failure, got success=%v failure=%v", state.success, state.failure)
+	}
+	if v, _ := state.attrs.Get("fault.injected"); !v.AsBool() {
+		t.Error("expected attributes registered when done to be recorded")
+	}
+
+	expected := []string{
+		"outer start checkout", "inner start checkout",
+		"inner done checkout true", "outer done checkout true",
+		"outer start flaky", "inner start flaky",
+		"inner done flaky true", "outer done flaky false",
+	}
+	if strings.Join(calls, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected calls %q, got %q", expected, calls)
+	}
+}
+
+func TestOperationInterceptorsSeeFinalOutcome(t *testing.T) {
+	var succeeded bool
+	var errorType string
+	ctx, close := Init(context.Background(),
+		WithConfig(Config{
+			Service:             "test-service",
+			OperationErrorTypes: true,
+			OperationInterceptors: []OperationInterceptor{func(ctx context.Context, op *Op) func() {
+				return func() {
+					succeeded = op.Succeeded()
+					v, _ := operationStateFromContext(ctx).attrs.Get("error.type")
+					errorType = v.String()
+				}
+			}},
+		}),
+	)
+	defer close()
+
+	cancelCtx, cancel := context.WithCancel(ctx)
+	op, _ := Operation(cancelCtx, "canceled", FailOnCancel())
+	cancel()
+	op.Done()
+	if succeeded || errorType != ErrorTypeCanceled {
+		t.Errorf("expected the interceptor to see the cancellation, got succeeded=%v error.type=%q", succeeded, errorType)
+	}
+
+	op, opCtx := Operation(ctx, "failed")
+	op.Fail(errors.New("boom"))
+	op.Done()
+	if errorType != ErrorTypeInternal {
+		t.Errorf("expected the interceptor to see the classified failure, got error.type=%q", errorType)
+	}
+	if v, _ := operationStateFromContext(opCtx).attrs.Get("error.type"); v.String() != ErrorTypeInternal {
+		t.Errorf("expected error.type=%s, got %q", ErrorTypeInternal, v.String())
+	}
+}
+
+func TestTraceAndSpanID(t *testing.T) {
+	ctx, close := Init(context.Background(),
+		WithConfig(Config{Service: "test-service"}),
+	)
+	defer close()
+
+	if TraceID(ctx) != "" || SpanID(ctx) != "" {
+		t.Error("expected empty IDs outside of an operation")
+	}
+
+	op, opCtx := Operation(ctx, "checkout")
+	defer op.Done()
+
+	span := op.state.span
+	if got := TraceID(opCtx); got != span.TraceID().String() || len(got) != 32 {
+		t.Errorf("expected trace ID %s, got %q", span.TraceID(), got)
+	}
+	if got := SpanID(opCtx); got != span.SpanID().String() || len(got) != 16 {
+		t.Errorf("expected span ID %s, got %q", span.SpanID(), got)
+	}
+
+	hot, hotCtx := Operation(ctx, "hot", NoTrace())
+	defer hot.Done()
+	if TraceID(hotCtx) != "" {
+		t.Error("expected no trace ID within a NoTrace operation")
+	}
+}
+
+func TestFatal(t *testing.T) {
+	var code int
+	exit = func(c int) { code = c }
+	defer func() { exit = os.Exit }()
+
+	var buf syncBuffer
+	ctx, close := Init(context.Background(),
+		WithConfig(Config{
+			Service:   "test-service",
+			LogOutput: &buf,
+		}),
+	)
+	defer close() // a no-op after Fatal
+
+	op, opCtx := Operation(ctx, "startup")
+	Fatal(opCtx, "failed to load config", attr.String("path", "/etc/app.yaml"))
+
+	if code != 1 {
+		t.Errorf("expected exit status 1, got %d", code)
+	}
+
+	var rec map[string]any
+	if err := json.Unmarshal([]byte(buf.String()), &rec); err != nil {
+		t.Fatalf("expected one log record, got %q: %v", buf.String(), err)
+	}
+	if rec["level"] != "FATAL" || rec["msg"] != "failed to load config" || rec["path"] != "/etc/app.yaml" {
+		t.Errorf("expected FATAL record, got %v", rec)
+	}
+
+	if op.state.span.IsRecording() {
+		t.Error("expected the operation span to be ended")
+	}
+	if status, msg := op.state.span.Status(); status != trace.StatusError || msg != "failed to load config" {
+		t.Errorf("expected the span to record the fatal error, got %v %q", status, msg)
+	}
+}
+
+func TestFatalNoop(t *testing.T) {
+	var code int
+	exit = func(c int) { code = c }
+	defer func() { exit = os.Exit }()
+
+	Fatal(context.Background(), "no bedrock")
+	if code != 1 {
+		t.Errorf("expected exit status 1 without bedrock, got %d", code)
+	}
+}
+
 func TestWithLogLevelOverride(t *testing.T) {
 	var buf bytes.Buffer
 	ctx, close := Init(context.Background(),
@@ -469,3 +1512,125 @@ func TestWithLogLevelStandalone(t *testing.T) {
 		t.Errorf("expected log level 'warn', got '%s'", b.config.LogLevel)
 	}
 }
+
+func TestMetricStrictNames(t *testing.T) {
+	var buf bytes.Buffer
+	ctx, close := Init(context.Background(),
+		WithConfig(Config{
+			Service:           "test-service",
+			LogOutput:         &buf,
+			MetricStrictNames: true,
+		}),
+	)
+	defer close()
+
+	// Valid names are accepted
+	Counter(ctx, "valid_total", "Valid counter", "method").Inc()
+
+	// Invalid names are logged once and their series dropped
+	for range 2 {
+		Counter(ctx, "invalid.name", "Invalid counter").Inc()
+	}
+	Gauge(ctx, "valid_gauge", "Gauge with an invalid label", "shard:id").Set(1)
+
+	if n := bytes.Count(buf.Bytes(), []byte(`"metric":"invalid.name"`)); n != 1 {
+		t.Errorf("expected one error for the invalid name, got %d: %s", n, buf.String())
+	}
+	if !bytes.Contains(buf.Bytes(), []byte(`"metric":"valid_gauge"`)) {
+		t.Errorf("expected an error for the label name with a colon, got: %s", buf.String())
+	}
+	for _, fam := range FromContext(ctx).Metrics().Gather() {
+		switch fam.Name {
+		case "valid_total":
+		case "invalid_name", "invalid.name", "valid_gauge":
+			t.Errorf("expected the invalid metric %q to be dropped", fam.Name)
+		}
+	}
+}
+
+func TestMetricStrictNamesStaticAttrs(t *testing.T) {
+	var buf bytes.Buffer
+	ctx, close := Init(context.Background(),
+		WithConfig(Config{
+			Service:           "test-service",
+			LogOutput:         &buf,
+			MetricStrictNames: true,
+		}),
+		WithStaticAttrs(attr.String("service.version", "1.2.3")),
+	)
+	defer close()
+
+	// Static attribute keys are sanitized, as everywhere else, rather than rejected
+	Counter(ctx, "jobs_total", "Jobs", "queue").With(attr.String("queue", "default")).Inc()
+	if bytes.Contains(buf.Bytes(), []byte("invalid metric name")) {
+		t.Fatalf("expected static attribute keys to be accepted, got: %s", buf.String())
+	}
+
+	for _, fam := range FromContext(ctx).Metrics().Gather() {
+		if fam.Name != "jobs_total" {
+			continue
+		}
+		if v, ok := fam.Metrics[0].Labels.Get("service_version"); !ok || v.String() != "1.2.3" {
+			t.Errorf("expected the sanitized static label, got %v", fam.Metrics[0].Labels)
+		}
+		return
+	}
+	t.Error("expected jobs_total to be gathered")
+}
+
+func TestMetricStrictNamesSanitizeWarning(t *testing.T) {
+	var buf bytes.Buffer
+	ctx, close := Init(context.Background(),
+		WithConfig(Config{
+			Service:           "test-service",
+			LogOutput:         &buf,
+			MetricStrictNames: true,
+		}),
+	)
+	defer close()
+
+	// Automatic operation metrics are still sanitized, with a one-time warning
+	for range 2 {
+		op, _ := Operation(ctx, "db.query")
+		op.Done()
+	}
+
+	if !bytes.Contains(buf.Bytes(), []byte(`"original":"db.query_count"`)) {
+		t.Errorf("expected sanitization warning, got: %s", buf.String())
+	}
+	if n := bytes.Count(buf.Bytes(), []byte(`"original":"db.query_count"`)); n != 1 {
+		t.Errorf("expected one warning per name, got %d", n)
+	}
+}
+
+func TestMetricStrictNamesSourceAggregates(t *testing.T) {
+	var buf bytes.Buffer
+	ctx, close := Init(context.Background(),
+		WithConfig(Config{
+			Service:           "test-service",
+			LogOutput:         &buf,
+			MetricStrictNames: true,
+		}),
+	)
+	defer close()
+
+	// Metrics named after a source are sanitized like operation metrics, not dropped
+	source, ctx := Source(ctx, "background.worker")
+	defer source.Done()
+	source.Aggregate(ctx, attr.Sum("jobs", 1), attr.Gauge("depth", 2), attr.Histogram("batch", 3))
+
+	for _, key := range []string{"up", "jobs", "depth", "batch"} {
+		if bytes.Contains(buf.Bytes(), []byte(`"metric":"background.worker_`+key+`"`)) {
+			t.Errorf("expected the %s aggregate not to be rejected, got: %s", key, buf.String())
+		}
+	}
+	found := map[string]bool{}
+	for _, fam := range FromContext(ctx).Metrics().Gather() {
+		found[fam.Name] = true
+	}
+	for _, name := range []string{"background_worker_up", "background_worker_jobs", "background_worker_depth", "background_worker_batch"} {
+		if !found[name] {
+			t.Errorf("expected %s to be gathered", name)
+		}
+	}
+}
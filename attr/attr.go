@@ -59,11 +59,12 @@ func Any(key string, value any) Attr {
 }
 
 // Error creates an attribute for an error.
+// The value is the error's message; the error itself is kept for Value.Err.
 func Error(err error) Attr {
 	if err == nil {
 		return Attr{Key: "error", Value: StringValue("")}
 	}
-	return Attr{Key: "error", Value: StringValue(err.Error())}
+	return Attr{Key: "error", Value: Value{kind: KindString, str: err.Error(), any: err}}
 }
 
 // Registrable represents an item that can be registered on operations and steps.
@@ -124,6 +125,22 @@ func Histogram(key string, value float64) HistogramAttr {
 	return HistogramAttr{Key: key, Value: value}
 }
 
+// LabeledAttr is an aggregation with values for the labels registered with
+// bedrock.SourceAggregateLabels.
+type LabeledAttr struct {
+	Aggregation Aggregation
+	Labels      Set
+}
+
+func (LabeledAttr) aggregation() {}
+
+// Labeled attaches label values to a Sum, Gauge, or Histogram aggregation.
+// Keeping them out of the aggregation itself leaves SumAttr, GaugeAttr, and
+// HistogramAttr comparable.
+func Labeled(agg Aggregation, labels ...Attr) LabeledAttr {
+	return LabeledAttr{Aggregation: agg, Labels: NewSet(labels...)}
+}
+
 // Event represents a trace event with attributes.
 type Event struct {
 	Name  string
@@ -110,3 +110,22 @@ func TestValueAsAny(t *testing.T) {
 		t.Error("AsAny failed for string")
 	}
 }
+
+func TestAggregationsComparable(t *testing.T) {
+	seen := map[Aggregation]bool{
+		Sum("requests", 1):      true,
+		Gauge("depth", 2):       true,
+		Histogram("latency", 3): true,
+	}
+	if !seen[Sum("requests", 1)] || Gauge("depth", 2) != Gauge("depth", 2) {
+		t.Error("expected aggregations to be comparable")
+	}
+
+	labeled := Labeled(Sum("requests", 1), String("topic", "orders"))
+	if labeled.Aggregation != Sum("requests", 1) {
+		t.Errorf("expected the wrapped aggregation, got %v", labeled.Aggregation)
+	}
+	if v, _ := labeled.Labels.Get("topic"); v.String() != "orders" {
+		t.Errorf("expected topic=orders, got %q", v.String())
+	}
+}
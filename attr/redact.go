@@ -0,0 +1,103 @@
+package attr
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Redacted replaces redacted attribute values.
+const Redacted = "[REDACTED]"
+
+// Redactor masks sensitive attribute values before they are logged or exported.
+// A nil Redactor redacts nothing.
+type Redactor struct {
+	keys   []string
+	values []*regexp.Regexp
+}
+
+// NewRedactor creates a Redactor that masks the values of attributes whose keys match
+// any of the key patterns, and the parts of string values that match any of the value
+// regular expressions. Key patterns are case-insensitive and use path.Match syntax,
+// so "*_secret" matches "client_secret". If there are no patterns, it returns a nil Redactor.
+func NewRedactor(keys, values []string) (*Redactor, error) {
+	r := &Redactor{}
+	for _, k := range keys {
+		k = strings.ToLower(strings.TrimSpace(k))
+		if k == "" {
+			continue
+		}
+		if _, err := path.Match(k, ""); err != nil {
+			return nil, fmt.Errorf("attr: invalid redaction key pattern %q: %w", k, err)
+		}
+		r.keys = append(r.keys, k)
+	}
+	for _, v := range values {
+		if v == "" {
+			continue
+		}
+		re, err := regexp.Compile(v)
+		if err != nil {
+			return nil, fmt.Errorf("attr: invalid redaction value pattern %q: %w", v, err)
+		}
+		r.values = append(r.values, re)
+	}
+	if len(r.keys) == 0 && len(r.values) == 0 {
+		return nil, nil
+	}
+	return r, nil
+}
+
+// RedactKey reports whether the value of the attribute with the given key is masked entirely.
+func (r *Redactor) RedactKey(key string) bool {
+	if r == nil {
+		return false
+	}
+	key = strings.ToLower(key)
+	for _, k := range r.keys {
+		if ok, _ := path.Match(k, key); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// RedactString masks the parts of s that match the value patterns.
+func (r *Redactor) RedactString(s string) string {
+	if r == nil {
+		return s
+	}
+	for _, re := range r.values {
+		s = re.ReplaceAllString(s, Redacted)
+	}
+	return s
+}
+
+// Redact returns a with its value masked if it is sensitive.
+func (r *Redactor) Redact(a Attr) Attr {
+	if r == nil {
+		return a
+	}
+	if r.RedactKey(a.Key) {
+		return String(a.Key, Redacted)
+	}
+	if a.Value.Kind() == KindString && len(r.values) > 0 {
+		if s := r.RedactString(a.Value.AsString()); s != a.Value.AsString() {
+			return String(a.Key, s)
+		}
+	}
+	return a
+}
+
+// RedactSet returns s with the values of its sensitive attributes masked.
+func (r *Redactor) RedactSet(s Set) Set {
+	if r == nil || s.Len() == 0 {
+		return s
+	}
+	attrs := make([]Attr, s.Len())
+	for i, a := range s.Attrs() {
+		attrs[i] = r.Redact(a)
+	}
+	return NewSet(attrs...)
+}
@@ -0,0 +1,54 @@
+package attr
+
+import "testing"
+
+func TestRedactor(t *testing.T) {
+	r, err := NewRedactor([]string{"password", "*_secret"}, []string{`\d{4}-\d{4}-\d{4}-\d{4}`})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		in   Attr
+		want Attr
+	}{
+		{String("password", "hunter2"), String("password", Redacted)},
+		{String("Password", "hunter2"), String("Password", Redacted)},
+		{Int("client_secret", 42), String("client_secret", Redacted)},
+		{String("card", "card 1234-5678-9012-3456 declined"), String("card", "card [REDACTED] declined")},
+		{String("user", "alice"), String("user", "alice")},
+		{Int("count", 3), Int("count", 3)},
+	}
+	for _, tt := range tests {
+		got := r.Redact(tt.in)
+		if got.Key != tt.want.Key || got.Value.String() != tt.want.Value.String() {
+			t.Errorf("Redact(%v) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+
+	s := NewSet(String("password", "hunter2"), String("user", "alice"))
+	redacted := r.RedactSet(s)
+	if v, _ := redacted.Get("password"); v.AsString() != Redacted {
+		t.Errorf("expected password redacted in set, got %q", v.AsString())
+	}
+	if v, _ := s.Get("password"); v.AsString() != "hunter2" {
+		t.Errorf("expected original set unchanged, got %q", v.AsString())
+	}
+}
+
+func TestNewRedactorInvalid(t *testing.T) {
+	if _, err := NewRedactor(nil, []string{"("}); err == nil {
+		t.Error("expected error for invalid value pattern")
+	}
+	if _, err := NewRedactor([]string{"["}, nil); err == nil {
+		t.Error("expected error for invalid key pattern")
+	}
+
+	r, err := NewRedactor(nil, nil)
+	if err != nil || r != nil {
+		t.Fatalf("expected nil Redactor without patterns, got %v, %v", r, err)
+	}
+	if a := r.Redact(String("password", "x")); a.Value.AsString() != "x" {
+		t.Error("expected nil Redactor to redact nothing")
+	}
+}
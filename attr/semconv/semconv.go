@@ -0,0 +1,28 @@
+// Package semconv maps bedrock's attribute names to OpenTelemetry semantic convention
+// names, for backends that key on the conventions. Bedrock uses it when Config.SemConv
+// is set; integrations can use it to name their own attributes the same way.
+package semconv
+
+// names maps bedrock attribute names to their semantic convention names.
+var names = map[string]string{
+	"http.method":        "http.request.method",
+	"http.path":          "url.path",
+	"http.target":        "url.path",
+	"http.url":           "url.full",
+	"http.scheme":        "url.scheme",
+	"http.host":          "server.address",
+	"http.status_code":   "http.response.status_code",
+	"http.user_agent":    "user_agent.original",
+	"http.request_size":  "http.request.body.size",
+	"http.response_size": "http.response.body.size",
+	"grpc.status_code":   "rpc.grpc.status_code",
+}
+
+// Name returns the semantic convention name of the bedrock attribute key, or key itself
+// if it has none or already follows the conventions, as http.route does.
+func Name(key string) string {
+	if name, ok := names[key]; ok {
+		return name
+	}
+	return key
+}
@@ -0,0 +1,19 @@
+package semconv
+
+import "testing"
+
+func TestName(t *testing.T) {
+	tests := map[string]string{
+		"http.method":      "http.request.method",
+		"http.path":        "url.path",
+		"http.host":        "server.address",
+		"http.status_code": "http.response.status_code",
+		"http.route":       "http.route",
+		"user.id":          "user.id",
+	}
+	for key, want := range tests {
+		if got := Name(key); got != want {
+			t.Errorf("Name(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
@@ -69,22 +69,17 @@ func (s Set) Has(key string) bool {
 
 // Merge creates a new Set by merging this set with additional attributes.
 // Attributes in 'other' override those in this set if keys match.
+// The result shares this set's storage if other is empty.
 func (s Set) Merge(other ...Attr) Set {
 	if len(other) == 0 {
 		return s
 	}
-	if len(s.attrs) == 0 {
-		return NewSet(other...)
-	}
-
-	combined := make([]Attr, 0, len(s.attrs)+len(other))
-	combined = append(combined, s.attrs...)
-	combined = append(combined, other...)
-	return NewSet(combined...)
+	return s.MergeSet(NewSet(other...))
 }
 
 // MergeSet creates a new Set by merging this set with another set.
 // Attributes in 'other' override those in this set if keys match.
+// The result shares storage with either set if the other is empty.
 func (s Set) MergeSet(other Set) Set {
 	if other.Len() == 0 {
 		return s
@@ -93,10 +88,26 @@ func (s Set) MergeSet(other Set) Set {
 		return other
 	}
 
-	combined := make([]Attr, 0, len(s.attrs)+len(other.attrs))
-	combined = append(combined, s.attrs...)
-	combined = append(combined, other.attrs...)
-	return NewSet(combined...)
+	// Both sets are sorted and deduplicated, so a linear merge keeps them so
+	merged := make([]Attr, 0, len(s.attrs)+len(other.attrs))
+	i, j := 0, 0
+	for i < len(s.attrs) && j < len(other.attrs) {
+		switch a, b := s.attrs[i], other.attrs[j]; {
+		case a.Key < b.Key:
+			merged = append(merged, a)
+			i++
+		case a.Key > b.Key:
+			merged = append(merged, b)
+			j++
+		default:
+			merged = append(merged, b)
+			i++
+			j++
+		}
+	}
+	merged = append(merged, s.attrs[i:]...)
+	merged = append(merged, other.attrs[j:]...)
+	return Set{attrs: merged}
 }
 
 // Range iterates over all attributes in the set.
@@ -1,6 +1,7 @@
 package attr
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -125,6 +126,36 @@ func TestSetMergeSet(t *testing.T) {
 	}
 }
 
+func TestSetMergeSetInterleaved(t *testing.T) {
+	s1 := NewSet(String("a", "1"), String("c", "3"), String("e", "5"))
+	s2 := NewSet(String("b", "2"), String("c", "override"), String("f", "6"))
+
+	merged := s1.MergeSet(s2)
+
+	var keys []string
+	merged.Range(func(a Attr) bool {
+		keys = append(keys, a.Key)
+		return true
+	})
+	if got := strings.Join(keys, ","); got != "a,b,c,e,f" {
+		t.Errorf("expected sorted keys a,b,c,e,f, got %s", got)
+	}
+	if v, _ := merged.Get("c"); v.AsString() != "override" {
+		t.Errorf("expected 'override', got %q", v.AsString())
+	}
+}
+
+func TestSetMergeEmptyShares(t *testing.T) {
+	s := NewSet(String("a", "1"), String("b", "2"))
+
+	if got := s.Merge(); &got.Attrs()[0] != &s.Attrs()[0] {
+		t.Error("merging nothing should share the set's storage")
+	}
+	if got := EmptySet.MergeSet(s); &got.Attrs()[0] != &s.Attrs()[0] {
+		t.Error("merging into an empty set should share the other set's storage")
+	}
+}
+
 func TestSetRange(t *testing.T) {
 	s := NewSet(
 		String("a", "1"),
@@ -0,0 +1,85 @@
+package attr
+
+import (
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// maxStackDepth is the maximum number of frames captured in a Stack.
+const maxStackDepth = 32
+
+// Stack is a captured call stack of program counters, innermost first.
+type Stack []uintptr
+
+// CaptureStack captures the call stack of its caller.
+// skip is the number of additional frames to skip, with 0 identifying the caller of CaptureStack.
+func CaptureStack(skip int) Stack {
+	var pcs [maxStackDepth]uintptr
+	n := runtime.Callers(skip+2, pcs[:])
+	return Stack(pcs[:n:n])
+}
+
+// String formats the stack like a goroutine trace, one "function\n\tfile:line" entry per frame.
+// Runtime frames, such as runtime.goexit, are trimmed.
+func (s Stack) String() string {
+	if len(s) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	frames := runtime.CallersFrames(s)
+	for {
+		frame, more := frames.Next()
+		if frame.Function != "" && !strings.HasPrefix(frame.Function, "runtime.") {
+			b.WriteString(frame.Function)
+			b.WriteString("\n\t")
+			b.WriteString(frame.File)
+			b.WriteByte(':')
+			b.WriteString(strconv.Itoa(frame.Line))
+			b.WriteByte('\n')
+		}
+		if !more {
+			break
+		}
+	}
+	return b.String()
+}
+
+// StackError is an error annotated with the call stack where it was recorded.
+// It wraps the original error, so errors.Is and errors.As see the full chain.
+type StackError struct {
+	err   error
+	stack Stack
+}
+
+// Error returns the message of the wrapped error.
+func (e *StackError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the wrapped error.
+func (e *StackError) Unwrap() error {
+	return e.err
+}
+
+// Stack returns the call stack where the error was recorded.
+func (e *StackError) Stack() Stack {
+	return e.stack
+}
+
+// NewStackError annotates err with the given call stack.
+func NewStackError(err error, stack Stack) *StackError {
+	return &StackError{err: err, stack: stack}
+}
+
+// ErrorWithStack creates an error attribute that also records the call stack of its caller.
+// Like Error, its value is the error message, so it marks operations as failed; logs render
+// it with a "stack" field, and operations record the stack on the span's exception event.
+func ErrorWithStack(err error) Attr {
+	if err == nil {
+		return Error(nil)
+	}
+	se := &StackError{err: err, stack: CaptureStack(1)}
+	return Attr{Key: "error", Value: Value{kind: KindString, str: err.Error(), any: se}}
+}
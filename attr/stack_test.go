@@ -0,0 +1,41 @@
+package attr
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestErrorWithStack(t *testing.T) {
+	base := errors.New("not found")
+	a := ErrorWithStack(fmt.Errorf("load user: %w", base))
+
+	if a.Key != "error" || a.Value.AsString() != "load user: not found" {
+		t.Errorf("expected error message value, got %v", a)
+	}
+
+	err := a.Value.Err()
+	if !errors.Is(err, base) {
+		t.Error("expected wrapped error chain to be preserved")
+	}
+
+	var se *StackError
+	if !errors.As(err, &se) {
+		t.Fatal("expected a StackError")
+	}
+	stack := se.Stack().String()
+	if !strings.Contains(stack, "attr.TestErrorWithStack") {
+		t.Errorf("expected stack to start at the caller, got %s", stack)
+	}
+	if strings.Contains(stack, "runtime.") {
+		t.Errorf("expected runtime frames trimmed, got %s", stack)
+	}
+
+	if err := Error(base).Value.Err(); err != base || errors.As(err, &se) {
+		t.Errorf("expected Error to keep the error without a stack, got %v", err)
+	}
+	if ErrorWithStack(nil).Value.AsString() != "" {
+		t.Error("expected empty value for nil error")
+	}
+}
@@ -176,6 +176,14 @@ func (v Value) AsAny() any {
 	}
 }
 
+// Err returns the error attached to a value created by Error or ErrorWithStack, or nil.
+func (v Value) Err() error {
+	if err, ok := v.any.(error); ok && v.kind == KindString {
+		return err
+	}
+	return nil
+}
+
 // String returns a string representation of the value.
 func (v Value) String() string {
 	switch v.kind {
@@ -2,10 +2,19 @@ package bedrock
 
 import (
 	"context"
+	"fmt"
+	"io"
 	"log/slog"
+	"math"
 	"os"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/kzs0/bedrock/attr"
+	"github.com/kzs0/bedrock/attr/semconv"
+	"github.com/kzs0/bedrock/health"
 	blog "github.com/kzs0/bedrock/log"
 	"github.com/kzs0/bedrock/metric"
 	"github.com/kzs0/bedrock/trace"
@@ -14,18 +23,45 @@ import (
 
 // Bedrock is the main entry point for observability.
 type Bedrock struct {
-	config     Config
-	logger     *slog.Logger
-	logBridge  *blog.Bridge
-	tracer     *trace.Tracer
-	metrics    *metric.Registry
-	staticAttr attr.Set
+	config    Config
+	logLevel  *slog.LevelVar
+	logFile   *blog.RotatingFile
+	auditFile *blog.RotatingFile
+	audit     slog.Handler
+	logBytes  *metric.CounterVec
+	logger    *slog.Logger
+	logBridge *blog.Bridge
+	tracer    *trace.Tracer
+	metrics   *metric.Registry
+
+	sampleRate atomic.Uint64 // float64 bits of the trace sample rate, customSampleRate if unknown
+
+	// Static attributes: keys at creation are the static metric label names, which stay
+	// fixed; the values, and the attributes on logs and spans, can change after creation
+	staticMu   sync.Mutex // serializes updates to static
+	staticKeys []string
+	static     atomic.Pointer[staticState]
+
+	namedMu      sync.Mutex
+	namedMetrics map[string]*metric.Registry
+
+	// Metrics rejected by MetricStrictNames are created in discardMetrics, which is never
+	// exposed; invalidMetrics holds the names already logged
+	discardMetrics *metric.Registry
+	invalidMetrics sync.Map
+
+	readiness *health.Registry
+	liveness  *health.Registry
+	startTime time.Time
 
 	exporter         *otlp.Exporter
 	batchProcessor   *otlp.BatchProcessor
 	runtimeCollector *metric.RuntimeCollector
+	processCollector *metric.ProcessCollector
+	buildCollector   *metric.BuildInfoCollector
 
-	isNoop bool // true if this is a noop instance
+	cleanup func() // set by Init, shuts down the observability server and flushes telemetry
+	isNoop  bool   // true if this is a noop instance
 }
 
 // New creates a new Bedrock instance with the given configuration.
@@ -37,22 +73,75 @@ func New(cfg Config, staticAttrs ...attr.Attr) (*Bedrock, error) {
 	if cfg.ShutdownTimeout == 0 {
 		cfg.ShutdownTimeout = DefaultConfig().ShutdownTimeout
 	}
+	redactor, err := attr.NewRedactor(cfg.RedactKeys, cfg.RedactValues)
+	if err != nil {
+		return nil, fmt.Errorf("bedrock: %w", err)
+	}
+
+	var logFile *blog.RotatingFile
+	if cfg.LogFile != "" {
+		var err error
+		logFile, err = blog.NewRotatingFile(cfg.LogFile, blog.RotateOptions{
+			MaxSizeMB:  cfg.LogMaxSizeMB,
+			MaxBackups: cfg.LogMaxBackups,
+			MaxAge:     cfg.LogMaxAge,
+			Compress:   cfg.LogCompress,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("bedrock: %w", err)
+		}
+		cfg.LogOutput = logFile
+	}
 	if cfg.LogOutput == nil {
 		cfg.LogOutput = os.Stderr
 	}
 
 	b := &Bedrock{
-		config:     cfg,
-		staticAttr: attr.NewSet(staticAttrs...),
-		metrics:    metric.NewRegistry(cfg.MetricPrefix),
+		config:  cfg,
+		logFile: logFile,
+		metrics: metric.NewRegistry(cfg.MetricPrefix, metric.WithDefaultBuckets(cfg.MetricBuckets)),
+
+		readiness: health.NewRegistry(),
+		liveness:  health.NewRegistry(),
+		startTime: time.Now(),
 	}
+	staticSet := attr.NewSet(staticAttrs...)
+	b.staticKeys = staticSet.Keys()
+	b.static.Store(b.newStaticState(staticSet))
 
 	// Setup logging
+	b.logLevel = new(slog.LevelVar)
+	b.logLevel.Set(cfg.logLevel())
+	logDestinations := cfg.logDestinations()
+	for i, d := range logDestinations {
+		if d.Handler == nil {
+			if d.Output == nil {
+				d.Output = os.Stderr
+			}
+			logDestinations[i].Output = b.countLogBytes(d.Output)
+		}
+	}
 	handler := blog.NewHandler(&blog.HandlerOptions{
-		Level:     cfg.logLevel(),
-		Output:    cfg.LogOutput,
-		Format:    cfg.LogFormat,
-		AddSource: cfg.LogAddSource,
+		Level:         b.logLevel,
+		Output:        b.countLogBytes(cfg.LogOutput),
+		Format:        cfg.LogFormat,
+		TimeKey:       cfg.LogTimeKey,
+		LevelKey:      cfg.LogLevelKey,
+		MessageKey:    cfg.LogMessageKey,
+		TimeFormat:    cfg.LogTimeFormat,
+		SyslogAddr:    cfg.LogSyslogAddr,
+		AppName:       cfg.Service,
+		GCPProject:    cfg.LogGCPProject,
+		Handler:       cfg.LogHandler,
+		AddSource:     cfg.LogAddSource,
+		Sampling:      b.logSampling(),
+		DedupWindow:   cfg.LogDedupWindow,
+		MaxRecordSize: cfg.LogMaxRecordSize,
+		Destinations:  logDestinations,
+		OnRecord:      b.logRecordCounter(),
+		Redactor:      redactor,
+		ErrorStacks:   cfg.LogErrorStacks,
+		TracedDebug:   cfg.LogTracedDebug,
 	})
 	handler.SetTraceContextFunc(func(ctx context.Context) (traceID, spanID string) {
 		span := trace.SpanFromContext(ctx)
@@ -61,21 +150,51 @@ func New(cfg Config, staticAttrs ...attr.Attr) (*Bedrock, error) {
 		}
 		return "", ""
 	})
-
-	// Add static attributes to logger
-	slogAttrs := make([]slog.Attr, 0, b.staticAttr.Len())
-	b.staticAttr.Range(func(a attr.Attr) bool {
-		slogAttrs = append(slogAttrs, blog.AttrToSlog(a))
-		return true
+	handler.SetTraceSampledFunc(func(ctx context.Context) bool {
+		span := trace.SpanFromContext(ctx)
+		return span != nil && span.IsSampled()
+	})
+	handler.SetErrorStackFunc(func(ctx context.Context, msg string, stack attr.Stack) {
+		if span := trace.SpanFromContext(ctx); span != nil {
+			span.AddEvent("exception",
+				attr.String("exception.message", msg),
+				attr.String("exception.stacktrace", stack.String()),
+			)
+		}
+	})
+	// Static attributes are added per record, so SetStaticAttr applies to existing loggers
+	handler.SetContextAttrsFunc(func(ctx context.Context) []slog.Attr {
+		logAttrs := logAttrsFromContext(ctx)
+		attrs := b.static.Load().slog
+		if logAttrs.Len() > 0 {
+			attrs = append(slices.Clip(attrs), blog.AttrsToSlog(logAttrs.Attrs())...)
+		}
+		if cfg.LogOperationAttrs {
+			attrs = operationLogAttrs(ctx, slices.Clip(attrs), logAttrs)
+		}
+		return attrs
 	})
 
-	var loggerHandler slog.Handler = handler
-	if len(slogAttrs) > 0 {
-		loggerHandler = handler.WithAttrs(slogAttrs)
+	b.logger = slog.New(handler)
+	b.logBridge = blog.NewBridge(b.logger)
+
+	if err := b.setupAudit(redactor); err != nil {
+		if logFile != nil {
+			_ = logFile.Close()
+		}
+		return nil, err
 	}
 
-	b.logger = slog.New(loggerHandler)
-	b.logBridge = blog.NewBridge(b.logger)
+	// Report metric name sanitization in strict mode
+	if cfg.MetricStrictNames {
+		b.discardMetrics = metric.NewRegistry(cfg.MetricPrefix)
+		b.metrics.OnSanitize(func(original, sanitized string) {
+			b.logger.Warn("metric name sanitized",
+				slog.String("original", original),
+				slog.String("sanitized", sanitized),
+			)
+		})
+	}
 
 	// Setup tracing
 	var exporter trace.Exporter
@@ -83,7 +202,7 @@ func New(cfg Config, staticAttrs ...attr.Attr) (*Bedrock, error) {
 		b.exporter = otlp.NewExporter(otlp.ExporterConfig{
 			Endpoint:    cfg.TraceURL,
 			ServiceName: cfg.Service,
-			Resource:    b.staticAttr,
+			Resource:    staticSet,
 		})
 		b.batchProcessor = otlp.NewBatchProcessor(b.exporter, otlp.DefaultBatchConfig())
 		exporter = b.exporter
@@ -94,32 +213,248 @@ func New(cfg Config, staticAttrs ...attr.Attr) (*Bedrock, error) {
 		// Use sample rate from config
 		if cfg.TraceSampleRate > 0 && cfg.TraceSampleRate < 1.0 {
 			sampler = trace.NewRatioSampler(cfg.TraceSampleRate)
+			b.sampleRate.Store(math.Float64bits(cfg.TraceSampleRate))
 		} else {
 			sampler = trace.AlwaysSampler{}
+			b.sampleRate.Store(math.Float64bits(1))
 		}
+	} else {
+		b.sampleRate.Store(math.Float64bits(customSampleRate))
 	}
 
 	b.tracer = trace.NewTracer(trace.TracerConfig{
 		ServiceName: cfg.Service,
-		Resource:    b.staticAttr,
+		Resource:    staticSet,
 		Sampler:     sampler,
 		Exporter:    exporter,
+		Redactor:    redactor,
 	})
 
+	// Get static labels for runtime, process, and build info metrics
+	_, staticLabels := b.staticLabels()
+
 	// Setup runtime metrics collector if enabled
 	if cfg.RuntimeMetrics {
-		// Get static labels for runtime metrics
-		staticLabels := make([]attr.Attr, 0, b.staticAttr.Len())
-		b.staticAttr.Range(func(a attr.Attr) bool {
-			staticLabels = append(staticLabels, a)
+		b.runtimeCollector = metric.NewRuntimeCollector(b.metrics, staticLabels...)
+		_ = b.metrics.RegisterCollector(b.runtimeCollector)
+		b.runtimeCollector.Start(cfg.RuntimeMetricsInterval, cfg.RuntimeMetricsInterval/10)
+	}
+
+	// Setup process metrics collector if enabled
+	if cfg.ProcessMetrics {
+		b.processCollector = metric.NewProcessCollector(b.metrics, staticLabels...)
+		_ = b.metrics.RegisterCollector(b.processCollector)
+	}
+
+	// Setup build info collector if enabled
+	if cfg.BuildInfoMetrics {
+		b.buildCollector = metric.NewBuildInfoCollector(b.metrics, staticLabels...)
+		_ = b.metrics.RegisterCollector(b.buildCollector)
+	}
+
+	// Expose the settings the /admin endpoints can change
+	_ = b.metrics.RegisterCollector(newConfigInfoCollector(b))
+
+	return b, nil
+}
+
+// setupAudit creates the audit log pipeline. It shares the trace context, static attributes,
+// context log attributes, and redaction of the application log, but none of its sampling,
+// deduplication, or level settings.
+func (b *Bedrock) setupAudit(redactor *attr.Redactor) error {
+	cfg := b.config
+	output := cfg.AuditOutput
+	if cfg.AuditFile != "" {
+		auditFile, err := blog.NewRotatingFile(cfg.AuditFile, blog.RotateOptions{
+			MaxSizeMB:  cfg.LogMaxSizeMB,
+			MaxBackups: cfg.LogMaxBackups,
+			MaxAge:     cfg.LogMaxAge,
+			Compress:   cfg.LogCompress,
+		})
+		if err != nil {
+			return fmt.Errorf("bedrock: audit: %w", err)
+		}
+		b.auditFile = auditFile
+		output = auditFile
+	}
+	if output == nil {
+		output = os.Stderr
+	}
+
+	handler := blog.NewHandler(&blog.HandlerOptions{
+		Level:    slog.LevelInfo,
+		Output:   output,
+		Format:   cfg.AuditFormat,
+		Redactor: redactor,
+	})
+	handler.SetTraceContextFunc(func(ctx context.Context) (traceID, spanID string) {
+		if span := trace.SpanFromContext(ctx); span != nil {
+			return span.TraceID().String(), span.SpanID().String()
+		}
+		return "", ""
+	})
+	handler.SetContextAttrsFunc(func(ctx context.Context) []slog.Attr {
+		static := b.static.Load()
+		attrs := make([]slog.Attr, 0, len(static.slog)+1)
+		if !static.attrs.Has("service") {
+			attrs = append(attrs, slog.String("service", cfg.Service))
+		}
+		attrs = append(attrs, static.slog...)
+		return append(attrs, blog.AttrsToSlog(logAttrsFromContext(ctx).Attrs())...)
+	})
+
+	b.audit = handler
+	return nil
+}
+
+// logSampling returns the log sampling options, or nil if sampling is disabled.
+// Dropped records are counted in log_dropped_total by level.
+func (b *Bedrock) logSampling() *blog.SamplingOptions {
+	if b.config.LogSampleInitial <= 0 {
+		return nil
+	}
+
+	labelNames, labels := b.staticLabels()
+	dropped := b.metrics.Counter("log_dropped_total", "Log records dropped by sampling", append(labelNames, "level")...)
+
+	return &blog.SamplingOptions{
+		Initial:    b.config.LogSampleInitial,
+		Thereafter: b.config.LogSampleThereafter,
+		OnDrop: func(level slog.Level, msg string) {
+			dropped.With(append(labels[:len(labels):len(labels)], attr.String("level", level.String()))...).Inc()
+		},
+	}
+}
+
+// logRecordCounter returns a function that counts log records in log_messages_total by level.
+func (b *Bedrock) logRecordCounter() func(level slog.Level) {
+	labelNames, labels := b.staticLabels()
+	messages := b.metrics.Counter("log_messages_total", "Log records written", append(labelNames, "level")...)
+
+	// Resolve the standard levels up front, so counting doesn't allocate
+	levels := map[slog.Level]*metric.CounterVec{}
+	for _, level := range []slog.Level{slog.LevelDebug, slog.LevelInfo, slog.LevelWarn, slog.LevelError} {
+		levels[level] = messages.With(append(labels[:len(labels):len(labels)], attr.String("level", level.String()))...)
+	}
+
+	return func(level slog.Level) {
+		if vec, ok := levels[level]; ok {
+			vec.Inc()
+			return
+		}
+		messages.With(append(labels[:len(labels):len(labels)], attr.String("level", level.String()))...).Inc()
+	}
+}
+
+// countLogBytes wraps a log output so the bytes written to it are counted in log_bytes_total.
+func (b *Bedrock) countLogBytes(w io.Writer) io.Writer {
+	if b.logBytes == nil {
+		labelNames, labels := b.staticLabels()
+		b.logBytes = b.metrics.Counter("log_bytes_total", "Bytes of log records written", labelNames...).With(labels...)
+	}
+	return &countingWriter{w: w, bytes: b.logBytes}
+}
+
+// staticState is a snapshot of the static attributes, replaced as a whole on update.
+type staticState struct {
+	attrs  attr.Set
+	labels []attr.Attr // a value for each of staticKeys, "_" if removed
+	slog   []slog.Attr // attrs, for log records
+}
+
+// newStaticState returns the snapshot for the static attributes attrs.
+func (b *Bedrock) newStaticState(attrs attr.Set) *staticState {
+	labels := make([]attr.Attr, len(b.staticKeys))
+	for i, key := range b.staticKeys {
+		if v, ok := attrs.Get(key); ok {
+			labels[i] = attr.Attr{Key: key, Value: v}
+		} else {
+			labels[i] = attr.String(key, "_")
+		}
+	}
+	return &staticState{
+		attrs:  attrs,
+		labels: labels,
+		slog:   blog.AttrsToSlog(attrs.Attrs()),
+	}
+}
+
+// staticLabels returns the static metric label names and their current values.
+// The returned slices must not be modified.
+func (b *Bedrock) staticLabels() ([]string, []attr.Attr) {
+	static := b.static.Load()
+	if static == nil {
+		return nil, nil
+	}
+	return slices.Clip(b.staticKeys), slices.Clip(static.labels)
+}
+
+// StaticAttrs returns the current static attributes.
+func (b *Bedrock) StaticAttrs() attr.Set {
+	if static := b.static.Load(); static != nil {
+		return static.attrs
+	}
+	return attr.NewSet()
+}
+
+// SetStaticAttr adds or updates static attributes after creation, such as a role or
+// shard assignment discovered at runtime. The change applies to subsequent log records,
+// to the trace resource of spans exported afterwards, and to the label values of
+// automatic operation metrics and of metrics from Counter, Gauge, and Histogram.
+//
+// Metric label names are fixed when their metrics are registered, so only attributes
+// present at creation are metric labels; attributes added later appear on logs and
+// spans only. Runtime, process, build info, and log metrics keep their values from
+// creation.
+func (b *Bedrock) SetStaticAttr(attrs ...attr.Attr) {
+	if b.isNoop || len(attrs) == 0 {
+		return
+	}
+	b.updateStatic(func(s attr.Set) attr.Set { return s.Merge(attrs...) })
+}
+
+// RemoveStaticAttr removes static attributes after creation. Removed attributes that
+// are metric labels keep their label, with a value of "_".
+func (b *Bedrock) RemoveStaticAttr(keys ...string) {
+	if b.isNoop || len(keys) == 0 {
+		return
+	}
+	b.updateStatic(func(s attr.Set) attr.Set {
+		kept := make([]attr.Attr, 0, s.Len())
+		s.Range(func(a attr.Attr) bool {
+			if !slices.Contains(keys, a.Key) {
+				kept = append(kept, a)
+			}
 			return true
 		})
+		return attr.NewSet(kept...)
+	})
+}
 
-		b.runtimeCollector = metric.NewRuntimeCollector(b.metrics, staticLabels...)
-		b.metrics.RegisterCollector(b.runtimeCollector)
+// updateStatic replaces the static attributes with update's result and propagates them
+// to the trace resource.
+func (b *Bedrock) updateStatic(update func(attr.Set) attr.Set) {
+	b.staticMu.Lock()
+	defer b.staticMu.Unlock()
+
+	attrs := update(b.static.Load().attrs)
+	b.static.Store(b.newStaticState(attrs))
+	b.tracer.SetResource(attrs)
+	if b.exporter != nil {
+		b.exporter.SetResource(attrs)
 	}
+}
 
-	return b, nil
+// countingWriter counts the bytes written to a log output.
+type countingWriter struct {
+	w     io.Writer
+	bytes *metric.CounterVec
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.bytes.Add(float64(n))
+	return n, err
 }
 
 // Logger returns the underlying slog.Logger.
@@ -127,16 +462,81 @@ func (b *Bedrock) Logger() *slog.Logger {
 	return b.logger
 }
 
+// SetLogLevel changes the minimum log level at runtime.
+// It affects every logger derived from this instance, including ones already created.
+func (b *Bedrock) SetLogLevel(level slog.Level) {
+	if b.logLevel == nil {
+		return // noop instance
+	}
+	b.logLevel.Set(level)
+}
+
+// LogLevel returns the current minimum log level.
+func (b *Bedrock) LogLevel() slog.Level {
+	if b.logLevel == nil {
+		return b.config.logLevel()
+	}
+	return b.logLevel.Level()
+}
+
 // Metrics returns the metric registry.
 func (b *Bedrock) Metrics() *metric.Registry {
 	return b.metrics
 }
 
+// NamedMetrics returns the metric registry with the given name, creating it if needed.
+// Named registries are separate from Metrics() and only contain the metrics created in them,
+// so they can expose a reduced metric set (e.g., "public" for a customer-facing scrape).
+// They use the configured MetricPrefix and MetricBuckets. The observability server serves each named
+// registry at /metrics/<name>.
+func (b *Bedrock) NamedMetrics(name string) *metric.Registry {
+	b.namedMu.Lock()
+	defer b.namedMu.Unlock()
+
+	if r, ok := b.namedMetrics[name]; ok {
+		return r
+	}
+	if b.namedMetrics == nil {
+		b.namedMetrics = make(map[string]*metric.Registry)
+	}
+	r := metric.NewRegistry(b.config.MetricPrefix, metric.WithDefaultBuckets(b.config.MetricBuckets))
+	b.namedMetrics[name] = r
+	return r
+}
+
+// lookupNamedMetrics returns the named registry if it has been created.
+func (b *Bedrock) lookupNamedMetrics(name string) (*metric.Registry, bool) {
+	b.namedMu.Lock()
+	defer b.namedMu.Unlock()
+
+	r, ok := b.namedMetrics[name]
+	return r, ok
+}
+
+// attrName returns the name key is recorded under: its semantic convention name if
+// Config.SemConv is set.
+func (b *Bedrock) attrName(key string) string {
+	if b.config.SemConv {
+		return semconv.Name(key)
+	}
+	return key
+}
+
 // Tracer returns the tracer.
 func (b *Bedrock) Tracer() *trace.Tracer {
 	return b.tracer
 }
 
+// Readiness returns the readiness checks, served at /ready by the observability server.
+func (b *Bedrock) Readiness() *health.Registry {
+	return b.readiness
+}
+
+// Liveness returns the liveness checks, served at /health by the observability server.
+func (b *Bedrock) Liveness() *health.Registry {
+	return b.liveness
+}
+
 // IsNoop returns true if this is a noop bedrock instance.
 func (b *Bedrock) IsNoop() bool {
 	return b.isNoop
@@ -144,6 +544,9 @@ func (b *Bedrock) IsNoop() bool {
 
 // Shutdown gracefully shuts down all components.
 func (b *Bedrock) Shutdown(ctx context.Context) error {
+	if b.runtimeCollector != nil {
+		b.runtimeCollector.Stop()
+	}
 	if b.batchProcessor != nil {
 		if err := b.batchProcessor.Shutdown(ctx); err != nil {
 			return err
@@ -154,5 +557,15 @@ func (b *Bedrock) Shutdown(ctx context.Context) error {
 			return err
 		}
 	}
+	if b.logFile != nil {
+		if err := b.logFile.Close(); err != nil {
+			return err
+		}
+	}
+	if b.auditFile != nil {
+		if err := b.auditFile.Close(); err != nil {
+			return err
+		}
+	}
 	return nil
 }
@@ -1,11 +1,27 @@
 package bedrock
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/kzs0/bedrock/attr"
+	"github.com/kzs0/bedrock/log/logtest"
+	"github.com/kzs0/bedrock/metric"
+	"github.com/kzs0/bedrock/metric/prometheus"
+	"github.com/kzs0/bedrock/server"
+	"github.com/kzs0/bedrock/trace"
 )
 
 func TestInit(t *testing.T) {
@@ -124,6 +140,636 @@ func TestRegisterError(t *testing.T) {
 	}
 }
 
+func TestGo(t *testing.T) {
+	ctx, close := Init(context.Background(),
+		WithConfig(Config{Service: "test-service"}),
+	)
+	defer close()
+
+	op, opCtx := Operation(ctx, "request")
+	cancelCtx, cancel := context.WithCancel(opCtx)
+	parent := operationStateFromContext(opCtx)
+
+	release := make(chan struct{})
+	states := make(chan *operationState)
+	Go(cancelCtx, "background", func(ctx context.Context) error {
+		<-release
+		if ctx.Err() != nil {
+			t.Error("expected the goroutine's context not to be canceled with the request")
+		}
+		states <- operationStateFromContext(ctx)
+		panic("boom")
+	})
+	cancel()
+	op.Done()
+	release <- struct{}{}
+
+	state := <-states
+	if state.parent != parent {
+		t.Error("expected the goroutine's operation to be a child of the request")
+	}
+	if state.span.ParentID() != parent.span.SpanID() || state.span.TraceID() != parent.span.TraceID() {
+		t.Error("expected the goroutine's span to be parented by the request's span")
+	}
+}
+
+func TestWithTimeout(t *testing.T) {
+	ctx, close := Init(context.Background(),
+		WithConfig(Config{Service: "test-service"}),
+	)
+	defer close()
+
+	var state *operationState
+	err := WithTimeout(ctx, "lookup", time.Second, func(ctx context.Context) error {
+		state = operationStateFromContext(ctx)
+		return nil
+	})
+	if err != nil || !state.success {
+		t.Errorf("expected success within the timeout, got err=%v success=%v", err, state.success)
+	}
+	if v, _ := state.attrs.Get("timeout.deadline_ms"); v.AsInt64() != 1000 {
+		t.Errorf("expected timeout.deadline_ms=1000, got %v", v)
+	}
+	if _, ok := state.attrs.Get("error.type"); ok {
+		t.Error("expected no error.type on success")
+	}
+
+	// fn ignores its context, so WithTimeout must return without it
+	release := make(chan struct{})
+	defer func() { release <- struct{}{} }()
+	states := make(chan *operationState, 1)
+	err = WithTimeout(ctx, "lookup", 10*time.Millisecond, func(ctx context.Context) error {
+		states <- operationStateFromContext(ctx)
+		<-release
+		return nil
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+	state = <-states
+	if state.success {
+		t.Error("expected the timed-out operation to fail")
+	}
+	if v, _ := state.attrs.Get("error.type"); v.AsString() != "timeout" {
+		t.Errorf("expected error.type=timeout, got %q", v.AsString())
+	}
+	if v, _ := state.attrs.Get("timeout.elapsed_ms"); v.AsInt64() < 10 {
+		t.Errorf("expected timeout.elapsed_ms of at least 10, got %v", v)
+	}
+
+	errBoom := errors.New("boom")
+	err = WithTimeout(ctx, "lookup", time.Second, func(ctx context.Context) error {
+		panic(errBoom)
+	})
+	if !errors.Is(err, errBoom) {
+		t.Errorf("expected the panic as an error, got %v", err)
+	}
+}
+
+func panicLookup(ctx context.Context) error {
+	panic("lookup failed")
+}
+
+func TestWithTimeoutPanicStack(t *testing.T) {
+	rec := logtest.NewRecorder()
+	ctx, close := Init(context.Background(),
+		WithConfig(Config{Service: "test-service", LogHandler: rec}),
+	)
+	defer close()
+
+	// The stack is the worker goroutine's, where fn panicked, not the re-panic's
+	err := WithTimeout(ctx, "lookup", time.Second, panicLookup)
+	var se *attr.StackError
+	if !errors.As(err, &se) {
+		t.Fatalf("expected a stack error, got %v", err)
+	}
+	if !strings.Contains(se.Stack().String(), "panicLookup") {
+		t.Errorf("expected the stack to include panicLookup, got:\n%s", se.Stack())
+	}
+
+	r, ok := rec.FindRecord(slog.LevelError, "operation panicked")
+	if !ok {
+		t.Fatalf("expected the panic to be logged, got %v", rec.Records())
+	}
+	if v, _ := r.Attr("stack"); !strings.Contains(v.String(), "panicLookup") {
+		t.Errorf("expected the logged stack to include panicLookup, got:\n%s", v)
+	}
+}
+
+func TestOpFailSucceed(t *testing.T) {
+	rec := logtest.NewRecorder()
+	ctx, close := Init(context.Background(),
+		WithConfig(Config{
+			Service:      "test-service",
+			LogHandler:   rec,
+			LogCanonical: true,
+		}),
+	)
+	defer close()
+
+	errNotFound := errors.New("not found")
+	op, opCtx := Operation(ctx, "lookup")
+	op.Fail(fmt.Errorf("user 42: %w", errNotFound))
+	op.Done()
+
+	state := operationStateFromContext(opCtx)
+	if state.success || !errors.Is(state.failure, errNotFound) {
+		t.Errorf("expected failure wrapping errNotFound, got success=%v failure=%v", state.success, state.failure)
+	}
+	if status, msg := state.span.Status(); status != trace.StatusError || msg != "user 42: not found" {
+		t.Errorf("expected error span status, got %v %q", status, msg)
+	}
+	if state.attrs.Has("error") {
+		t.Error("expected Fail not to register an error attribute")
+	}
+
+	canonical, ok := rec.FindRecord(slog.LevelInfo, "operation.complete", attr.String("operation", "lookup"))
+	if !ok {
+		t.Fatalf("expected canonical log line, got %v", rec.Records())
+	}
+	if v, _ := canonical.Attr("error"); !errors.Is(v.Any().(error), errNotFound) {
+		t.Errorf("expected the original error in the canonical log, got %v", v)
+	}
+
+	op, opCtx = Operation(ctx, "retry")
+	op.Register(opCtx, attr.Error(errors.New("attempt 1 failed")))
+	op.Succeed()
+	op.Done()
+
+	state = operationStateFromContext(opCtx)
+	if !state.success || state.failure != nil {
+		t.Errorf("expected success after Succeed, got success=%v failure=%v", state.success, state.failure)
+	}
+	if status, _ := state.span.Status(); status != trace.StatusOK {
+		t.Errorf("expected OK span status, got %v", status)
+	}
+}
+
+func TestOpFailNil(t *testing.T) {
+	ctx, close := Init(context.Background(),
+		WithConfig(Config{Service: "test-service"}),
+	)
+	defer close()
+
+	op, opCtx := Operation(ctx, "lookup")
+	op.Fail(nil)
+	op.Done()
+
+	state := operationStateFromContext(opCtx)
+	if state.success {
+		t.Error("expected Fail(nil) to fail the operation")
+	}
+	if status, _ := state.span.Status(); status != trace.StatusError {
+		t.Errorf("expected error span status, got %v", status)
+	}
+}
+
+func TestOpDoneEndOptions(t *testing.T) {
+	ctx, close := Init(context.Background(),
+		WithConfig(Config{Service: "test-service"}),
+	)
+	defer close()
+
+	errFailed := errors.New("failed")
+	process := func(fail bool) (state *operationState, err error) {
+		op, opCtx := Operation(ctx, "process")
+		state = operationStateFromContext(opCtx)
+		defer func() { op.Done(EndFailure(err)) }()
+		if fail {
+			return state, errFailed
+		}
+		return state, nil
+	}
+
+	state, _ := process(true)
+	if state.success || state.failure != errFailed {
+		t.Errorf("expected failure from EndFailure, got success=%v failure=%v", state.success, state.failure)
+	}
+	if state.span.IsRecording() {
+		t.Error("expected span to be ended")
+	}
+
+	state, _ = process(false)
+	if !state.success {
+		t.Errorf("expected EndFailure(nil) to keep success, got failure=%v", state.failure)
+	}
+
+	op, opCtx := Operation(ctx, "retry")
+	op.Register(opCtx, attr.Error(errFailed))
+	op.Done(EndSuccess())
+	if state := operationStateFromContext(opCtx); !state.success {
+		t.Error("expected EndSuccess to override the registered error")
+	}
+}
+
+func TestOpDoneRecover(t *testing.T) {
+	rec := logtest.NewRecorder()
+	ctx, close := Init(context.Background(),
+		WithConfig(Config{Service: "test-service", LogHandler: rec}),
+	)
+	defer close()
+
+	var state *operationState
+	func() {
+		op, opCtx := Operation(ctx, "job")
+		state = operationStateFromContext(opCtx)
+		defer op.DoneRecover()
+		panic("boom")
+	}()
+
+	if state.success || state.failure == nil || state.failure.Error() != "panic: boom" {
+		t.Errorf("expected failure from the panic, got success=%v failure=%v", state.success, state.failure)
+	}
+	if state.span.IsRecording() {
+		t.Error("expected span to be ended")
+	}
+	events := state.span.Events()
+	if len(events) != 1 || events[0].Name != "exception" {
+		t.Fatalf("expected an exception event, got %v", events)
+	}
+	stack, _ := events[0].Attrs.Get("exception.stacktrace")
+	if !strings.Contains(stack.AsString(), "TestOpDoneRecover") {
+		t.Errorf("expected the panicking function in the stack, got %q", stack.AsString())
+	}
+	if !rec.HasRecord(slog.LevelError, "operation panicked", attr.String("error", "panic: boom")) {
+		t.Errorf("expected panic log record, got %v", rec.Records())
+	}
+
+	errBoom := errors.New("boom")
+	defer func() {
+		if r := recover(); r != errBoom {
+			t.Errorf("expected Repanic to panic again with the original value, got %v", r)
+		}
+		if !errors.Is(state.failure, errBoom) {
+			t.Errorf("expected failure wrapping the panic error, got %v", state.failure)
+		}
+	}()
+	op, opCtx := Operation(ctx, "job")
+	state = operationStateFromContext(opCtx)
+	defer op.DoneRecover(Repanic())
+	panic(errBoom)
+}
+
+func TestRun(t *testing.T) {
+	ctx, close := Init(context.Background(),
+		WithConfig(Config{Service: "test-service"}),
+	)
+	defer close()
+
+	var state *operationState
+	err := Run(ctx, "job", func(ctx context.Context) error {
+		state = operationStateFromContext(ctx)
+		return nil
+	}, Attrs(attr.String("key", "value")))
+	if err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+	if state == nil || state.name != "job" || !state.success {
+		t.Fatalf("expected a successful job operation, got %+v", state)
+	}
+	if v, _ := state.attrs.Get("key"); v.AsString() != "value" {
+		t.Error("expected options to be applied to the operation")
+	}
+	if state.span.IsRecording() {
+		t.Error("expected span to be ended")
+	}
+
+	errBoom := errors.New("boom")
+	err = Run(ctx, "job", func(ctx context.Context) error {
+		state = operationStateFromContext(ctx)
+		return errBoom
+	})
+	if err != errBoom {
+		t.Errorf("expected fn's error to be returned, got %v", err)
+	}
+	if state.success || state.failure != errBoom {
+		t.Errorf("expected failure from fn's error, got success=%v failure=%v", state.success, state.failure)
+	}
+
+	err = Run(ctx, "job", func(ctx context.Context) error {
+		state = operationStateFromContext(ctx)
+		panic(errBoom)
+	})
+	if !errors.Is(err, errBoom) || err.Error() != "panic: boom" {
+		t.Errorf("expected the panic as an error, got %v", err)
+	}
+	if state.success || !errors.Is(state.failure, errBoom) {
+		t.Errorf("expected failure from the panic, got success=%v failure=%v", state.success, state.failure)
+	}
+	if state.span.IsRecording() {
+		t.Error("expected span to be ended after a panic")
+	}
+}
+
+func TestOperationFailOnCancel(t *testing.T) {
+	ctx, close := Init(context.Background(),
+		WithConfig(Config{Service: "test-service"}),
+	)
+	defer close()
+
+	// Without the option, a canceled operation is still a success
+	cancelCtx, cancel := context.WithCancel(ctx)
+	op, opCtx := Operation(cancelCtx, "lenient")
+	cancel()
+	op.Done()
+	if !operationStateFromContext(opCtx).success {
+		t.Error("expected success without FailOnCancel")
+	}
+
+	cancelCtx, cancel = context.WithCancel(ctx)
+	op, opCtx = Operation(cancelCtx, "canceled", FailOnCancel(), MetricLabels("error.type"))
+	cancel()
+	op.Done()
+	state := operationStateFromContext(opCtx)
+	if state.success || !errors.Is(state.failure, context.Canceled) {
+		t.Errorf("expected canceled failure, got success=%v failure=%v", state.success, state.failure)
+	}
+	if v, _ := state.attrs.Get("error.type"); v.AsString() != "canceled" {
+		t.Errorf("expected error.type=canceled, got %q", v.AsString())
+	}
+
+	deadlineCtx, cancel := context.WithTimeout(ctx, time.Nanosecond)
+	defer cancel()
+	op, opCtx = Operation(deadlineCtx, "slow", FailOnCancel())
+	<-deadlineCtx.Done()
+	op.Done()
+	if v, _ := operationStateFromContext(opCtx).attrs.Get("error.type"); v.AsString() != "deadline_exceeded" {
+		t.Errorf("expected error.type=deadline_exceeded, got %q", v.AsString())
+	}
+
+	// Operations that finish before cancellation are unaffected
+	cancelCtx, cancel = context.WithCancel(ctx)
+	op, opCtx = Operation(cancelCtx, "fast", FailOnCancel())
+	op.Done()
+	cancel()
+	if !operationStateFromContext(opCtx).success {
+		t.Error("expected success when done before cancellation")
+	}
+
+	for _, fam := range FromContext(ctx).Metrics().Gather() {
+		if fam.Name != "canceled_failures" {
+			continue
+		}
+		if v, _ := fam.Metrics[0].Labels.Get("error_type"); v.AsString() != "canceled" {
+			t.Errorf("expected error.type label on the failure metric, got %q", v.AsString())
+		}
+		return
+	}
+	t.Error("expected canceled_failures to be gathered")
+}
+
+func TestConfigFailOnCancel(t *testing.T) {
+	ctx, close := Init(context.Background(),
+		WithConfig(Config{Service: "test-service", OperationFailOnCancel: true}),
+	)
+	defer close()
+
+	cancelCtx, cancel := context.WithCancel(ctx)
+	op, opCtx := Operation(cancelCtx, "canceled")
+	cancel()
+	op.Done()
+	if operationStateFromContext(opCtx).success {
+		t.Error("expected OperationFailOnCancel to fail canceled operations")
+	}
+}
+
+func TestOperationErrorTypes(t *testing.T) {
+	errInvalid := errors.New("invalid input")
+	ctx, close := Init(context.Background(),
+		WithConfig(Config{
+			Service:             "test-service",
+			OperationErrorTypes: true,
+			ErrorClassifier: func(err error) string {
+				if errors.Is(err, errInvalid) {
+					return "validation"
+				}
+				return ClassifyError(err)
+			},
+		}),
+	)
+	defer close()
+
+	for _, err := range []error{
+		fmt.Errorf("parse: %w", errInvalid),
+		fmt.Errorf("dial: %w", context.DeadlineExceeded),
+		errors.New("boom"),
+		errors.New("boom again"),
+	} {
+		op, _ := Operation(ctx, "classified")
+		op.Register(ctx, attr.Error(err))
+		op.Done()
+	}
+
+	op, opCtx := Operation(ctx, "classified")
+	op.Done()
+	if _, ok := operationStateFromContext(opCtx).attrs.Get("error.type"); ok {
+		t.Error("expected no error.type on a successful operation")
+	}
+
+	failures := make(map[string]float64)
+	for _, fam := range FromContext(ctx).Metrics().Gather() {
+		if fam.Name != "classified_failures" {
+			continue
+		}
+		for _, m := range fam.Metrics {
+			v, _ := m.Labels.Get("error_type")
+			failures[v.AsString()] += m.Value
+		}
+	}
+	expected := map[string]float64{"validation": 1, "timeout": 1, "internal": 2}
+	for errorType, want := range expected {
+		if failures[errorType] != want {
+			t.Errorf("expected %v failures with error_type %q, got %v", want, errorType, failures[errorType])
+		}
+	}
+	if len(failures) != len(expected) {
+		t.Errorf("expected error_type values %v, got %v", expected, failures)
+	}
+}
+
+func TestOperationSLO(t *testing.T) {
+	ctx, close := Init(context.Background(),
+		WithConfig(Config{
+			Service:                    "test-service",
+			TraceSampler:               trace.NeverSampler{},
+			OperationSLOSampleBreaches: true,
+		}),
+	)
+	defer close()
+
+	op, fastCtx := Operation(ctx, "lookup", WithSLO(time.Hour))
+	op.Done()
+	op, slowCtx := Operation(ctx, "lookup", WithSLO(time.Millisecond))
+	time.Sleep(2 * time.Millisecond)
+	op.Done()
+
+	fast, slow := operationStateFromContext(fastCtx), operationStateFromContext(slowCtx)
+	if v, _ := fast.attrs.Get("slo_breached"); v.AsBool() {
+		t.Error("expected slo_breached=false within the SLO")
+	}
+	if v, _ := slow.attrs.Get("slo_breached"); !v.AsBool() {
+		t.Error("expected slo_breached=true over the SLO")
+	}
+	if fast.span.IsSampled() || !slow.span.IsSampled() {
+		t.Errorf("expected only the breaching span to be sampled, got fast=%v slow=%v",
+			fast.span.IsSampled(), slow.span.IsSampled())
+	}
+
+	for _, fam := range FromContext(ctx).Metrics().Gather() {
+		if fam.Name == "lookup_slo_breaches" {
+			if len(fam.Metrics) != 1 || fam.Metrics[0].Value != 1 {
+				t.Errorf("expected 1 SLO breach, got %+v", fam.Metrics)
+			}
+			return
+		}
+	}
+	t.Error("expected lookup_slo_breaches to be gathered")
+}
+
+func TestRegisterErrorWithStack(t *testing.T) {
+	ctx, close := Init(context.Background(),
+		WithConfig(Config{Service: "test-service"}),
+	)
+	defer close()
+
+	op, ctx := Operation(ctx, "test")
+	defer op.Done()
+
+	op.Register(ctx, attr.ErrorWithStack(fmt.Errorf("query: %w", context.DeadlineExceeded)))
+
+	state := operationStateFromContext(ctx)
+	if state.success {
+		t.Error("expected success to be false after registering error")
+	}
+	if !errors.Is(state.failure, context.DeadlineExceeded) {
+		t.Errorf("expected failure to preserve the error chain, got %v", state.failure)
+	}
+
+	events := state.span.Events()
+	if len(events) != 1 || events[0].Name != "exception" {
+		t.Fatalf("expected exception event, got %+v", events)
+	}
+	stack, ok := events[0].Attrs.Get("exception.stacktrace")
+	if !ok || !strings.Contains(stack.AsString(), "TestRegisterErrorWithStack") {
+		t.Errorf("expected stack trace on exception event, got %q", stack)
+	}
+}
+
+func TestLogErrorStacks(t *testing.T) {
+	var buf bytes.Buffer
+	ctx, close := Init(context.Background(),
+		WithConfig(Config{
+			Service:        "test-service",
+			LogOutput:      &buf,
+			LogErrorStacks: true,
+		}),
+	)
+	defer close()
+
+	op, ctx := Operation(ctx, "test")
+	defer op.Done()
+
+	Warn(ctx, "retrying")
+	Error(ctx, "gave up")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 log records, got %q", buf.String())
+	}
+	if strings.Contains(lines[0], `"stack"`) {
+		t.Errorf("expected no stack on Warn records, got %s", lines[0])
+	}
+
+	var rec map[string]any
+	if err := json.Unmarshal([]byte(lines[1]), &rec); err != nil {
+		t.Fatal(err)
+	}
+	stack, _ := rec["stack"].(string)
+	if !strings.HasPrefix(stack, "github.com/kzs0/bedrock.TestLogErrorStacks") {
+		t.Errorf("expected stack to start at the logging call site, got %q", stack)
+	}
+
+	events := operationStateFromContext(ctx).span.Events()
+	if len(events) != 1 || events[0].Name != "exception" {
+		t.Fatalf("expected exception event on span, got %+v", events)
+	}
+	if msg, _ := events[0].Attrs.Get("exception.message"); msg.AsString() != "gave up" {
+		t.Errorf("expected exception message, got %q", msg)
+	}
+}
+
+func TestLogErrorWithStack(t *testing.T) {
+	var buf bytes.Buffer
+	ctx, close := Init(context.Background(),
+		WithConfig(Config{
+			Service:        "test-service",
+			LogOutput:      &buf,
+			LogErrorStacks: true,
+		}),
+	)
+	defer close()
+
+	Error(ctx, "failed", attr.ErrorWithStack(errors.New("boom")))
+
+	var rec map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+		t.Fatal(err)
+	}
+	errField, ok := rec["error"].(map[string]any)
+	if !ok || errField["message"] != "boom" {
+		t.Fatalf("expected structured error field, got %v", rec["error"])
+	}
+	if stack, _ := errField["stack"].(string); !strings.Contains(stack, "TestLogErrorWithStack") {
+		t.Errorf("expected stack in error field, got %q", stack)
+	}
+	if _, ok := rec["stack"]; ok {
+		t.Error("expected no separate stack when the error carries one")
+	}
+}
+
+func TestLogTracedDebug(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		sampler trace.Sampler
+		want    []string
+	}{
+		{"sampled", trace.AlwaysSampler{}, []string{"in trace", "outside trace"}},
+		{"not sampled", trace.NeverSampler{}, []string{"outside trace"}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			ctx, close := Init(context.Background(),
+				WithConfig(Config{
+					Service:        "test-service",
+					LogOutput:      &buf,
+					LogLevel:       "info",
+					LogTracedDebug: true,
+					TraceSampler:   tt.sampler,
+				}),
+			)
+			defer close()
+
+			Debug(ctx, "no trace")
+			op, opCtx := Operation(ctx, "test")
+			Debug(opCtx, "in trace")
+			op.Done()
+			Info(ctx, "outside trace")
+
+			var got []string
+			for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+				var rec map[string]any
+				if err := json.Unmarshal([]byte(line), &rec); err != nil {
+					t.Fatalf("invalid log line %q: %v", line, err)
+				}
+				got = append(got, rec["msg"].(string))
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("expected records %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
 func TestSource(t *testing.T) {
 	ctx, close := Init(context.Background(),
 		WithConfig(Config{Service: "test-service"}),
@@ -145,116 +791,781 @@ func TestSource(t *testing.T) {
 		t.Errorf("expected name 'background.worker', got %q", sourceCfg.name)
 	}
 
-	// Create operation from source context
-	op, ctx := Operation(ctx, "process",
-		Attrs(attr.Int("batch.size", 100)),
-	)
-	defer op.Done()
+	// Create operation from source context
+	op, ctx := Operation(ctx, "process",
+		Attrs(attr.Int("batch.size", 100)),
+	)
+	defer op.Done()
+
+	state := operationStateFromContext(ctx)
+	if state.name != "background.worker.process" {
+		t.Errorf("expected name 'background.worker.process', got %q", state.name)
+	}
+
+	// Should inherit source metric labels
+	if len(state.metricLabels) != 1 || state.metricLabels[0] != "worker.type" {
+		t.Errorf("expected to inherit source metric labels, got %v", state.metricLabels)
+	}
+}
+
+func TestSourceOperationsShareAttrs(t *testing.T) {
+	ctx, close := Init(context.Background(),
+		WithConfig(Config{Service: "test-service"}),
+	)
+	defer close()
+
+	source, ctx := Source(ctx, "background.worker",
+		SourceAttrs(attr.String("worker.type", "test")),
+	)
+	defer source.Done()
+
+	op, opCtx := Operation(ctx, "process", Attrs(attr.Int("batch.size", 100)))
+	defer op.Done()
+
+	// Source attributes and names are shared between operations without leaking
+	other, otherCtx := Operation(ctx, "process", Attrs(attr.String("other", "x")))
+	defer other.Done()
+
+	for _, c := range []context.Context{opCtx, otherCtx} {
+		state := operationStateFromContext(c)
+		if state.name != "background.worker.process" {
+			t.Errorf("expected name 'background.worker.process', got %q", state.name)
+		}
+		if v, _ := state.attrs.Get("worker.type"); v.AsString() != "test" {
+			t.Errorf("expected source attribute worker.type=test, got %q", v.AsString())
+		}
+	}
+	if _, ok := operationStateFromContext(otherCtx).attrs.Get("batch.size"); ok {
+		t.Error("expected attributes of one operation not to leak into another")
+	}
+}
+
+func TestSourceAggregateLabels(t *testing.T) {
+	ctx, close := Init(context.Background(),
+		WithConfig(Config{Service: "test-service"}),
+	)
+	defer close()
+
+	source, ctx := Source(ctx, "consumer", SourceAggregateLabels("topic"))
+	defer source.Done()
+
+	source.Aggregate(ctx,
+		attr.Labeled(attr.Sum("messages", 2), attr.String("topic", "orders")),
+		attr.Labeled(attr.Sum("messages", 3), attr.String("topic", "payments"), attr.String("ignored", "x")),
+		attr.Sum("messages", 1),
+		attr.Labeled(attr.Gauge("lag", 7), attr.String("topic", "orders")),
+		attr.Labeled(attr.Histogram("batch_size", 10), attr.String("topic", "orders")),
+	)
+
+	totals := make(map[string]float64)
+	var foundGauge, foundHistogram bool
+	for _, fam := range FromContext(ctx).Metrics().Gather() {
+		switch fam.Name {
+		case "consumer_messages":
+			for _, m := range fam.Metrics {
+				if _, ok := m.Labels.Get("ignored"); ok {
+					t.Error("expected unregistered label to be ignored")
+				}
+				topic, _ := m.Labels.Get("topic")
+				totals[topic.AsString()] += m.Value
+			}
+		case "consumer_lag":
+			foundGauge = len(fam.Metrics) == 1 && fam.Metrics[0].Value == 7
+		case "consumer_batch_size":
+			foundHistogram = len(fam.Metrics) == 1 && fam.Metrics[0].Count == 1
+		}
+	}
+
+	expected := map[string]float64{"orders": 2, "payments": 3, "_": 1}
+	for topic, want := range expected {
+		if totals[topic] != want {
+			t.Errorf("expected consumer_messages{topic=%q} = %v, got %v", topic, want, totals[topic])
+		}
+	}
+	if !foundGauge {
+		t.Error("expected labeled consumer_lag gauge")
+	}
+	if !foundHistogram {
+		t.Error("expected labeled consumer_batch_size histogram")
+	}
+}
+
+func TestSourceDone(t *testing.T) {
+	rec := logtest.NewRecorder()
+	ctx, close := Init(context.Background(),
+		WithConfig(Config{Service: "test-service", LogHandler: rec}),
+	)
+	defer close()
+
+	source, ctx := Source(ctx, "worker")
+	source.Aggregate(ctx,
+		attr.Sum("jobs", 2),
+		attr.Sum("jobs", 3),
+		attr.Gauge("queue_depth", 4),
+		attr.Histogram("batch_size", 10),
+		attr.Histogram("batch_size", 20),
+	)
+
+	upValue := func() float64 {
+		for _, fam := range FromContext(ctx).Metrics().Gather() {
+			if fam.Name == "worker_up" && len(fam.Metrics) == 1 {
+				return fam.Metrics[0].Value
+			}
+		}
+		t.Fatal("expected worker_up gauge")
+		return 0
+	}
+	if v := upValue(); v != 1 {
+		t.Errorf("expected worker_up 1 while running, got %v", v)
+	}
+
+	source.Done()
+	source.Done() // idempotent
+
+	if v := upValue(); v != 0 {
+		t.Errorf("expected worker_up 0 after Done, got %v", v)
+	}
+	if source.span == nil || source.span.EndTime().IsZero() {
+		t.Error("expected source span to be ended")
+	}
+
+	var records []logtest.Record
+	for _, r := range rec.Records() {
+		if r.Message == "source done" {
+			records = append(records, r)
+		}
+	}
+	if len(records) != 1 {
+		t.Fatalf("expected 1 source done record, got %d", len(records))
+	}
+	if v, ok := records[0].Attr("source"); !ok || v.String() != "worker" {
+		t.Errorf("expected source 'worker', got %v", v)
+	}
+	v, ok := records[0].Attr("aggregates")
+	if !ok {
+		t.Fatal("expected aggregates in source done record")
+	}
+	aggregates := v.Any().(map[string]any)
+	if aggregates["jobs"] != 5.0 {
+		t.Errorf("expected jobs total 5, got %v", aggregates["jobs"])
+	}
+	if aggregates["queue_depth"] != 4.0 {
+		t.Errorf("expected last queue_depth 4, got %v", aggregates["queue_depth"])
+	}
+	batch := aggregates["batch_size"].(map[string]any)
+	if batch["count"] != 2 || batch["sum"] != 30.0 {
+		t.Errorf("expected batch_size count 2 sum 30, got %v", batch)
+	}
+}
+
+func TestAutomaticMetrics(t *testing.T) {
+	ctx, close := Init(context.Background(),
+		WithConfig(Config{Service: "test-service"}),
+	)
+	defer close()
+
+	op, ctx := Operation(ctx, "test.operation",
+		MetricLabels("status"),
+		Attrs(attr.String("status", "ok")),
+	)
+	time.Sleep(5 * time.Millisecond)
+	op.Done()
+
+	// Verify automatic metrics were recorded
+	b := FromContext(ctx)
+	families := b.Metrics().Gather()
+
+	expectedMetrics := map[string]bool{
+		"test_operation_count":       false,
+		"test_operation_successes":   false,
+		"test_operation_duration_ms": false,
+	}
+
+	for _, fam := range families {
+		if _, exists := expectedMetrics[fam.Name]; exists {
+			expectedMetrics[fam.Name] = true
+
+			if len(fam.Metrics) == 0 {
+				t.Errorf("metric %s has no data points", fam.Name)
+				continue
+			}
+
+			// Verify metric has the label
+			metric := fam.Metrics[0]
+			hasStatusLabel := false
+			metric.Labels.Range(func(a attr.Attr) bool {
+				if a.Key == "status" && a.Value.AsString() == "ok" {
+					hasStatusLabel = true
+					return false
+				}
+				return true
+			})
+			if !hasStatusLabel {
+				t.Errorf("metric %s missing 'status' label", fam.Name)
+			}
+		}
+	}
+
+	for metric, found := range expectedMetrics {
+		if !found {
+			t.Errorf("expected metric %s not found", metric)
+		}
+	}
+}
+
+func TestMetricLabelDefaults(t *testing.T) {
+	ctx, close := Init(context.Background(),
+		WithConfig(Config{Service: "test-service"}),
+	)
+	defer close()
+
+	op, ctx := Operation(ctx, "test.op",
+		MetricLabels("foo", "bar"),
+		Attrs(attr.String("foo", "value1")),
+		// Note: "bar" is not provided
+	)
+	op.Done()
+
+	// Verify metrics have "_" for missing label
+	b := FromContext(ctx)
+	families := b.Metrics().Gather()
+	for _, fam := range families {
+		if fam.Name == "test.op_count" {
+			if len(fam.Metrics) == 0 {
+				t.Fatal("no metrics found")
+			}
+
+			metric := fam.Metrics[0]
+			foundBar := false
+			metric.Labels.Range(func(a attr.Attr) bool {
+				if a.Key == "bar" {
+					foundBar = true
+					if a.Value.AsString() != "_" {
+						t.Errorf("expected label 'bar' to have value '_', got %q", a.Value.AsString())
+					}
+				}
+				return true
+			})
+			if !foundBar {
+				t.Error("expected to find 'bar' label with default value")
+			}
+			break
+		}
+	}
+}
+
+func TestMetricLabelAllowDenyLists(t *testing.T) {
+	ctx, close := Init(context.Background(),
+		WithConfig(Config{
+			Service:              "test-service",
+			MetricLabelAllowlist: []string{"method", "status", "user_id"},
+			MetricLabelDenylist:  []string{"user_id"},
+		}),
+	)
+	defer close()
+
+	op, ctx := Operation(ctx, "filtered.op",
+		MetricLabels("method", "route", "user_id"),
+		Attrs(
+			attr.String("method", "GET"),
+			attr.String("route", "/users/123"),
+			attr.String("user_id", "123"),
+		),
+	)
+	op.Done()
+
+	b := FromContext(ctx)
+	for _, fam := range b.Metrics().Gather() {
+		if fam.Name != "filtered_op_count" {
+			continue
+		}
+		if len(fam.Metrics) == 0 {
+			t.Fatal("no metrics found")
+		}
+		labels := fam.Metrics[0].Labels
+		if _, ok := labels.Get("method"); !ok {
+			t.Error("expected allowed label 'method'")
+		}
+		if _, ok := labels.Get("route"); ok {
+			t.Error("expected label 'route' to be dropped by the allowlist")
+		}
+		if _, ok := labels.Get("user_id"); ok {
+			t.Error("expected label 'user_id' to be dropped by the denylist")
+		}
+		return
+	}
+	t.Error("expected filtered_op_count to be gathered")
+}
+
+func TestMetricConsolidatedOperations(t *testing.T) {
+	ctx, close := Init(context.Background(),
+		WithConfig(Config{Service: "test-service", MetricConsolidatedOperations: true}),
+		WithStaticAttrs(attr.String("env", "test")),
+	)
+	defer close()
+
+	op, _ := Operation(ctx, "first.op", MetricLabels("method"), Attrs(attr.String("method", "GET")))
+	op.Done()
+	op, _ = Operation(ctx, "second.op")
+	op.Register(ctx, attr.Error(errors.New("boom")))
+	op.Done()
+
+	families := map[string]metric.MetricFamily{}
+	for _, fam := range FromContext(ctx).Metrics().Gather() {
+		families[fam.Name] = fam
+	}
+
+	if _, ok := families["first_op_count"]; ok {
+		t.Error("expected no per-operation metric families")
+	}
+
+	count, ok := families["operation_count"]
+	if !ok {
+		t.Fatal("expected operation_count to be gathered")
+	}
+	if len(count.Metrics) != 2 {
+		t.Fatalf("expected one series per operation, got %d", len(count.Metrics))
+	}
+	for _, m := range count.Metrics {
+		if _, ok := m.Labels.Get("operation"); !ok {
+			t.Error("expected operation label")
+		}
+		if _, ok := m.Labels.Get("env"); !ok {
+			t.Error("expected static label env")
+		}
+		if _, ok := m.Labels.Get("method"); ok {
+			t.Error("expected operation-specific labels to be dropped")
+		}
+	}
+
+	failures := families["operation_failures"]
+	if len(failures.Metrics) != 1 {
+		t.Fatalf("expected 1 failure series, got %d", len(failures.Metrics))
+	}
+	if v, _ := failures.Metrics[0].Labels.Get("operation"); v.AsString() != "second.op" {
+		t.Errorf("expected failure for second.op, got %q", v.AsString())
+	}
+
+	if _, ok := families["operation_duration_ms"]; !ok {
+		t.Error("expected operation_duration_ms to be gathered")
+	}
+}
+
+func TestNoMetrics(t *testing.T) {
+	ctx, close := Init(context.Background(),
+		WithConfig(Config{Service: "test-service"}),
+	)
+	defer close()
+
+	op, opCtx := Operation(ctx, "hot.op", NoMetrics())
+	if operationStateFromContext(opCtx).span == nil {
+		t.Error("expected NoMetrics to keep tracing enabled")
+	}
+	child, _ := Operation(opCtx, "child.op")
+	child.Done()
+	op.Done()
+
+	names := map[string]bool{}
+	for _, fam := range FromContext(ctx).Metrics().Gather() {
+		names[fam.Name] = true
+	}
+	if names["hot_op_count"] || names["hot_op_duration_ms"] {
+		t.Error("expected no metrics for an operation with NoMetrics")
+	}
+	if !names["child_op_count"] {
+		t.Error("expected child operations to still record metrics")
+	}
+}
+
+func TestSampleMetrics(t *testing.T) {
+	ctx, close := Init(context.Background(),
+		WithConfig(Config{Service: "test-service"}),
+	)
+	defer close()
+
+	const n, sample = 1000, 10
+	for range n {
+		op, _ := Operation(ctx, "hot.op", SampleMetrics(sample))
+		op.Done()
+	}
+
+	var count, successes float64
+	var observed uint64
+	for _, fam := range FromContext(ctx).Metrics().Gather() {
+		for _, m := range fam.Metrics {
+			switch fam.Name {
+			case "hot_op_count":
+				count += m.Value
+			case "hot_op_successes":
+				successes += m.Value
+			case "hot_op_duration_ms":
+				observed += m.Count
+			}
+		}
+	}
+
+	// Each sampled invocation adds sample to the counters and one histogram observation
+	if count != successes {
+		t.Errorf("expected successes %v to match count %v", successes, count)
+	}
+	if count != float64(observed*sample) {
+		t.Errorf("expected count %v to be %d times the %d histogram observations", count, sample, observed)
+	}
+	if count < n/2 || count > n*3/2 {
+		t.Errorf("expected count near %d, got %v", n, count)
+	}
+}
+
+func TestMetricDurationSeconds(t *testing.T) {
+	ctx, close := Init(context.Background(),
+		WithConfig(Config{Service: "test-service", MetricDurationSeconds: true}),
+	)
+	defer close()
+
+	op, _ := Operation(ctx, "timed.op")
+	time.Sleep(2 * time.Millisecond)
+	op.Done()
+
+	for _, fam := range FromContext(ctx).Metrics().Gather() {
+		if fam.Name == "timed_op_duration_ms" {
+			t.Error("expected no milliseconds histogram")
+		}
+		if fam.Name != "timed_op_duration_seconds" {
+			continue
+		}
+		if fam.Unit != metric.UnitSeconds {
+			t.Errorf("expected unit seconds, got %q", fam.Unit)
+		}
+		sum := fam.Metrics[0].Sum
+		if sum < 0.002 || sum >= 1 {
+			t.Errorf("expected duration in fractional seconds, got %v", sum)
+		}
+		return
+	}
+	t.Error("expected timed_op_duration_seconds to be gathered")
+}
+
+func TestNamedMetrics(t *testing.T) {
+	b, err := New(Config{Service: "test-service"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	public := b.NamedMetrics("public")
+	if b.NamedMetrics("public") != public {
+		t.Error("expected the same registry for the same name")
+	}
+	if public == b.Metrics() {
+		t.Error("expected named registry to be separate from the default registry")
+	}
+	public.Counter("requests_total", "Total requests").Inc()
+
+	cfg := DefaultConfig().serverConfig()
+	cfg.NamedMetrics = b.lookupNamedMetrics
+	handler := server.New(b.Metrics(), cfg).Handler()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics/public", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	if !strings.Contains(rec.Body.String(), "requests_total 1") {
+		t.Errorf("expected named registry metrics, got:\n%s", rec.Body.String())
+	}
+	if strings.Contains(rec.Body.String(), "go_goroutines") {
+		t.Error("expected runtime metrics to stay in the default registry")
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics/internal", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for unknown registry, got %d", rec.Code)
+	}
+}
+
+func TestMetricBuckets(t *testing.T) {
+	ctx, close := Init(context.Background(),
+		WithConfig(Config{Service: "test-service", MetricBuckets: []float64{1, 10, 100}}),
+	)
+	defer close()
+
+	op, _ := Operation(ctx, "bucketed.op")
+	op.Done()
+
+	for _, fam := range FromContext(ctx).Metrics().Gather() {
+		if fam.Name != "bucketed_op_duration_ms" {
+			continue
+		}
+		if n := len(fam.Metrics[0].Buckets); n != 3 {
+			t.Errorf("expected 3 configured buckets, got %d", n)
+		}
+		return
+	}
+	t.Error("expected bucketed_op_duration_ms to be gathered")
+}
+
+func TestAdminLogLevelEndpoint(t *testing.T) {
+	b, err := New(Config{Service: "test-service", LogLevel: "info"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := DefaultConfig().serverConfig()
+	cfg.AdminToken = "secret"
+	cfg.LogLevel = b.logLevel
+	handler := server.New(b.Metrics(), cfg).Handler()
+
+	put := func(token, body string) int {
+		req := httptest.NewRequest(http.MethodPut, "/admin/loglevel", strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if code := put("", "debug"); code != http.StatusUnauthorized {
+		t.Errorf("expected 401 without token, got %d", code)
+	}
+	if code := put("wrong", "debug"); code != http.StatusUnauthorized {
+		t.Errorf("expected 401 with wrong token, got %d", code)
+	}
+	if code := put("secret", "verbose"); code != http.StatusBadRequest {
+		t.Errorf("expected 400 for invalid level, got %d", code)
+	}
+	if b.LogLevel() != slog.LevelInfo {
+		t.Fatalf("expected level to be unchanged, got %v", b.LogLevel())
+	}
+
+	if code := put("secret", "debug"); code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	if b.LogLevel() != slog.LevelDebug {
+		t.Errorf("expected level debug, got %v", b.LogLevel())
+	}
+
+	// Without a token the endpoint isn't served at all
+	cfg.AdminToken = ""
+	rec := httptest.NewRecorder()
+	server.New(b.Metrics(), cfg).Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/loglevel", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 without admin token, got %d", rec.Code)
+	}
+}
+
+func TestAdminSampleRateEndpoint(t *testing.T) {
+	b, err := New(Config{Service: "test-service", MetricPrefix: "app"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rate, ok := b.TraceSampleRate(); !ok || rate != 1 {
+		t.Fatalf("expected every trace to be sampled by default, got %v, %v", rate, ok)
+	}
+
+	cfg := DefaultConfig().serverConfig()
+	cfg.AdminToken = "secret"
+	cfg.Admin = b
+	handler := server.New(b.Metrics(), cfg).Handler()
 
-	state := operationStateFromContext(ctx)
-	if state.name != "background.worker.process" {
-		t.Errorf("expected name 'background.worker.process', got %q", state.name)
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer secret")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
 	}
 
-	// Should inherit source metric labels
-	if len(state.metricLabels) != 1 || state.metricLabels[0] != "worker.type" {
-		t.Errorf("expected to inherit source metric labels, got %v", state.metricLabels)
+	if rec := do(http.MethodPut, "/admin/samplerate", "2"); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a rate above 1, got %d", rec.Code)
+	}
+	rec := do(http.MethodPut, "/admin/samplerate", "0.25")
+	if rec.Code != http.StatusOK || rec.Body.String() != "0.25" {
+		t.Fatalf("expected 200 with the new rate, got %d %q", rec.Code, rec.Body.String())
+	}
+	if rate, _ := b.TraceSampleRate(); rate != 0.25 {
+		t.Errorf("expected rate 0.25, got %v", rate)
+	}
+	if _, ok := b.Tracer().Sampler().(*trace.RatioSampler); !ok {
+		t.Errorf("expected a ratio sampler, got %T", b.Tracer().Sampler())
+	}
+
+	if rec := do(http.MethodGet, "/admin/flush", ""); rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405 for GET /admin/flush, got %d", rec.Code)
 	}
+	if rec := do(http.MethodPost, "/admin/flush", ""); rec.Code != http.StatusNoContent {
+		t.Errorf("expected 204 from /admin/flush, got %d", rec.Code)
+	}
+
+	// The changes are reflected in config_info
+	b.SetLogLevel(slog.LevelDebug)
+	for _, fam := range b.Metrics().Gather() {
+		if fam.Name != "app_config_info" {
+			continue
+		}
+		if len(fam.Metrics) != 1 {
+			t.Fatalf("expected a single config_info series, got %+v", fam.Metrics)
+		}
+		labels := fam.Metrics[0].Labels
+		if v, _ := labels.Get("log_level"); v.AsString() != "DEBUG" {
+			t.Errorf("expected log_level DEBUG, got %q", v.AsString())
+		}
+		if v, _ := labels.Get("trace_sample_rate"); v.AsString() != "0.25" {
+			t.Errorf("expected trace_sample_rate 0.25, got %q", v.AsString())
+		}
+		return
+	}
+	t.Error("expected app_config_info to be gathered")
 }
 
-func TestAutomaticMetrics(t *testing.T) {
+func TestConfigInfoStaticLabels(t *testing.T) {
 	ctx, close := Init(context.Background(),
 		WithConfig(Config{Service: "test-service"}),
+		WithStaticAttrs(attr.String("deploy.env", "prod")),
 	)
 	defer close()
 
-	op, ctx := Operation(ctx, "test.operation",
-		MetricLabels("status"),
-		Attrs(attr.String("status", "ok")),
-	)
-	time.Sleep(5 * time.Millisecond)
-	op.Done()
+	var buf bytes.Buffer
+	if err := prometheus.Encode(&buf, FromContext(ctx).Metrics().Gather()); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range strings.Split(buf.String(), "\n") {
+		if !strings.HasPrefix(line, "config_info{") {
+			continue
+		}
+		if !strings.Contains(line, `deploy_env="prod"`) || strings.Contains(line, "deploy.env") {
+			t.Errorf("expected the sanitized static label, got %s", line)
+		}
+		return
+	}
+	t.Errorf("expected config_info to be exposed, got:\n%s", buf.String())
+}
 
-	// Verify automatic metrics were recorded
-	b := FromContext(ctx)
-	families := b.Metrics().Gather()
+func TestTraceSampleRateCustomSampler(t *testing.T) {
+	b, err := New(Config{Service: "test-service", TraceSampler: trace.NeverSampler{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := b.TraceSampleRate(); ok {
+		t.Error("expected the rate of a custom sampler to be unknown")
+	}
 
-	expectedMetrics := map[string]bool{
-		"test_operation_count":       false,
-		"test_operation_successes":   false,
-		"test_operation_duration_ms": false,
+	b.SetTraceSampleRate(1.5)
+	if rate, ok := b.TraceSampleRate(); !ok || rate != 1 {
+		t.Errorf("expected the rate to be clamped to 1, got %v, %v", rate, ok)
 	}
+	if _, ok := b.Tracer().Sampler().(trace.AlwaysSampler); !ok {
+		t.Errorf("expected AlwaysSampler, got %T", b.Tracer().Sampler())
+	}
+}
 
-	for _, fam := range families {
-		if _, exists := expectedMetrics[fam.Name]; exists {
-			expectedMetrics[fam.Name] = true
+func TestVersionEndpoint(t *testing.T) {
+	b, err := New(Config{Service: "test-service"}, attr.String("version", "1.2.3"))
+	if err != nil {
+		t.Fatal(err)
+	}
 
-			if len(fam.Metrics) == 0 {
-				t.Errorf("metric %s has no data points", fam.Name)
-				continue
-			}
+	cfg := DefaultConfig().serverConfig()
+	cfg.Version = b.versionInfo()
+	rec := httptest.NewRecorder()
+	server.New(b.Metrics(), cfg).Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/version", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
 
-			// Verify metric has the label
-			metric := fam.Metrics[0]
-			hasStatusLabel := false
-			metric.Labels.Range(func(a attr.Attr) bool {
-				if a.Key == "status" && a.Value.AsString() == "ok" {
-					hasStatusLabel = true
-					return false
-				}
-				return true
-			})
-			if !hasStatusLabel {
-				t.Errorf("metric %s missing 'status' label", fam.Name)
-			}
-		}
+	var info server.VersionInfo
+	if err := json.Unmarshal(rec.Body.Bytes(), &info); err != nil {
+		t.Fatal(err)
+	}
+	if info.Service != "test-service" || info.Version != "1.2.3" {
+		t.Errorf("expected the service and its version attribute, got %+v", info)
+	}
+	if info.Revision == "" || info.GoVersion == "" {
+		t.Errorf("expected the revision and Go version from the build info, got %+v", info)
+	}
+	if !info.StartTime.Equal(b.startTime) {
+		t.Errorf("expected start time %v, got %v", b.startTime, info.StartTime)
 	}
 
-	for metric, found := range expectedMetrics {
-		if !found {
-			t.Errorf("expected metric %s not found", metric)
-		}
+	// Without version info the endpoint isn't served
+	cfg.Version = nil
+	rec = httptest.NewRecorder()
+	server.New(b.Metrics(), cfg).Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/version", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 without version info, got %d", rec.Code)
 	}
 }
 
-func TestMetricLabelDefaults(t *testing.T) {
-	ctx, close := Init(context.Background(),
-		WithConfig(Config{Service: "test-service"}),
-	)
-	defer close()
+func TestExpvarEndpoint(t *testing.T) {
+	expvar.NewInt("bedrock_test_jobs").Add(3)
 
-	op, ctx := Operation(ctx, "test.op",
-		MetricLabels("foo", "bar"),
-		Attrs(attr.String("foo", "value1")),
-		// Note: "bar" is not provided
-	)
-	op.Done()
+	cfg := DefaultConfig().serverConfig()
+	rec := httptest.NewRecorder()
+	server.New(metric.NewRegistry(""), cfg).Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/vars", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
 
-	// Verify metrics have "_" for missing label
-	b := FromContext(ctx)
-	families := b.Metrics().Gather()
-	for _, fam := range families {
-		if fam.Name == "test.op_count" {
-			if len(fam.Metrics) == 0 {
-				t.Fatal("no metrics found")
-			}
+	var vars map[string]json.RawMessage
+	if err := json.Unmarshal(rec.Body.Bytes(), &vars); err != nil {
+		t.Fatal(err)
+	}
+	if string(vars["bedrock_test_jobs"]) != "3" {
+		t.Errorf("expected the published var, got %s", vars["bedrock_test_jobs"])
+	}
+	if _, ok := vars["memstats"]; !ok {
+		t.Error("expected the standard memstats var")
+	}
 
-			metric := fam.Metrics[0]
-			foundBar := false
-			metric.Labels.Range(func(a attr.Attr) bool {
-				if a.Key == "bar" {
-					foundBar = true
-					if a.Value.AsString() != "_" {
-						t.Errorf("expected label 'bar' to have value '_', got %q", a.Value.AsString())
-					}
-				}
-				return true
-			})
-			if !foundBar {
-				t.Error("expected to find 'bar' label with default value")
-			}
-			break
+	cfg.EnableExpvar = false
+	rec = httptest.NewRecorder()
+	server.New(metric.NewRegistry(""), cfg).Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/vars", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 with expvar disabled, got %d", rec.Code)
+	}
+}
+
+func TestLogFileRotation(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+
+	b, err := New(Config{
+		Service:       "test-service",
+		LogFile:       path,
+		LogMaxBackups: 2,
+		LogCompress:   true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := range 4 {
+		b.Logger().Info("before rotation", slog.Int("n", i))
+		if err := b.logFile.Rotate(); err != nil {
+			t.Fatal(err)
 		}
+		time.Sleep(2 * time.Millisecond) // distinct backup timestamps
+	}
+	b.Logger().Info("after rotation")
+
+	if err := b.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	current, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(current), "after rotation") || strings.Contains(string(current), "before rotation") {
+		t.Errorf("expected only post-rotation logs in the current file, got:\n%s", current)
+	}
+
+	backups, _ := filepath.Glob(filepath.Join(dir, "app-*.log.gz"))
+	if len(backups) != 2 {
+		t.Errorf("expected 2 compressed backups to be kept, got %v", backups)
+	}
+	if uncompressed, _ := filepath.Glob(filepath.Join(dir, "app-*.log")); len(uncompressed) != 0 {
+		t.Errorf("expected rotated files to be compressed, got %v", uncompressed)
 	}
 }
 
@@ -291,6 +1602,54 @@ func TestStep(t *testing.T) {
 	}
 }
 
+func TestStepMetrics(t *testing.T) {
+	ctx, close := Init(context.Background(),
+		WithConfig(Config{Service: "test-service"}),
+	)
+	defer close()
+
+	op, ctx := Operation(ctx, "parent")
+
+	step := Step(ctx, "cache.lookup", WithStepMetrics())
+	step.Done()
+
+	// Steps without the option record no metrics
+	Step(ctx, "helper").Done()
+	op.Done()
+
+	if step.duration <= 0 {
+		t.Error("expected step duration to be recorded")
+	}
+
+	var foundCount, foundDuration, foundHelper bool
+	for _, fam := range FromContext(ctx).Metrics().Gather() {
+		switch fam.Name {
+		case "cache_lookup_count":
+			foundCount = true
+			if len(fam.Metrics) != 1 {
+				t.Fatalf("expected 1 series, got %d", len(fam.Metrics))
+			}
+			operation, ok := fam.Metrics[0].Labels.Get("operation")
+			if !ok || operation.AsString() != "parent" {
+				t.Errorf("expected operation label 'parent', got %v", operation)
+			}
+		case "cache_lookup_duration_ms":
+			foundDuration = true
+		case "helper_count":
+			foundHelper = true
+		}
+	}
+	if !foundCount {
+		t.Error("expected cache_lookup_count metric")
+	}
+	if !foundDuration {
+		t.Error("expected cache_lookup_duration_ms metric")
+	}
+	if foundHelper {
+		t.Error("expected no metrics for step without WithStepMetrics")
+	}
+}
+
 func TestNoopBedrock(t *testing.T) {
 	// Context without bedrock should use noop
 	ctx := context.Background()
@@ -310,6 +1669,66 @@ func TestNoopBedrock(t *testing.T) {
 	op.Register(ctx, attr.String("key", "value"))
 }
 
+func TestSetStaticAttr(t *testing.T) {
+	rec := logtest.NewRecorder()
+	ctx, close := Init(context.Background(),
+		WithConfig(Config{Service: "test-service", LogHandler: rec}),
+		WithStaticAttrs(attr.String("region", "us-east-1")),
+	)
+	defer close()
+	b := FromContext(ctx)
+
+	jobs := Counter(ctx, "jobs_total", "Jobs")
+	jobs.Inc()
+
+	b.SetStaticAttr(attr.String("region", "us-west-2"), attr.String("role", "leader"))
+	jobs.Inc()
+	op, _ := Operation(ctx, "elect")
+	op.Done()
+	Info(ctx, "elected")
+
+	if !rec.HasRecord(slog.LevelInfo, "elected", attr.String("region", "us-west-2"), attr.String("role", "leader")) {
+		t.Errorf("expected updated static attributes on log records, got %v", rec.Records())
+	}
+	if v, _ := b.Tracer().Resource().Get("role"); v.AsString() != "leader" {
+		t.Error("expected the trace resource to be updated")
+	}
+
+	b.RemoveStaticAttr("region")
+	jobs.Inc()
+	rec.Reset()
+	Info(ctx, "stepped down")
+	if r, ok := rec.FindRecord(slog.LevelInfo, "stepped down"); !ok {
+		t.Fatal("expected log record")
+	} else if _, ok := r.Attr("region"); ok {
+		t.Error("expected removed static attribute to be dropped from log records")
+	}
+
+	regions := make(map[string]float64)
+	for _, fam := range b.Metrics().Gather() {
+		switch fam.Name {
+		case "jobs_total":
+			for _, m := range fam.Metrics {
+				if m.Labels.Has("role") {
+					t.Error("expected attributes added after Init not to become metric labels")
+				}
+				v, _ := m.Labels.Get("region")
+				regions[v.AsString()] += m.Value
+			}
+		case "elect_count":
+			if v, _ := fam.Metrics[0].Labels.Get("region"); v.AsString() != "us-west-2" {
+				t.Errorf("expected operation metrics to use the updated region, got %q", v.AsString())
+			}
+		}
+	}
+	expected := map[string]float64{"us-east-1": 1, "us-west-2": 1, "_": 1}
+	for region, want := range expected {
+		if regions[region] != want {
+			t.Errorf("expected jobs_total{region=%q} = %v, got %v", region, want, regions[region])
+		}
+	}
+}
+
 func TestStaticAttributesInMetrics(t *testing.T) {
 	ctx, close := Init(context.Background(),
 		WithConfig(Config{Service: "test-service"}),
@@ -375,3 +1794,239 @@ func TestStaticAttributesInMetrics(t *testing.T) {
 		t.Error("expected to find test.static_metrics_count metric")
 	}
 }
+
+func TestCanonicalLogConfig(t *testing.T) {
+	var buf bytes.Buffer
+	ctx, close := Init(context.Background(),
+		WithConfig(Config{
+			Service:                  "test-service",
+			LogOutput:                &buf,
+			LogCanonical:             true,
+			LogCanonicalLevel:        "debug",
+			LogCanonicalFailureLevel: "error",
+			LogCanonicalMessage:      "canonical-log-line",
+			LogCanonicalFields:       []string{"attributes", "events"},
+			LogCanonicalAttrs:        []string{"user_id"},
+			LogLevel:                 "debug",
+		}),
+	)
+	defer close()
+
+	op, opCtx := Operation(ctx, "checkout", Attrs(attr.String("user_id", "123"), attr.String("card", "4242")))
+	step := StepFromContext(opCtx, "validate")
+	step.Done()
+	op.Register(opCtx, attr.NewEvent("cache.miss"))
+	op.Register(opCtx, attr.Error(errors.New("declined")))
+	op.Done()
+
+	var rec map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+		t.Fatalf("expected one canonical line, got %q: %v", buf.String(), err)
+	}
+	if rec["msg"] != "canonical-log-line" || rec["level"] != "ERROR" {
+		t.Errorf("expected configured message at failure level, got %v", rec)
+	}
+	if rec["error"] != "declined" || rec["success"] != false {
+		t.Errorf("expected failure fields, got %v", rec)
+	}
+	if attrs, _ := rec["attributes"].(map[string]any); len(attrs) != 1 || attrs["user_id"] != "123" {
+		t.Errorf("expected only allowlisted attributes, got %v", rec["attributes"])
+	}
+	if _, ok := rec["steps"]; ok {
+		t.Errorf("expected steps to be omitted, got %v", rec["steps"])
+	}
+	events, _ := rec["events"].([]any)
+	if len(events) == 0 || events[0].(map[string]any)["name"] != "cache.miss" {
+		t.Errorf("expected span events, got %v", rec["events"])
+	}
+	if rec["trace_id"] == nil {
+		t.Error("expected canonical line to be correlated with the trace")
+	}
+}
+
+func TestCanonicalLogChildrenAndEvents(t *testing.T) {
+	rec := logtest.NewRecorder()
+	ctx, close := Init(context.Background(),
+		WithConfig(Config{
+			Service:            "test-service",
+			LogHandler:         rec,
+			LogCanonical:       true,
+			LogCanonicalFields: []string{"children", "events"},
+		}),
+	)
+	defer close()
+
+	op, opCtx := Operation(ctx, "request")
+	child, _ := Operation(opCtx, "db.query")
+	child.Done()
+	failed, _ := Operation(opCtx, "cache.get")
+	failed.Fail(errors.New("miss"))
+	failed.Done()
+	op.Register(opCtx, attr.NewEvent("retrying", attr.Int("attempt", 2)))
+	op.Done()
+
+	r, ok := rec.FindRecord(slog.LevelInfo, "operation.complete", attr.String("operation", "request"))
+	if !ok {
+		t.Fatalf("expected canonical line for request, got %v", rec.Records())
+	}
+
+	v, ok := r.Attr("children")
+	if !ok {
+		t.Fatal("expected children in the canonical line")
+	}
+	children := v.Any().([]map[string]any)
+	if len(children) != 2 {
+		t.Fatalf("expected 2 children, got %v", children)
+	}
+	if children[0]["name"] != "db.query" {
+		t.Errorf("expected db.query child first, got %v", children[0])
+	}
+	if children[0]["success"] != true || children[1]["success"] != false {
+		t.Errorf("expected child success flags, got %v", children)
+	}
+	if _, ok := children[0]["duration_ms"]; !ok {
+		t.Errorf("expected child duration, got %v", children[0])
+	}
+
+	v, ok = r.Attr("events")
+	if !ok {
+		t.Fatal("expected events in the canonical line")
+	}
+	events := v.Any().([]map[string]any)
+	if len(events) != 1 || events[0]["name"] != "retrying" || events[0]["time"] == nil {
+		t.Errorf("expected the registered event with its time, got %v", events)
+	}
+}
+
+func TestCanonicalLogEventsUnsampled(t *testing.T) {
+	rec := logtest.NewRecorder()
+	ctx, close := Init(context.Background(),
+		WithConfig(Config{
+			Service:            "test-service",
+			LogHandler:         rec,
+			LogCanonical:       true,
+			LogCanonicalFields: []string{"events"},
+			TraceSampler:       trace.NeverSampler{},
+		}),
+	)
+	defer close()
+
+	// Events are kept for the canonical log even when the span isn't recorded
+	op, opCtx := Operation(ctx, "request")
+	op.Register(opCtx, attr.NewEvent("retrying", attr.Int("attempt", 2)))
+	op.Fail(errors.New("unavailable"))
+	op.Done()
+
+	r, ok := rec.FindRecord(slog.LevelInfo, "operation.complete", attr.String("operation", "request"))
+	if !ok {
+		t.Fatalf("expected canonical line for request, got %v", rec.Records())
+	}
+	v, ok := r.Attr("events")
+	if !ok {
+		t.Fatal("expected events in the canonical line")
+	}
+	events := v.Any().([]map[string]any)
+	if len(events) != 2 || events[0]["name"] != "retrying" || events[1]["name"] != "exception" {
+		t.Fatalf("expected the registered event and the exception, got %v", events)
+	}
+	if attrs := events[0]["attributes"].(map[string]any); attrs["attempt"] != int64(2) {
+		t.Errorf("expected the event attributes, got %v", attrs)
+	}
+}
+
+func TestCanonicalLogChildrenLimits(t *testing.T) {
+	rec := logtest.NewRecorder()
+	ctx, close := Init(context.Background(),
+		WithConfig(Config{Service: "test-service", LogHandler: rec, LogCanonical: true}),
+	)
+	defer close()
+
+	// Children aren't logged by default, so they aren't kept either
+	op, opCtx := Operation(ctx, "worker")
+	child, _ := Operation(opCtx, "job")
+	child.Done()
+	if n := len(op.state.children); n != 0 {
+		t.Errorf("expected no children kept when they aren't logged, got %d", n)
+	}
+	op.Done()
+	r, _ := rec.FindRecord(slog.LevelInfo, "operation.complete", attr.String("operation", "worker"))
+	if _, ok := r.Attr("children"); ok {
+		t.Error("expected no children in the canonical line by default")
+	}
+
+	rec = logtest.NewRecorder()
+	ctx, close = Init(context.Background(),
+		WithConfig(Config{Service: "test-service", LogHandler: rec, LogCanonical: true, LogCanonicalFields: []string{"children"}}),
+	)
+	defer close()
+
+	op, opCtx = Operation(ctx, "worker")
+	for range maxCanonicalChildren + 5 {
+		child, _ := Operation(opCtx, "job")
+		child.Done()
+	}
+	op.Done()
+
+	r, ok := rec.FindRecord(slog.LevelInfo, "operation.complete", attr.String("operation", "worker"))
+	if !ok {
+		t.Fatal("expected canonical line for worker")
+	}
+	v, _ := r.Attr("children")
+	if n := len(v.Any().([]map[string]any)); n != maxCanonicalChildren {
+		t.Errorf("expected %d children, got %d", maxCanonicalChildren, n)
+	}
+	if v, _ := r.Attr("children_dropped"); v.Int64() != 5 {
+		t.Errorf("expected 5 children dropped, got %v", v)
+	}
+}
+
+func TestCanonicalLogSuccessSampling(t *testing.T) {
+	var buf bytes.Buffer
+	ctx, close := Init(context.Background(),
+		WithConfig(Config{
+			Service:                       "test-service",
+			LogOutput:                     &buf,
+			LogCanonical:                  true,
+			LogCanonicalSuccessSampleRate: 1e-12,
+		}),
+	)
+	defer close()
+
+	for range 10 {
+		op, _ := Operation(ctx, "ok")
+		op.Done()
+	}
+	op, opCtx := Operation(ctx, "failed")
+	op.Register(opCtx, attr.Error(errors.New("boom")))
+	op.Done()
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 1 || !strings.Contains(lines[0], `"operation":"failed"`) {
+		t.Errorf("expected only the failed operation to be logged, got %q", lines)
+	}
+	if !strings.Contains(lines[0], `"msg":"operation.complete"`) || !strings.Contains(lines[0], `"level":"INFO"`) {
+		t.Errorf("expected default message and level, got %q", lines[0])
+	}
+}
+
+func TestRegisterHealthChecks(t *testing.T) {
+	ctx, close := Init(context.Background())
+	defer close()
+
+	RegisterReadinessCheck(ctx, "db", func(ctx context.Context) error { return errors.New("down") })
+	RegisterLivenessCheck(ctx, "worker", func(ctx context.Context) error { return nil })
+
+	b := FromContext(ctx)
+	if err := b.Readiness().Healthy(ctx); err == nil {
+		t.Error("expected the failing readiness check to make the service unready")
+	}
+	if err := b.Liveness().Healthy(ctx); err != nil {
+		t.Errorf("expected the service to be live, got %v", err)
+	}
+
+	// Without an instance, registering does nothing
+	RegisterReadinessCheck(context.Background(), "db", func(ctx context.Context) error { return errors.New("down") })
+	if err := noopBedrock().Readiness().Healthy(ctx); err != nil {
+		t.Error("expected no checks on the noop instance")
+	}
+}
@@ -2,9 +2,14 @@ package bedrock
 
 import (
 	"context"
+	"fmt"
 	"io"
 	"net/http"
+	"strconv"
+	"time"
 
+	"github.com/kzs0/bedrock/attr"
+	"github.com/kzs0/bedrock/trace"
 	"github.com/kzs0/bedrock/transport"
 )
 
@@ -15,21 +20,111 @@ type instrumentedTransport struct {
 
 // RoundTrip implements http.RoundTripper.
 func (t *instrumentedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
-	ctx := req.Context()
+	// Create transport with tracer if bedrock is available
+	return clientTransport(req.Context(), t.base).RoundTrip(req)
+}
 
-	// Get bedrock from context
+// clientTransport returns a transport instrumented with the bedrock instance in ctx.
+func clientTransport(ctx context.Context, base http.RoundTripper) *transport.Transport {
+	tr := &transport.Transport{
+		Base:    base,
+		Tracer:  clientTracer(ctx),
+		Metrics: clientMetrics(ctx),
+	}
+	if b := FromContext(ctx); b != nil && b.config.ForwardRequestID {
+		tr.RequestID = RequestID
+	}
+	if tr.Tracer != nil {
+		cfg := FromContext(ctx).config
+		tr.HTTPTrace = cfg.TraceHTTPClientEvents
+		tr.URL = cfg.TraceHTTPClientURL
+		tr.SemConv = cfg.SemConv
+	}
+	return tr
+}
+
+// clientTracer returns ClientTracer(ctx) as a transport.Tracer, nil if it is nil.
+func clientTracer(ctx context.Context) transport.Tracer {
+	if tracer := ClientTracer(ctx); tracer != nil {
+		return tracer
+	}
+	return nil
+}
+
+// ClientTracer returns the tracer for client spans of outbound calls from the bedrock
+// instance in ctx, or nil if there is none or ctx is within a NoTrace operation.
+// Integrations use it to trace clients other than HTTP, as the gRPC client interceptors do.
+func ClientTracer(ctx context.Context) *trace.Tracer {
 	b := FromContext(ctx)
+	if b == nil || b.IsNoop() || isNoTrace(ctx) {
+		return nil
+	}
+	return b.Tracer()
+}
 
-	// Create transport with tracer if bedrock is available
-	tr := &transport.Transport{
-		Base: t.base,
+// clientMetrics returns the recorder for HTTP client metrics from the bedrock instance in
+// ctx, or nil if there is none. Unlike spans, metrics are recorded within NoTrace operations.
+func clientMetrics(ctx context.Context) transport.Metrics {
+	b := FromContext(ctx)
+	if b == nil || b.IsNoop() {
+		return nil
 	}
+	return httpClientMetrics{b: b}
+}
+
+// httpClientMetrics records HTTP client requests in http_client_request_count,
+// http_client_request_failures, and the http_client_request duration histogram,
+// labeled by static attributes, method, host, and status class.
+type httpClientMetrics struct {
+	b *Bedrock
+}
+
+// RecordRequest implements transport.Metrics.
+func (m httpClientMetrics) RecordRequest(req *http.Request, resp *http.Response, err error, duration time.Duration) {
+	b := m.b
 
-	if b != nil && !b.IsNoop() {
-		tr.Tracer = b.Tracer()
+	statusClass := "error"
+	if err == nil && resp != nil {
+		statusClass = strconv.Itoa(resp.StatusCode/100) + "xx"
 	}
 
-	return tr.RoundTrip(req)
+	key := b.attrName
+	labelNames, labels := b.staticLabels()
+	labelNames = append(labelNames, key("http.method"), key("http.host"), "http.status_class")
+	labels = append(labels,
+		attr.String(key("http.method"), req.Method),
+		attr.String(key("http.host"), req.URL.Host),
+		attr.String("http.status_class", statusClass),
+	)
+
+	b.metrics.Counter(
+		"http_client_request_count",
+		"Total count of HTTP client requests",
+		labelNames...,
+	).With(labels...).Inc()
+
+	// Requests that got no response or a 4xx or 5xx response, as for their spans
+	if err != nil || resp == nil || resp.StatusCode >= 400 {
+		b.metrics.Counter(
+			"http_client_request_failures",
+			"Failed HTTP client requests",
+			labelNames...,
+		).With(labels...).Inc()
+	}
+
+	b.durationHistogram("http_client_request", "HTTP client requests", labelNames).With(labels...).Observe(b.durationValue(duration))
+}
+
+// ClientRoute returns a context whose HTTP client requests are named by the route template
+// route, such as "/users/{id}", matching the server's span names: "GET /users/{id}" rather
+// than "HTTP GET". The route is also recorded as http.route.
+//
+// Usage:
+//
+//	req, _ := http.NewRequestWithContext(bedrock.ClientRoute(ctx, "/users/{id}"), "GET", url, nil)
+//	resp, err := client.Do(req)
+func ClientRoute(ctx context.Context, route string) context.Context {
+	return transport.ContextWithRoute(ctx, route)
 }
 
 // NewClient creates an http.Client with bedrock instrumentation.
@@ -58,6 +153,197 @@ func NewClient(base *http.Client) *http.Client {
 	}
 }
 
+// StatusError is the error a retrying client passes to RetryPolicy.Retryable for
+// responses with status 429 or 5xx.
+type StatusError struct {
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return "HTTP " + strconv.Itoa(e.StatusCode)
+}
+
+// NewRetryClient creates an http.Client like NewClient that retries requests according to
+// policy: on transport errors, and on responses with status 429 or 5xx, as *StatusError.
+// Requests with a body are only retried if it can be replayed with Request.GetBody, as it
+// can for the bodies http.NewRequest creates from a bytes or strings reader.
+//
+// Without policy.Retryable, only idempotent requests are retried: GET, HEAD, OPTIONS,
+// TRACE, PUT and DELETE, and requests with an Idempotency-Key or X-Idempotency-Key
+// header, as net/http does. Set Retryable to retry other requests, such as a POST the
+// server deduplicates.
+//
+// Each attempt gets its own client span with an "http.retry.attempt" attribute, under a
+// span for the whole request with "http.retry.attempts", and "http.retry.exhausted" if it
+// ran out of attempts. The last attempt's response or error is returned.
+//
+// Usage:
+//
+//	client := bedrock.NewRetryClient(nil, bedrock.RetryPolicy{
+//	    Attempts: 3,
+//	    Backoff:  100 * time.Millisecond,
+//	    Jitter:   0.2,
+//	})
+//	resp, err := client.Do(req)
+func NewRetryClient(base *http.Client, policy RetryPolicy) *http.Client {
+	if base == nil {
+		base = &http.Client{}
+	}
+
+	client := NewClient(base)
+	client.Transport = &retryTransport{base: base.Transport, policy: policy}
+	return client
+}
+
+// retryTransport retries requests, instrumenting each attempt as instrumentedTransport does.
+type retryTransport struct {
+	base   http.RoundTripper
+	policy RetryPolicy
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+
+	attempts := max(t.policy.Attempts, 1)
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		attempts = 1
+	}
+	if t.policy.Retryable == nil && !idempotent(req) {
+		attempts = 1
+	}
+
+	// A span for the whole request, parenting a client span for each attempt
+	var span *trace.Span
+	b := bedrockFromContext(ctx)
+	key := b.attrName
+	if tracer := clientTracer(ctx); tracer != nil {
+		urlFunc := b.config.TraceHTTPClientURL
+		if urlFunc == nil {
+			urlFunc = transport.SanitizeURL
+		}
+		name := "HTTP " + req.Method
+		attrs := []attr.Attr{
+			attr.String(key("http.method"), req.Method),
+			attr.String(key("http.url"), urlFunc(req.URL)),
+		}
+		if route := transport.RouteFromContext(ctx); route != "" {
+			name = req.Method + " " + route
+			attrs = append(attrs, attr.String("http.route", route))
+		}
+		ctx, span = tracer.Start(ctx, name, trace.WithAttrs(attrs...))
+		defer span.End()
+	}
+
+	var (
+		resp    *http.Response
+		err     error
+		failure error
+	)
+	attempt := 1
+	for ; ; attempt++ {
+		// Each attempt gets a copy, so headers injected into one do not leak into the next
+		attemptReq := req.Clone(ctx)
+		if attempt > 1 && req.GetBody != nil {
+			attemptReq.Body, err = req.GetBody()
+			if err != nil {
+				// The previous response has been discarded
+				resp = nil
+				break
+			}
+		}
+
+		tr := clientTransport(ctx, t.base)
+		tr.Attrs = []attr.Attr{attr.Int("http.retry.attempt", attempt)}
+		resp, err = tr.RoundTrip(attemptReq)
+
+		failure = err
+		if err == nil && (resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500) {
+			failure = &StatusError{StatusCode: resp.StatusCode}
+		}
+		if failure == nil || attempt == attempts || (t.policy.Retryable != nil && !t.policy.Retryable(failure)) {
+			break
+		}
+
+		// Discard the failed response, so its connection can be reused
+		if resp != nil {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			_ = resp.Body.Close()
+		}
+
+		timer := time.NewTimer(t.policy.delay(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			resp, err = nil, ctx.Err()
+		case <-timer.C:
+		}
+		if err != nil {
+			break
+		}
+	}
+
+	if span != nil {
+		span.SetAttr(attr.Int("http.retry.attempts", attempt))
+		if failure != nil && attempt == attempts && attempts > 1 {
+			span.SetAttr(attr.Bool("http.retry.exhausted", true))
+		}
+		switch {
+		case err != nil:
+			span.RecordError(err)
+			span.SetStatus(trace.StatusError, err.Error())
+		case resp.StatusCode >= 400:
+			span.SetAttr(attr.Int(key("http.status_code"), resp.StatusCode))
+			span.SetStatus(trace.StatusError, "HTTP "+strconv.Itoa(resp.StatusCode))
+		default:
+			span.SetAttr(attr.Int(key("http.status_code"), resp.StatusCode))
+			span.SetStatus(trace.StatusOK, "")
+		}
+	}
+	return resp, err
+}
+
+// idempotent reports whether req can be retried without a policy saying so, as net/http
+// decides for the requests it retries itself.
+func idempotent(req *http.Request) bool {
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	// As in net/http, a nil value, set to keep the header from being sent, counts too
+	_, hasKey := req.Header["Idempotency-Key"]
+	_, hasXKey := req.Header["X-Idempotency-Key"]
+	return hasKey || hasXKey
+}
+
+// ClientOption configures a request made with Do, Get, or Post.
+type ClientOption func(*clientConfig)
+
+// clientConfig holds the configuration of a request made with Do, Get, or Post.
+type clientConfig struct {
+	operationName string
+	operationOpts []OperationOption
+}
+
+// WithOperation runs the request in an operation named name, so calls made outside any
+// operation still get operation metrics and a canonical log line. The operation has
+// http.method, http.host, and http.status_code attributes, which label its metrics, and
+// fails on errors and 4xx or 5xx responses. opts configure it as for Operation.
+func WithOperation(name string, opts ...OperationOption) ClientOption {
+	return func(c *clientConfig) {
+		c.operationName = name
+		c.operationOpts = opts
+	}
+}
+
+func applyClientOptions(opts []ClientOption) clientConfig {
+	var cfg clientConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
 // Do executes an HTTP request with bedrock instrumentation.
 // This is a convenience function that creates a one-time instrumented client.
 //
@@ -68,21 +354,39 @@ func NewClient(base *http.Client) *http.Client {
 //
 //	req, _ := http.NewRequestWithContext(ctx, "GET", "https://api.example.com/users", nil)
 //	resp, err := bedrock.Do(ctx, req)
-func Do(ctx context.Context, req *http.Request) (*http.Response, error) {
-	// Set context on request
-	req = req.WithContext(ctx)
+//
+// Or in its own operation:
+//
+//	resp, err := bedrock.Do(ctx, req, bedrock.WithOperation("users.fetch"))
+func Do(ctx context.Context, req *http.Request, opts ...ClientOption) (*http.Response, error) {
+	cfg := applyClientOptions(opts)
+	if cfg.operationName == "" {
+		return clientTransport(ctx, nil).RoundTrip(req.WithContext(ctx))
+	}
 
-	// Get bedrock from context
-	b := FromContext(ctx)
+	key := bedrockFromContext(ctx).attrName
 
-	// Create instrumented transport
-	tr := &transport.Transport{}
+	opOpts := append([]OperationOption{
+		Attrs(
+			attr.String(key("http.method"), req.Method),
+			attr.String(key("http.host"), req.URL.Host),
+		),
+		MetricLabels(key("http.method"), key("http.host"), key("http.status_code")),
+	}, cfg.operationOpts...)
+	op, opCtx := Operation(ctx, cfg.operationName, opOpts...)
+	defer op.Done()
 
-	if b != nil && !b.IsNoop() {
-		tr.Tracer = b.Tracer()
+	resp, err := clientTransport(opCtx, nil).RoundTrip(req.WithContext(opCtx))
+	if err != nil {
+		op.Register(opCtx, attr.Error(err))
+		return resp, err
 	}
 
-	return tr.RoundTrip(req)
+	op.Register(opCtx, attr.Int(key("http.status_code"), resp.StatusCode))
+	if resp.StatusCode >= 400 {
+		op.Register(opCtx, attr.Error(fmt.Errorf("HTTP %d", resp.StatusCode)))
+	}
+	return resp, nil
 }
 
 // Get is a convenience function for GET requests with bedrock instrumentation.
@@ -90,12 +394,12 @@ func Do(ctx context.Context, req *http.Request) (*http.Response, error) {
 // Usage:
 //
 //	resp, err := bedrock.Get(ctx, "https://api.example.com/users")
-func Get(ctx context.Context, url string) (*http.Response, error) {
+func Get(ctx context.Context, url string, opts ...ClientOption) (*http.Response, error) {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return nil, err
 	}
-	return Do(ctx, req)
+	return Do(ctx, req, opts...)
 }
 
 // Post is a convenience function for POST requests with bedrock instrumentation.
@@ -104,7 +408,7 @@ func Get(ctx context.Context, url string) (*http.Response, error) {
 //
 //	body := strings.NewReader(`{"name": "John"}`)
 //	resp, err := bedrock.Post(ctx, "https://api.example.com/users", "application/json", body)
-func Post(ctx context.Context, url, contentType string, body io.Reader) (*http.Response, error) {
+func Post(ctx context.Context, url, contentType string, body io.Reader, opts ...ClientOption) (*http.Response, error) {
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, body)
 	if err != nil {
 		return nil, err
@@ -112,5 +416,5 @@ func Post(ctx context.Context, url, contentType string, body io.Reader) (*http.R
 	if contentType != "" {
 		req.Header.Set("Content-Type", contentType)
 	}
-	return Do(ctx, req)
+	return Do(ctx, req, opts...)
 }
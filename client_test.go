@@ -2,11 +2,15 @@ package bedrock
 
 import (
 	"context"
+	"errors"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"regexp"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/kzs0/bedrock/trace"
 	httpProp "github.com/kzs0/bedrock/trace/http"
@@ -381,3 +385,574 @@ func TestTransportWithoutBedrock(t *testing.T) {
 	// Verify no traceparent was injected (would need to capture in server)
 	// This is implicitly tested by the request succeeding without bedrock
 }
+
+func TestClientNoTrace(t *testing.T) {
+	var traceparent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	ctx, close := Init(context.Background())
+	defer close()
+
+	op, ctx := Operation(ctx, "hot_path", NoTrace())
+	defer op.Done()
+
+	resp, err := Get(ctx, server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+
+	if traceparent != "" {
+		t.Errorf("expected no client span within a NoTrace operation, got traceparent %q", traceparent)
+	}
+}
+
+func TestClientMetrics(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	ctx, close := Init(context.Background(),
+		WithConfig(Config{Service: "test-service"}),
+	)
+	defer close()
+
+	// Metrics are recorded within NoTrace operations too
+	op, opCtx := Operation(ctx, "hot_path", NoTrace())
+	client := NewClient(nil)
+	for _, path := range []string{"/ok", "/ok", "/missing"} {
+		req, _ := http.NewRequestWithContext(opCtx, http.MethodGet, server.URL+path, nil)
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = resp.Body.Close()
+	}
+	op.Done()
+
+	// A request that gets no response
+	if _, err := Get(ctx, "http://127.0.0.1:1"); err == nil {
+		t.Fatal("expected a connection error")
+	}
+
+	host := strings.TrimPrefix(server.URL, "http://")
+	counts := map[string]float64{}
+	for _, fam := range FromContext(ctx).Metrics().Gather() {
+		for _, m := range fam.Metrics {
+			if h, _ := m.Labels.Get("http_host"); h.AsString() != host && h.AsString() != "127.0.0.1:1" {
+				continue
+			}
+			class, _ := m.Labels.Get("http_status_class")
+			switch fam.Name {
+			case "http_client_request_count", "http_client_request_failures":
+				counts[fam.Name+"/"+class.AsString()] += m.Value
+			case "http_client_request_duration_ms":
+				counts[fam.Name+"/"+class.AsString()] += float64(m.Count)
+			}
+		}
+	}
+
+	want := map[string]float64{
+		"http_client_request_count/2xx":       2,
+		"http_client_request_count/4xx":       1,
+		"http_client_request_count/error":     1,
+		"http_client_request_failures/4xx":    1,
+		"http_client_request_failures/error":  1,
+		"http_client_request_duration_ms/2xx": 2,
+		"http_client_request_duration_ms/4xx": 1,
+	}
+	for key, v := range want {
+		if counts[key] != v {
+			t.Errorf("expected %s = %v, got %v (all: %v)", key, v, counts[key], counts)
+		}
+	}
+	if counts["http_client_request_failures/2xx"] != 0 {
+		t.Errorf("expected no failures for 2xx responses, got %v", counts["http_client_request_failures/2xx"])
+	}
+}
+
+// recordingTracer is a transport.Tracer that keeps the spans it starts.
+type recordingTracer struct {
+	tracer *trace.Tracer
+	spans  []*trace.Span
+}
+
+func (r *recordingTracer) Start(ctx context.Context, name string, opts ...trace.StartSpanOption) (context.Context, *trace.Span) {
+	ctx, span := r.tracer.Start(ctx, name, opts...)
+	r.spans = append(r.spans, span)
+	return ctx, span
+}
+
+func TestTransportHTTPTrace(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	ctx, close := Init(context.Background())
+	defer close()
+
+	tracer := &recordingTracer{tracer: FromContext(ctx).Tracer()}
+	tr := &transport.Transport{Tracer: tracer, HTTPTrace: true}
+
+	for range 2 {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+		resp, err := tr.RoundTrip(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+	}
+
+	if len(tracer.spans) != 2 {
+		t.Fatalf("expected 2 client spans, got %d", len(tracer.spans))
+	}
+
+	events := func(span *trace.Span) map[string]trace.Event {
+		byName := map[string]trace.Event{}
+		for _, e := range span.Events() {
+			byName[e.Name] = e
+		}
+		return byName
+	}
+
+	first := events(tracer.spans[0])
+	for _, name := range []string{"http.get_conn", "http.connect.start", "http.connect.done", "http.got_conn", "http.wrote_request", "http.first_byte"} {
+		if _, ok := first[name]; !ok {
+			t.Errorf("expected %s event on the first request, got %v", name, first)
+		}
+	}
+	if _, ok := first["http.connect.done"].Attrs.Get("duration_ms"); !ok {
+		t.Error("expected duration_ms on http.connect.done")
+	}
+	if v, _ := first["http.got_conn"].Attrs.Get("http.conn.reused"); v.AsBool() {
+		t.Error("expected a new connection on the first request")
+	}
+
+	second := events(tracer.spans[1])
+	if _, ok := second["http.connect.start"]; ok {
+		t.Error("expected no connect on the second request, which reuses the connection")
+	}
+	if v, _ := second["http.got_conn"].Attrs.Get("http.conn.reused"); !v.AsBool() {
+		t.Error("expected a reused connection on the second request")
+	}
+}
+
+func TestRetryClient(t *testing.T) {
+	var calls int
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		if calls < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	ctx, close := Init(context.Background())
+	defer close()
+
+	op, ctx := Operation(ctx, "test.retry_client")
+	defer op.Done()
+
+	// Capture each attempt's client span from the request context
+	var spans []*trace.Span
+	base := &http.Client{Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		spans = append(spans, trace.SpanFromContext(req.Context()))
+		return http.DefaultTransport.RoundTrip(req)
+	})}
+	client := NewRetryClient(base, RetryPolicy{Attempts: 4, Backoff: time.Millisecond})
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, server.URL, strings.NewReader("payload"))
+	req.Header.Set("Idempotency-Key", "payment-1")
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected status 200 after retries, got %d", resp.StatusCode)
+	}
+	if calls != 3 {
+		t.Fatalf("expected 3 attempts, got %d", calls)
+	}
+	for i, body := range bodies {
+		if body != "payload" {
+			t.Errorf("expected attempt %d to replay the body, got %q", i+1, body)
+		}
+	}
+
+	if len(spans) != 3 {
+		t.Fatalf("expected 3 client spans, got %d", len(spans))
+	}
+	for i, span := range spans {
+		if v, _ := span.Attrs().Get("http.retry.attempt"); v.AsInt64() != int64(i+1) {
+			t.Errorf("expected attempt %d on span %d, got %v", i+1, i, v)
+		}
+		if span.Kind() != trace.SpanKindClient {
+			t.Errorf("expected a client span for attempt %d", i+1)
+		}
+		if span.ParentID() != spans[0].ParentID() {
+			t.Error("expected attempts to share a parent span")
+		}
+	}
+	if spans[0].ParentID() == op.state.span.SpanID() {
+		t.Error("expected attempts under a span for the whole request, not the operation's")
+	}
+}
+
+func TestRetryClientExhausted(t *testing.T) {
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	ctx, close := Init(context.Background())
+	defer close()
+
+	client := NewRetryClient(nil, RetryPolicy{Attempts: 3, Backoff: time.Millisecond})
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+
+	if resp.StatusCode != http.StatusTooManyRequests || calls != 3 {
+		t.Errorf("expected the last 429 after 3 attempts, got %d after %d", resp.StatusCode, calls)
+	}
+
+	// Retryable can refuse to retry by status
+	calls = 0
+	client = NewRetryClient(nil, RetryPolicy{
+		Attempts: 3,
+		Retryable: func(err error) bool {
+			var status *StatusError
+			return !errors.As(err, &status) || status.StatusCode != http.StatusTooManyRequests
+		},
+	})
+	req, _ = http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	resp, err = client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if calls != 1 {
+		t.Errorf("expected a single attempt when Retryable refuses, got %d", calls)
+	}
+}
+
+func TestRetryClientIdempotent(t *testing.T) {
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	ctx, close := Init(context.Background())
+	defer close()
+
+	// Without Retryable, a POST is not retried
+	client := NewRetryClient(nil, RetryPolicy{Attempts: 3, Backoff: time.Millisecond})
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, server.URL, strings.NewReader("payload"))
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if calls != 1 {
+		t.Errorf("expected a single attempt for a POST, got %d", calls)
+	}
+
+	// With Retryable, it is
+	calls = 0
+	client = NewRetryClient(nil, RetryPolicy{
+		Attempts:  3,
+		Backoff:   time.Millisecond,
+		Retryable: func(error) bool { return true },
+	})
+	req, _ = http.NewRequestWithContext(ctx, http.MethodPost, server.URL, strings.NewReader("payload"))
+	resp, err = client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if calls != 3 {
+		t.Errorf("expected 3 attempts with Retryable, got %d", calls)
+	}
+}
+
+func TestRetryClientGetBodyError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	ctx, close := Init(context.Background())
+	defer close()
+
+	client := NewRetryClient(nil, RetryPolicy{Attempts: 3, Backoff: time.Millisecond})
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPut, server.URL, strings.NewReader("payload"))
+	bodyErr := errors.New("body unavailable")
+	req.GetBody = func() (io.ReadCloser, error) { return nil, bodyErr }
+
+	resp, err := client.Transport.RoundTrip(req)
+	if !errors.Is(err, bodyErr) {
+		t.Errorf("expected the GetBody error, got %v", err)
+	}
+	if resp != nil {
+		t.Error("expected no response with the error")
+	}
+}
+
+// roundTripperFunc adapts a function to http.RoundTripper.
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestTransportSanitizesURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	ctx, close := Init(context.Background())
+	defer close()
+
+	tracer := &recordingTracer{tracer: FromContext(ctx).Tracer()}
+	tr := &transport.Transport{Tracer: tracer}
+
+	u := strings.Replace(server.URL, "http://", "http://user:secret@", 1) + "/users/42?token=abc#frag"
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	resp, err := tr.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+
+	got, _ := tracer.spans[0].Attrs().Get("http.url")
+	if want := server.URL + "/users/42"; got.AsString() != want {
+		t.Errorf("expected http.url %q, got %q", want, got.AsString())
+	}
+}
+
+func TestClientURLFunc(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	ctx, close := Init(context.Background(), WithConfig(Config{
+		TraceHTTPClientURL: func(u *url.URL) string {
+			return u.Scheme + "://" + u.Host + regexp.MustCompile(`/\d+`).ReplaceAllString(u.Path, "/{id}")
+		},
+	}))
+	defer close()
+
+	var span *trace.Span
+	client := NewClient(&http.Client{Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		span = trace.SpanFromContext(req.Context())
+		return http.DefaultTransport.RoundTrip(req)
+	})})
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/users/42?token=abc", nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+
+	got, _ := span.Attrs().Get("http.url")
+	if want := server.URL + "/users/{id}"; got.AsString() != want {
+		t.Errorf("expected http.url %q, got %q", want, got.AsString())
+	}
+}
+
+func TestClientRoute(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	ctx, close := Init(context.Background())
+	defer close()
+
+	var span *trace.Span
+	client := NewClient(&http.Client{Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		span = trace.SpanFromContext(req.Context())
+		return http.DefaultTransport.RoundTrip(req)
+	})})
+
+	req, _ := http.NewRequestWithContext(ClientRoute(ctx, "/users/{id}"), http.MethodGet, server.URL+"/users/42", nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+
+	if span.Name() != "GET /users/{id}" {
+		t.Errorf("expected span name %q, got %q", "GET /users/{id}", span.Name())
+	}
+	if route, _ := span.Attrs().Get("http.route"); route.AsString() != "/users/{id}" {
+		t.Errorf("expected http.route /users/{id}, got %v", route)
+	}
+
+	// A Transport's Route function takes precedence
+	tracer := &recordingTracer{tracer: FromContext(ctx).Tracer()}
+	tr := &transport.Transport{Tracer: tracer, Route: func(*http.Request) string { return "/users/:id" }}
+	req, _ = http.NewRequestWithContext(ctx, http.MethodDelete, server.URL+"/users/42", nil)
+	resp, err = tr.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if name := tracer.spans[0].Name(); name != "DELETE /users/:id" {
+		t.Errorf("expected span name %q, got %q", "DELETE /users/:id", name)
+	}
+}
+
+func TestDoWithOperation(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	ctx, close := Init(context.Background())
+	defer close()
+
+	// Outside any operation, each call gets its own
+	for _, path := range []string{"/ok", "/missing"} {
+		resp, err := Get(ctx, server.URL+path, WithOperation("users_fetch"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = resp.Body.Close()
+	}
+
+	values := map[string]float64{}
+	for _, fam := range FromContext(ctx).Metrics().Gather() {
+		for _, m := range fam.Metrics {
+			status, _ := m.Labels.Get("http_status_code")
+			values[fam.Name+"/"+status.String()] += m.Value
+		}
+	}
+	for key, want := range map[string]float64{
+		"users_fetch_count/200":     1,
+		"users_fetch_count/404":     1,
+		"users_fetch_successes/200": 1,
+		"users_fetch_failures/404":  1,
+	} {
+		if values[key] != want {
+			t.Errorf("expected %s = %v, got %v", key, want, values[key])
+		}
+	}
+}
+
+func TestTransportSemConv(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	ctx, close := Init(context.Background())
+	defer close()
+
+	tracer := &recordingTracer{tracer: FromContext(ctx).Tracer()}
+	tr := &transport.Transport{Tracer: tracer, SemConv: true}
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/users", nil)
+	resp, err := tr.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+
+	attrs := tracer.spans[0].Attrs()
+	for _, key := range []string{"http.request.method", "url.full", "url.path", "server.address", "http.response.status_code"} {
+		if _, ok := attrs.Get(key); !ok {
+			t.Errorf("expected %s attribute, got %v", key, attrs)
+		}
+	}
+	if _, ok := attrs.Get("http.method"); ok {
+		t.Error("expected no http.method attribute in semconv mode")
+	}
+}
+
+func TestClientForwardsRequestID(t *testing.T) {
+	var forwarded string
+	downstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		forwarded = r.Header.Get(RequestIDHeader)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer downstream.Close()
+
+	ctx, close := Init(context.Background(),
+		WithConfig(Config{Service: "test-service", ForwardRequestID: true}),
+	)
+	defer close()
+
+	client := NewClient(nil)
+	handler := HTTPMiddleware(ctx, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req, _ := http.NewRequestWithContext(r.Context(), http.MethodGet, downstream.URL, nil)
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		_ = resp.Body.Close()
+
+		if req.Header.Get(RequestIDHeader) != "" {
+			t.Error("expected the caller's request to be left unmodified")
+		}
+	}), WithRequestID())
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(RequestIDHeader, "req-456")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if forwarded != "req-456" {
+		t.Errorf("expected forwarded request ID req-456, got %q", forwarded)
+	}
+}
+
+func TestClientTracer(t *testing.T) {
+	if ClientTracer(context.Background()) != nil {
+		t.Error("expected no tracer without a bedrock instance")
+	}
+
+	ctx, close := Init(context.Background())
+	defer close()
+
+	if ClientTracer(ctx) == nil {
+		t.Error("expected the instance's tracer")
+	}
+
+	op, opCtx := Operation(ctx, "quiet", NoTrace())
+	defer op.Done()
+	if ClientTracer(opCtx) != nil {
+		t.Error("expected no tracer within a NoTrace operation")
+	}
+}
@@ -1,13 +1,18 @@
 package bedrock
 
 import (
+	"crypto/tls"
 	"fmt"
 	"io"
 	"log/slog"
+	"math/rand/v2"
+	"net/url"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/kzs0/bedrock/env"
+	blog "github.com/kzs0/bedrock/log"
 	"github.com/kzs0/bedrock/server"
 	"github.com/kzs0/bedrock/trace"
 )
@@ -24,27 +29,200 @@ type Config struct {
 	TraceSampleRate float64 `env:"BEDROCK_TRACE_SAMPLE_RATE" envDefault:"1.0"`
 	// TraceSampler controls trace sampling (overrides TraceSampleRate if set).
 	TraceSampler trace.Sampler `env:"-"`
+	// TraceHTTPClientEvents records the phases of HTTP client requests as events on their
+	// spans: DNS lookup, connect, TLS handshake, and time to first byte.
+	TraceHTTPClientEvents bool `env:"BEDROCK_TRACE_HTTP_CLIENT_EVENTS"`
+	// SemConv names the HTTP attributes of the middleware and client by the OpenTelemetry
+	// semantic conventions, such as http.request.method, url.path, server.address, and
+	// http.response.status_code, for backends that key on them. Metric labels follow.
+	SemConv bool `env:"BEDROCK_SEMCONV"`
+	// ForwardRequestID forwards the request ID of the request being handled, from the
+	// middleware's WithRequestID, in the X-Request-ID header of HTTP client requests.
+	ForwardRequestID bool `env:"BEDROCK_FORWARD_REQUEST_ID"`
+	// TraceHTTPClientURL returns the http.url attribute of HTTP client spans for a request
+	// URL, such as to template identifiers out of paths. If nil, transport.SanitizeURL
+	// strips user info, the query string, and the fragment.
+	TraceHTTPClientURL func(*url.URL) string `env:"-"`
 
 	// Logging configuration
 	// LogLevel is the minimum log level (DEBUG, INFO, WARN, ERROR).
 	LogLevel string `env:"BEDROCK_LOG_LEVEL" envDefault:"INFO"`
+	// LogTracedDebug emits Debug logs below LogLevel within sampled traces, giving detailed
+	// logs for traced requests without the cost of debug logging everywhere. Combined with
+	// parent-based sampling, a caller can force them by sending a sampled traceparent.
+	LogTracedDebug bool `env:"BEDROCK_LOG_TRACED_DEBUG"`
 
-	// LogFormat is "json" or "text".
+	// LogFormat is "json", "text", "pretty", "gcp", "ecs", "syslog", or "journald".
+	// "pretty" is a colored, human-friendly format for local development, "gcp" is JSON
+	// with the fields Google Cloud Logging expects, qualifying trace IDs with LogGCPProject,
+	// and "ecs" is JSON with Elastic Common Schema field names, with Service as service.name.
+	// "syslog" sends RFC 5424 messages to LogSyslogAddr and "journald" sends records to
+	// systemd-journald, with log levels mapped to priorities. LogOutput is ignored for both.
 	LogFormat string `env:"BEDROCK_LOG_FORMAT" envDefault:"json"`
+	// LogTimeKey, LogLevelKey, and LogMessageKey rename the time, level, and msg fields
+	// of the "json" and "text" formats, such as to "timestamp", "severity", and "message".
+	LogTimeKey    string `env:"BEDROCK_LOG_TIME_KEY"`
+	LogLevelKey   string `env:"BEDROCK_LOG_LEVEL_KEY"`
+	LogMessageKey string `env:"BEDROCK_LOG_MESSAGE_KEY"`
+	// LogTimeFormat is the time encoding of the "json" and "text" formats: "rfc3339",
+	// "rfc3339nano", or epoch "unix", "unixmilli", or "unixnano" numbers.
+	// Defaults to RFC 3339 with millisecond precision.
+	LogTimeFormat string `env:"BEDROCK_LOG_TIME_FORMAT"`
+	// LogSyslogAddr is the syslog server for the "syslog" format, such as "udp://host:514",
+	// "tcp://host:601", or "unix:///dev/log". Defaults to the local /dev/log socket.
+	LogSyslogAddr string `env:"BEDROCK_LOG_SYSLOG_ADDR"`
+	// LogGCPProject is the Google Cloud project ID for the "gcp" format.
+	// Defaults to the GOOGLE_CLOUD_PROJECT environment variable.
+	LogGCPProject string `env:"BEDROCK_LOG_GCP_PROJECT"`
 	// LogOutput is the log output writer. Defaults to os.Stderr.
 	LogOutput io.Writer `env:"-"`
+	// LogHandler, if set, receives log records instead of them being formatted to LogOutput,
+	// such as to emit into a zap core or zerolog logger during a migration. Records still
+	// get trace context, static attributes, sampling, and LogLevel, but not redaction.
+	LogHandler slog.Handler `env:"-"`
+	// LogFile, if set, writes logs to this file instead of LogOutput, rotating it by size.
+	LogFile string `env:"BEDROCK_LOG_FILE"`
+	// LogMaxSizeMB is the size in megabytes at which LogFile is rotated.
+	LogMaxSizeMB int `env:"BEDROCK_LOG_MAX_SIZE_MB" envDefault:"100"`
+	// LogMaxBackups is the number of rotated log files to keep (0 keeps all).
+	LogMaxBackups int `env:"BEDROCK_LOG_MAX_BACKUPS" envDefault:"5"`
+	// LogMaxAge removes rotated log files older than this, and rotates the log file once it
+	// has been open this long (0 keeps them regardless of age).
+	LogMaxAge time.Duration `env:"BEDROCK_LOG_MAX_AGE"`
+	// LogCompress gzips rotated log files.
+	LogCompress bool `env:"BEDROCK_LOG_COMPRESS" envDefault:"true"`
 	// LogAddSource adds source code position to log output.
 	LogAddSource bool `env:"BEDROCK_LOG_ADD_SOURCE" envDefault:"true"`
+	// LogOperationAttrs adds the current operation's name and attributes to log records
+	// written within it. It is off by default, so existing log lines keep their shape.
+	LogOperationAttrs bool `env:"BEDROCK_LOG_OPERATION_ATTRS" envDefault:"false"`
+	// LogErrorStacks adds a stack trace to Error-level log records, as a "stack" field
+	// and as an exception event on the current span.
+	LogErrorStacks bool `env:"BEDROCK_LOG_ERROR_STACKS" envDefault:"false"`
 	// LogCanonical enables structured logging of operation completion.
 	LogCanonical bool `env:"BEDROCK_LOG_CANONICAL" envDefault:"false"`
+	// LogCanonicalLevel is the level of canonical log lines for successful operations.
+	LogCanonicalLevel string `env:"BEDROCK_LOG_CANONICAL_LEVEL" envDefault:"INFO"`
+	// LogCanonicalFailureLevel is the level of canonical log lines for failed operations.
+	// Defaults to ERROR. If empty, LogCanonicalLevel is used.
+	LogCanonicalFailureLevel string `env:"BEDROCK_LOG_CANONICAL_FAILURE_LEVEL" envDefault:"ERROR"`
+	// LogCanonicalMessage is the message of canonical log lines.
+	LogCanonicalMessage string `env:"BEDROCK_LOG_CANONICAL_MESSAGE" envDefault:"operation.complete"`
+	// LogCanonicalFields are the optional fields of canonical log lines: "attributes",
+	// "steps", "children" (child operations that ended before the operation, with their
+	// duration and success, up to 100 with the rest counted in "children_dropped"), and
+	// "events" (registered events and exceptions, with their times, even if the span
+	// isn't sampled, up to 100 with the rest counted in "events_dropped"). The operation
+	// name, duration, success, and error are always included. Defaults to attributes and steps.
+	LogCanonicalFields []string `env:"BEDROCK_LOG_CANONICAL_FIELDS" envDefault:"attributes,steps"`
+	// LogCanonicalAttrs limits the attributes in canonical log lines to these keys.
+	// If empty, all attributes are included.
+	LogCanonicalAttrs []string `env:"BEDROCK_LOG_CANONICAL_ATTRS"`
+	// LogCanonicalSuccessSampleRate is the fraction (0.0 to 1.0) of successful operations
+	// that get a canonical log line; 0 or 1 logs all of them. Failed operations are always logged.
+	LogCanonicalSuccessSampleRate float64 `env:"BEDROCK_LOG_CANONICAL_SUCCESS_SAMPLE_RATE" envDefault:"1.0"`
+	// LogSampleInitial enables log sampling when positive: each second, only the first
+	// LogSampleInitial records with the same level and message are logged, then every
+	// LogSampleThereafter-th. Dropped records are counted in log_dropped_total.
+	LogSampleInitial int `env:"BEDROCK_LOG_SAMPLE_INITIAL" envDefault:"0"`
+	// LogSampleThereafter is the sampling rate once LogSampleInitial is exceeded.
+	// If zero, all further records in that second are dropped.
+	LogSampleThereafter int `env:"BEDROCK_LOG_SAMPLE_THEREAFTER" envDefault:"100"`
+	// LogDedupWindow, if positive, collapses bursts of identical log records (same level
+	// and message) within the window into one "msg (repeated N times in Xs)" record,
+	// so a crash loop doesn't drown everything else.
+	LogDedupWindow time.Duration `env:"BEDROCK_LOG_DEDUP_WINDOW"`
+	// LogMaxRecordSize, if positive, is the approximate maximum size in bytes of a log
+	// record's message and attributes. The longest values of larger records are truncated
+	// and marked with "...[truncated]".
+	LogMaxRecordSize int `env:"BEDROCK_LOG_MAX_RECORD_SIZE"`
+	// LogDestinations are additional log outputs, each with its own format and level,
+	// e.g. text at INFO to stderr via LogOutput plus JSON at DEBUG to a file.
+	LogDestinations []LogDestination `env:"-"`
+
+	// Redaction configuration
+	// RedactKeys masks the values of log and span attributes whose keys match these
+	// case-insensitive patterns, such as "password", "token", or "*_secret".
+	RedactKeys []string `env:"BEDROCK_REDACT_KEYS"`
+	// RedactValues masks the parts of string log and span attribute values that match
+	// these regular expressions.
+	RedactValues []string `env:"BEDROCK_REDACT_VALUES"`
+
+	// Audit configuration
+	// Audit events from bedrock.Audit go through their own pipeline, separate from the
+	// application log: they are never sampled, deduplicated, or filtered by level.
+	// AuditOutput is the audit log writer. Defaults to os.Stderr.
+	AuditOutput io.Writer `env:"-"`
+	// AuditFile, if set, writes audit events to this file instead of AuditOutput, rotated
+	// like LogFile with the LogMaxSizeMB, LogMaxBackups, LogMaxAge, and LogCompress settings.
+	AuditFile string `env:"BEDROCK_AUDIT_FILE"`
+	// AuditFormat is "json" or "text".
+	AuditFormat string `env:"BEDROCK_AUDIT_FORMAT" envDefault:"json"`
+	// AuditRequiredAttrs are attribute keys every audit event must have, such as "actor".
+	// Events missing any are still written, with the missing keys in "audit.missing",
+	// and Audit returns an error.
+	AuditRequiredAttrs []string `env:"BEDROCK_AUDIT_REQUIRED_ATTRS"`
+
+	// Operation configuration
+	// OperationFailOnCancel marks operations as failed if their context is canceled or
+	// their deadline is exceeded before Done, as the FailOnCancel option does per operation.
+	OperationFailOnCancel bool `env:"BEDROCK_OPERATION_FAIL_ON_CANCEL" envDefault:"false"`
+	// OperationSLOSampleBreaches exports the spans of operations slower than their WithSLO
+	// target even if the trace sampler dropped them. Such operations' spans are recorded
+	// regardless of sampling, but their child spans follow the sampler as usual.
+	OperationSLOSampleBreaches bool `env:"BEDROCK_OPERATION_SLO_SAMPLE_BREACHES" envDefault:"false"`
+	// OperationErrorTypes classifies the error of each failed operation into a small,
+	// bounded category with ErrorClassifier, recorded in an "error.type" attribute on the
+	// operation and its span and as an error_type label on its failures counter.
+	// An "error.type" the operation already has, such as one set by FailOnCancel, is kept.
+	OperationErrorTypes bool `env:"BEDROCK_OPERATION_ERROR_TYPES" envDefault:"false"`
+	// ErrorClassifier maps an operation's error, which may be nil, to its "error.type"
+	// category when OperationErrorTypes is set. If nil, ClassifyError is used.
+	ErrorClassifier func(error) string `env:"-"`
+	// OperationInterceptors run when every operation starts, in order, and the functions
+	// they return run when it is done, in reverse order, before its telemetry is recorded.
+	// Use them for cross-cutting concerns such as per-tenant quota accounting or
+	// fault injection in tests.
+	OperationInterceptors []OperationInterceptor `env:"-"`
 
 	// Metrics configuration
 	// MetricPrefix is prepended to all metric names.
 	MetricPrefix string `env:"BEDROCK_METRIC_PREFIX"`
-	// MetricBuckets are the default histogram buckets.
+	// MetricStrictNames rejects invalid metric and label names passed to Counter, Gauge,
+	// and Histogram instead of silently sanitizing them, logging an error the first time
+	// and dropping the metric's series, and logs a warning the first time any other metric
+	// or label name is sanitized, such as those bedrock derives from operation and source
+	// names.
+	MetricStrictNames bool `env:"BEDROCK_METRIC_STRICT_NAMES" envDefault:"false"`
+	// MetricLabelAllowlist, if non-empty, restricts which attribute keys may become labels
+	// on automatic operation metrics. Label names not in the list are dropped.
+	MetricLabelAllowlist []string `env:"BEDROCK_METRIC_LABEL_ALLOWLIST"`
+	// MetricLabelDenylist lists attribute keys that never become labels on automatic
+	// operation metrics (e.g., user_id), even if an operation registers them.
+	// It takes precedence over MetricLabelAllowlist.
+	MetricLabelDenylist []string `env:"BEDROCK_METRIC_LABEL_DENYLIST"`
+	// MetricConsolidatedOperations records all operations into a single set of metrics
+	// (operation_count, operation_failures, operation_duration_ms) with an "operation" label,
+	// instead of creating metric families per operation name. Operation-specific metric
+	// labels are not recorded in this mode; static attributes still are.
+	MetricConsolidatedOperations bool `env:"BEDROCK_METRIC_CONSOLIDATED_OPERATIONS" envDefault:"false"`
+	// MetricDurationSeconds records automatic operation durations as float seconds in
+	// <name>_duration_seconds histograms, following Prometheus and OpenTelemetry conventions,
+	// instead of truncated milliseconds in <name>_duration_ms histograms.
+	MetricDurationSeconds bool `env:"BEDROCK_METRIC_DURATION_SECONDS" envDefault:"false"`
+	// MetricBuckets are the default histogram buckets for this instance's registries.
+	// If empty, metric.DefaultBuckets is used.
 	MetricBuckets []float64 `env:"BEDROCK_METRIC_BUCKETS"`
 	// RuntimeMetrics enables automatic collection of Go runtime metrics.
 	RuntimeMetrics bool `env:"BEDROCK_RUNTIME_METRICS" envDefault:"true"`
+	// RuntimeMetricsInterval, if positive, also collects runtime metrics in the background
+	// at this interval (with up to 10% jitter) instead of only when metrics are gathered.
+	RuntimeMetricsInterval time.Duration `env:"BEDROCK_RUNTIME_METRICS_INTERVAL"`
+	// ProcessMetrics enables automatic collection of process metrics (CPU, memory, file descriptors).
+	ProcessMetrics bool `env:"BEDROCK_PROCESS_METRICS" envDefault:"true"`
+	// BuildInfoMetrics enables the build_info metric populated from the binary's build information.
+	// Static attributes named like its labels, such as those WithBuildInfoAttrs adds, replace them.
+	BuildInfoMetrics bool `env:"BEDROCK_BUILD_INFO_METRICS" envDefault:"true"`
 
 	// Server configuration
 	// ServerEnabled enables the automatic observability server.
@@ -55,6 +233,22 @@ type Config struct {
 	ServerMetrics bool `env:"BEDROCK_SERVER_METRICS" envDefault:"true"`
 	// ServerPprof enables /debug/pprof endpoints.
 	ServerPprof bool `env:"BEDROCK_SERVER_PPROF" envDefault:"true"`
+	// ServerExpvar enables the /debug/vars endpoint, serving the variables published with
+	// the expvar package.
+	ServerExpvar bool `env:"BEDROCK_SERVER_EXPVAR" envDefault:"true"`
+	// ServerTLSCertFile and ServerTLSKeyFile serve the observability server over HTTPS
+	// with the PEM-encoded certificate and key.
+	ServerTLSCertFile string `env:"BEDROCK_SERVER_TLS_CERT_FILE"`
+	ServerTLSKeyFile  string `env:"BEDROCK_SERVER_TLS_KEY_FILE"`
+	// ServerTLSClientCAFile requires clients of the observability server to present a
+	// certificate signed by a CA in the PEM bundle.
+	ServerTLSClientCAFile string `env:"BEDROCK_SERVER_TLS_CLIENT_CA_FILE"`
+	// ServerTLSConfig serves the observability server over HTTPS with the configuration,
+	// as server.Config.TLSConfig does.
+	ServerTLSConfig *tls.Config `env:"-"`
+	// ServerAdminToken enables the /admin endpoints (e.g., PUT /admin/loglevel) when set.
+	// Requests must send it as a bearer token in the Authorization header.
+	ServerAdminToken string `env:"BEDROCK_SERVER_ADMIN_TOKEN"`
 	// ServerReadTimeout is the max request read duration.
 	ServerReadTimeout time.Duration `env:"BEDROCK_SERVER_READ_TIMEOUT" envDefault:"10s"`
 	// ServerReadHeaderTimeout is the header read timeout.
@@ -73,23 +267,37 @@ type Config struct {
 // DefaultConfig returns a default configuration.
 func DefaultConfig() Config {
 	return Config{
-		Service:                 "unknown",
-		TraceSampleRate:         1.0,
-		LogLevel:                "info",
-		LogFormat:               "json",
-		LogAddSource:            true,
-		LogCanonical:            false,
-		RuntimeMetrics:          true,
-		ServerEnabled:           true,
-		ServerAddr:              ":9090",
-		ServerMetrics:           true,
-		ServerPprof:             true,
-		ServerReadTimeout:       10 * time.Second,
-		ServerReadHeaderTimeout: 5 * time.Second,
-		ServerWriteTimeout:      30 * time.Second,
-		ServerIdleTimeout:       120 * time.Second,
-		ServerMaxHeaderBytes:    1 << 20, // 1 MB
-		ShutdownTimeout:         30 * time.Second,
+		Service:                       "unknown",
+		TraceSampleRate:               1.0,
+		LogLevel:                      "info",
+		LogFormat:                     "json",
+		LogMaxSizeMB:                  100,
+		LogMaxBackups:                 5,
+		LogCompress:                   true,
+		LogAddSource:                  true,
+		LogOperationAttrs:             false,
+		LogCanonical:                  false,
+		LogCanonicalLevel:             "INFO",
+		LogCanonicalFailureLevel:      "ERROR",
+		LogCanonicalMessage:           "operation.complete",
+		LogCanonicalFields:            []string{"attributes", "steps"},
+		LogCanonicalSuccessSampleRate: 1.0,
+		LogSampleThereafter:           100,
+		AuditFormat:                   "json",
+		RuntimeMetrics:                true,
+		ProcessMetrics:                true,
+		BuildInfoMetrics:              true,
+		ServerEnabled:                 true,
+		ServerAddr:                    ":9090",
+		ServerMetrics:                 true,
+		ServerPprof:                   true,
+		ServerExpvar:                  true,
+		ServerReadTimeout:             10 * time.Second,
+		ServerReadHeaderTimeout:       5 * time.Second,
+		ServerWriteTimeout:            30 * time.Second,
+		ServerIdleTimeout:             120 * time.Second,
+		ServerMaxHeaderBytes:          1 << 20, // 1 MB
+		ShutdownTimeout:               30 * time.Second,
 	}
 }
 
@@ -127,17 +335,95 @@ func parseLogLevel(level string) slog.Level {
 	}
 }
 
+// LogDestination is an additional log output with its own format and level.
+type LogDestination struct {
+	// Output is the writer to write logs to. Defaults to os.Stderr.
+	Output io.Writer
+	// Format is the output format, as in Config.LogFormat. Defaults to "json".
+	Format string
+	// Level is the minimum log level (DEBUG, INFO, WARN, ERROR). Defaults to Config.LogLevel.
+	Level string
+	// SyslogAddr is the syslog server for the "syslog" format, as in Config.LogSyslogAddr.
+	SyslogAddr string
+	// Handler, if set, receives records instead of them being formatted to Output,
+	// as in Config.LogHandler.
+	Handler slog.Handler
+}
+
 // logLevel returns the parsed slog.Level from the string LogLevel field.
 func (c Config) logLevel() slog.Level {
 	return parseLogLevel(c.LogLevel)
 }
 
+// canonicalLevel returns the level of the canonical log line for an operation.
+func (c Config) canonicalLevel(success bool) slog.Level {
+	if !success && c.LogCanonicalFailureLevel != "" {
+		return parseLogLevel(c.LogCanonicalFailureLevel)
+	}
+	return parseLogLevel(c.LogCanonicalLevel)
+}
+
+// canonicalMessage returns the message of canonical log lines.
+func (c Config) canonicalMessage() string {
+	if c.LogCanonicalMessage == "" {
+		return "operation.complete"
+	}
+	return c.LogCanonicalMessage
+}
+
+// canonicalField reports whether the optional field is included in canonical log lines.
+func (c Config) canonicalField(name string) bool {
+	if len(c.LogCanonicalFields) == 0 {
+		return name == "attributes" || name == "steps"
+	}
+	return slices.Contains(c.LogCanonicalFields, name)
+}
+
+// canonicalAttr reports whether the attribute key is included in canonical log lines.
+func (c Config) canonicalAttr(key string) bool {
+	return len(c.LogCanonicalAttrs) == 0 || slices.Contains(c.LogCanonicalAttrs, key)
+}
+
+// sampleCanonical reports whether a successful operation gets a canonical log line.
+func (c Config) sampleCanonical() bool {
+	if c.LogCanonicalSuccessSampleRate > 0 && c.LogCanonicalSuccessSampleRate < 1.0 {
+		return rand.Float64() < c.LogCanonicalSuccessSampleRate
+	}
+	return true
+}
+
+// filterMetricLabels returns the operation metric label names permitted by
+// MetricLabelAllowlist and MetricLabelDenylist, preserving order.
+func (c Config) filterMetricLabels(labelNames []string) []string {
+	if len(c.MetricLabelAllowlist) == 0 && len(c.MetricLabelDenylist) == 0 {
+		return labelNames
+	}
+
+	filtered := make([]string, 0, len(labelNames))
+	for _, name := range labelNames {
+		if slices.Contains(c.MetricLabelDenylist, name) {
+			continue
+		}
+		if len(c.MetricLabelAllowlist) > 0 && !slices.Contains(c.MetricLabelAllowlist, name) {
+			continue
+		}
+		filtered = append(filtered, name)
+	}
+	return filtered
+}
+
 // serverConfig returns a server.Config from the Config fields.
 func (c Config) serverConfig() server.Config {
 	return server.Config{
 		Addr:              c.ServerAddr,
 		EnableMetrics:     c.ServerMetrics,
 		EnablePprof:       c.ServerPprof,
+		EnableExpvar:      c.ServerExpvar,
+		TLSCertFile:       c.ServerTLSCertFile,
+		TLSKeyFile:        c.ServerTLSKeyFile,
+		TLSClientCAFile:   c.ServerTLSClientCAFile,
+		TLSConfig:         c.ServerTLSConfig,
+		AdminToken:        c.ServerAdminToken,
 		ReadTimeout:       c.ServerReadTimeout,
 		ReadHeaderTimeout: c.ServerReadHeaderTimeout,
 		WriteTimeout:      c.ServerWriteTimeout,
@@ -146,3 +432,26 @@ func (c Config) serverConfig() server.Config {
 		ShutdownTimeout:   c.ShutdownTimeout,
 	}
 }
+
+// logDestinations converts LogDestinations to log handler destinations.
+// Destinations without a level follow the primary level, including runtime changes.
+func (c Config) logDestinations() []blog.Destination {
+	if len(c.LogDestinations) == 0 {
+		return nil
+	}
+
+	dests := make([]blog.Destination, 0, len(c.LogDestinations))
+	for _, d := range c.LogDestinations {
+		dest := blog.Destination{
+			Output:     d.Output,
+			Format:     d.Format,
+			SyslogAddr: d.SyslogAddr,
+			Handler:    d.Handler,
+		}
+		if d.Level != "" {
+			dest.Level = parseLogLevel(d.Level)
+		}
+		dests = append(dests, dest)
+	}
+	return dests
+}
@@ -2,6 +2,8 @@ package bedrock
 
 import (
 	"context"
+
+	"github.com/kzs0/bedrock/attr"
 )
 
 type contextKey int
@@ -11,6 +13,8 @@ const (
 	operationKey
 	sourceKey
 	noTraceKey
+	logAttrsKey
+	valuesKey
 )
 
 // WithBedrock returns a context with the bedrock instance attached.
@@ -71,3 +75,29 @@ func isNoTrace(ctx context.Context) bool {
 	v, _ := ctx.Value(noTraceKey).(bool)
 	return v
 }
+
+// withLogAttrs stores log attributes in the context, merged with any already there.
+func withLogAttrs(ctx context.Context, attrs ...attr.Attr) context.Context {
+	return context.WithValue(ctx, logAttrsKey, logAttrsFromContext(ctx).Merge(attrs...))
+}
+
+// logAttrsFromContext retrieves log attributes from the context.
+func logAttrsFromContext(ctx context.Context) attr.Set {
+	if set, ok := ctx.Value(logAttrsKey).(attr.Set); ok {
+		return set
+	}
+	return attr.Set{}
+}
+
+// withValue stores a request-scoped value in the context, replacing any with the same key.
+func withValue(ctx context.Context, a attr.Attr) context.Context {
+	return context.WithValue(ctx, valuesKey, valuesFromContext(ctx).Merge(a))
+}
+
+// valuesFromContext retrieves request-scoped values from the context.
+func valuesFromContext(ctx context.Context) attr.Set {
+	if set, ok := ctx.Value(valuesKey).(attr.Set); ok {
+		return set
+	}
+	return attr.Set{}
+}
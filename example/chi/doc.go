@@ -0,0 +1,18 @@
+// Package chibedrock wires bedrock's HTTP middleware into github.com/go-chi/chi routers,
+// with chi's route patterns as the http.route label and span name.
+//
+// It is a separate module, github.com/kzs0/bedrock/example/chi, so the core bedrock
+// module stays free of the github.com/go-chi/chi/v5 dependency:
+//
+//	go get github.com/kzs0/bedrock/example/chi
+//
+// Install the middleware with Use, so it runs after chi has put its routing context in
+// the request:
+//
+//	router := chi.NewRouter()
+//	router.Use(chibedrock.Middleware(ctx))
+//	router.Get("/users/{id}", getUser) // http.route="/users/{id}", span "GET /users/{id}"
+//
+// chi handlers are plain net/http handlers, so status codes, panics, and sizes are
+// handled as with bedrock.HTTPMiddleware, and its options apply.
+package chibedrock
@@ -0,0 +1,10 @@
+module github.com/kzs0/bedrock/example/chi
+
+go 1.25
+
+require (
+	github.com/go-chi/chi/v5 v5.2.1
+	github.com/kzs0/bedrock v0.1.0
+)
+
+replace github.com/kzs0/bedrock => ../../
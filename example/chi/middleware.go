@@ -0,0 +1,32 @@
+package chibedrock
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/kzs0/bedrock"
+)
+
+// Middleware returns chi middleware that starts a bedrock operation for each request,
+// using the bedrock instance in ctx. Options are those of bedrock.HTTPMiddleware.
+//
+// Usage:
+//
+//	router.Use(chibedrock.Middleware(ctx, bedrock.WithSkipPaths("/healthz")))
+func Middleware(ctx context.Context, opts ...bedrock.MiddlewareOption) func(http.Handler) http.Handler {
+	opts = append([]bedrock.MiddlewareOption{bedrock.WithRouteFunc(RoutePattern)}, opts...)
+	return func(next http.Handler) http.Handler {
+		return bedrock.HTTPMiddleware(ctx, next, opts...)
+	}
+}
+
+// RoutePattern returns the pattern of the chi route that matched r, such as "/users/{id}",
+// including the patterns of any mounted sub-routers. It is only complete once the
+// request has been routed, which bedrock.HTTPMiddleware waits for.
+func RoutePattern(r *http.Request) string {
+	if rctx := chi.RouteContext(r.Context()); rctx != nil {
+		return rctx.RoutePattern()
+	}
+	return ""
+}
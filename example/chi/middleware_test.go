@@ -0,0 +1,60 @@
+package chibedrock
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/kzs0/bedrock"
+	"github.com/kzs0/bedrock/trace"
+)
+
+func TestMiddleware(t *testing.T) {
+	ctx, close := bedrock.Init(context.Background())
+	defer close()
+
+	var op *bedrock.Op
+	var span *trace.Span
+	router := chi.NewRouter()
+	router.Use(Middleware(ctx))
+	router.Route("/api", func(r chi.Router) {
+		r.Get("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+			op, _ = bedrock.OperationFromContext(r.Context())
+			span = trace.SpanFromContext(r.Context())
+			http.NotFound(w, r)
+		})
+	})
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest("GET", "/api/users/42", nil))
+
+	if op == nil || span == nil {
+		t.Fatal("expected the handler to run within an operation")
+	}
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected the handler's 404, got %d", rec.Code)
+	}
+	if op.Succeeded() {
+		t.Error("expected the 404 to fail the operation")
+	}
+
+	// The pattern includes the sub-router's prefix
+	if span.Name() != "GET /api/users/{id}" {
+		t.Errorf("expected the span to be named by the route pattern, got %q", span.Name())
+	}
+	attrs := span.Attrs()
+	if v, _ := attrs.Get("http.route"); v.String() != "/api/users/{id}" {
+		t.Errorf("expected http.route /api/users/{id}, got %q", v.String())
+	}
+	if v, _ := attrs.Get("http.status_code"); v.AsInt64() != http.StatusNotFound {
+		t.Errorf("expected http.status_code 404, got %d", v.AsInt64())
+	}
+}
+
+func TestRoutePatternUnrouted(t *testing.T) {
+	if got := RoutePattern(httptest.NewRequest("GET", "/users/42", nil)); got != "" {
+		t.Errorf("expected no pattern outside a chi router, got %q", got)
+	}
+}
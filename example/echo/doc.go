@@ -0,0 +1,22 @@
+// Package echobedrock wires bedrock operations into github.com/labstack/echo, with
+// echo's route paths as the http.route label and span name, and errors returned by
+// handlers recorded as the operation's failure.
+//
+// It is a separate module, github.com/kzs0/bedrock/example/echo, so the core bedrock
+// module stays free of the github.com/labstack/echo/v4 dependency:
+//
+//	go get github.com/kzs0/bedrock/example/echo
+//
+// Install the middleware with Use:
+//
+//	e := echo.New()
+//	e.Use(echobedrock.Middleware(ctx))
+//	e.GET("/users/:id", getUser) // http.route="/users/:id", span "GET /users/:id"
+//
+// echo writes the response for a returned error in its HTTPErrorHandler, after the
+// handler chain returns. The middleware calls it within the operation instead, so the
+// error's status is recorded, and returns nil so echo doesn't handle the error twice.
+//
+// The middleware is bedrock.HTTPMiddleware, so its options apply. It recovers panics
+// itself, responding with a 500.
+package echobedrock
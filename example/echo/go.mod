@@ -0,0 +1,10 @@
+module github.com/kzs0/bedrock/example/echo
+
+go 1.25
+
+require (
+	github.com/kzs0/bedrock v0.1.0
+	github.com/labstack/echo/v4 v4.13.3
+)
+
+replace github.com/kzs0/bedrock => ../../
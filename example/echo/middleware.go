@@ -0,0 +1,63 @@
+package echobedrock
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/kzs0/bedrock"
+	"github.com/kzs0/bedrock/attr"
+	"github.com/labstack/echo/v4"
+)
+
+// requestKey is the request context key of the echo request being served.
+type requestKey struct{}
+
+// request is an echo request being served, with the rest of its handler chain.
+type request struct {
+	c    echo.Context
+	next echo.HandlerFunc
+}
+
+// Middleware returns echo middleware that starts a bedrock operation for each request,
+// using the bedrock instance in ctx. Options are those of bedrock.HTTPMiddleware.
+//
+// Usage:
+//
+//	e.Use(echobedrock.Middleware(ctx, bedrock.WithSkipPaths("/healthz")))
+func Middleware(ctx context.Context, opts ...bedrock.MiddlewareOption) echo.MiddlewareFunc {
+	opts = append([]bedrock.MiddlewareOption{bedrock.WithRouteFunc(routePath)}, opts...)
+	handler := bedrock.HTTPMiddleware(ctx, http.HandlerFunc(serve), opts...)
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			handler.ServeHTTP(c.Response(), req.WithContext(context.WithValue(req.Context(), requestKey{}, &request{c: c, next: next})))
+			return nil
+		}
+	}
+}
+
+// serve runs the rest of the echo handler chain within the operation.
+func serve(w http.ResponseWriter, r *http.Request) {
+	req := r.Context().Value(requestKey{}).(*request)
+
+	// Handlers write through echo's response; send writes through bedrock's writer,
+	// so the operation sees the status and size
+	req.c.SetRequest(r)
+	req.c.SetResponse(echo.NewResponse(w, req.c.Echo()))
+
+	if err := req.next(req.c); err != nil {
+		if op, ok := bedrock.OperationFromContext(r.Context()); ok {
+			op.Register(r.Context(), attr.Error(err))
+		}
+		req.c.Error(err)
+	}
+}
+
+// routePath returns the path of the echo route that matched r, such as "/users/:id".
+func routePath(r *http.Request) string {
+	if req, ok := r.Context().Value(requestKey{}).(*request); ok {
+		return req.c.Path()
+	}
+	return ""
+}
@@ -0,0 +1,76 @@
+package echobedrock
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kzs0/bedrock"
+	"github.com/kzs0/bedrock/trace"
+	"github.com/labstack/echo/v4"
+)
+
+func TestMiddleware(t *testing.T) {
+	ctx, close := bedrock.Init(context.Background())
+	defer close()
+
+	var op *bedrock.Op
+	var span *trace.Span
+	e := echo.New()
+	e.Use(Middleware(ctx))
+	e.GET("/users/:id", func(c echo.Context) error {
+		op, _ = bedrock.OperationFromContext(c.Request().Context())
+		span = trace.SpanFromContext(c.Request().Context())
+		return echo.NewHTTPError(http.StatusNotFound, "no such user")
+	})
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest("GET", "/users/42", nil))
+
+	if op == nil || span == nil {
+		t.Fatal("expected the handler to run within an operation")
+	}
+
+	// The returned error is the operation's failure, and echo's error handler responds
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected echo's 404 response, got %d", rec.Code)
+	}
+	if op.Succeeded() {
+		t.Error("expected the operation to fail with the handler's error")
+	}
+
+	if span.Name() != "GET /users/:id" {
+		t.Errorf("expected the span to be named by the route path, got %q", span.Name())
+	}
+	attrs := span.Attrs()
+	if v, _ := attrs.Get("http.route"); v.String() != "/users/:id" {
+		t.Errorf("expected http.route /users/:id, got %q", v.String())
+	}
+	if v, _ := attrs.Get("http.status_code"); v.AsInt64() != http.StatusNotFound {
+		t.Errorf("expected http.status_code 404, got %d", v.AsInt64())
+	}
+}
+
+func TestMiddlewareSuccess(t *testing.T) {
+	ctx, close := bedrock.Init(context.Background())
+	defer close()
+
+	var op *bedrock.Op
+	e := echo.New()
+	e.Use(Middleware(ctx))
+	e.GET("/healthz", func(c echo.Context) error {
+		op, _ = bedrock.OperationFromContext(c.Request().Context())
+		return c.String(http.StatusOK, "ok")
+	})
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest("GET", "/healthz", nil))
+
+	if rec.Code != http.StatusOK || rec.Body.String() != "ok" {
+		t.Errorf("expected the handler's response, got %d %q", rec.Code, rec.Body.String())
+	}
+	if op == nil || !op.Succeeded() {
+		t.Error("expected a successful operation")
+	}
+}
@@ -0,0 +1,28 @@
+// Package fiberbedrock wires bedrock operations into github.com/gofiber/fiber, with
+// fiber's route paths as the http.route label, and errors returned by handlers
+// recorded as the operation's failure.
+//
+// It is a separate module, github.com/kzs0/bedrock/example/fiber, so the core bedrock
+// module stays free of the github.com/gofiber/fiber/v2 dependency:
+//
+//	go get github.com/kzs0/bedrock/example/fiber
+//
+// fiber is built on fasthttp rather than net/http, so bedrock.HTTPMiddleware can't wrap
+// it. The middleware starts the operation itself, with the same attributes and metric
+// labels, and puts the operation's context in the request's user context:
+//
+//	app := fiber.New()
+//	app.Use(recover.New())
+//	app.Use(fiberbedrock.Middleware(ctx))
+//	app.Get("/users/:id", func(c *fiber.Ctx) error {
+//	    bedrock.Info(c.UserContext(), "loading user")
+//	    ...
+//	})
+//
+// fiber writes the response for a returned error in its ErrorHandler, after the handler
+// chain returns. The middleware calls it within the operation instead, so the error's
+// status is recorded, and returns nil so fiber doesn't handle the error twice.
+//
+// A panic fails the operation and is then re-raised, so install fiber's recover
+// middleware before this one to respond to it.
+package fiberbedrock
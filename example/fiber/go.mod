@@ -0,0 +1,10 @@
+module github.com/kzs0/bedrock/example/fiber
+
+go 1.25
+
+require (
+	github.com/gofiber/fiber/v2 v2.52.6
+	github.com/kzs0/bedrock v0.1.0
+)
+
+replace github.com/kzs0/bedrock => ../../
@@ -0,0 +1,74 @@
+package fiberbedrock
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/kzs0/bedrock"
+	"github.com/kzs0/bedrock/attr"
+	httpProp "github.com/kzs0/bedrock/trace/http"
+)
+
+// Middleware returns fiber middleware that starts a bedrock operation named
+// "http.request" for each request, using the bedrock instance in ctx unless the
+// request's user context has one. Options are applied to each operation.
+//
+// Usage:
+//
+//	app.Use(fiberbedrock.Middleware(ctx, bedrock.Attrs(attr.String("api.version", "v2"))))
+func Middleware(ctx context.Context, opts ...bedrock.OperationOption) fiber.Handler {
+	b := bedrock.FromContext(ctx)
+	prop := &httpProp.Propagator{}
+
+	return func(c *fiber.Ctx) error {
+		reqCtx := c.UserContext()
+		if bedrock.FromContext(reqCtx) == nil && b != nil {
+			reqCtx = bedrock.WithBedrock(reqCtx, b)
+		}
+
+		opOpts := []bedrock.OperationOption{
+			bedrock.Attrs(
+				attr.String("http.method", c.Method()),
+				attr.String("http.path", c.Path()),
+				attr.String("http.scheme", c.Protocol()),
+				attr.String("http.host", c.Hostname()),
+				attr.String("http.user_agent", c.Get(fiber.HeaderUserAgent)),
+			),
+			bedrock.MetricLabels("http.method", "http.route", "http.status_code"),
+		}
+
+		// fasthttp headers aren't an http.Header, so copy the trace context ones over
+		header := http.Header{}
+		header.Set("traceparent", c.Get("traceparent"))
+		header.Set("tracestate", c.Get("tracestate"))
+		if remote, err := prop.Extract(header); err == nil && remote.IsValid() {
+			opOpts = append(opOpts, bedrock.WithRemoteParent(remote))
+		}
+
+		op, opCtx := bedrock.Operation(reqCtx, "http.request", append(opOpts, opts...)...)
+		defer op.DoneRecover(bedrock.Repanic())
+		c.SetUserContext(opCtx)
+
+		if err := c.Next(); err != nil {
+			op.Register(opCtx, attr.Error(err))
+			if herr := c.App().ErrorHandler(c, err); herr != nil {
+				_ = c.SendStatus(fiber.StatusInternalServerError)
+			}
+		}
+
+		// The matched route is only known once the chain has run
+		status := c.Response().StatusCode()
+		op.Register(opCtx,
+			attr.String("http.route", c.Route().Path),
+			attr.Int("http.status_code", status),
+			attr.Int("http.request_size", len(c.Body())),
+			attr.Int("http.response_size", len(c.Response().Body())),
+		)
+		if status >= 400 && op.Succeeded() {
+			op.Register(opCtx, attr.Error(fmt.Errorf("HTTP %d", status)))
+		}
+		return nil
+	}
+}
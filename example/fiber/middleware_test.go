@@ -0,0 +1,60 @@
+package fiberbedrock
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/kzs0/bedrock"
+	"github.com/kzs0/bedrock/trace"
+)
+
+func TestMiddleware(t *testing.T) {
+	ctx, close := bedrock.Init(context.Background())
+	defer close()
+
+	var op *bedrock.Op
+	var span *trace.Span
+	app := fiber.New()
+	app.Use(Middleware(ctx))
+	app.Get("/users/:id", func(c *fiber.Ctx) error {
+		op, _ = bedrock.OperationFromContext(c.UserContext())
+		span = trace.SpanFromContext(c.UserContext())
+		return fiber.NewError(fiber.StatusNotFound, "no such user")
+	})
+
+	req := httptest.NewRequest("GET", "/users/42", nil)
+	req.Header.Set("traceparent", "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if op == nil || span == nil {
+		t.Fatal("expected the handler to run within an operation")
+	}
+	if op.Name() != "http.request" {
+		t.Errorf("expected the http.request operation, got %q", op.Name())
+	}
+
+	// The returned error is the operation's failure, and fiber's error handler responds
+	if resp.StatusCode != fiber.StatusNotFound {
+		t.Errorf("expected fiber's 404 response, got %d", resp.StatusCode)
+	}
+	if op.Succeeded() {
+		t.Error("expected the operation to fail with the handler's error")
+	}
+
+	if span.TraceID().String() != "0af7651916cd43dd8448eb211c80319c" {
+		t.Errorf("expected the remote trace ID, got %s", span.TraceID())
+	}
+	attrs := span.Attrs()
+	if v, _ := attrs.Get("http.route"); v.String() != "/users/:id" {
+		t.Errorf("expected http.route /users/:id, got %q", v.String())
+	}
+	if v, _ := attrs.Get("http.status_code"); v.AsInt64() != fiber.StatusNotFound {
+		t.Errorf("expected http.status_code 404, got %d", v.AsInt64())
+	}
+}
@@ -0,0 +1,19 @@
+// Package ginbedrock wires bedrock operations into github.com/gin-gonic/gin, with gin's
+// route templates as the http.route label and span name, and errors added with
+// c.Error recorded as the operation's failure.
+//
+// It is a separate module, github.com/kzs0/bedrock/example/gin, so the core bedrock
+// module stays free of the github.com/gin-gonic/gin dependency:
+//
+//	go get github.com/kzs0/bedrock/example/gin
+//
+// Install the middleware with Use:
+//
+//	router := gin.New()
+//	router.Use(ginbedrock.Middleware(ctx))
+//	router.GET("/users/:id", getUser) // http.route="/users/:id", span "GET /users/:id"
+//
+// The middleware is bedrock.HTTPMiddleware, so its options apply. It recovers panics
+// itself, responding with a 500, so gin.Recovery only sees panics from middleware
+// installed before it.
+package ginbedrock
@@ -0,0 +1,10 @@
+module github.com/kzs0/bedrock/example/gin
+
+go 1.25
+
+require (
+	github.com/gin-gonic/gin v1.10.1
+	github.com/kzs0/bedrock v0.1.0
+)
+
+replace github.com/kzs0/bedrock => ../../
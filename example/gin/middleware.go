@@ -0,0 +1,73 @@
+package ginbedrock
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kzs0/bedrock"
+	"github.com/kzs0/bedrock/attr"
+)
+
+// ginContextKey is the request context key of the gin.Context being served.
+type ginContextKey struct{}
+
+// Middleware returns gin middleware that starts a bedrock operation for each request,
+// using the bedrock instance in ctx. Options are those of bedrock.HTTPMiddleware.
+//
+// Usage:
+//
+//	router.Use(ginbedrock.Middleware(ctx, bedrock.WithSkipPaths("/healthz")))
+func Middleware(ctx context.Context, opts ...bedrock.MiddlewareOption) gin.HandlerFunc {
+	opts = append([]bedrock.MiddlewareOption{bedrock.WithRouteFunc(routeTemplate)}, opts...)
+	handler := bedrock.HTTPMiddleware(ctx, http.HandlerFunc(serve), opts...)
+
+	return func(c *gin.Context) {
+		r := c.Request.WithContext(context.WithValue(c.Request.Context(), ginContextKey{}, c))
+		handler.ServeHTTP(c.Writer, r)
+	}
+}
+
+// serve runs the rest of the gin handler chain within the operation.
+func serve(w http.ResponseWriter, r *http.Request) {
+	c := r.Context().Value(ginContextKey{}).(*gin.Context)
+
+	// Handlers write through gin's writer; send writes through bedrock's, which wraps it,
+	// so the operation sees the status and size
+	c.Request = r
+	c.Writer = &responseWriter{ResponseWriter: c.Writer, w: w}
+	c.Next()
+
+	if err := c.Errors.Last(); err != nil {
+		if op, ok := bedrock.OperationFromContext(r.Context()); ok {
+			op.Register(r.Context(), attr.Error(err.Err))
+		}
+	}
+}
+
+// routeTemplate returns the template of the gin route that matched r, such as "/users/:id".
+func routeTemplate(r *http.Request) string {
+	if c, ok := r.Context().Value(ginContextKey{}).(*gin.Context); ok {
+		return c.FullPath()
+	}
+	return ""
+}
+
+// responseWriter is a gin.ResponseWriter that writes through bedrock's writer w.
+// gin's own writer, which w wraps, still tracks the status and size for gin.
+type responseWriter struct {
+	gin.ResponseWriter
+	w http.ResponseWriter
+}
+
+func (rw *responseWriter) WriteHeader(code int) {
+	rw.w.WriteHeader(code)
+}
+
+func (rw *responseWriter) Write(b []byte) (int, error) {
+	return rw.w.Write(b)
+}
+
+func (rw *responseWriter) WriteString(s string) (int, error) {
+	return rw.w.Write([]byte(s))
+}
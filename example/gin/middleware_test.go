@@ -0,0 +1,62 @@
+package ginbedrock
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kzs0/bedrock"
+	"github.com/kzs0/bedrock/trace"
+)
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctx, close := bedrock.Init(context.Background())
+	defer close()
+
+	var op *bedrock.Op
+	var span *trace.Span
+	router := gin.New()
+	router.Use(Middleware(ctx))
+	router.GET("/users/:id", func(c *gin.Context) {
+		op, _ = bedrock.OperationFromContext(c.Request.Context())
+		span = trace.SpanFromContext(c.Request.Context())
+		_ = c.Error(errors.New("no such user"))
+		c.String(http.StatusNotFound, "not found")
+	})
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest("GET", "/users/42", nil))
+
+	if op == nil || span == nil {
+		t.Fatal("expected the handler to run within an operation")
+	}
+	if rec.Code != http.StatusNotFound || rec.Body.String() != "not found" {
+		t.Errorf("expected the handler's response, got %d %q", rec.Code, rec.Body.String())
+	}
+
+	// The error added with c.Error is the operation's failure
+	if op.Succeeded() {
+		t.Error("expected the operation to fail")
+	}
+	attrs := span.Attrs()
+	if v, _ := attrs.Get("error"); v.String() != "no such user" {
+		t.Errorf("expected the c.Error error, got %q", v.String())
+	}
+
+	if span.Name() != "GET /users/:id" {
+		t.Errorf("expected the span to be named by the route template, got %q", span.Name())
+	}
+	if v, _ := attrs.Get("http.route"); v.String() != "/users/:id" {
+		t.Errorf("expected http.route /users/:id, got %q", v.String())
+	}
+	if v, _ := attrs.Get("http.status_code"); v.AsInt64() != http.StatusNotFound {
+		t.Errorf("expected http.status_code 404, got %d", v.AsInt64())
+	}
+	if v, _ := attrs.Get("http.response_size"); v.AsInt64() != int64(len("not found")) {
+		t.Errorf("expected http.response_size %d, got %d", len("not found"), v.AsInt64())
+	}
+}
@@ -0,0 +1,146 @@
+package prombridge
+
+import (
+	"github.com/kzs0/bedrock/attr"
+	"github.com/kzs0/bedrock/metric"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// Collector is a bedrock metric.Collector that gathers a prometheus.Gatherer
+// and emits its families into a bedrock registry.
+//
+// Counters, gauges, and histograms are converted directly. Untyped metrics become gauges.
+// Summaries, which bedrock has no equivalent for, become a gauge with a "quantile"
+// label plus _sum and _count counters, matching their exposition format.
+type Collector struct {
+	gatherer prometheus.Gatherer
+}
+
+// NewCollector creates a collector that mounts the given gatherer into a bedrock registry.
+func NewCollector(gatherer prometheus.Gatherer) *Collector {
+	return &Collector{gatherer: gatherer}
+}
+
+// Describe reports the families currently produced by the gatherer.
+// client_golang registries can grow at any time, so this is a snapshot.
+func (c *Collector) Describe(describe func(metric.Desc)) {
+	families, _ := c.gatherer.Gather()
+	for _, fam := range families {
+		for _, f := range fromDTO(fam) {
+			describe(metric.Desc{Name: f.Name, Help: f.Help, Type: f.Type, Unit: f.Unit})
+		}
+	}
+}
+
+// Collect gathers the wrapped gatherer and emits every family it returns.
+// Families gathered alongside an error are still emitted.
+func (c *Collector) Collect(emit func(metric.MetricFamily)) error {
+	families, err := c.gatherer.Gather()
+	for _, fam := range families {
+		for _, f := range fromDTO(fam) {
+			emit(f)
+		}
+	}
+	return err
+}
+
+// fromDTO converts a client_model family into one or more bedrock families.
+func fromDTO(fam *dto.MetricFamily) []metric.MetricFamily {
+	base := metric.MetricFamily{
+		Name: fam.GetName(),
+		Help: fam.GetHelp(),
+		Unit: metric.Unit(fam.GetUnit()),
+	}
+
+	switch fam.GetType() {
+	case dto.MetricType_COUNTER:
+		base.Type = metric.TypeCounter
+		for _, m := range fam.GetMetric() {
+			base.Metrics = append(base.Metrics, metric.Metric{
+				Labels: fromLabelPairs(m.GetLabel()),
+				Value:  m.GetCounter().GetValue(),
+			})
+		}
+		return []metric.MetricFamily{base}
+
+	case dto.MetricType_HISTOGRAM, dto.MetricType_GAUGE_HISTOGRAM:
+		base.Type = metric.TypeHistogram
+		for _, m := range fam.GetMetric() {
+			h := m.GetHistogram()
+			buckets := make([]metric.Bucket, 0, len(h.GetBucket()))
+			for _, b := range h.GetBucket() {
+				buckets = append(buckets, metric.Bucket{
+					UpperBound: b.GetUpperBound(),
+					Count:      b.GetCumulativeCount(),
+				})
+			}
+			base.Metrics = append(base.Metrics, metric.Metric{
+				Labels:  fromLabelPairs(m.GetLabel()),
+				Buckets: buckets,
+				Count:   h.GetSampleCount(),
+				Sum:     h.GetSampleSum(),
+			})
+		}
+		return []metric.MetricFamily{base}
+
+	case dto.MetricType_SUMMARY:
+		return fromSummary(base, fam.GetMetric())
+
+	default: // GAUGE and UNTYPED
+		base.Type = metric.TypeGauge
+		for _, m := range fam.GetMetric() {
+			value := m.GetGauge().GetValue()
+			if fam.GetType() == dto.MetricType_UNTYPED {
+				value = m.GetUntyped().GetValue()
+			}
+			base.Metrics = append(base.Metrics, metric.Metric{
+				Labels: fromLabelPairs(m.GetLabel()),
+				Value:  value,
+			})
+		}
+		return []metric.MetricFamily{base}
+	}
+}
+
+// fromSummary splits a summary into quantile, sum, and count families.
+func fromSummary(base metric.MetricFamily, metrics []*dto.Metric) []metric.MetricFamily {
+	quantiles := base
+	quantiles.Type = metric.TypeGauge
+
+	sum := base
+	sum.Name += "_sum"
+	sum.Type = metric.TypeCounter
+
+	count := base
+	count.Name += "_count"
+	count.Type = metric.TypeCounter
+
+	for _, m := range metrics {
+		labels := fromLabelPairs(m.GetLabel())
+		s := m.GetSummary()
+
+		for _, q := range s.GetQuantile() {
+			quantileLabels := make([]attr.Attr, 0, labels.Len()+1)
+			quantileLabels = append(quantileLabels, labels.Attrs()...)
+			quantileLabels = append(quantileLabels, attr.Float64("quantile", q.GetQuantile()))
+			quantiles.Metrics = append(quantiles.Metrics, metric.Metric{
+				Labels: attr.NewSet(quantileLabels...),
+				Value:  q.GetValue(),
+			})
+		}
+		sum.Metrics = append(sum.Metrics, metric.Metric{Labels: labels, Value: s.GetSampleSum()})
+		count.Metrics = append(count.Metrics, metric.Metric{Labels: labels, Value: float64(s.GetSampleCount())})
+	}
+
+	return []metric.MetricFamily{quantiles, sum, count}
+}
+
+// fromLabelPairs converts label pairs into an attribute set.
+func fromLabelPairs(pairs []*dto.LabelPair) attr.Set {
+	attrs := make([]attr.Attr, 0, len(pairs))
+	for _, p := range pairs {
+		attrs = append(attrs, attr.String(p.GetName(), p.GetValue()))
+	}
+	return attr.NewSet(attrs...)
+}
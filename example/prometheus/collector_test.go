@@ -0,0 +1,72 @@
+package prombridge
+
+import (
+	"testing"
+
+	"github.com/kzs0/bedrock/metric"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestCollector(t *testing.T) {
+	preg := prometheus.NewRegistry()
+	requests := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "requests_total", Help: "Requests"}, []string{"code"})
+	inflight := prometheus.NewGauge(prometheus.GaugeOpts{Name: "inflight", Help: "In-flight requests"})
+	latency := prometheus.NewHistogram(prometheus.HistogramOpts{Name: "latency_seconds", Help: "Latency", Buckets: []float64{0.1, 1}})
+	size := prometheus.NewSummary(prometheus.SummaryOpts{Name: "size_bytes", Help: "Sizes", Objectives: map[float64]float64{0.5: 0.05}})
+	preg.MustRegister(requests, inflight, latency, size)
+
+	requests.WithLabelValues("200").Add(2)
+	inflight.Set(4)
+	latency.Observe(0.5)
+	size.Observe(100)
+
+	reg := metric.NewRegistry("")
+	if err := reg.RegisterCollector(NewCollector(preg)); err != nil {
+		t.Fatal(err)
+	}
+	byName := map[string]metric.MetricFamily{}
+	for _, fam := range reg.Gather() {
+		byName[fam.Name] = fam
+	}
+
+	for name, want := range map[string]metric.MetricType{
+		"requests_total":   metric.TypeCounter,
+		"inflight":         metric.TypeGauge,
+		"latency_seconds":  metric.TypeHistogram,
+		"size_bytes":       metric.TypeGauge,
+		"size_bytes_sum":   metric.TypeCounter,
+		"size_bytes_count": metric.TypeCounter,
+	} {
+		fam, ok := byName[name]
+		if !ok || len(fam.Metrics) != 1 {
+			t.Errorf("expected %s with one series, got %v", name, fam)
+			continue
+		}
+		if fam.Type != want {
+			t.Errorf("expected %s to be a %s, got %s", name, want, fam.Type)
+		}
+	}
+
+	m := byName["requests_total"].Metrics[0]
+	if v, _ := m.Labels.Get("code"); m.Value != 2 || v.String() != "200" {
+		t.Errorf("expected requests_total{code=\"200\"} 2, got %v %v", m.Labels, m.Value)
+	}
+	if m := byName["inflight"].Metrics[0]; m.Value != 4 {
+		t.Errorf("expected inflight 4, got %v", m.Value)
+	}
+	if m := byName["latency_seconds"].Metrics[0]; m.Count != 1 || m.Sum != 0.5 || len(m.Buckets) < 2 || m.Buckets[1].Count != 1 {
+		t.Errorf("expected one latency observation in the 1 bucket, got %+v", m)
+	}
+
+	// Summaries become a quantile gauge with _sum and _count counters
+	q := byName["size_bytes"].Metrics[0]
+	if v, _ := q.Labels.Get("quantile"); v.AsFloat64() != 0.5 || q.Value != 100 {
+		t.Errorf("expected the 0.5 quantile of 100, got %v %v", q.Labels, q.Value)
+	}
+	if m := byName["size_bytes_sum"].Metrics[0]; m.Value != 100 {
+		t.Errorf("expected size_bytes_sum 100, got %v", m.Value)
+	}
+	if m := byName["size_bytes_count"].Metrics[0]; m.Value != 1 {
+		t.Errorf("expected size_bytes_count 1, got %v", m.Value)
+	}
+}
@@ -0,0 +1,32 @@
+// Package prombridge provides adapters between a bedrock metric.Registry and the
+// Prometheus client_golang library.
+//
+// It is a separate module, github.com/kzs0/bedrock/example/prometheus, so the core
+// bedrock module stays free of the client_golang dependency:
+//
+//	go get github.com/kzs0/bedrock/example/prometheus
+//
+// # Serving bedrock metrics from a client_golang endpoint
+//
+// Gatherer exposes a bedrock registry as a prometheus.Gatherer, so bedrock and
+// client_golang metrics can be served from a single /metrics endpoint:
+//
+//	b := bedrock.FromContext(ctx)
+//	gatherers := prometheus.Gatherers{
+//	    prometheus.DefaultGatherer,
+//	    prombridge.Gatherer(b.Metrics()),
+//	}
+//	http.Handle("/metrics", promhttp.HandlerFor(gatherers, promhttp.HandlerOpts{}))
+//
+// # Serving client_golang metrics from the bedrock endpoint
+//
+// NewCollector mounts a prometheus.Gatherer into a bedrock registry, so libraries
+// that register into client_golang show up on bedrock's observability server:
+//
+//	b := bedrock.FromContext(ctx)
+//	if err := b.Metrics().RegisterCollector(prombridge.NewCollector(prometheus.DefaultGatherer)); err != nil {
+//	    return err
+//	}
+//
+// Only use one direction per pair of registries, or every metric will be served twice.
+package prombridge
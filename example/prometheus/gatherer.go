@@ -0,0 +1,89 @@
+package prombridge
+
+import (
+	"github.com/kzs0/bedrock/attr"
+	"github.com/kzs0/bedrock/metric"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/proto"
+)
+
+// Gatherer returns a prometheus.Gatherer that gathers the given bedrock registry.
+// Collector errors are returned alongside the families that were gathered,
+// matching the behavior of prometheus.Gatherers.
+func Gatherer(registry *metric.Registry) prometheus.Gatherer {
+	return prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+		families, err := registry.GatherWithError()
+
+		result := make([]*dto.MetricFamily, 0, len(families))
+		for _, fam := range families {
+			if len(fam.Metrics) == 0 {
+				continue // client_golang rejects empty families
+			}
+			result = append(result, toDTO(fam))
+		}
+		return result, err
+	})
+}
+
+// toDTO converts a bedrock metric family into its client_model representation.
+func toDTO(fam metric.MetricFamily) *dto.MetricFamily {
+	out := &dto.MetricFamily{
+		Name: proto.String(fam.Name),
+		Help: proto.String(fam.Help),
+	}
+	if fam.Unit != metric.UnitNone {
+		out.Unit = proto.String(string(fam.Unit))
+	}
+
+	switch fam.Type {
+	case metric.TypeCounter:
+		out.Type = dto.MetricType_COUNTER.Enum()
+	case metric.TypeHistogram:
+		out.Type = dto.MetricType_HISTOGRAM.Enum()
+	default:
+		out.Type = dto.MetricType_GAUGE.Enum()
+	}
+
+	for _, m := range fam.Metrics {
+		dm := &dto.Metric{
+			Label: toLabelPairs(m.Labels),
+		}
+
+		switch fam.Type {
+		case metric.TypeCounter:
+			dm.Counter = &dto.Counter{Value: proto.Float64(m.Value)}
+		case metric.TypeHistogram:
+			h := &dto.Histogram{
+				SampleCount: proto.Uint64(m.Count),
+				SampleSum:   proto.Float64(m.Sum),
+			}
+			for _, b := range m.Buckets {
+				h.Bucket = append(h.Bucket, &dto.Bucket{
+					UpperBound:      proto.Float64(b.UpperBound),
+					CumulativeCount: proto.Uint64(b.Count),
+				})
+			}
+			dm.Histogram = h
+		default:
+			dm.Gauge = &dto.Gauge{Value: proto.Float64(m.Value)}
+		}
+
+		out.Metric = append(out.Metric, dm)
+	}
+
+	return out
+}
+
+// toLabelPairs converts an attribute set into label pairs.
+func toLabelPairs(labels attr.Set) []*dto.LabelPair {
+	pairs := make([]*dto.LabelPair, 0, labels.Len())
+	labels.Range(func(a attr.Attr) bool {
+		pairs = append(pairs, &dto.LabelPair{
+			Name:  proto.String(a.Key),
+			Value: proto.String(a.Value.String()),
+		})
+		return true
+	})
+	return pairs
+}
@@ -0,0 +1,59 @@
+package prombridge
+
+import (
+	"testing"
+
+	"github.com/kzs0/bedrock/attr"
+	"github.com/kzs0/bedrock/metric"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestGatherer(t *testing.T) {
+	reg := metric.NewRegistry("")
+	reg.Counter("jobs_total", "Jobs processed", "queue").With(attr.String("queue", "emails")).Add(3)
+	reg.Gauge("queue_depth", "Jobs waiting").Set(7)
+	reg.Histogram("job_seconds", "Job duration", []float64{1, 5}).Observe(2)
+	reg.Gauge("unused", "Never set", "queue") // no series, so left out
+
+	// Gatherers checks the families are consistent, as promhttp does when serving them
+	families, err := prometheus.Gatherers{Gatherer(reg)}.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	byName := map[string]*dto.MetricFamily{}
+	for _, fam := range families {
+		byName[fam.GetName()] = fam
+	}
+	if _, ok := byName["unused"]; ok {
+		t.Error("expected the empty family to be left out")
+	}
+
+	jobs := byName["jobs_total"]
+	if jobs.GetType() != dto.MetricType_COUNTER || len(jobs.GetMetric()) != 1 {
+		t.Fatalf("expected a jobs_total counter with one series, got %v", jobs)
+	}
+	m := jobs.GetMetric()[0]
+	if m.GetCounter().GetValue() != 3 {
+		t.Errorf("expected jobs_total 3, got %v", m.GetCounter().GetValue())
+	}
+	if l := m.GetLabel(); len(l) != 1 || l[0].GetName() != "queue" || l[0].GetValue() != "emails" {
+		t.Errorf("expected the queue label, got %v", l)
+	}
+
+	if depth := byName["queue_depth"]; depth.GetType() != dto.MetricType_GAUGE || depth.GetMetric()[0].GetGauge().GetValue() != 7 {
+		t.Errorf("expected a queue_depth gauge of 7, got %v", depth)
+	}
+
+	h := byName["job_seconds"]
+	if h.GetType() != dto.MetricType_HISTOGRAM {
+		t.Fatalf("expected a job_seconds histogram, got %v", h)
+	}
+	hist := h.GetMetric()[0].GetHistogram()
+	if hist.GetSampleCount() != 1 || hist.GetSampleSum() != 2 {
+		t.Errorf("expected one observation of 2, got %d and %v", hist.GetSampleCount(), hist.GetSampleSum())
+	}
+	if b := hist.GetBucket(); len(b) < 2 || b[0].GetCumulativeCount() != 0 || b[1].GetCumulativeCount() != 1 {
+		t.Errorf("expected the observation in the 5 bucket, got %v", b)
+	}
+}
@@ -0,0 +1,12 @@
+module github.com/kzs0/bedrock/example/prometheus
+
+go 1.25
+
+require (
+	github.com/kzs0/bedrock v0.1.0
+	github.com/prometheus/client_golang v1.22.0
+	github.com/prometheus/client_model v0.6.2
+	google.golang.org/protobuf v1.36.8
+)
+
+replace github.com/kzs0/bedrock => ../../
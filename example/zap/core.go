@@ -0,0 +1,117 @@
+package zapbridge
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// contextKey is the key of the field created by Context.
+const contextKey = "bedrock.context"
+
+// Context returns a field that carries ctx to the bedrock handler, so records get
+// the trace and span IDs of the active span. The field itself is not logged.
+func Context(ctx context.Context) zap.Field {
+	return zap.Field{Key: contextKey, Type: zapcore.SkipType, Interface: ctx}
+}
+
+// core is a zapcore.Core that writes entries to an slog.Handler.
+type core struct {
+	handler slog.Handler
+	ctx     context.Context
+}
+
+// NewCore returns a zapcore.Core that writes zap entries to the given handler,
+// typically bedrock.FromContext(ctx).Logger().Handler().
+func NewCore(handler slog.Handler) zapcore.Core {
+	return &core{handler: handler, ctx: context.Background()}
+}
+
+// Enabled reports whether the handler accepts records at the given level.
+func (c *core) Enabled(level zapcore.Level) bool {
+	return c.handler.Enabled(c.ctx, slogLevel(level))
+}
+
+// With returns a core whose records include the given fields.
+func (c *core) With(fields []zapcore.Field) zapcore.Core {
+	ctx, attrs := convertFields(c.ctx, fields)
+	return &core{handler: c.handler.WithAttrs(attrs), ctx: ctx}
+}
+
+// Check adds this core to the checked entry if the level is enabled.
+func (c *core) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+// Write converts the entry into an slog.Record and passes it to the handler.
+func (c *core) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	ctx, attrs := convertFields(c.ctx, fields)
+
+	var pc uintptr
+	if ent.Caller.Defined {
+		pc = ent.Caller.PC
+	}
+	r := slog.NewRecord(ent.Time, slogLevel(ent.Level), ent.Message, pc)
+	if ent.LoggerName != "" {
+		r.AddAttrs(slog.String("logger", ent.LoggerName))
+	}
+	r.AddAttrs(attrs...)
+	if ent.Stack != "" {
+		r.AddAttrs(slog.String("stack", ent.Stack))
+	}
+	return c.handler.Handle(ctx, r)
+}
+
+// Sync is a no-op; bedrock handlers write synchronously.
+func (c *core) Sync() error {
+	return nil
+}
+
+// convertFields converts zap fields to slog attributes, sorted by key, and returns
+// the context carried by a Context field, or ctx if there is none.
+func convertFields(ctx context.Context, fields []zapcore.Field) (context.Context, []slog.Attr) {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range fields {
+		if f.Key == contextKey && f.Type == zapcore.SkipType {
+			if fctx, ok := f.Interface.(context.Context); ok {
+				ctx = fctx
+			}
+			continue
+		}
+		f.AddTo(enc)
+	}
+
+	keys := make([]string, 0, len(enc.Fields))
+	for k := range enc.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	attrs := make([]slog.Attr, 0, len(keys))
+	for _, k := range keys {
+		attrs = append(attrs, slog.Any(k, enc.Fields[k]))
+	}
+	return ctx, attrs
+}
+
+// slogLevel maps a zap level to an slog level. DPanic, Panic, and Fatal map above Error.
+func slogLevel(level zapcore.Level) slog.Level {
+	switch {
+	case level >= zapcore.DPanicLevel:
+		return slog.LevelError + 4
+	case level >= zapcore.ErrorLevel:
+		return slog.LevelError
+	case level >= zapcore.WarnLevel:
+		return slog.LevelWarn
+	case level >= zapcore.InfoLevel:
+		return slog.LevelInfo
+	default:
+		return slog.LevelDebug
+	}
+}
@@ -0,0 +1,99 @@
+package zapbridge
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/kzs0/bedrock"
+	"github.com/kzs0/bedrock/attr"
+	"github.com/kzs0/bedrock/log/logtest"
+	"github.com/kzs0/bedrock/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestCore(t *testing.T) {
+	rec := logtest.NewRecorder()
+	logger := zap.New(NewCore(rec)).Named("billing").With(zap.String("region", "eu"))
+
+	logger.Warn("charging card", zap.String("user_id", "42"), zap.Int("attempt", 2))
+
+	r, ok := rec.FindRecord(slog.LevelWarn, "charging card",
+		attr.String("user_id", "42"),
+		attr.String("region", "eu"),
+		attr.String("logger", "billing"),
+	)
+	if !ok {
+		t.Fatalf("expected the zap entry as a record, got %v", rec.Records())
+	}
+	if v, _ := r.Attr("attempt"); v.Int64() != 2 {
+		t.Errorf("expected attempt 2, got %v", v)
+	}
+}
+
+func TestCoreContext(t *testing.T) {
+	rec := logtest.NewRecorder()
+	ctx, close := bedrock.Init(context.Background(), bedrock.WithConfig(bedrock.Config{LogHandler: rec}))
+	defer close()
+
+	op, opCtx := bedrock.Operation(ctx, "charge")
+	defer op.Done()
+
+	// The context field correlates the entry with the active span, and isn't logged itself
+	logger := zap.New(NewCore(bedrock.FromContext(ctx).Logger().Handler()))
+	logger.Info("charging card", Context(opCtx))
+
+	span := trace.SpanFromContext(opCtx)
+	r, ok := rec.FindRecord(slog.LevelInfo, "charging card", attr.String("trace_id", span.TraceID().String()))
+	if !ok {
+		t.Fatalf("expected the entry with the span's trace ID, got %v", rec.Records())
+	}
+	if _, ok := r.Attr(contextKey); ok {
+		t.Errorf("expected the context field not to be logged")
+	}
+}
+
+func TestCoreEnabled(t *testing.T) {
+	rec := logtest.NewRecorder()
+	ctx, close := bedrock.Init(context.Background(), bedrock.WithConfig(bedrock.Config{LogHandler: rec, LogLevel: "info"}))
+	defer close()
+
+	logger := zap.New(NewCore(bedrock.FromContext(ctx).Logger().Handler()))
+	logger.Debug("skipped")
+	if rec.Len() != 0 {
+		t.Errorf("expected debug entries to be dropped at the info level, got %v", rec.Records())
+	}
+}
+
+func TestSlogLevel(t *testing.T) {
+	for level, want := range map[zapcore.Level]slog.Level{
+		zapcore.DebugLevel:  slog.LevelDebug,
+		zapcore.InfoLevel:   slog.LevelInfo,
+		zapcore.WarnLevel:   slog.LevelWarn,
+		zapcore.ErrorLevel:  slog.LevelError,
+		zapcore.DPanicLevel: slog.LevelError + 4,
+		zapcore.FatalLevel:  slog.LevelError + 4,
+	} {
+		if got := slogLevel(level); got != want {
+			t.Errorf("slogLevel(%v) = %v, want %v", level, got, want)
+		}
+	}
+}
+
+func TestHandler(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	slog.New(NewHandler(core)).Warn("slow request", "user_id", "42")
+
+	entries := logs.FilterMessage("slow request").All()
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 zap entry, got %d", len(entries))
+	}
+	if entries[0].Level != zapcore.WarnLevel {
+		t.Errorf("expected a warn entry, got %v", entries[0].Level)
+	}
+	if got := entries[0].ContextMap()["user_id"]; got != "42" {
+		t.Errorf("expected user_id 42, got %v", got)
+	}
+}
@@ -0,0 +1,30 @@
+// Package zapbridge provides adapters between bedrock logging and go.uber.org/zap,
+// for migrating a codebase from zap to bedrock incrementally.
+//
+// It is a separate module, github.com/kzs0/bedrock/example/zap, so the core bedrock
+// module stays free of the go.uber.org/zap dependency:
+//
+//	go get github.com/kzs0/bedrock/example/zap
+//
+// # Writing zap call sites through bedrock
+//
+// NewCore returns a zapcore.Core that writes to bedrock's handler, so existing zap
+// call sites gain bedrock's static attributes, format, and redaction. Pass the
+// request context with Context to also get trace correlation:
+//
+//	b := bedrock.FromContext(ctx)
+//	logger := zap.New(zapbridge.NewCore(b.Logger().Handler()), zap.AddCaller())
+//
+//	logger.Info("charging card", zapbridge.Context(ctx), zap.String("user_id", id))
+//
+// # Writing bedrock logs into an existing zap core
+//
+// NewHandler wraps a zap core as an slog.Handler for Config.LogHandler, so bedrock
+// logs go wherever zap logs go today:
+//
+//	ctx, close := bedrock.Init(ctx, bedrock.WithConfig(bedrock.Config{
+//	    LogHandler: zapbridge.NewHandler(zapLogger.Core()),
+//	}))
+//
+// Only use one direction per logger, or records will loop between the two.
+package zapbridge
@@ -0,0 +1,11 @@
+module github.com/kzs0/bedrock/example/zap
+
+go 1.25
+
+require (
+	github.com/kzs0/bedrock v0.1.0
+	go.uber.org/zap v1.27.0
+	go.uber.org/zap/exp v0.3.0
+)
+
+replace github.com/kzs0/bedrock => ../../
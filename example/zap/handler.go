@@ -0,0 +1,15 @@
+package zapbridge
+
+import (
+	"log/slog"
+
+	"go.uber.org/zap/exp/zapslog"
+	"go.uber.org/zap/zapcore"
+)
+
+// NewHandler returns an slog.Handler that writes records to the given zap core,
+// for use as Config.LogHandler. Bedrock still adds trace context and static
+// attributes before records reach the core.
+func NewHandler(core zapcore.Core) slog.Handler {
+	return zapslog.NewHandler(core, zapslog.WithCaller(true))
+}
@@ -0,0 +1,32 @@
+// Package zerologbridge provides adapters between bedrock logging and github.com/rs/zerolog,
+// for migrating a codebase from zerolog to bedrock incrementally.
+//
+// It is a separate module, github.com/kzs0/bedrock/example/zerolog, so the core bedrock
+// module stays free of the github.com/rs/zerolog dependency:
+//
+//	go get github.com/kzs0/bedrock/example/zerolog
+//
+// # Writing zerolog call sites through bedrock
+//
+// NewWriter returns a zerolog.LevelWriter that decodes zerolog events and writes them
+// to bedrock's handler, so existing zerolog call sites gain bedrock's static attributes,
+// format, and redaction. Add TraceHook and pass the request context with Ctx to also
+// get trace correlation:
+//
+//	b := bedrock.FromContext(ctx)
+//	logger := zerolog.New(zerologbridge.NewWriter(b.Logger().Handler())).
+//	    Hook(zerologbridge.TraceHook{})
+//
+//	logger.Info().Ctx(ctx).Str("user_id", id).Msg("charging card")
+//
+// # Writing bedrock logs into an existing zerolog logger
+//
+// NewHandler wraps a zerolog.Logger as an slog.Handler for Config.LogHandler, so
+// bedrock logs go wherever zerolog logs go today:
+//
+//	ctx, close := bedrock.Init(ctx, bedrock.WithConfig(bedrock.Config{
+//	    LogHandler: zerologbridge.NewHandler(zerologLogger),
+//	}))
+//
+// Only use one direction per logger, or records will loop between the two.
+package zerologbridge
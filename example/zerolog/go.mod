@@ -0,0 +1,10 @@
+module github.com/kzs0/bedrock/example/zerolog
+
+go 1.25
+
+require (
+	github.com/kzs0/bedrock v0.1.0
+	github.com/rs/zerolog v1.34.0
+)
+
+replace github.com/kzs0/bedrock => ../../
@@ -0,0 +1,123 @@
+package zerologbridge
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/rs/zerolog"
+)
+
+// handler is an slog.Handler that writes records as zerolog events.
+type handler struct {
+	logger zerolog.Logger
+	attrs  []slog.Attr // resolved, with group prefixes applied to keys
+	prefix string
+}
+
+// NewHandler returns an slog.Handler that writes records to the given zerolog logger,
+// for use as Config.LogHandler. Bedrock still adds trace context and static
+// attributes before records reach the logger.
+func NewHandler(logger zerolog.Logger) slog.Handler {
+	return &handler{logger: logger}
+}
+
+// Enabled reports whether the logger writes events at the given level.
+func (h *handler) Enabled(_ context.Context, level slog.Level) bool {
+	return zerologLevel(level) >= h.logger.GetLevel()
+}
+
+// Handle writes the record as a zerolog event.
+func (h *handler) Handle(_ context.Context, r slog.Record) error {
+	e := h.logger.WithLevel(zerologLevel(r.Level))
+	if e == nil {
+		return nil
+	}
+	if !r.Time.IsZero() {
+		e = e.Time(zerolog.TimestampFieldName, r.Time)
+	}
+	for _, a := range h.attrs {
+		e = appendAttr(e, "", a)
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		e = appendAttr(e, h.prefix, a)
+		return true
+	})
+	e.Msg(r.Message)
+	return nil
+}
+
+// WithAttrs returns a handler whose events include the given attributes.
+func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	h2 := *h
+	h2.attrs = append([]slog.Attr(nil), h.attrs...)
+	for _, a := range attrs {
+		a.Key = h.prefix + a.Key
+		h2.attrs = append(h2.attrs, a)
+	}
+	return &h2
+}
+
+// WithGroup returns a handler that prefixes subsequent attribute keys with the group name.
+func (h *handler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	h2.prefix = h.prefix + name + "."
+	return &h2
+}
+
+// appendAttr adds an attribute to the event, flattening groups into dotted keys.
+func appendAttr(e *zerolog.Event, prefix string, a slog.Attr) *zerolog.Event {
+	v := a.Value.Resolve()
+	key := prefix + a.Key
+
+	switch v.Kind() {
+	case slog.KindGroup:
+		groupPrefix := prefix
+		if a.Key != "" {
+			groupPrefix = key + "."
+		}
+		for _, ga := range v.Group() {
+			e = appendAttr(e, groupPrefix, ga)
+		}
+		return e
+	case slog.KindString:
+		return e.Str(key, v.String())
+	case slog.KindInt64:
+		return e.Int64(key, v.Int64())
+	case slog.KindUint64:
+		return e.Uint64(key, v.Uint64())
+	case slog.KindFloat64:
+		return e.Float64(key, v.Float64())
+	case slog.KindBool:
+		return e.Bool(key, v.Bool())
+	case slog.KindDuration:
+		return e.Dur(key, v.Duration())
+	case slog.KindTime:
+		return e.Time(key, v.Time())
+	default:
+		if err, ok := v.Any().(error); ok {
+			return e.AnErr(key, err)
+		}
+		return e.Interface(key, v.Any())
+	}
+}
+
+// zerologLevel maps an slog level to a zerolog level.
+func zerologLevel(level slog.Level) zerolog.Level {
+	switch {
+	case level > slog.LevelError:
+		return zerolog.FatalLevel
+	case level >= slog.LevelError:
+		return zerolog.ErrorLevel
+	case level >= slog.LevelWarn:
+		return zerolog.WarnLevel
+	case level >= slog.LevelInfo:
+		return zerolog.InfoLevel
+	case level >= slog.LevelDebug:
+		return zerolog.DebugLevel
+	default:
+		return zerolog.TraceLevel
+	}
+}
@@ -0,0 +1,55 @@
+package zerologbridge
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+func TestHandler(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(NewHandler(zerolog.New(&buf).Level(zerolog.InfoLevel)))
+
+	logger.Debug("skipped")
+	logger.With("service", "billing").WithGroup("http").Warn("slow request",
+		"method", "GET",
+		"status", 504,
+		"err", errors.New("upstream timeout"),
+	)
+
+	var event map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &event); err != nil {
+		t.Fatalf("expected one zerolog event, got %q: %v", buf.String(), err)
+	}
+	for key, want := range map[string]any{
+		"level":       "warn",
+		"message":     "slow request",
+		"service":     "billing",
+		"http.method": "GET",
+		"http.status": float64(504),
+		"http.err":    "upstream timeout",
+	} {
+		if event[key] != want {
+			t.Errorf("expected %s = %v, got %v", key, want, event[key])
+		}
+	}
+}
+
+func TestZerologLevel(t *testing.T) {
+	for level, want := range map[slog.Level]zerolog.Level{
+		slog.LevelDebug - 4: zerolog.TraceLevel,
+		slog.LevelDebug:     zerolog.DebugLevel,
+		slog.LevelInfo:      zerolog.InfoLevel,
+		slog.LevelWarn:      zerolog.WarnLevel,
+		slog.LevelError:     zerolog.ErrorLevel,
+		slog.LevelError + 4: zerolog.FatalLevel,
+	} {
+		if got := zerologLevel(level); got != want {
+			t.Errorf("zerologLevel(%v) = %v, want %v", level, got, want)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package zerologbridge
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/kzs0/bedrock/trace"
+	"github.com/rs/zerolog"
+)
+
+// TraceHook adds the trace and span IDs of the span in the event's context,
+// set with Event.Ctx, to zerolog events.
+type TraceHook struct{}
+
+// Run implements zerolog.Hook.
+func (TraceHook) Run(e *zerolog.Event, _ zerolog.Level, _ string) {
+	span := trace.SpanFromContext(e.GetCtx())
+	if span == nil {
+		return
+	}
+	e.Str("trace_id", span.TraceID().String())
+	e.Str("span_id", span.SpanID().String())
+}
+
+// writer decodes zerolog JSON events and writes them to an slog.Handler.
+type writer struct {
+	handler slog.Handler
+}
+
+// NewWriter returns a zerolog.LevelWriter that writes zerolog events to the given
+// handler, typically bedrock.FromContext(ctx).Logger().Handler().
+func NewWriter(handler slog.Handler) zerolog.LevelWriter {
+	return &writer{handler: handler}
+}
+
+// Write writes an event whose level is read from the event itself.
+func (w *writer) Write(p []byte) (int, error) {
+	return w.WriteLevel(zerolog.NoLevel, p)
+}
+
+// WriteLevel decodes the event and passes it to the handler as an slog.Record.
+// The level, message, and timestamp fields become the record's own.
+func (w *writer) WriteLevel(level zerolog.Level, p []byte) (int, error) {
+	var fields map[string]any
+	dec := json.NewDecoder(bytes.NewReader(p))
+	dec.UseNumber()
+	if err := dec.Decode(&fields); err != nil {
+		return 0, err
+	}
+
+	if level == zerolog.NoLevel {
+		if s, ok := fields[zerolog.LevelFieldName].(string); ok {
+			level, _ = zerolog.ParseLevel(s)
+		}
+	}
+	delete(fields, zerolog.LevelFieldName)
+
+	msg, _ := fields[zerolog.MessageFieldName].(string)
+	delete(fields, zerolog.MessageFieldName)
+
+	t := time.Now()
+	if s, ok := fields[zerolog.TimestampFieldName].(string); ok {
+		if parsed, err := time.Parse(time.RFC3339Nano, s); err == nil {
+			t = parsed
+		}
+	}
+	delete(fields, zerolog.TimestampFieldName)
+
+	ctx := context.Background()
+	sl := slogLevel(level)
+	if !w.handler.Enabled(ctx, sl) {
+		return len(p), nil
+	}
+
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	r := slog.NewRecord(t, sl, msg, 0)
+	for _, k := range keys {
+		r.AddAttrs(slog.Any(k, fields[k]))
+	}
+	if err := w.handler.Handle(ctx, r); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// slogLevel maps a zerolog level to an slog level. Fatal and Panic map above Error.
+func slogLevel(level zerolog.Level) slog.Level {
+	switch level {
+	case zerolog.TraceLevel:
+		return slog.LevelDebug - 4
+	case zerolog.DebugLevel:
+		return slog.LevelDebug
+	case zerolog.WarnLevel:
+		return slog.LevelWarn
+	case zerolog.ErrorLevel:
+		return slog.LevelError
+	case zerolog.FatalLevel, zerolog.PanicLevel:
+		return slog.LevelError + 4
+	default:
+		return slog.LevelInfo
+	}
+}
@@ -0,0 +1,60 @@
+package zerologbridge
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/kzs0/bedrock"
+	"github.com/kzs0/bedrock/attr"
+	"github.com/kzs0/bedrock/log/logtest"
+	"github.com/kzs0/bedrock/trace"
+	"github.com/rs/zerolog"
+)
+
+func TestWriter(t *testing.T) {
+	rec := logtest.NewRecorder()
+	ctx, close := bedrock.Init(context.Background(), bedrock.WithConfig(bedrock.Config{LogHandler: rec}))
+	defer close()
+
+	op, opCtx := bedrock.Operation(ctx, "charge")
+	defer op.Done()
+
+	logger := zerolog.New(NewWriter(bedrock.FromContext(ctx).Logger().Handler())).
+		Hook(TraceHook{}).
+		With().Timestamp().Logger()
+	logger.Warn().Ctx(opCtx).Str("user_id", "42").Int("attempt", 2).Msg("charging card")
+
+	// The level, message, and timestamp become the record's own, and the hook adds the trace
+	span := trace.SpanFromContext(opCtx)
+	r, ok := rec.FindRecord(slog.LevelWarn, "charging card",
+		attr.String("user_id", "42"),
+		attr.String("trace_id", span.TraceID().String()),
+		attr.String("span_id", span.SpanID().String()),
+	)
+	if !ok {
+		t.Fatalf("expected the zerolog event as a record, got %v", rec.Records())
+	}
+	if v, _ := r.Attr("attempt"); v.String() != "2" {
+		t.Errorf("expected attempt 2, got %v", v)
+	}
+	for _, key := range []string{zerolog.LevelFieldName, zerolog.MessageFieldName, zerolog.TimestampFieldName} {
+		if _, ok := r.Attr(key); ok {
+			t.Errorf("expected the %s field not to be an attribute", key)
+		}
+	}
+}
+
+func TestWriterEnabled(t *testing.T) {
+	rec := logtest.NewRecorder()
+	ctx, close := bedrock.Init(context.Background(), bedrock.WithConfig(bedrock.Config{LogHandler: rec, LogLevel: "info"}))
+	defer close()
+
+	logger := zerolog.New(NewWriter(bedrock.FromContext(ctx).Logger().Handler()))
+	logger.Debug().Msg("skipped")
+	logger.Error().Msg("kept")
+
+	if rec.Len() != 1 || !rec.HasRecord(slog.LevelError, "kept") {
+		t.Errorf("expected only the error event, got %v", rec.Records())
+	}
+}
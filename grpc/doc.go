@@ -0,0 +1,156 @@
+// Package grpc provides W3C Trace Context propagation and bedrock instrumentation for
+// gRPC services.
+//
+// It is a separate module, github.com/kzs0/bedrock/grpc, so the core bedrock module
+// stays free of the google.golang.org/grpc dependency:
+//
+//	go get github.com/kzs0/bedrock/grpc
+//
+// This package implements the trace.Propagator interface for gRPC metadata and provides
+// convenient interceptors for both client and server-side tracing.
+//
+// # Server-Side Instrumentation
+//
+// Use the provided interceptors when creating your gRPC server:
+//
+//	import (
+//	    "github.com/kzs0/bedrock"
+//	    bedrockGrpc "github.com/kzs0/bedrock/grpc"
+//	    "google.golang.org/grpc"
+//	)
+//
+//	func main() {
+//	    ctx, close := bedrock.Init(context.Background())
+//	    defer close()
+//
+//	    server := grpc.NewServer(
+//	        grpc.UnaryInterceptor(bedrockGrpc.UnaryServerInterceptor()),
+//	        grpc.StreamInterceptor(bedrockGrpc.StreamServerInterceptor()),
+//	    )
+//
+//	    // Register your services...
+//	    pb.RegisterYourServiceServer(server, &yourService{})
+//
+//	    listener, _ := net.Listen("tcp", ":50051")
+//	    server.Serve(listener)
+//	}
+//
+// The server interceptors automatically:
+//   - Extract W3C Trace Context from incoming gRPC metadata
+//   - Start a bedrock operation for each RPC
+//   - Link child spans to the remote parent trace
+//   - Mark operations as failed if the RPC returns an error
+//
+// # Status Codes
+//
+// Operations and client spans get the RPC's status code as grpc.status_code, and its name,
+// such as NotFound, as grpc.code, which also labels the operation metrics, so failures can
+// be broken down by code with a handful of label values. RPCs with any code but OK fail,
+// as HTTP requests with 4xx and 5xx responses do, and their error.type tells failures the
+// client caused from the server's, as classified by ClassifyError: "client" for codes such
+// as NotFound and InvalidArgument, "internal" for codes such as Internal and Unavailable,
+// and "timeout" and "canceled" for DeadlineExceeded and Canceled.
+//
+// # Server Metrics
+//
+// The server interceptors record the same metrics as go-grpc-prometheus in bedrock's
+// registry, with its static labels, so existing dashboards and alerts keep working:
+//
+//   - grpc_server_started_total: RPCs started
+//   - grpc_server_handled_total: RPCs completed, labeled by grpc_code, such as OK or NotFound
+//   - grpc_server_handling_seconds: RPC latency histogram
+//   - grpc_server_msg_received_total, grpc_server_msg_sent_total: messages, including
+//     each message of a stream
+//   - grpc_server_msg_received_bytes, grpc_server_msg_sent_bytes: protobuf message size
+//     histograms
+//
+// All are labeled by grpc_type (unary, client_stream, server_stream, or bidi_stream),
+// grpc_service, and grpc_method.
+//
+// The client interceptors record the same metrics for outgoing RPCs, named
+// grpc_client_started_total, grpc_client_handled_total, and so on.
+//
+// # Streams
+//
+// Streaming RPCs also get the number of messages each stream sent and received, in the
+// grpc_<side>_stream_msgs_sent and grpc_<side>_stream_msgs_received histograms and as
+// grpc.stream.messages_sent and grpc.stream.messages_received span attributes. Their
+// spans get message.first_sent and message.first_received events as the first messages
+// go each way, and message.last_sent and message.last_received events, at the time of
+// the last messages, when the stream ends, so a long-lived stream's span shows when it
+// was active.
+//
+// # Client-Side Instrumentation
+//
+// Use the provided interceptors when creating your gRPC client connection:
+//
+//	conn, err := grpc.Dial(
+//	    "localhost:50051",
+//	    grpc.WithUnaryInterceptor(bedrockGrpc.UnaryClientInterceptor()),
+//	    grpc.WithStreamInterceptor(bedrockGrpc.StreamClientInterceptor()),
+//	)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer conn.Close()
+//
+//	client := pb.NewYourServiceClient(conn)
+//
+// The client interceptors automatically:
+//   - Start a client span for each RPC, named by its full method, with rpc.system,
+//     rpc.service, rpc.method, and grpc.status_code attributes
+//   - Inject W3C Trace Context into outgoing gRPC metadata, continuing the trace from
+//     the client span
+//   - Record the client metrics below, as grpc_client_started_total, and so on
+//
+// Like the HTTP client's, client spans are skipped within NoTrace operations, while
+// metrics are still recorded.
+//
+// # Health Checks
+//
+// HealthServer implements grpc.health.v1.Health with its status driven by a readiness
+// check. Drive it with the checks registered with bedrock.RegisterReadinessCheck, so
+// Kubernetes gRPC probes and the observability server's /ready endpoint agree:
+//
+//	healthpb.RegisterHealthServer(server, bedrockGrpc.NewHealthServer(bedrock.FromContext(ctx).Readiness().Healthy))
+//
+// Call Shutdown when draining to report NOT_SERVING before the server stops.
+//
+// # Manual Propagation
+//
+// For advanced use cases, you can use the Propagator directly:
+//
+//	prop := &bedrockGrpc.Propagator{}
+//
+//	// Extract from incoming context (server-side)
+//	if md, ok := metadata.FromIncomingContext(ctx); ok {
+//	    remoteCtx, err := prop.Extract(md)
+//	    if err == nil && remoteCtx.IsValid() {
+//	        op, ctx := bedrock.Operation(ctx, "my-handler",
+//	            bedrock.WithRemoteParent(remoteCtx))
+//	        defer op.Done()
+//	    }
+//	}
+//
+//	// Inject into outgoing context (client-side)
+//	md := metadata.New(nil)
+//	prop.Inject(ctx, md)
+//	ctx = metadata.NewOutgoingContext(ctx, md)
+//
+// # Distributed Tracing Flow
+//
+// When a client calls a server with instrumentation on both sides:
+//
+//	Client                                Server
+//	  |                                      |
+//	  | Start span (trace_id: ABC)           |
+//	  | Inject traceparent in metadata       |
+//	  |------------------------------------->|
+//	  |                                      | Extract traceparent
+//	  |                                      | Start child span (trace_id: ABC, parent: client_span)
+//	  |                                      | Process request
+//	  |<-------------------------------------|
+//	  | End span                             | End span
+//
+// Both spans share the same trace_id, enabling full trace visualization in tools like Jaeger.
+package grpc
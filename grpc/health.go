@@ -0,0 +1,99 @@
+package grpc
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// HealthServer implements the gRPC health checking protocol, grpc.health.v1.Health, with
+// its serving status driven by a readiness check, so gRPC health probes, such as
+// Kubernetes' grpc probes, report the same readiness as the observability server's HTTP
+// /ready endpoint. The status is SERVING while the check passes and NOT_SERVING while it
+// fails or after Shutdown, for every service name, including the empty name that probes
+// check by default.
+type HealthServer struct {
+	healthpb.UnimplementedHealthServer
+
+	check    func(ctx context.Context) error
+	interval time.Duration
+	shutdown atomic.Bool
+}
+
+// HealthOption configures a HealthServer.
+type HealthOption func(*HealthServer)
+
+// WithWatchInterval sets how often Watch streams run the readiness check to send status
+// changes. The default is 5 seconds.
+func WithWatchInterval(d time.Duration) HealthOption {
+	return func(h *HealthServer) {
+		h.interval = d
+	}
+}
+
+// NewHealthServer returns a HealthServer whose status is driven by check, which returns
+// nil while the service is ready. A nil check is always ready, as /ready is without
+// readiness checks.
+//
+// Usage:
+//
+//	healthpb.RegisterHealthServer(server, bedrockGrpc.NewHealthServer(bedrock.FromContext(ctx).Readiness().Healthy))
+func NewHealthServer(check func(ctx context.Context) error, opts ...HealthOption) *HealthServer {
+	h := &HealthServer{
+		check:    check,
+		interval: 5 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// Shutdown sets the status to NOT_SERVING, whatever the readiness check returns, so
+// clients and load balancers stop sending requests while the server drains.
+func (h *HealthServer) Shutdown() {
+	h.shutdown.Store(true)
+}
+
+// Check implements healthpb.HealthServer.
+func (h *HealthServer) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	return &healthpb.HealthCheckResponse{Status: h.status(ctx)}, nil
+}
+
+// Watch implements healthpb.HealthServer. It sends the status, then each change to it
+// until the client cancels the stream.
+func (h *HealthServer) Watch(req *healthpb.HealthCheckRequest, stream healthpb.Health_WatchServer) error {
+	ctx := stream.Context()
+	ticker := time.NewTicker(h.interval)
+	defer ticker.Stop()
+
+	last := healthpb.HealthCheckResponse_SERVICE_UNKNOWN
+	for {
+		if st := h.status(ctx); st != last {
+			if err := stream.Send(&healthpb.HealthCheckResponse{Status: st}); err != nil {
+				return err
+			}
+			last = st
+		}
+
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// status returns the serving status from the readiness check.
+func (h *HealthServer) status(ctx context.Context) healthpb.HealthCheckResponse_ServingStatus {
+	if h.shutdown.Load() {
+		return healthpb.HealthCheckResponse_NOT_SERVING
+	}
+	if h.check != nil && h.check(ctx) != nil {
+		return healthpb.HealthCheckResponse_NOT_SERVING
+	}
+	return healthpb.HealthCheckResponse_SERVING
+}
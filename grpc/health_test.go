@@ -0,0 +1,92 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+func TestHealthServerCheck(t *testing.T) {
+	var ready atomic.Bool
+	h := NewHealthServer(func(ctx context.Context) error {
+		if !ready.Load() {
+			return errors.New("warming up")
+		}
+		return nil
+	})
+
+	check := func() healthpb.HealthCheckResponse_ServingStatus {
+		resp, err := h.Check(context.Background(), &healthpb.HealthCheckRequest{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.Status
+	}
+
+	if got := check(); got != healthpb.HealthCheckResponse_NOT_SERVING {
+		t.Errorf("expected NOT_SERVING while the check fails, got %v", got)
+	}
+	ready.Store(true)
+	if got := check(); got != healthpb.HealthCheckResponse_SERVING {
+		t.Errorf("expected SERVING once the check passes, got %v", got)
+	}
+	h.Shutdown()
+	if got := check(); got != healthpb.HealthCheckResponse_NOT_SERVING {
+		t.Errorf("expected NOT_SERVING after Shutdown, got %v", got)
+	}
+
+	if resp, _ := NewHealthServer(nil).Check(context.Background(), &healthpb.HealthCheckRequest{}); resp.Status != healthpb.HealthCheckResponse_SERVING {
+		t.Error("expected SERVING without a readiness check")
+	}
+}
+
+// fakeWatchServer is a healthpb.Health_WatchServer that records the statuses sent.
+type fakeWatchServer struct {
+	grpc.ServerStream
+	ctx  context.Context
+	sent chan healthpb.HealthCheckResponse_ServingStatus
+}
+
+func (s *fakeWatchServer) Context() context.Context { return s.ctx }
+func (s *fakeWatchServer) Send(resp *healthpb.HealthCheckResponse) error {
+	s.sent <- resp.Status
+	return nil
+}
+
+func TestHealthServerWatch(t *testing.T) {
+	var ready atomic.Bool
+	h := NewHealthServer(func(ctx context.Context) error {
+		if !ready.Load() {
+			return errors.New("warming up")
+		}
+		return nil
+	}, WithWatchInterval(time.Millisecond))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream := &fakeWatchServer{ctx: ctx, sent: make(chan healthpb.HealthCheckResponse_ServingStatus, 10)}
+	done := make(chan error)
+	go func() { done <- h.Watch(&healthpb.HealthCheckRequest{}, stream) }()
+
+	if got := <-stream.sent; got != healthpb.HealthCheckResponse_NOT_SERVING {
+		t.Errorf("expected NOT_SERVING first, got %v", got)
+	}
+	ready.Store(true)
+	if got := <-stream.sent; got != healthpb.HealthCheckResponse_SERVING {
+		t.Errorf("expected SERVING on the change, got %v", got)
+	}
+
+	cancel()
+	if err := <-done; status.Code(err) != codes.Canceled {
+		t.Errorf("expected Canceled when the client cancels, got %v", err)
+	}
+	if len(stream.sent) != 0 {
+		t.Error("expected only status changes to be sent")
+	}
+}
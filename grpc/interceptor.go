@@ -0,0 +1,399 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/kzs0/bedrock"
+	"github.com/kzs0/bedrock/attr"
+	"github.com/kzs0/bedrock/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryServerInterceptor returns a gRPC unary server interceptor that extracts
+// trace context from incoming requests and starts a bedrock operation.
+//
+// The interceptor:
+//   - Extracts W3C Trace Context from gRPC metadata
+//   - Starts a bedrock operation with the remote parent
+//   - Automatically marks operations as failed if the RPC returns an error, labeling
+//     their metrics by status code as grpc.code and classifying it with ClassifyError
+//   - Records go-grpc-prometheus style server metrics, as described in the package docs
+//
+// Usage:
+//
+//	server := grpc.NewServer(
+//	    grpc.UnaryInterceptor(bedrockGrpc.UnaryServerInterceptor()),
+//	)
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	prop := &Propagator{}
+
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		// Extract trace context from incoming metadata
+		var opOpts []bedrock.OperationOption
+
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			remoteCtx, err := prop.Extract(md)
+			if err == nil && remoteCtx.IsValid() {
+				opOpts = append(opOpts, bedrock.WithRemoteParent(remoteCtx))
+			}
+		}
+
+		// Start operation for this RPC
+		opOpts = append(opOpts, serverOperationOptions(info.FullMethod)...)
+		op, opCtx := bedrock.Operation(ctx, info.FullMethod, opOpts...)
+		defer op.Done()
+
+		metrics := startMetrics(opCtx, server, unary, info.FullMethod)
+		metrics.received(req)
+
+		// Call handler
+		resp, err := handler(opCtx, req)
+		if err == nil {
+			metrics.sent(resp)
+		}
+		metrics.handled(err)
+
+		// Register status, and error if RPC failed
+		registerStatus(opCtx, op, err)
+
+		return resp, err
+	}
+}
+
+// UnaryClientInterceptor returns a gRPC unary client interceptor that traces outgoing
+// requests and injects trace context into them.
+//
+// The interceptor:
+//   - Starts a client span for each RPC, as the HTTP client does for requests, with the
+//     RPC's method and status code
+//   - Injects W3C Trace Context into gRPC metadata, continuing the trace from the span
+//   - Records go-grpc-prometheus style client metrics, as described in the package docs
+//
+// Usage:
+//
+//	conn, err := grpc.Dial(
+//	    target,
+//	    grpc.WithUnaryInterceptor(bedrockGrpc.UnaryClientInterceptor()),
+//	)
+func UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	prop := &Propagator{}
+
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		ctx, call := startClientCall(ctx, unary, method)
+		call.metrics.sent(req)
+
+		// Call RPC with trace context in its metadata
+		err := invoker(outgoingContext(ctx, prop), method, req, reply, cc, opts...)
+		if err == nil {
+			call.metrics.received(reply)
+		}
+		call.finish(err)
+
+		return err
+	}
+}
+
+// StreamServerInterceptor returns a gRPC stream server interceptor that extracts
+// trace context from incoming requests and starts a bedrock operation.
+//
+// The interceptor:
+//   - Extracts W3C Trace Context from gRPC metadata
+//   - Starts a bedrock operation with the remote parent
+//   - Automatically marks operations as failed if the stream returns an error, labeling
+//     their metrics by status code as grpc.code and classifying it with ClassifyError
+//   - Records go-grpc-prometheus style server metrics, counting each stream message
+//
+// Usage:
+//
+//	server := grpc.NewServer(
+//	    grpc.StreamInterceptor(bedrockGrpc.StreamServerInterceptor()),
+//	)
+func StreamServerInterceptor() grpc.StreamServerInterceptor {
+	prop := &Propagator{}
+
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx := ss.Context()
+
+		// Extract trace context from incoming metadata
+		var opOpts []bedrock.OperationOption
+
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			remoteCtx, err := prop.Extract(md)
+			if err == nil && remoteCtx.IsValid() {
+				opOpts = append(opOpts, bedrock.WithRemoteParent(remoteCtx))
+			}
+		}
+
+		// Start operation for this RPC
+		opOpts = append(opOpts, serverOperationOptions(info.FullMethod)...)
+		op, opCtx := bedrock.Operation(ctx, info.FullMethod, opOpts...)
+		defer op.Done()
+
+		// Wrap server stream to use operation context and count messages, recording them on
+		// the operation's span if it started one
+		stats := &streamStats{}
+		if span := trace.SpanFromContext(opCtx); span != trace.SpanFromContext(ctx) {
+			stats.span = span
+		}
+		wrappedStream := &wrappedServerStream{
+			ServerStream: ss,
+			ctx:          opCtx,
+			metrics:      startMetrics(opCtx, server, rpcType(info.IsClientStream, info.IsServerStream), info.FullMethod),
+			stats:        stats,
+		}
+
+		// Call handler
+		err := handler(srv, wrappedStream)
+		wrappedStream.metrics.handled(err)
+		stats.end(wrappedStream.metrics)
+
+		// Register status, and error if stream failed
+		registerStatus(opCtx, op, err)
+
+		return err
+	}
+}
+
+// StreamClientInterceptor returns a gRPC stream client interceptor that traces outgoing
+// streams and injects trace context into them.
+//
+// The interceptor:
+//   - Starts a client span for each stream, ended when the stream finishes, fails or its
+//     context is canceled, with the RPC's method and status code
+//   - Injects W3C Trace Context into gRPC metadata, continuing the trace from the span
+//   - Records go-grpc-prometheus style client metrics, counting each stream message
+//
+// Usage:
+//
+//	conn, err := grpc.Dial(
+//	    target,
+//	    grpc.WithStreamInterceptor(bedrockGrpc.StreamClientInterceptor()),
+//	)
+func StreamClientInterceptor() grpc.StreamClientInterceptor {
+	prop := &Propagator{}
+
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		ctx, call := startClientCall(ctx, rpcType(desc.ClientStreams, desc.ServerStreams), method)
+
+		// Call streamer with trace context in its metadata
+		stream, err := streamer(outgoingContext(ctx, prop), desc, cc, method, opts...)
+		if err != nil {
+			call.finish(err)
+			return nil, err
+		}
+
+		call.stream = &streamStats{span: call.span}
+		// A stream that's abandoned, without reading it to the end, still finishes when its
+		// context is canceled
+		go func() {
+			select {
+			case <-ctx.Done():
+				call.finish(status.FromContextError(ctx.Err()).Err())
+			case <-call.done:
+			}
+		}()
+		return &wrappedClientStream{
+			ClientStream:  stream,
+			call:          call,
+			serverStreams: desc.ServerStreams,
+		}, nil
+	}
+}
+
+// outgoingContext returns ctx with its trace context injected into its outgoing metadata,
+// keeping any metadata already there.
+func outgoingContext(ctx context.Context, prop *Propagator) context.Context {
+	// Get or create metadata
+	md, ok := metadata.FromOutgoingContext(ctx)
+	if !ok {
+		md = metadata.New(nil)
+	} else {
+		// Copy to avoid modifying shared metadata
+		md = md.Copy()
+	}
+
+	// Inject trace context into metadata
+	prop.Inject(ctx, md)
+
+	return metadata.NewOutgoingContext(ctx, md)
+}
+
+// clientCall is the span and metrics of an outgoing RPC.
+type clientCall struct {
+	ctx     context.Context
+	span    *trace.Span
+	metrics *rpcMetrics
+	stream  *streamStats // nil for unary RPCs
+	once    sync.Once
+	done    chan struct{} // closed when the RPC finishes
+}
+
+// startClientCall starts a client span, unless tracing is off in ctx, and metrics for an
+// RPC of rpcType to method, returning the span's context.
+func startClientCall(ctx context.Context, rpcType, method string) (context.Context, *clientCall) {
+	call := &clientCall{done: make(chan struct{})}
+	if tracer := bedrock.ClientTracer(ctx); tracer != nil {
+		ctx, call.span = tracer.Start(ctx, method,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttrs(rpcAttrs(method)...),
+		)
+	}
+	call.ctx = ctx
+	call.metrics = startMetrics(ctx, client, rpcType, method)
+	return ctx, call
+}
+
+// finish records the RPC's completion with err's status code and ends its span. Only the
+// first call has any effect.
+func (c *clientCall) finish(err error) {
+	c.once.Do(func() {
+		defer close(c.done)
+		c.metrics.handled(err)
+		if c.stream != nil {
+			c.stream.end(c.metrics)
+		}
+		if c.span == nil {
+			return
+		}
+		code := status.Code(err)
+		c.span.SetAttr(
+			attr.Int(bedrock.AttrName(c.ctx, "grpc.status_code"), int(code)),
+			attr.String("grpc.code", code.String()),
+		)
+		if err != nil {
+			c.span.SetAttr(attr.String("error.type", errorType(code)))
+			c.span.RecordError(err)
+		} else {
+			c.span.SetStatus(trace.StatusOK, "")
+		}
+		c.span.End()
+	})
+}
+
+// rpcAttrs returns the semantic convention attributes of an RPC from its full method
+// name, "/package.Service/Method".
+func rpcAttrs(fullMethod string) []attr.Attr {
+	service, method, _ := strings.Cut(strings.TrimPrefix(fullMethod, "/"), "/")
+	return []attr.Attr{
+		attr.String("rpc.system", "grpc"),
+		attr.String("rpc.service", service),
+		attr.String("rpc.method", method),
+	}
+}
+
+// registerStatus registers the gRPC status code of an RPC, as rpc.grpc.status_code with
+// Config.SemConv, and its name as grpc.code, which labels the operation's metrics. If the
+// RPC failed, it registers its error, classified by ClassifyError as error.type.
+func registerStatus(ctx context.Context, op *bedrock.Op, err error) {
+	code := status.Code(err)
+	op.Register(ctx,
+		attr.Int(bedrock.AttrName(ctx, "grpc.status_code"), int(code)),
+		attr.String("grpc.code", code.String()),
+	)
+	if err != nil {
+		op.Register(ctx, attr.String("error.type", errorType(code)), attr.Error(err))
+	}
+}
+
+// serverOperationOptions returns the options of the operation of an RPC to fullMethod.
+func serverOperationOptions(fullMethod string) []bedrock.OperationOption {
+	return []bedrock.OperationOption{
+		bedrock.Attrs(rpcAttrs(fullMethod)...),
+		bedrock.MetricLabels("grpc.code"),
+	}
+}
+
+// wrappedServerStream wraps grpc.ServerStream to override Context() and count messages.
+type wrappedServerStream struct {
+	grpc.ServerStream
+	ctx     context.Context
+	metrics *rpcMetrics
+	stats   *streamStats
+}
+
+// Context returns the wrapper's context instead of the underlying stream's context.
+func (w *wrappedServerStream) Context() context.Context {
+	return w.ctx
+}
+
+// RecvMsg receives a message from the client, counting it.
+func (w *wrappedServerStream) RecvMsg(m any) error {
+	err := w.ServerStream.RecvMsg(m)
+	if err == nil {
+		w.metrics.received(m)
+		w.stats.recordReceived()
+	}
+	return err
+}
+
+// SendMsg sends a message to the client, counting it.
+func (w *wrappedServerStream) SendMsg(m any) error {
+	err := w.ServerStream.SendMsg(m)
+	if err == nil {
+		w.metrics.sent(m)
+		w.stats.recordSent()
+	}
+	return err
+}
+
+// wrappedClientStream wraps grpc.ClientStream to count messages and finish the RPC when
+// the stream ends or fails.
+type wrappedClientStream struct {
+	grpc.ClientStream
+	call          *clientCall
+	serverStreams bool
+}
+
+// Header returns the header metadata from the server. The RPC finishes if it fails.
+func (w *wrappedClientStream) Header() (metadata.MD, error) {
+	md, err := w.ClientStream.Header()
+	if err != nil {
+		w.call.finish(err)
+	}
+	return md, err
+}
+
+// CloseSend closes the sending side of the stream. The RPC finishes if it fails.
+func (w *wrappedClientStream) CloseSend() error {
+	err := w.ClientStream.CloseSend()
+	if err != nil {
+		w.call.finish(err)
+	}
+	return err
+}
+
+// SendMsg sends a message to the server, counting it.
+func (w *wrappedClientStream) SendMsg(m any) error {
+	err := w.ClientStream.SendMsg(m)
+	if err == nil {
+		w.call.metrics.sent(m)
+		w.call.stream.recordSent()
+	}
+	return err
+}
+
+// RecvMsg receives a message from the server, counting it. The RPC finishes when the
+// server ends the stream, with an error or io.EOF, or with the only response of a
+// stream the server doesn't stream.
+func (w *wrappedClientStream) RecvMsg(m any) error {
+	err := w.ClientStream.RecvMsg(m)
+	switch {
+	case err == nil:
+		w.call.metrics.received(m)
+		w.call.stream.recordReceived()
+		if !w.serverStreams {
+			w.call.finish(nil)
+		}
+	case errors.Is(err, io.EOF):
+		w.call.finish(nil)
+	default:
+		w.call.finish(err)
+	}
+	return err
+}
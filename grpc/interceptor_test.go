@@ -0,0 +1,405 @@
+package grpc
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"slices"
+	"testing"
+
+	"github.com/kzs0/bedrock"
+	"github.com/kzs0/bedrock/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	ctx, close := bedrock.Init(context.Background())
+	defer close()
+
+	md := metadata.Pairs("traceparent", "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+	ctx = metadata.NewIncomingContext(ctx, md)
+	info := &grpc.UnaryServerInfo{FullMethod: "/users.v1.Users/Get"}
+
+	var op *bedrock.Op
+	var span *trace.Span
+	_, err := UnaryServerInterceptor()(ctx, nil, info, func(ctx context.Context, req any) (any, error) {
+		op, _ = bedrock.OperationFromContext(ctx)
+		span = trace.SpanFromContext(ctx)
+		return nil, status.Error(codes.NotFound, "no such user")
+	})
+	if status.Code(err) != codes.NotFound {
+		t.Fatalf("expected the handler's error, got %v", err)
+	}
+
+	if op == nil || op.Name() != "/users.v1.Users/Get" {
+		t.Fatal("expected an operation named by the full method")
+	}
+	if op.Succeeded() {
+		t.Error("expected the operation to fail with the handler's error")
+	}
+	if span.TraceID().String() != "0af7651916cd43dd8448eb211c80319c" {
+		t.Errorf("expected the remote trace ID, got %s", span.TraceID())
+	}
+
+	attrs := span.Attrs()
+	for key, want := range map[string]string{
+		"rpc.system":       "grpc",
+		"rpc.service":      "users.v1.Users",
+		"rpc.method":       "Get",
+		"grpc.status_code": "5",
+		"grpc.code":        "NotFound",
+		"error.type":       "client",
+	} {
+		if v, _ := attrs.Get(key); v.String() != want {
+			t.Errorf("expected %s = %q, got %q", key, want, v.String())
+		}
+	}
+}
+
+func TestUnaryClientInterceptor(t *testing.T) {
+	ctx, close := bedrock.Init(context.Background())
+	defer close()
+
+	op, ctx := bedrock.Operation(ctx, "caller")
+	defer op.Done()
+
+	// Existing outgoing metadata is kept
+	ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer token")
+
+	var outgoing metadata.MD
+	var span *trace.Span
+	invoker := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		outgoing, _ = metadata.FromOutgoingContext(ctx)
+		span = trace.SpanFromContext(ctx)
+		return status.Error(codes.Unavailable, "connection refused")
+	}
+	err := UnaryClientInterceptor()(ctx, "/users.v1.Users/Get", nil, nil, nil, invoker)
+	if status.Code(err) != codes.Unavailable {
+		t.Fatalf("expected the invoker's error, got %v", err)
+	}
+
+	// The RPC gets a client span under the caller's, which is sent in the metadata
+	caller := trace.SpanFromContext(ctx)
+	if span == nil || span == caller || span.Kind() != trace.SpanKindClient {
+		t.Fatal("expected a client span for the RPC")
+	}
+	if span.ParentID() != caller.SpanID() {
+		t.Error("expected the client span's parent to be the caller's span")
+	}
+	sc, err := (&Propagator{}).Extract(outgoing)
+	if err != nil {
+		t.Fatalf("expected a traceparent in the outgoing metadata: %v", err)
+	}
+	if sc.TraceID != caller.TraceID() || sc.SpanID != span.SpanID() {
+		t.Error("expected the client span's context in the outgoing metadata")
+	}
+	if got := outgoing.Get("authorization"); len(got) != 1 || got[0] != "Bearer token" {
+		t.Errorf("expected existing metadata to be kept, got %v", got)
+	}
+
+	if span.EndTime().IsZero() {
+		t.Error("expected the client span to end with the RPC")
+	}
+	if st, _ := span.Status(); st != trace.StatusError {
+		t.Error("expected the client span to fail with the RPC")
+	}
+	attrs := span.Attrs()
+	for key, want := range map[string]string{
+		"rpc.system":       "grpc",
+		"rpc.service":      "users.v1.Users",
+		"rpc.method":       "Get",
+		"grpc.status_code": "14",
+		"grpc.code":        "Unavailable",
+		"error.type":       "internal",
+	} {
+		if v, _ := attrs.Get(key); v.String() != want {
+			t.Errorf("expected %s = %q, got %q", key, want, v.String())
+		}
+	}
+}
+
+func TestUnaryClientInterceptorNoTrace(t *testing.T) {
+	ctx, close := bedrock.Init(context.Background())
+	defer close()
+
+	op, ctx := bedrock.Operation(ctx, "caller", bedrock.NoTrace())
+	defer op.Done()
+
+	var outgoing metadata.MD
+	invoker := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		outgoing, _ = metadata.FromOutgoingContext(ctx)
+		return nil
+	}
+	if err := UnaryClientInterceptor()(ctx, "/users.v1.Users/Get", nil, nil, nil, invoker); err != nil {
+		t.Fatal(err)
+	}
+	if len(outgoing.Get("traceparent")) != 0 {
+		t.Error("expected no client span within a NoTrace operation")
+	}
+}
+
+func TestUnaryClientInterceptorMetrics(t *testing.T) {
+	ctx, close := bedrock.Init(context.Background())
+	defer close()
+
+	interceptor := UnaryClientInterceptor()
+	for _, code := range []codes.Code{codes.OK, codes.Unavailable} {
+		_ = interceptor(ctx, "/users.v1.Users/Get", wrapperspb.String("user-1"), wrapperspb.String(""), nil,
+			func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+				return status.Error(code, "failed")
+			})
+	}
+
+	values := map[string]float64{}
+	for _, fam := range bedrock.FromContext(ctx).Metrics().Gather() {
+		for _, m := range fam.Metrics {
+			if v, _ := m.Labels.Get("grpc_method"); v.String() != "Get" {
+				continue
+			}
+			key := fam.Name
+			if code, ok := m.Labels.Get("grpc_code"); ok {
+				key += "/" + code.String()
+			}
+			switch fam.Name {
+			case "grpc_client_handling_seconds", "grpc_client_msg_received_bytes", "grpc_client_msg_sent_bytes":
+				values[key] += float64(m.Count)
+			default:
+				values[key] += m.Value
+			}
+		}
+	}
+
+	for key, want := range map[string]float64{
+		"grpc_client_started_total":             2,
+		"grpc_client_handled_total/OK":          1,
+		"grpc_client_handled_total/Unavailable": 1,
+		"grpc_client_handling_seconds":          2,
+		"grpc_client_msg_sent_total":            2,
+		"grpc_client_msg_received_total":        1,
+		"grpc_client_msg_sent_bytes":            2,
+		"grpc_client_msg_received_bytes":        1,
+	} {
+		if values[key] != want {
+			t.Errorf("expected %s = %v, got %v", key, want, values[key])
+		}
+	}
+}
+
+func TestUnaryServerInterceptorMetrics(t *testing.T) {
+	ctx, close := bedrock.Init(context.Background())
+	defer close()
+
+	info := &grpc.UnaryServerInfo{FullMethod: "/users.v1.Users/Get"}
+	interceptor := UnaryServerInterceptor()
+	for _, code := range []codes.Code{codes.OK, codes.OK, codes.NotFound} {
+		_, _ = interceptor(ctx, wrapperspb.String("user-1"), info, func(ctx context.Context, req any) (any, error) {
+			if code != codes.OK {
+				return nil, status.Error(code, "failed")
+			}
+			return wrapperspb.String("hello"), nil
+		})
+	}
+
+	values := map[string]float64{}
+	for _, fam := range bedrock.FromContext(ctx).Metrics().Gather() {
+		for _, m := range fam.Metrics {
+			if v, _ := m.Labels.Get("grpc_method"); v.String() != "Get" {
+				continue
+			}
+			if v, _ := m.Labels.Get("grpc_service"); v.String() != "users.v1.Users" {
+				t.Errorf("expected grpc_service users.v1.Users on %s, got %q", fam.Name, v.String())
+			}
+			key := fam.Name
+			if code, ok := m.Labels.Get("grpc_code"); ok {
+				key += "/" + code.String()
+			}
+			switch fam.Name {
+			case "grpc_server_handling_seconds", "grpc_server_msg_received_bytes", "grpc_server_msg_sent_bytes":
+				values[key] += float64(m.Count)
+			default:
+				values[key] += m.Value
+			}
+		}
+	}
+
+	for key, want := range map[string]float64{
+		"grpc_server_started_total":          3,
+		"grpc_server_handled_total/OK":       2,
+		"grpc_server_handled_total/NotFound": 1,
+		"grpc_server_handling_seconds":       3,
+		"grpc_server_msg_received_total":     3,
+		"grpc_server_msg_sent_total":         2,
+		"grpc_server_msg_received_bytes":     3,
+		"grpc_server_msg_sent_bytes":         2,
+	} {
+		if values[key] != want {
+			t.Errorf("expected %s = %v, got %v", key, want, values[key])
+		}
+	}
+}
+
+func TestUnaryServerInterceptorMetricsStrictNames(t *testing.T) {
+	var buf bytes.Buffer
+	ctx, close := bedrock.Init(context.Background(), bedrock.WithConfig(bedrock.Config{
+		LogOutput:         &buf,
+		MetricStrictNames: true,
+	}))
+	defer close()
+
+	info := &grpc.UnaryServerInfo{FullMethod: "/users.v1.Users/Get"}
+	_, _ = UnaryServerInterceptor()(ctx, wrapperspb.String("user-1"), info, func(ctx context.Context, req any) (any, error) {
+		return wrapperspb.String("hello"), nil
+	})
+
+	if bytes.Contains(buf.Bytes(), []byte("grpc_server_")) {
+		t.Errorf("expected the RPC metrics not to be rejected, got: %s", buf.String())
+	}
+	var handled float64
+	for _, fam := range bedrock.FromContext(ctx).Metrics().Gather() {
+		if fam.Name != "grpc_server_handled_total" {
+			continue
+		}
+		for _, m := range fam.Metrics {
+			if v, _ := m.Labels.Get("grpc_code"); v.String() == "OK" {
+				handled += m.Value
+			}
+		}
+	}
+	if handled != 1 {
+		t.Errorf("expected 1 handled RPC, got %v", handled)
+	}
+}
+
+// fakeServerStream is a grpc.ServerStream that receives n messages, then io.EOF.
+type fakeServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+	n   int
+}
+
+func (s *fakeServerStream) Context() context.Context { return s.ctx }
+func (s *fakeServerStream) SendMsg(m any) error      { return nil }
+func (s *fakeServerStream) RecvMsg(m any) error {
+	if s.n == 0 {
+		return io.EOF
+	}
+	s.n--
+	return nil
+}
+
+func TestStreamServerInterceptorMessages(t *testing.T) {
+	ctx, close := bedrock.Init(context.Background())
+	defer close()
+
+	info := &grpc.StreamServerInfo{FullMethod: "/chat.v1.Chat/Talk", IsClientStream: true, IsServerStream: true}
+	var span *trace.Span
+	err := StreamServerInterceptor()(nil, &fakeServerStream{ctx: ctx, n: 2}, info, func(srv any, ss grpc.ServerStream) error {
+		span = trace.SpanFromContext(ss.Context())
+		for ss.RecvMsg(wrapperspb.String("")) == nil {
+		}
+		for range 3 {
+			if err := ss.SendMsg(wrapperspb.String("hi")); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	attrs := span.Attrs()
+	for key, want := range map[string]string{
+		"grpc.stream.messages_received": "2",
+		"grpc.stream.messages_sent":     "3",
+	} {
+		if v, _ := attrs.Get(key); v.String() != want {
+			t.Errorf("expected %s = %q, got %q", key, want, v.String())
+		}
+	}
+
+	var names []string
+	for _, e := range span.Events() {
+		names = append(names, e.Name)
+	}
+	want := []string{"message.first_received", "message.first_sent", "message.last_sent", "message.last_received"}
+	if !slices.Equal(names, want) {
+		t.Errorf("expected events %v, got %v", want, names)
+	}
+
+	counts := map[string]uint64{}
+	sums := map[string]float64{}
+	for _, fam := range bedrock.FromContext(ctx).Metrics().Gather() {
+		for _, m := range fam.Metrics {
+			if v, _ := m.Labels.Get("grpc_type"); v.String() != "bidi_stream" {
+				continue
+			}
+			counts[fam.Name] += m.Count
+			sums[fam.Name] += m.Sum
+		}
+	}
+	if counts["grpc_server_stream_msgs_received"] != 1 || sums["grpc_server_stream_msgs_received"] != 2 {
+		t.Errorf("expected one stream with 2 messages received, got %d with %v",
+			counts["grpc_server_stream_msgs_received"], sums["grpc_server_stream_msgs_received"])
+	}
+	if counts["grpc_server_stream_msgs_sent"] != 1 || sums["grpc_server_stream_msgs_sent"] != 3 {
+		t.Errorf("expected one stream with 3 messages sent, got %d with %v",
+			counts["grpc_server_stream_msgs_sent"], sums["grpc_server_stream_msgs_sent"])
+	}
+}
+
+// fakeClientStream is a grpc.ClientStream whose CloseSend and Header fail with err.
+type fakeClientStream struct {
+	grpc.ClientStream
+	err error
+}
+
+func (s *fakeClientStream) CloseSend() error             { return s.err }
+func (s *fakeClientStream) Header() (metadata.MD, error) { return nil, s.err }
+
+func TestStreamClientInterceptorFinishes(t *testing.T) {
+	ctx, close := bedrock.Init(context.Background())
+	defer close()
+
+	desc := &grpc.StreamDesc{ClientStreams: true, ServerStreams: true}
+	unavailable := status.Error(codes.Unavailable, "failed")
+	for _, tc := range []struct {
+		name string
+		err  error
+		end  func(grpc.ClientStream, context.CancelFunc)
+		want codes.Code
+	}{
+		{"canceled", nil, func(cs grpc.ClientStream, cancel context.CancelFunc) { cancel() }, codes.Canceled},
+		{"close send", unavailable, func(cs grpc.ClientStream, cancel context.CancelFunc) { _ = cs.CloseSend() }, codes.Unavailable},
+		{"header", unavailable, func(cs grpc.ClientStream, cancel context.CancelFunc) { _, _ = cs.Header() }, codes.Unavailable},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(ctx)
+			defer cancel()
+
+			var span *trace.Span
+			cs, err := StreamClientInterceptor()(ctx, desc, nil, "/chat.v1.Chat/Talk",
+				func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+					span = trace.SpanFromContext(ctx)
+					return &fakeClientStream{err: tc.err}, nil
+				})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			tc.end(cs, cancel)
+			<-cs.(*wrappedClientStream).call.done
+
+			if span.IsRecording() {
+				t.Error("expected span to be ended")
+			}
+			if v, _ := span.Attrs().Get("grpc.code"); v.String() != tc.want.String() {
+				t.Errorf("expected grpc.code %s, got %q", tc.want, v.String())
+			}
+		})
+	}
+}
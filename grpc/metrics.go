@@ -0,0 +1,130 @@
+package grpc
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/kzs0/bedrock"
+	"github.com/kzs0/bedrock/attr"
+	"github.com/kzs0/bedrock/metric"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// RPC types, for the grpc_type label.
+const (
+	unary        = "unary"
+	clientStream = "client_stream"
+	serverStream = "server_stream"
+	bidiStream   = "bidi_stream"
+)
+
+// Sides of an RPC, for metric names.
+const (
+	server = "server"
+	client = "client"
+)
+
+// sizeBuckets are the buckets of the message size histograms, in bytes.
+var sizeBuckets = []float64{64, 256, 1 << 10, 4 << 10, 16 << 10, 64 << 10, 256 << 10, 1 << 20, 4 << 20}
+
+// countBuckets are the buckets of the per-stream message count histograms.
+var countBuckets = []float64{1, 2, 5, 10, 25, 50, 100, 250, 500, 1000, 5000, 10000}
+
+// rpcMetrics records an RPC in the same metrics as go-grpc-prometheus, with the bedrock
+// instance's static labels, where side is server or client:
+//
+//   - grpc_<side>_started_total: RPCs started
+//   - grpc_<side>_handled_total: RPCs completed, also labeled by grpc_code
+//   - grpc_<side>_handling_seconds: RPC duration histogram
+//   - grpc_<side>_msg_received_total, grpc_<side>_msg_sent_total: stream messages
+//   - grpc_<side>_msg_received_bytes, grpc_<side>_msg_sent_bytes: protobuf message sizes
+//   - grpc_<side>_stream_msgs_received, grpc_<side>_stream_msgs_sent: messages per
+//     stream histograms, for streaming RPCs
+//
+// All are labeled by grpc_type, grpc_service, and grpc_method.
+type rpcMetrics struct {
+	ctx    context.Context
+	side   string
+	labels []attr.Attr
+	start  time.Time
+}
+
+// startMetrics counts an RPC of rpcType for fullMethod as started on side.
+func startMetrics(ctx context.Context, side, rpcType, fullMethod string) *rpcMetrics {
+	service, method, _ := strings.Cut(strings.TrimPrefix(fullMethod, "/"), "/")
+	m := &rpcMetrics{
+		ctx:  ctx,
+		side: side,
+		labels: []attr.Attr{
+			attr.String("grpc_type", rpcType),
+			attr.String("grpc_service", service),
+			attr.String("grpc_method", method),
+		},
+		start: time.Now(),
+	}
+
+	bedrock.Counter(ctx, m.name("started_total"), "Total number of RPCs started on the "+side,
+		"grpc_type", "grpc_service", "grpc_method").With(m.labels...).Inc()
+	return m
+}
+
+// name returns the name of the metric for the RPC's side.
+func (m *rpcMetrics) name(suffix string) string {
+	return "grpc_" + m.side + "_" + suffix
+}
+
+// received records a message received from the peer.
+func (m *rpcMetrics) received(msg any) {
+	bedrock.Counter(m.ctx, m.name("msg_received_total"), "Total number of RPC stream messages received on the "+m.side,
+		"grpc_type", "grpc_service", "grpc_method").With(m.labels...).Inc()
+	m.observeSize(m.name("msg_received_bytes"), "Size of messages received on the "+m.side+" in bytes", msg)
+}
+
+// sent records a message sent to the peer.
+func (m *rpcMetrics) sent(msg any) {
+	bedrock.Counter(m.ctx, m.name("msg_sent_total"), "Total number of RPC stream messages sent by the "+m.side,
+		"grpc_type", "grpc_service", "grpc_method").With(m.labels...).Inc()
+	m.observeSize(m.name("msg_sent_bytes"), "Size of messages sent by the "+m.side+" in bytes", msg)
+}
+
+// observeSize observes the size of msg, if it is a protobuf message.
+func (m *rpcMetrics) observeSize(name, help string, msg any) {
+	pm, ok := msg.(proto.Message)
+	if !ok {
+		return
+	}
+	bedrock.Histogram(m.ctx, name, help, sizeBuckets,
+		"grpc_type", "grpc_service", "grpc_method").With(m.labels...).Observe(float64(proto.Size(pm)))
+}
+
+// streamed records the number of messages a stream sent and received.
+func (m *rpcMetrics) streamed(sent, received int) {
+	bedrock.Histogram(m.ctx, m.name("stream_msgs_sent"), "Number of messages sent per stream by the "+m.side, countBuckets,
+		"grpc_type", "grpc_service", "grpc_method").With(m.labels...).Observe(float64(sent))
+	bedrock.Histogram(m.ctx, m.name("stream_msgs_received"), "Number of messages received per stream on the "+m.side, countBuckets,
+		"grpc_type", "grpc_service", "grpc_method").With(m.labels...).Observe(float64(received))
+}
+
+// handled records the RPC's completion with err's status code and its duration.
+func (m *rpcMetrics) handled(err error) {
+	labels := append(m.labels[:len(m.labels):len(m.labels)], attr.String("grpc_code", status.Code(err).String()))
+	bedrock.Counter(m.ctx, m.name("handled_total"), "Total number of RPCs completed on the "+m.side+", regardless of success or failure",
+		"grpc_type", "grpc_service", "grpc_method", "grpc_code").With(labels...).Inc()
+	bedrock.Histogram(m.ctx, m.name("handling_seconds"), "Histogram of response latency (seconds) of RPCs completed on the "+m.side,
+		metric.DefaultSecondsBuckets, "grpc_type", "grpc_service", "grpc_method").With(m.labels...).Observe(time.Since(m.start).Seconds())
+}
+
+// rpcType returns the type of a streaming RPC, for the grpc_type label.
+func rpcType(isClientStream, isServerStream bool) string {
+	switch {
+	case isClientStream && isServerStream:
+		return bidiStream
+	case isClientStream:
+		return clientStream
+	case isServerStream:
+		return serverStream
+	}
+	return unary
+}
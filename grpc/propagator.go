@@ -1,13 +1,3 @@
-//go:build ignore
-// +build ignore
-
-// Package grpc provides an example implementation of W3C Trace Context propagation for gRPC.
-//
-// This is a reference implementation showing how to implement the trace.Propagator
-// interface for gRPC metadata. Copy this code into your own project and adapt as needed.
-//
-// This package requires the google.golang.org/grpc dependency.
-// This file uses build tag 'ignore' to prevent it from being built by default.
 package grpc
 
 import (
@@ -33,7 +23,7 @@ const (
 //
 // Usage:
 //
-//	prop := &grpc.Propagator{}
+//	prop := &bedrockGrpc.Propagator{}
 //
 //	// Extract from incoming RPC (server-side)
 //	md, ok := metadata.FromIncomingContext(ctx)
@@ -0,0 +1,106 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kzs0/bedrock/trace"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestPropagatorExtract(t *testing.T) {
+	prop := &Propagator{}
+
+	tests := []struct {
+		name    string
+		md      metadata.MD
+		wantErr bool
+		check   func(t *testing.T, sc trace.SpanContext)
+	}{
+		{
+			name: "valid traceparent",
+			md:   metadata.Pairs("traceparent", "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"),
+			check: func(t *testing.T, sc trace.SpanContext) {
+				if !sc.IsValid() || !sc.IsRemote || !sc.Sampled {
+					t.Errorf("expected a valid, remote, sampled span context, got %+v", sc)
+				}
+				if sc.TraceID.String() != "0af7651916cd43dd8448eb211c80319c" {
+					t.Errorf("trace ID = %s", sc.TraceID)
+				}
+			},
+		},
+		{
+			name: "multiple tracestate values",
+			md: metadata.Pairs(
+				"traceparent", "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-00",
+				"tracestate", "vendor1=value1",
+				"tracestate", "vendor2=value2",
+			),
+			check: func(t *testing.T, sc trace.SpanContext) {
+				if sc.Sampled {
+					t.Error("expected an unsampled span context")
+				}
+				if sc.Tracestate != "vendor1=value1,vendor2=value2" {
+					t.Errorf("tracestate = %q, want vendor1=value1,vendor2=value2", sc.Tracestate)
+				}
+			},
+		},
+		{
+			name:    "missing traceparent",
+			md:      metadata.MD{},
+			wantErr: true,
+		},
+		{
+			name:    "invalid traceparent",
+			md:      metadata.Pairs("traceparent", "not-a-traceparent"),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sc, err := prop.Extract(tt.md)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Extract() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.check != nil {
+				tt.check(t, sc)
+			}
+		})
+	}
+
+	if _, err := prop.Extract(map[string]string{}); err == nil {
+		t.Error("expected an error for a carrier that isn't metadata.MD")
+	}
+}
+
+func TestPropagatorInject(t *testing.T) {
+	prop := &Propagator{}
+	tracer := trace.NewTracer(trace.TracerConfig{ServiceName: "test"})
+
+	// No span: nothing injected
+	md := metadata.MD{}
+	if err := prop.Inject(context.Background(), md); err != nil {
+		t.Fatal(err)
+	}
+	if len(md.Get("traceparent")) != 0 {
+		t.Error("expected no traceparent without a span")
+	}
+
+	ctx, span := tracer.Start(context.Background(), "client")
+	defer span.End()
+
+	md = metadata.MD{}
+	if err := prop.Inject(ctx, md); err != nil {
+		t.Fatal(err)
+	}
+
+	// Round trip: the extracted context is the injected span's
+	sc, err := prop.Extract(md)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sc.TraceID != span.TraceID() || sc.SpanID != span.SpanID() {
+		t.Errorf("expected the span's IDs, got %+v", sc)
+	}
+}
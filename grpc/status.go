@@ -0,0 +1,48 @@
+package grpc
+
+import (
+	"github.com/kzs0/bedrock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ErrorTypeClient is the error type ClassifyError returns for status codes caused by the
+// client's request, such as NotFound and InvalidArgument.
+const ErrorTypeClient = "client"
+
+// ClassifyError classifies gRPC status errors by their code, and other errors as
+// bedrock.ClassifyError does:
+//
+//   - Canceled: bedrock.ErrorTypeCanceled
+//   - DeadlineExceeded: bedrock.ErrorTypeTimeout
+//   - InvalidArgument, NotFound, AlreadyExists, PermissionDenied, ResourceExhausted,
+//     FailedPrecondition, Aborted, OutOfRange, and Unauthenticated: ErrorTypeClient
+//   - Unknown, Unimplemented, Internal, Unavailable, and DataLoss: bedrock.ErrorTypeInternal
+//
+// The interceptors use it for the error.type attribute of failed RPCs, so failures the
+// client caused can be told apart from the server's. Set it as Config.ErrorClassifier to
+// classify errors from gRPC calls the same way in other operations:
+//
+//	cfg.ErrorClassifier = bedrockGrpc.ClassifyError
+func ClassifyError(err error) string {
+	s, ok := status.FromError(err)
+	if !ok {
+		return bedrock.ClassifyError(err)
+	}
+	return errorType(s.Code())
+}
+
+// errorType returns the error type of a failed RPC's status code.
+func errorType(code codes.Code) string {
+	switch code {
+	case codes.Canceled:
+		return bedrock.ErrorTypeCanceled
+	case codes.DeadlineExceeded:
+		return bedrock.ErrorTypeTimeout
+	case codes.InvalidArgument, codes.NotFound, codes.AlreadyExists, codes.PermissionDenied,
+		codes.ResourceExhausted, codes.FailedPrecondition, codes.Aborted, codes.OutOfRange,
+		codes.Unauthenticated:
+		return ErrorTypeClient
+	}
+	return bedrock.ErrorTypeInternal
+}
@@ -0,0 +1,36 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/kzs0/bedrock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{status.Error(codes.NotFound, "no such user"), ErrorTypeClient},
+		{status.Error(codes.InvalidArgument, "bad id"), ErrorTypeClient},
+		{status.Error(codes.Unauthenticated, "no token"), ErrorTypeClient},
+		{status.Error(codes.Internal, "boom"), bedrock.ErrorTypeInternal},
+		{status.Error(codes.Unavailable, "connection refused"), bedrock.ErrorTypeInternal},
+		{status.Error(codes.DeadlineExceeded, "too slow"), bedrock.ErrorTypeTimeout},
+		{status.Error(codes.Canceled, "gone"), bedrock.ErrorTypeCanceled},
+		{fmt.Errorf("fetching user: %w", status.Error(codes.NotFound, "no such user")), ErrorTypeClient},
+		{context.DeadlineExceeded, bedrock.ErrorTypeTimeout},
+		{errors.New("plain"), bedrock.ErrorTypeInternal},
+	}
+
+	for _, tt := range tests {
+		if got := ClassifyError(tt.err); got != tt.want {
+			t.Errorf("ClassifyError(%v) = %q, want %q", tt.err, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package grpc
+
+import (
+	"sync"
+	"time"
+
+	"github.com/kzs0/bedrock/attr"
+	"github.com/kzs0/bedrock/trace"
+)
+
+// streamStats counts the messages of a stream, for its span and the per-stream message
+// histograms. The first message each way is recorded on the span as it happens, as a
+// message.first_sent or message.first_received event, and the last when the stream
+// ends, as a message.last_sent or message.last_received event at the time it was sent
+// or received, with the stream's message count. A nil span records only the counts.
+type streamStats struct {
+	span *trace.Span
+
+	mu           sync.Mutex
+	sent         int
+	received     int
+	lastSent     time.Time
+	lastReceived time.Time
+}
+
+// recordSent records a message sent to the peer.
+func (s *streamStats) recordSent() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sent++
+	s.lastSent = time.Now()
+	if s.sent == 1 && s.span != nil {
+		s.span.AddEventAt("message.first_sent", s.lastSent)
+	}
+}
+
+// recordReceived records a message received from the peer.
+func (s *streamStats) recordReceived() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.received++
+	s.lastReceived = time.Now()
+	if s.received == 1 && s.span != nil {
+		s.span.AddEventAt("message.first_received", s.lastReceived)
+	}
+}
+
+// end records the stream's message counts in m and on the span, as
+// grpc.stream.messages_sent and grpc.stream.messages_received, with the events of the
+// last messages. It must be called before the span ends.
+func (s *streamStats) end(m *rpcMetrics) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m.streamed(s.sent, s.received)
+	if s.span == nil {
+		return
+	}
+
+	s.span.SetAttr(
+		attr.Int("grpc.stream.messages_sent", s.sent),
+		attr.Int("grpc.stream.messages_received", s.received),
+	)
+	if s.sent > 0 {
+		s.span.AddEventAt("message.last_sent", s.lastSent, attr.Int("message.count", s.sent))
+	}
+	if s.received > 0 {
+		s.span.AddEventAt("message.last_received", s.lastReceived, attr.Int("message.count", s.received))
+	}
+}
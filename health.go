@@ -0,0 +1,37 @@
+package bedrock
+
+import (
+	"context"
+
+	"github.com/kzs0/bedrock/health"
+)
+
+// RegisterReadinessCheck registers a check of whether the service is ready to serve
+// traffic, such as a ping of a database it can't serve without. The observability
+// server's /ready endpoint responds 503 while any readiness check fails, so Kubernetes
+// takes the pod out of its Service until they pass. Checks run concurrently on each
+// request, with a 5 second timeout by default; use health.WithTimeout and
+// health.WithCache to change it or to reuse results.
+//
+// Usage:
+//
+//	bedrock.RegisterReadinessCheck(ctx, "postgres", db.PingContext, health.WithTimeout(time.Second))
+func RegisterReadinessCheck(ctx context.Context, name string, check health.Check, opts ...health.CheckOption) {
+	b := bedrockFromContext(ctx)
+	if b.isNoop {
+		return
+	}
+	b.readiness.Register(name, check, opts...)
+}
+
+// RegisterLivenessCheck registers a check of whether the process is alive, such as
+// whether a worker loop is still making progress. The observability server's /health
+// endpoint responds 503 while any liveness check fails, so Kubernetes restarts the pod;
+// don't check dependencies here, or an outage restarts every pod.
+func RegisterLivenessCheck(ctx context.Context, name string, check health.Check, opts ...health.CheckOption) {
+	b := bedrockFromContext(ctx)
+	if b.isNoop {
+		return
+	}
+	b.liveness.Register(name, check, opts...)
+}
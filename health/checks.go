@@ -0,0 +1,95 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+)
+
+// Pinger is implemented by database handles such as *sql.DB and *sql.Conn.
+type Pinger interface {
+	PingContext(ctx context.Context) error
+}
+
+// SQLPing returns a check that pings db, verifying the database is reachable and a
+// connection can be established.
+//
+// Usage:
+//
+//	bedrock.RegisterReadinessCheck(ctx, "postgres", health.SQLPing(db))
+func SQLPing(db Pinger) Check {
+	return func(ctx context.Context) error {
+		return db.PingContext(ctx)
+	}
+}
+
+// TCPDial returns a check that opens a TCP connection to addr, a "host:port" address,
+// and closes it, verifying something is listening there.
+//
+// Usage:
+//
+//	bedrock.RegisterReadinessCheck(ctx, "redis", health.TCPDial("redis:6379"))
+func TCPDial(addr string) Check {
+	return func(ctx context.Context) error {
+		var d net.Dialer
+		conn, err := d.DialContext(ctx, "tcp", addr)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+}
+
+// HTTPGet returns a check that sends a GET request to url and fails unless the response
+// has status expectedStatus, or any 2xx status if expectedStatus is 0. Its requests are
+// not instrumented, so frequent probes don't add spans or client metrics.
+//
+// Usage:
+//
+//	bedrock.RegisterReadinessCheck(ctx, "auth", health.HTTPGet("http://auth:8080/ready", 0))
+func HTTPGet(url string, expectedStatus int) Check {
+	return func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		// Drain a little of the body so the connection can be reused
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4<<10))
+
+		switch {
+		case expectedStatus != 0 && resp.StatusCode != expectedStatus:
+			return fmt.Errorf("GET %s: status %d, want %d", url, resp.StatusCode, expectedStatus)
+		case expectedStatus == 0 && (resp.StatusCode < 200 || resp.StatusCode > 299):
+			return fmt.Errorf("GET %s: status %d", url, resp.StatusCode)
+		}
+		return nil
+	}
+}
+
+// DiskSpace returns a check that fails when the filesystem containing path has less than
+// minFreeBytes available to unprivileged users. It is supported on Linux, macOS, and
+// FreeBSD, and fails on other platforms.
+//
+// Usage:
+//
+//	// Fail liveness before a full disk corrupts the local cache
+//	bedrock.RegisterLivenessCheck(ctx, "disk", health.DiskSpace("/var/cache/app", 1<<30))
+func DiskSpace(path string, minFreeBytes uint64) Check {
+	return func(ctx context.Context) error {
+		free, err := diskFree(path)
+		if err != nil {
+			return err
+		}
+		if free < minFreeBytes {
+			return fmt.Errorf("%s: %d bytes free, want at least %d", path, free, minFreeBytes)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,84 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type pingerFunc func(ctx context.Context) error
+
+func (f pingerFunc) PingContext(ctx context.Context) error { return f(ctx) }
+
+func TestSQLPing(t *testing.T) {
+	ctx := context.Background()
+	if err := SQLPing(pingerFunc(func(ctx context.Context) error { return nil }))(ctx); err != nil {
+		t.Errorf("expected a successful ping to pass, got %v", err)
+	}
+	down := errors.New("connection refused")
+	if err := SQLPing(pingerFunc(func(ctx context.Context) error { return down }))(ctx); !errors.Is(err, down) {
+		t.Errorf("expected the ping's error, got %v", err)
+	}
+}
+
+func TestTCPDial(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+
+	if err := TCPDial(addr)(context.Background()); err != nil {
+		t.Errorf("expected a listening address to pass, got %v", err)
+	}
+	ln.Close()
+	if err := TCPDial(addr)(context.Background()); err == nil {
+		t.Error("expected a closed address to fail")
+	}
+}
+
+func TestHTTPGet(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ok":
+			w.WriteHeader(http.StatusNoContent)
+		case "/teapot":
+			w.WriteHeader(http.StatusTeapot)
+		default:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	tests := []struct {
+		path    string
+		status  int
+		wantErr bool
+	}{
+		{"/ok", 0, false},
+		{"/down", 0, true},
+		{"/teapot", http.StatusTeapot, false},
+		{"/ok", http.StatusOK, true},
+	}
+	for _, tt := range tests {
+		err := HTTPGet(server.URL+tt.path, tt.status)(ctx)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("HTTPGet(%s, %d) error = %v, wantErr %v", tt.path, tt.status, err, tt.wantErr)
+		}
+	}
+}
+
+func TestDiskSpace(t *testing.T) {
+	dir := t.TempDir()
+	if err := DiskSpace(dir, 1)(context.Background()); err != nil {
+		t.Skipf("disk space not available here: %v", err)
+	}
+	if err := DiskSpace(dir, math.MaxUint64)(context.Background()); err == nil {
+		t.Error("expected an impossible threshold to fail")
+	}
+}
@@ -0,0 +1,13 @@
+//go:build !linux && !darwin && !freebsd
+
+package health
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// diskFree is not supported on this platform.
+func diskFree(path string) (uint64, error) {
+	return 0, fmt.Errorf("disk space checks are not supported on %s", runtime.GOOS)
+}
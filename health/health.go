@@ -0,0 +1,240 @@
+// Package health provides registries of health checks, such as the readiness and liveness
+// checks served by the observability server at /ready and /health.
+// Most users register checks with bedrock.RegisterReadinessCheck and
+// bedrock.RegisterLivenessCheck rather than using this package directly.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultTimeout is how long a check may run before it fails, unless set with WithTimeout.
+const DefaultTimeout = 5 * time.Second
+
+// Check statuses, in Report and CheckResult.
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+// Check reports whether a dependency or the service itself is healthy, returning nil if
+// it is. It should return when ctx is done.
+type Check func(ctx context.Context) error
+
+// CheckOption configures a registered check.
+type CheckOption func(*check)
+
+// WithTimeout sets how long the check may run before it fails. The default is
+// DefaultTimeout.
+func WithTimeout(d time.Duration) CheckOption {
+	return func(c *check) {
+		c.timeout = d
+	}
+}
+
+// WithCache reuses the check's result for d after it runs, so frequent probes don't
+// overload the dependency it checks. Concurrent requests wait for a single run.
+func WithCache(d time.Duration) CheckOption {
+	return func(c *check) {
+		c.cacheFor = d
+	}
+}
+
+// Registry is a set of named health checks. It is safe for concurrent use.
+type Registry struct {
+	mu     sync.RWMutex
+	checks map[string]*check
+}
+
+// NewRegistry creates an empty registry, which is healthy.
+func NewRegistry() *Registry {
+	return &Registry{checks: make(map[string]*check)}
+}
+
+// Register adds a check named name, replacing any check already registered with it.
+func (r *Registry) Register(name string, fn Check, opts ...CheckOption) {
+	c := &check{fn: fn, timeout: DefaultTimeout}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checks[name] = c
+}
+
+// Unregister removes the check named name, if any.
+func (r *Registry) Unregister(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.checks, name)
+}
+
+// Report is the result of running a registry's checks.
+type Report struct {
+	// Status is StatusOK if every check passed, and StatusFail otherwise.
+	Status string `json:"status"`
+	// Checks are the results of the checks by name.
+	Checks map[string]CheckResult `json:"checks"`
+}
+
+// CheckResult is the result of a single check.
+type CheckResult struct {
+	// Status is StatusOK if the check passed, and StatusFail otherwise.
+	Status string `json:"status"`
+	// Error is the check's error, if it failed.
+	Error string `json:"error,omitempty"`
+	// DurationMs is how long the check took, in milliseconds.
+	DurationMs float64 `json:"duration_ms"`
+	// Cached is true if the result is from an earlier run, within the check's WithCache.
+	Cached bool `json:"cached,omitempty"`
+}
+
+// Run runs every check concurrently, each with its own timeout, and reports the results.
+func (r *Registry) Run(ctx context.Context) Report {
+	r.mu.RLock()
+	checks := make(map[string]*check, len(r.checks))
+	for name, c := range r.checks {
+		checks[name] = c
+	}
+	r.mu.RUnlock()
+
+	report := Report{Status: StatusOK, Checks: make(map[string]CheckResult, len(checks))}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, c := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result := c.run(ctx)
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[name] = result
+			if result.Status != StatusOK {
+				report.Status = StatusFail
+			}
+		}()
+	}
+	wg.Wait()
+	return report
+}
+
+// Healthy runs every check, returning nil if all passed, or an error naming those that
+// failed. Use it where a single check function is expected, such as for gRPC health
+// checks.
+func (r *Registry) Healthy(ctx context.Context) error {
+	report := r.Run(ctx)
+	if report.Status == StatusOK {
+		return nil
+	}
+
+	names := make([]string, 0, len(report.Checks))
+	for name, result := range report.Checks {
+		if result.Status != StatusOK {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	errs := make([]error, len(names))
+	for i, name := range names {
+		errs[i] = fmt.Errorf("%s: %s", name, report.Checks[name].Error)
+	}
+	return errors.Join(errs...)
+}
+
+// Handler returns an HTTP handler that runs the checks and responds with the Report as
+// JSON, with status 200 if every check passed and 503 otherwise.
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		report := r.Run(req.Context())
+
+		status := http.StatusOK
+		if report.Status != StatusOK {
+			status = http.StatusServiceUnavailable
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(report)
+	})
+}
+
+// check is a registered check with its options and cached result.
+type check struct {
+	fn       Check
+	timeout  time.Duration
+	cacheFor time.Duration
+
+	mu       sync.Mutex // held while running a cached check, so concurrent runs share it
+	result   CheckResult
+	resultAt time.Time
+}
+
+// run returns the cached result, if it is fresh, or runs the check.
+func (c *check) run(ctx context.Context) CheckResult {
+	if c.cacheFor <= 0 {
+		return c.runOnce(ctx)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.resultAt.IsZero() && time.Since(c.resultAt) < c.cacheFor {
+		result := c.result
+		result.Cached = true
+		return result
+	}
+	result := c.runOnce(ctx)
+	if ctx.Err() == nil {
+		// A result cut short by the caller, not the check's timeout, isn't reused
+		c.result = result
+		c.resultAt = time.Now()
+	}
+	return result
+}
+
+// runOnce runs the check with its timeout. A check that ignores its context fails at
+// the timeout and is left to finish in the background.
+func (c *check) runOnce(ctx context.Context) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				done <- fmt.Errorf("check panicked: %v", p)
+			}
+		}()
+		done <- c.fn(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+		if errors.Is(err, context.DeadlineExceeded) {
+			err = errors.New("check timed out")
+		}
+	}
+
+	result := CheckResult{
+		Status:     StatusOK,
+		DurationMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		result.Status = StatusFail
+		result.Error = err.Error()
+	}
+	return result
+}
@@ -0,0 +1,114 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRegistryRun(t *testing.T) {
+	r := NewRegistry()
+
+	report := r.Run(context.Background())
+	if report.Status != StatusOK || len(report.Checks) != 0 {
+		t.Errorf("expected an empty registry to be healthy, got %+v", report)
+	}
+
+	r.Register("db", func(ctx context.Context) error { return nil })
+	r.Register("cache", func(ctx context.Context) error { return errors.New("connection refused") })
+
+	report = r.Run(context.Background())
+	if report.Status != StatusFail {
+		t.Error("expected a failing check to fail the report")
+	}
+	if got := report.Checks["db"]; got.Status != StatusOK {
+		t.Errorf("expected db to pass, got %+v", got)
+	}
+	if got := report.Checks["cache"]; got.Status != StatusFail || got.Error != "connection refused" {
+		t.Errorf("expected cache to fail with its error, got %+v", got)
+	}
+
+	if err := r.Healthy(context.Background()); err == nil || err.Error() != "cache: connection refused" {
+		t.Errorf("expected an error naming the failed check, got %v", err)
+	}
+
+	r.Unregister("cache")
+	if err := r.Healthy(context.Background()); err != nil {
+		t.Errorf("expected healthy after unregistering the failed check, got %v", err)
+	}
+}
+
+func TestCheckTimeout(t *testing.T) {
+	r := NewRegistry()
+	release := make(chan struct{})
+	defer close(release)
+	r.Register("stuck", func(ctx context.Context) error {
+		<-release // ignores ctx
+		return nil
+	}, WithTimeout(10*time.Millisecond))
+	r.Register("panics", func(ctx context.Context) error { panic("boom") })
+
+	start := time.Now()
+	report := r.Run(context.Background())
+	if time.Since(start) > time.Second {
+		t.Error("expected the run to end at the check's timeout")
+	}
+	if got := report.Checks["stuck"]; got.Status != StatusFail || got.Error != "check timed out" {
+		t.Errorf("expected the stuck check to time out, got %+v", got)
+	}
+	if got := report.Checks["panics"]; got.Status != StatusFail || got.Error != "check panicked: boom" {
+		t.Errorf("expected the panic to fail the check, got %+v", got)
+	}
+}
+
+func TestCheckCache(t *testing.T) {
+	r := NewRegistry()
+	var runs atomic.Int32
+	r.Register("db", func(ctx context.Context) error {
+		runs.Add(1)
+		return nil
+	}, WithCache(time.Hour))
+
+	r.Run(context.Background())
+	report := r.Run(context.Background())
+	if runs.Load() != 1 {
+		t.Errorf("expected one run within the cache period, got %d", runs.Load())
+	}
+	if !report.Checks["db"].Cached {
+		t.Error("expected the second result to be marked cached")
+	}
+}
+
+func TestHandler(t *testing.T) {
+	r := NewRegistry()
+	var failing atomic.Bool
+	r.Register("db", func(ctx context.Context) error {
+		if failing.Load() {
+			return errors.New("down")
+		}
+		return nil
+	})
+
+	serve := func() (int, Report) {
+		rec := httptest.NewRecorder()
+		r.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/ready", nil))
+		var report Report
+		if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+			t.Fatalf("expected a JSON report, got %q: %v", rec.Body.String(), err)
+		}
+		return rec.Code, report
+	}
+
+	if code, report := serve(); code != http.StatusOK || report.Status != StatusOK {
+		t.Errorf("expected 200 ok, got %d %s", code, report.Status)
+	}
+	failing.Store(true)
+	if code, report := serve(); code != http.StatusServiceUnavailable || report.Checks["db"].Error != "down" {
+		t.Errorf("expected 503 with the check's error, got %d %+v", code, report)
+	}
+}
@@ -0,0 +1,103 @@
+package log
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// deduper collapses bursts of identical records, like kernel log deduplication.
+// The first record of a burst is logged as is. Records with the same level and
+// message within the window are suppressed, then summarized in one record,
+// "msg (repeated N times in Xs)", when the window ends or a different record arrives.
+// It is shared by all handlers derived from the same root handler.
+type deduper struct {
+	window time.Duration
+
+	mu      sync.Mutex
+	active  bool // a burst is in progress
+	gen     uint64
+	level   slog.Level
+	msg     string
+	start   time.Time
+	pending *dedupSummary
+	timer   *time.Timer
+}
+
+// dedupSummary holds the records suppressed in the current burst.
+type dedupSummary struct {
+	h     *Handler
+	ctx   context.Context
+	last  slog.Record // the last suppressed record, whose attributes the summary keeps
+	count int
+	start time.Time
+}
+
+func newDeduper(window time.Duration) *deduper {
+	return &deduper{window: window}
+}
+
+// observe reports whether r should be logged. If r ends a burst, it also returns
+// the burst's summary, which the caller emits before r.
+func (d *deduper) observe(now time.Time, h *Handler, ctx context.Context, r slog.Record) (*dedupSummary, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.active && r.Level == d.level && r.Message == d.msg && now.Sub(d.start) < d.window {
+		if d.pending == nil {
+			d.pending = &dedupSummary{start: d.start}
+			gen := d.gen
+			d.timer = time.AfterFunc(d.window-now.Sub(d.start), func() { d.expire(gen) })
+		}
+		d.pending.h, d.pending.ctx, d.pending.last = h, ctx, r.Clone()
+		d.pending.count++
+		return nil, false
+	}
+
+	summary := d.take()
+	d.active, d.level, d.msg, d.start = true, r.Level, r.Message, now
+	d.gen++
+	return summary, true
+}
+
+// expire emits the summary of burst gen when its window ends.
+func (d *deduper) expire(gen uint64) {
+	d.mu.Lock()
+	if gen != d.gen {
+		d.mu.Unlock()
+		return // the burst already ended
+	}
+	summary := d.take()
+	d.active = false
+	d.mu.Unlock()
+
+	if summary != nil {
+		_ = summary.emit()
+	}
+}
+
+// take returns and clears the pending summary. The caller must hold d.mu.
+func (d *deduper) take() *dedupSummary {
+	summary := d.pending
+	d.pending = nil
+	if d.timer != nil {
+		d.timer.Stop()
+		d.timer = nil
+	}
+	return summary
+}
+
+// emit writes the summary record through the handler of the last suppressed record.
+func (s *dedupSummary) emit() error {
+	elapsed := s.last.Time.Sub(s.start).Round(time.Millisecond)
+	msg := fmt.Sprintf("%s (repeated %d times in %s)", s.last.Message, s.count, elapsed)
+
+	r := slog.NewRecord(s.last.Time, s.last.Level, msg, s.last.PC)
+	s.last.Attrs(func(a slog.Attr) bool {
+		r.AddAttrs(a)
+		return true
+	})
+	return s.h.handle(s.ctx, r)
+}
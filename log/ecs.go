@@ -0,0 +1,73 @@
+package log
+
+import (
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// ecsVersion is the Elastic Common Schema version the "ecs" format follows.
+const ecsVersion = "8.11.0"
+
+// newECSHandler creates a JSON handler whose records use Elastic Common Schema field names:
+// @timestamp, log.level, message, log.origin, trace.id, span.id, error.message, and
+// service.name, so they can be ingested by Elasticsearch without an ingest pipeline.
+// See https://www.elastic.co/guide/en/ecs/current/ecs-field-reference.html.
+func newECSHandler(output io.Writer, service string, opts *slog.HandlerOptions) slog.Handler {
+	o := *opts
+	replace := opts.ReplaceAttr
+	o.ReplaceAttr = func(groups []string, a slog.Attr) slog.Attr {
+		if replace != nil {
+			a = replace(groups, a)
+		}
+		if len(groups) == 1 && groups[0] == "error" && a.Key == "stack" {
+			// The message and stack of an attr.ErrorWithStack error
+			return slog.Attr{Key: "stack_trace", Value: a.Value}
+		}
+		if len(groups) > 0 {
+			return a
+		}
+		switch a.Key {
+		case slog.TimeKey:
+			return slog.Attr{Key: "@timestamp", Value: a.Value}
+		case slog.LevelKey:
+			level, _ := a.Value.Any().(slog.Level)
+			return slog.String("log.level", ecsLevel(level))
+		case slog.MessageKey:
+			return slog.Attr{Key: "message", Value: a.Value}
+		case slog.SourceKey:
+			src, ok := a.Value.Any().(*slog.Source)
+			if !ok {
+				return a
+			}
+			return slog.Group("log.origin",
+				slog.Group("file",
+					slog.String("name", src.File),
+					slog.Int("line", src.Line),
+				),
+				slog.String("function", src.Function),
+			)
+		case "trace_id":
+			return slog.Attr{Key: "trace.id", Value: a.Value}
+		case "span_id":
+			return slog.Attr{Key: "span.id", Value: a.Value}
+		case "error":
+			return slog.Group("error", slog.Attr{Key: "message", Value: a.Value})
+		case "stack":
+			return slog.Attr{Key: "error.stack_trace", Value: a.Value}
+		}
+		return a
+	}
+	return slog.NewJSONHandler(output, &o).WithAttrs([]slog.Attr{
+		slog.String("ecs.version", ecsVersion),
+		slog.String("service.name", service),
+	})
+}
+
+// ecsLevel returns the lowercase level name, such as "info" or "fatal", for log.level.
+func ecsLevel(level slog.Level) string {
+	if level == LevelFatal {
+		return "fatal"
+	}
+	return strings.ToLower(level.String())
+}
@@ -0,0 +1,77 @@
+package log
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"strconv"
+)
+
+// Cloud Logging special fields, see https://cloud.google.com/logging/docs/structured-logging.
+const (
+	gcpTraceKey          = "logging.googleapis.com/trace"
+	gcpSpanIDKey         = "logging.googleapis.com/spanId"
+	gcpSourceLocationKey = "logging.googleapis.com/sourceLocation"
+)
+
+// newGCPHandler creates a JSON handler whose records use the field names Cloud Logging
+// expects: severity, time, message, sourceLocation, and trace and span IDs. Trace IDs are
+// converted to the projects/<project>/traces/<trace> form when the project is known.
+func newGCPHandler(output io.Writer, project string, opts *slog.HandlerOptions) slog.Handler {
+	if project == "" {
+		project = os.Getenv("GOOGLE_CLOUD_PROJECT")
+	}
+
+	o := *opts
+	replace := opts.ReplaceAttr
+	o.ReplaceAttr = func(groups []string, a slog.Attr) slog.Attr {
+		if replace != nil {
+			a = replace(groups, a)
+		}
+		if len(groups) > 0 {
+			return a
+		}
+		switch a.Key {
+		case slog.LevelKey:
+			level, _ := a.Value.Any().(slog.Level)
+			return slog.String("severity", gcpSeverity(level))
+		case slog.MessageKey:
+			return slog.Attr{Key: "message", Value: a.Value}
+		case slog.SourceKey:
+			src, ok := a.Value.Any().(*slog.Source)
+			if !ok {
+				return a
+			}
+			return slog.Group(gcpSourceLocationKey,
+				slog.String("file", src.File),
+				slog.String("line", strconv.Itoa(src.Line)),
+				slog.String("function", src.Function),
+			)
+		case "trace_id":
+			if project == "" {
+				return slog.Attr{Key: gcpTraceKey, Value: a.Value}
+			}
+			return slog.String(gcpTraceKey, "projects/"+project+"/traces/"+a.Value.String())
+		case "span_id":
+			return slog.Attr{Key: gcpSpanIDKey, Value: a.Value}
+		}
+		return a
+	}
+	return slog.NewJSONHandler(output, &o)
+}
+
+// gcpSeverity maps a log level to a Cloud Logging severity.
+func gcpSeverity(level slog.Level) string {
+	switch severity(level) {
+	case severityCritical:
+		return "CRITICAL"
+	case severityError:
+		return "ERROR"
+	case severityWarning:
+		return "WARNING"
+	case severityInfo:
+		return "INFO"
+	default:
+		return "DEBUG"
+	}
+}
@@ -2,20 +2,35 @@ package log
 
 import (
 	"context"
+	"errors"
 	"io"
 	"log/slog"
 	"os"
+	"runtime"
 	"strings"
+	"time"
 
 	"github.com/kzs0/bedrock/attr"
 )
 
+// LevelFatal is the level of records logged just before the process exits.
+// It is rendered as "FATAL".
+const LevelFatal = slog.LevelError + 4
+
 // Handler is a custom slog.Handler that injects trace context into logs.
 type Handler struct {
 	inner       slog.Handler
 	attrs       []slog.Attr
 	groups      []string
 	getTraceCtx func(ctx context.Context) (traceID, spanID string)
+	getCtxAttrs func(ctx context.Context) []slog.Attr
+	onStack     func(ctx context.Context, msg string, stack attr.Stack)
+	onRecord    func(level slog.Level)
+	sampler     *sampler
+	deduper     *deduper
+	errorStacks bool
+	gate        *traceGate
+	maxSize     int
 }
 
 // HandlerOptions configures the Handler.
@@ -26,8 +41,77 @@ type HandlerOptions struct {
 	AddSource bool
 	// Output is the writer to write logs to. Defaults to os.Stderr.
 	Output io.Writer
-	// Format is the output format ("json" or "text"). Defaults to "json".
+	// Format is the output format: "json" (default), "text", "pretty", "gcp", "ecs", "syslog", or
+	// "journald". "pretty" is a colored, human-friendly format for local development, "gcp" is JSON
+	// with the severity, trace, and sourceLocation fields Google Cloud Logging expects, and "ecs"
+	// is JSON with Elastic Common Schema field names, with AppName as service.name.
+	// "syslog" sends RFC 5424 messages to SyslogAddr and "journald" sends records to
+	// systemd-journald, both with the record level mapped to the message priority and
+	// the record rendered as text. Records are dropped while the server is unreachable,
+	// with reconnects backing off up to 30 seconds. Output is ignored for these formats.
 	Format string
+	// SyslogAddr is the syslog server address for the "syslog" format, such as
+	// "udp://host:514", "tcp://host:601", or "unix:///dev/log". Defaults to /dev/log.
+	SyslogAddr string
+	// TimeKey, LevelKey, and MessageKey rename the time, level, and msg fields of the
+	// "json" and "text" formats, such as to "timestamp", "severity", and "message".
+	TimeKey    string
+	LevelKey   string
+	MessageKey string
+	// TimeFormat is the time encoding of the "json" and "text" formats: "rfc3339" (seconds),
+	// "rfc3339nano", "unix" (seconds), "unixmilli", or "unixnano". The unix formats are
+	// written as numbers. Defaults to RFC 3339 with millisecond precision.
+	TimeFormat string
+	// AppName identifies the application in syslog, journald, and the "ecs" format.
+	// Defaults to the executable name.
+	AppName string
+	// GCPProject is the Google Cloud project ID used to qualify trace IDs in the "gcp" format.
+	// Defaults to the GOOGLE_CLOUD_PROJECT environment variable.
+	GCPProject string
+	// Sampling, if set, samples repeated records with the same level and message,
+	// so a tight error loop can't flood the log pipeline.
+	Sampling *SamplingOptions
+	// DedupWindow, if positive, collapses bursts of records with the same level and message
+	// within the window into the first record plus one "msg (repeated N times in Xs)" record.
+	DedupWindow time.Duration
+	// Destinations are additional outputs, each with its own format and level.
+	// Records are written to Output and to every destination whose level they meet.
+	Destinations []Destination
+	// Redactor, if set, masks sensitive attribute values in every destination.
+	Redactor *attr.Redactor
+	// Handler, if set, receives records instead of them being formatted to Output,
+	// such as to emit into another logging library. Level still applies, but Format,
+	// SyslogAddr, and Redactor do not.
+	Handler slog.Handler
+	// OnRecord is called for every record that passes sampling, e.g., to count log volume in a metric.
+	OnRecord func(level slog.Level)
+	// ErrorStacks adds a "stack" field with the caller's stack trace to Error-level records
+	// that don't already carry one from attr.ErrorWithStack.
+	ErrorStacks bool
+	// MaxRecordSize, if positive, is the approximate maximum size in bytes of a record's
+	// message and attributes. Longer records have their longest values truncated, marked
+	// with "...[truncated]", so one oversized payload can't break a log shipper.
+	MaxRecordSize int
+	// TracedDebug writes Debug records below Level when the context's trace is sampled,
+	// as reported by the function set with SetTraceSampledFunc, giving detailed logs for
+	// traced requests without debug logging globally. It applies to Output and to the
+	// destinations without a level of their own.
+	TracedDebug bool
+}
+
+// Destination is an additional log output with its own format and level.
+type Destination struct {
+	// Output is the writer to write logs to. Defaults to os.Stderr.
+	Output io.Writer
+	// Format is the output format, as in HandlerOptions.Format.
+	Format string
+	// Level is the minimum log level for this destination. Defaults to HandlerOptions.Level.
+	Level slog.Leveler
+	// SyslogAddr is the syslog server address for the "syslog" format, as in HandlerOptions.SyslogAddr.
+	SyslogAddr string
+	// Handler, if set, receives records instead of them being formatted to Output,
+	// as in HandlerOptions.Handler.
+	Handler slog.Handler
 }
 
 // NewHandler creates a new Handler with the given options.
@@ -38,25 +122,102 @@ func NewHandler(opts *HandlerOptions) *Handler {
 		}
 	}
 
-	output := opts.Output
-	if output == nil {
-		output = os.Stderr
+	o := *opts
+	if o.AppName == "" {
+		o.AppName = defaultAppName()
 	}
 
-	var inner slog.Handler
-	handlerOpts := &slog.HandlerOptions{
-		Level:     opts.Level,
-		AddSource: opts.AddSource,
+	var gate *traceGate
+	if opts.TracedDebug {
+		gate = &traceGate{}
 	}
 
-	if strings.ToLower(opts.Format) == "text" {
-		inner = slog.NewTextHandler(output, handlerOpts)
-	} else {
-		inner = slog.NewJSONHandler(output, handlerOpts)
+	replace := redactAttr(opts.Redactor)
+	newHandler := func(d Destination, level slog.Leveler) slog.Handler {
+		hopts := &slog.HandlerOptions{
+			Level:       level,
+			AddSource:   opts.AddSource,
+			ReplaceAttr: replace,
+		}
+		if gate == nil {
+			return newFormatHandler(d, &o, hopts)
+		}
+		hopts.Level = debugFloor{level}
+		return &tracedDebugHandler{
+			inner: newFormatHandler(d, &o, hopts),
+			level: level,
+			gate:  gate,
+		}
 	}
 
-	return &Handler{
-		inner: inner,
+	primary := Destination{Output: opts.Output, Format: opts.Format, SyslogAddr: opts.SyslogAddr, Handler: opts.Handler}
+	inner := newHandler(primary, opts.Level)
+	if len(opts.Destinations) > 0 {
+		handlers := []slog.Handler{inner}
+		for _, d := range opts.Destinations {
+			if d.Level != nil {
+				handlers = append(handlers, newFormatHandler(d, &o, &slog.HandlerOptions{
+					Level:       d.Level,
+					AddSource:   opts.AddSource,
+					ReplaceAttr: replace,
+				}))
+				continue
+			}
+			handlers = append(handlers, newHandler(d, opts.Level))
+		}
+		inner = &multiHandler{handlers: handlers}
+	}
+
+	h := &Handler{
+		inner:       inner,
+		onRecord:    opts.OnRecord,
+		errorStacks: opts.ErrorStacks,
+		gate:        gate,
+		maxSize:     opts.MaxRecordSize,
+	}
+	if opts.Sampling != nil {
+		h.sampler = newSampler(*opts.Sampling)
+	}
+	if opts.DedupWindow > 0 {
+		h.deduper = newDeduper(opts.DedupWindow)
+	}
+	return h
+}
+
+// newFormatHandler creates the slog handler for one destination's output and format,
+// using the format settings of o. Its level is ignored in favor of opts.Level.
+// If the syslog address is invalid, logs are written to the output in text format instead.
+func newFormatHandler(d Destination, o *HandlerOptions, opts *slog.HandlerOptions) slog.Handler {
+	if d.Handler != nil {
+		return &levelHandler{inner: d.Handler, level: opts.Level}
+	}
+
+	output := d.Output
+	if output == nil {
+		output = os.Stderr
+	}
+
+	switch strings.ToLower(d.Format) {
+	case "syslog":
+		out, err := newSyslogOutput(d.SyslogAddr, o.AppName)
+		if err != nil {
+			return slog.NewTextHandler(output, withFieldNames(withLevelNames(opts), o))
+		}
+		w := &leveledWriter{out: out}
+		return &leveledHandler{inner: slog.NewTextHandler(w, withoutTime(withLevelNames(opts))), w: w}
+	case "journald":
+		w := &leveledWriter{out: newJournaldOutput(o.AppName)}
+		return &leveledHandler{inner: slog.NewTextHandler(w, withoutTime(withLevelNames(opts))), w: w}
+	case "text":
+		return slog.NewTextHandler(output, withFieldNames(withLevelNames(opts), o))
+	case "pretty":
+		return newPrettyHandler(output, opts)
+	case "gcp":
+		return newGCPHandler(output, o.GCPProject, opts)
+	case "ecs":
+		return newECSHandler(output, o.AppName, opts)
+	default:
+		return slog.NewJSONHandler(output, withFieldNames(withLevelNames(opts), o))
 	}
 }
 
@@ -65,6 +226,26 @@ func (h *Handler) SetTraceContextFunc(fn func(ctx context.Context) (traceID, spa
 	h.getTraceCtx = fn
 }
 
+// SetContextAttrsFunc sets the function used to extract additional attributes from context,
+// such as those of the current operation. Attributes whose keys the record already has are skipped.
+func (h *Handler) SetContextAttrsFunc(fn func(ctx context.Context) []slog.Attr) {
+	h.getCtxAttrs = fn
+}
+
+// SetErrorStackFunc sets the function called with each stack trace captured for an
+// Error-level record, such as to record it on the current span.
+func (h *Handler) SetErrorStackFunc(fn func(ctx context.Context, msg string, stack attr.Stack)) {
+	h.onStack = fn
+}
+
+// SetTraceSampledFunc sets the function used to report whether the context's trace is
+// sampled, for HandlerOptions.TracedDebug.
+func (h *Handler) SetTraceSampledFunc(fn func(ctx context.Context) bool) {
+	if h.gate != nil {
+		h.gate.sampled = fn
+	}
+}
+
 // Enabled reports whether the handler handles records at the given level.
 func (h *Handler) Enabled(ctx context.Context, level slog.Level) bool {
 	return h.inner.Enabled(ctx, level)
@@ -72,6 +253,27 @@ func (h *Handler) Enabled(ctx context.Context, level slog.Level) bool {
 
 // Handle handles the Record.
 func (h *Handler) Handle(ctx context.Context, r slog.Record) error {
+	if h.sampler != nil && !h.sampler.allow(time.Now(), r.Level, r.Message) {
+		return nil
+	}
+	if h.deduper != nil {
+		summary, ok := h.deduper.observe(time.Now(), h, ctx, r)
+		if summary != nil {
+			_ = summary.emit()
+		}
+		if !ok {
+			return nil
+		}
+	}
+	return h.handle(ctx, r)
+}
+
+// handle writes a record that passed sampling and deduplication.
+func (h *Handler) handle(ctx context.Context, r slog.Record) error {
+	if h.onRecord != nil {
+		h.onRecord(r.Level)
+	}
+
 	// Inject trace context if available
 	if h.getTraceCtx != nil {
 		traceID, spanID := h.getTraceCtx(ctx)
@@ -83,6 +285,37 @@ func (h *Handler) Handle(ctx context.Context, r slog.Record) error {
 		}
 	}
 
+	// Inject context attributes, letting the call site's attributes take precedence
+	if h.getCtxAttrs != nil {
+		if ctxAttrs := h.getCtxAttrs(ctx); len(ctxAttrs) > 0 {
+			keys := make(map[string]struct{}, r.NumAttrs())
+			r.Attrs(func(a slog.Attr) bool {
+				keys[a.Key] = struct{}{}
+				return true
+			})
+			for _, a := range ctxAttrs {
+				if _, ok := keys[a.Key]; !ok {
+					r.AddAttrs(a)
+				}
+			}
+		}
+	}
+
+	// Capture a stack trace for errors, starting at the logging call site
+	if h.errorStacks && r.Level >= slog.LevelError && r.PC != 0 && !hasStack(r) {
+		if stack := stackFrom(r.PC); len(stack) > 0 {
+			r.AddAttrs(slog.String("stack", stack.String()))
+			if h.onStack != nil {
+				h.onStack(ctx, r.Message, stack)
+			}
+		}
+	}
+
+	// Truncate oversized values last, so injected attributes are accounted for
+	if h.maxSize > 0 {
+		r = limitRecord(r, h.maxSize)
+	}
+
 	// Note: handler-level attributes are already added by inner.WithAttrs()
 	// in the WithAttrs method below. We don't need to add them again here
 	// as that would cause duplication.
@@ -90,6 +323,180 @@ func (h *Handler) Handle(ctx context.Context, r slog.Record) error {
 	return h.inner.Handle(ctx, r)
 }
 
+// hasStack reports whether the record already has a stack trace,
+// either as a "stack" attribute or within a group such as an attr.ErrorWithStack error.
+func hasStack(r slog.Record) bool {
+	found := false
+	r.Attrs(func(a slog.Attr) bool {
+		if a.Key == "stack" {
+			found = true
+		} else if a.Value.Kind() == slog.KindGroup {
+			for _, ga := range a.Value.Group() {
+				if ga.Key == "stack" {
+					found = true
+				}
+			}
+		}
+		return !found
+	})
+	return found
+}
+
+// stackFrom returns the current call stack starting at the frame of pc,
+// trimming the logging frames above it. It returns nil if pc is not on the stack.
+func stackFrom(pc uintptr) attr.Stack {
+	var pcs [64]uintptr
+	n := runtime.Callers(2, pcs[:])
+	for i, p := range pcs[:n] {
+		if p == pc {
+			return attr.Stack(pcs[i:n:n])
+		}
+	}
+	return nil
+}
+
+// levelHandler applies a minimum level on top of another handler's own.
+type levelHandler struct {
+	inner slog.Handler
+	level slog.Leveler
+}
+
+func (h *levelHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	if h.level != nil && level < h.level.Level() {
+		return false
+	}
+	return h.inner.Enabled(ctx, level)
+}
+
+func (h *levelHandler) Handle(ctx context.Context, r slog.Record) error {
+	return h.inner.Handle(ctx, r)
+}
+
+func (h *levelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &levelHandler{inner: h.inner.WithAttrs(attrs), level: h.level}
+}
+
+func (h *levelHandler) WithGroup(name string) slog.Handler {
+	return &levelHandler{inner: h.inner.WithGroup(name), level: h.level}
+}
+
+// withLevelNames returns handler options that render LevelFatal as "FATAL"
+// rather than slog's default "ERROR+4".
+func withLevelNames(opts *slog.HandlerOptions) *slog.HandlerOptions {
+	o := *opts
+	replace := opts.ReplaceAttr
+	o.ReplaceAttr = func(groups []string, a slog.Attr) slog.Attr {
+		if len(groups) == 0 && a.Key == slog.LevelKey {
+			if level, ok := a.Value.Any().(slog.Level); ok && level == LevelFatal {
+				return slog.String(slog.LevelKey, "FATAL")
+			}
+		}
+		if replace != nil {
+			return replace(groups, a)
+		}
+		return a
+	}
+	return &o
+}
+
+// withFieldNames returns handler options that rename the built-in time, level, and message
+// attributes and encode the time as configured by o. It must be applied after withLevelNames.
+func withFieldNames(opts *slog.HandlerOptions, o *HandlerOptions) *slog.HandlerOptions {
+	if o.TimeKey == "" && o.LevelKey == "" && o.MessageKey == "" && o.TimeFormat == "" {
+		return opts
+	}
+	h := *opts
+	replace := opts.ReplaceAttr
+	h.ReplaceAttr = func(groups []string, a slog.Attr) slog.Attr {
+		if replace != nil {
+			a = replace(groups, a)
+		}
+		if len(groups) > 0 {
+			return a
+		}
+		switch a.Key {
+		case slog.TimeKey:
+			if a.Value.Kind() != slog.KindTime {
+				break
+			}
+			a.Value = formatTime(a.Value.Time(), o.TimeFormat)
+			if o.TimeKey != "" {
+				a.Key = o.TimeKey
+			}
+		case slog.LevelKey:
+			if o.LevelKey != "" {
+				a.Key = o.LevelKey
+			}
+		case slog.MessageKey:
+			if o.MessageKey != "" {
+				a.Key = o.MessageKey
+			}
+		}
+		return a
+	}
+	return &h
+}
+
+// formatTime encodes t in the given HandlerOptions.TimeFormat.
+func formatTime(t time.Time, format string) slog.Value {
+	switch strings.ToLower(format) {
+	case "rfc3339":
+		return slog.StringValue(t.Format(time.RFC3339))
+	case "rfc3339nano":
+		return slog.StringValue(t.Format(time.RFC3339Nano))
+	case "unix":
+		return slog.Int64Value(t.Unix())
+	case "unixmilli":
+		return slog.Int64Value(t.UnixMilli())
+	case "unixnano":
+		return slog.Int64Value(t.UnixNano())
+	default:
+		return slog.TimeValue(t)
+	}
+}
+
+// withoutTime returns handler options that omit the time attribute,
+// for outputs that timestamp records themselves.
+func withoutTime(opts *slog.HandlerOptions) *slog.HandlerOptions {
+	o := *opts
+	replace := opts.ReplaceAttr
+	o.ReplaceAttr = func(groups []string, a slog.Attr) slog.Attr {
+		if len(groups) == 0 && a.Key == slog.TimeKey {
+			return slog.Attr{}
+		}
+		if replace != nil {
+			return replace(groups, a)
+		}
+		return a
+	}
+	return &o
+}
+
+// redactAttr returns a ReplaceAttr function that masks sensitive attributes,
+// or nil if r is nil. The built-in time, level, message, and source attributes are left as is.
+func redactAttr(r *attr.Redactor) func(groups []string, a slog.Attr) slog.Attr {
+	if r == nil {
+		return nil
+	}
+	return func(groups []string, a slog.Attr) slog.Attr {
+		if len(groups) == 0 {
+			switch a.Key {
+			case slog.TimeKey, slog.LevelKey, slog.MessageKey, slog.SourceKey:
+				return a
+			}
+		}
+		if r.RedactKey(a.Key) {
+			return slog.String(a.Key, attr.Redacted)
+		}
+		if a.Value.Kind() == slog.KindString {
+			if s := r.RedactString(a.Value.String()); s != a.Value.String() {
+				return slog.String(a.Key, s)
+			}
+		}
+		return a
+	}
+}
+
 // WithAttrs returns a new Handler with the given attributes added.
 func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
 	newAttrs := make([]slog.Attr, len(h.attrs), len(h.attrs)+len(attrs))
@@ -101,6 +508,14 @@ func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
 		attrs:       newAttrs,
 		groups:      h.groups,
 		getTraceCtx: h.getTraceCtx,
+		getCtxAttrs: h.getCtxAttrs,
+		onStack:     h.onStack,
+		onRecord:    h.onRecord,
+		sampler:     h.sampler,
+		deduper:     h.deduper,
+		errorStacks: h.errorStacks,
+		gate:        h.gate,
+		maxSize:     h.maxSize,
 	}
 }
 
@@ -115,6 +530,14 @@ func (h *Handler) WithGroup(name string) slog.Handler {
 		attrs:       h.attrs,
 		groups:      newGroups,
 		getTraceCtx: h.getTraceCtx,
+		getCtxAttrs: h.getCtxAttrs,
+		onStack:     h.onStack,
+		onRecord:    h.onRecord,
+		sampler:     h.sampler,
+		deduper:     h.deduper,
+		errorStacks: h.errorStacks,
+		gate:        h.gate,
+		maxSize:     h.maxSize,
 	}
 }
 
@@ -122,6 +545,13 @@ func (h *Handler) WithGroup(name string) slog.Handler {
 func AttrToSlog(a attr.Attr) slog.Attr {
 	switch a.Value.Kind() {
 	case attr.KindString:
+		var se *attr.StackError
+		if errors.As(a.Value.Err(), &se) {
+			return slog.Group(a.Key,
+				slog.String("message", a.Value.AsString()),
+				slog.String("stack", se.Stack().String()),
+			)
+		}
 		return slog.String(a.Key, a.Value.AsString())
 	case attr.KindInt64:
 		return slog.Int64(a.Key, a.Value.AsInt64())
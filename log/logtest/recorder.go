@@ -0,0 +1,177 @@
+// Package logtest provides an in-memory slog.Handler for asserting on log records in tests.
+//
+// Use it as Config.LogHandler to capture what bedrock logs:
+//
+//	rec := logtest.NewRecorder()
+//	ctx, close := bedrock.Init(ctx, bedrock.WithConfig(bedrock.Config{LogHandler: rec}))
+//	defer close()
+//
+//	bedrock.Info(ctx, "user created", attr.String("user_id", "42"))
+//	if !rec.HasRecord(slog.LevelInfo, "user created", attr.String("user_id", "42")) {
+//	    t.Errorf("expected user created record, got %v", rec.Records())
+//	}
+package logtest
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/kzs0/bedrock/attr"
+	blog "github.com/kzs0/bedrock/log"
+)
+
+// Record is a log record captured by a Recorder.
+type Record struct {
+	Time    time.Time
+	Level   slog.Level
+	Message string
+	// Attrs are the record's attributes, including those added with WithAttrs.
+	// Attributes within groups have dotted keys, such as "request.method".
+	Attrs []slog.Attr
+}
+
+// Attr returns the value of the attribute with the given key.
+func (r Record) Attr(key string) (slog.Value, bool) {
+	for _, a := range r.Attrs {
+		if a.Key == key {
+			return a.Value, true
+		}
+	}
+	return slog.Value{}, false
+}
+
+// Recorder is a slog.Handler that stores the records it handles. It handles all levels.
+// Handlers derived from it with WithAttrs and WithGroup record into the same Recorder.
+// It is safe for concurrent use.
+type Recorder struct {
+	store  *store
+	attrs  []slog.Attr
+	prefix string // group prefix for subsequent attributes
+}
+
+type store struct {
+	mu      sync.Mutex
+	records []Record
+}
+
+// NewRecorder creates an empty Recorder.
+func NewRecorder() *Recorder {
+	return &Recorder{store: &store{}}
+}
+
+// Enabled always returns true.
+func (r *Recorder) Enabled(context.Context, slog.Level) bool {
+	return true
+}
+
+// Handle stores the record.
+func (r *Recorder) Handle(_ context.Context, rec slog.Record) error {
+	attrs := make([]slog.Attr, len(r.attrs), len(r.attrs)+rec.NumAttrs())
+	copy(attrs, r.attrs)
+	rec.Attrs(func(a slog.Attr) bool {
+		attrs = appendAttr(attrs, r.prefix, a)
+		return true
+	})
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	r.store.records = append(r.store.records, Record{
+		Time:    rec.Time,
+		Level:   rec.Level,
+		Message: rec.Message,
+		Attrs:   attrs,
+	})
+	return nil
+}
+
+// WithAttrs returns a handler that records into r, adding attrs to every record.
+func (r *Recorder) WithAttrs(attrs []slog.Attr) slog.Handler {
+	r2 := *r
+	r2.attrs = make([]slog.Attr, len(r.attrs), len(r.attrs)+len(attrs))
+	copy(r2.attrs, r.attrs)
+	for _, a := range attrs {
+		r2.attrs = appendAttr(r2.attrs, r.prefix, a)
+	}
+	return &r2
+}
+
+// WithGroup returns a handler that records into r, qualifying subsequent attributes with name.
+func (r *Recorder) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return r
+	}
+	r2 := *r
+	r2.prefix = r.prefix + name + "."
+	return &r2
+}
+
+// Records returns a copy of the recorded records, oldest first.
+func (r *Recorder) Records() []Record {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	return append([]Record(nil), r.store.records...)
+}
+
+// Len returns the number of recorded records.
+func (r *Recorder) Len() int {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	return len(r.store.records)
+}
+
+// Reset discards the recorded records.
+func (r *Recorder) Reset() {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	r.store.records = nil
+}
+
+// HasRecord reports whether a record with the given level and message was recorded
+// that has all of the given attributes. The record may have other attributes too.
+func (r *Recorder) HasRecord(level slog.Level, msg string, attrs ...attr.Attr) bool {
+	_, ok := r.FindRecord(level, msg, attrs...)
+	return ok
+}
+
+// FindRecord returns the first record matching the given level, message, and attributes,
+// as in HasRecord.
+func (r *Recorder) FindRecord(level slog.Level, msg string, attrs ...attr.Attr) (Record, bool) {
+	want := blog.AttrsToSlog(attrs)
+	for _, rec := range r.Records() {
+		if rec.Level == level && rec.Message == msg && hasAttrs(rec, want) {
+			return rec, true
+		}
+	}
+	return Record{}, false
+}
+
+// hasAttrs reports whether rec has every attribute in want.
+func hasAttrs(rec Record, want []slog.Attr) bool {
+	for _, w := range want {
+		v, ok := rec.Attr(w.Key)
+		if !ok || !v.Equal(w.Value.Resolve()) {
+			return false
+		}
+	}
+	return true
+}
+
+// appendAttr appends a, flattening groups into dotted keys.
+func appendAttr(attrs []slog.Attr, prefix string, a slog.Attr) []slog.Attr {
+	a.Value = a.Value.Resolve()
+	if a.Value.Kind() != slog.KindGroup {
+		if a.Key == "" {
+			return attrs
+		}
+		return append(attrs, slog.Attr{Key: prefix + a.Key, Value: a.Value})
+	}
+	if a.Key != "" {
+		prefix += a.Key + "."
+	}
+	for _, ga := range a.Value.Group() {
+		attrs = appendAttr(attrs, prefix, ga)
+	}
+	return attrs
+}
@@ -0,0 +1,117 @@
+package logtest
+
+import (
+	"log/slog"
+	"sync"
+	"testing"
+
+	"github.com/kzs0/bedrock/attr"
+)
+
+func TestRecorderCapturesRecords(t *testing.T) {
+	rec := NewRecorder()
+	logger := slog.New(rec)
+
+	logger.Debug("starting")
+	logger.With("request_id", "abc").WithGroup("http").Info("handled", "method", "GET", slog.Group("route", "name", "users"))
+
+	records := rec.Records()
+	if len(records) != 2 || rec.Len() != 2 {
+		t.Fatalf("expected 2 records, got %d", len(records))
+	}
+	if records[0].Level != slog.LevelDebug || records[0].Message != "starting" {
+		t.Errorf("expected the debug record first, got %v %q", records[0].Level, records[0].Message)
+	}
+	if records[1].Time.IsZero() {
+		t.Error("expected the record time")
+	}
+
+	for key, want := range map[string]string{
+		"request_id":      "abc",
+		"http.method":     "GET",
+		"http.route.name": "users",
+	} {
+		if v, ok := records[1].Attr(key); !ok || v.String() != want {
+			t.Errorf("expected %s = %q, got %q", key, want, v.String())
+		}
+	}
+
+	// Records returns a copy
+	records[0].Message = "changed"
+	if rec.Records()[0].Message != "starting" {
+		t.Error("expected Records to return a copy")
+	}
+
+	rec.Reset()
+	if rec.Len() != 0 {
+		t.Errorf("expected no records after Reset, got %d", rec.Len())
+	}
+}
+
+func TestRecorderFindRecord(t *testing.T) {
+	rec := NewRecorder()
+	logger := slog.New(rec)
+
+	logger.Info("user created", "user_id", "41")
+	logger.Info("user created", "user_id", "42", "admin", true)
+	logger.Warn("user created", "user_id", "43")
+
+	r, ok := rec.FindRecord(slog.LevelInfo, "user created", attr.String("user_id", "42"))
+	if !ok {
+		t.Fatal("expected a matching record")
+	}
+	if v, _ := r.Attr("admin"); !v.Bool() {
+		t.Error("expected the record with the matching attribute")
+	}
+
+	if !rec.HasRecord(slog.LevelInfo, "user created") {
+		t.Error("expected a match without attributes")
+	}
+	for _, tt := range []struct {
+		name  string
+		level slog.Level
+		msg   string
+		attrs []attr.Attr
+	}{
+		{"level", slog.LevelError, "user created", nil},
+		{"message", slog.LevelInfo, "user deleted", nil},
+		{"value", slog.LevelInfo, "user created", []attr.Attr{attr.String("user_id", "43")}},
+		{"key", slog.LevelInfo, "user created", []attr.Attr{attr.String("email", "a@b.c")}},
+	} {
+		if rec.HasRecord(tt.level, tt.msg, tt.attrs...) {
+			t.Errorf("expected no match with a different %s", tt.name)
+		}
+	}
+
+	if _, ok := (Record{}).Attr("missing"); ok {
+		t.Error("expected no attribute on an empty record")
+	}
+}
+
+func TestRecorderConcurrent(t *testing.T) {
+	rec := NewRecorder()
+
+	var wg sync.WaitGroup
+	for i := range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			logger := slog.New(rec).With("worker", i)
+			for range 100 {
+				logger.Info("tick")
+				_ = rec.Records()
+				_ = rec.HasRecord(slog.LevelInfo, "tick", attr.Int("worker", i))
+			}
+		}()
+	}
+	wg.Wait()
+
+	if rec.Len() != 1000 {
+		t.Errorf("expected 1000 records, got %d", rec.Len())
+	}
+	for i := range 10 {
+		if !rec.HasRecord(slog.LevelInfo, "tick", attr.Int("worker", i)) {
+			t.Errorf("expected records from worker %d", i)
+		}
+	}
+}
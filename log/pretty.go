@@ -0,0 +1,234 @@
+package log
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// ANSI escape codes used by the pretty format.
+const (
+	ansiReset   = "\x1b[0m"
+	ansiDim     = "\x1b[2m"
+	ansiRed     = "\x1b[31m"
+	ansiGreen   = "\x1b[32m"
+	ansiYellow  = "\x1b[33m"
+	ansiMagenta = "\x1b[35m"
+	ansiCyan    = "\x1b[36m"
+)
+
+// prettyMessageWidth is the width messages are padded to, so attributes line up.
+const prettyMessageWidth = 40
+
+// prettyHandler writes human-friendly, colored records for local development:
+//
+//	15:04:05.000 INF [4bf92f35] user logged in                 user_id=123 duration=1.5ms
+//
+// Colors are disabled when the NO_COLOR environment variable is set.
+type prettyHandler struct {
+	mu     *sync.Mutex
+	w      io.Writer
+	opts   slog.HandlerOptions
+	color  bool
+	attrs  []byte // preformatted attributes from WithAttrs
+	prefix string // group prefix for subsequent attributes
+	groups []string
+}
+
+func newPrettyHandler(w io.Writer, opts *slog.HandlerOptions) *prettyHandler {
+	_, noColor := os.LookupEnv("NO_COLOR")
+	return &prettyHandler{
+		mu:    &sync.Mutex{},
+		w:     w,
+		opts:  *opts,
+		color: !noColor,
+	}
+}
+
+func (h *prettyHandler) Enabled(_ context.Context, level slog.Level) bool {
+	minLevel := slog.LevelInfo
+	if h.opts.Level != nil {
+		minLevel = h.opts.Level.Level()
+	}
+	return level >= minLevel
+}
+
+func (h *prettyHandler) Handle(_ context.Context, r slog.Record) error {
+	buf := make([]byte, 0, 256)
+
+	if !r.Time.IsZero() {
+		buf = h.colorize(buf, ansiDim, r.Time.Format("15:04:05.000"))
+		buf = append(buf, ' ')
+	}
+	buf = h.appendLevel(buf, r.Level)
+
+	// Pull the trace ID inline; it is added to the record by Handler.
+	var traceID string
+	var attrs []slog.Attr
+	r.Attrs(func(a slog.Attr) bool {
+		if a.Key == "trace_id" && traceID == "" {
+			traceID = a.Value.String()
+			return true
+		}
+		attrs = append(attrs, a)
+		return true
+	})
+	if traceID != "" {
+		short := traceID
+		if len(short) > 8 {
+			short = short[:8]
+		}
+		buf = append(buf, ' ')
+		buf = h.colorize(buf, ansiDim, "["+short+"]")
+	}
+
+	buf = append(buf, ' ')
+	buf = append(buf, r.Message...)
+
+	if len(h.attrs) > 0 || len(attrs) > 0 {
+		if pad := prettyMessageWidth - len(r.Message); pad > 0 {
+			buf = append(buf, strings.Repeat(" ", pad)...)
+		}
+		buf = append(buf, h.attrs...)
+		for _, a := range attrs {
+			buf = h.appendAttr(buf, h.prefix, h.groups, a)
+		}
+	}
+
+	if h.opts.AddSource && r.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		if frame.File != "" {
+			buf = append(buf, ' ')
+			buf = h.colorize(buf, ansiDim, filepath.Base(frame.File)+":"+strconv.Itoa(frame.Line))
+		}
+	}
+	buf = append(buf, '\n')
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := h.w.Write(buf)
+	return err
+}
+
+func (h *prettyHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	h2 := *h
+	h2.attrs = append([]byte(nil), h.attrs...)
+	for _, a := range attrs {
+		h2.attrs = h.appendAttr(h2.attrs, h.prefix, h.groups, a)
+	}
+	return &h2
+}
+
+func (h *prettyHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	h2.prefix = h.prefix + name + "."
+	h2.groups = append(append([]string(nil), h.groups...), name)
+	return &h2
+}
+
+// appendLevel appends a short, colored level name.
+func (h *prettyHandler) appendLevel(buf []byte, level slog.Level) []byte {
+	switch {
+	case level >= LevelFatal:
+		return h.colorize(buf, ansiRed, "FTL")
+	case level >= slog.LevelError:
+		return h.colorize(buf, ansiRed, "ERR")
+	case level >= slog.LevelWarn:
+		return h.colorize(buf, ansiYellow, "WRN")
+	case level >= slog.LevelInfo:
+		return h.colorize(buf, ansiGreen, "INF")
+	default:
+		return h.colorize(buf, ansiMagenta, "DBG")
+	}
+}
+
+// appendAttr appends " key=value", flattening groups into dotted keys.
+func (h *prettyHandler) appendAttr(buf []byte, prefix string, groups []string, a slog.Attr) []byte {
+	a.Value = a.Value.Resolve()
+	if a.Value.Kind() != slog.KindGroup && h.opts.ReplaceAttr != nil {
+		a = h.opts.ReplaceAttr(groups, a)
+		a.Value = a.Value.Resolve()
+	}
+	if a.Equal(slog.Attr{}) {
+		return buf
+	}
+
+	if a.Value.Kind() == slog.KindGroup {
+		group := a.Value.Group()
+		if len(group) == 0 {
+			return buf
+		}
+		if a.Key != "" {
+			prefix += a.Key + "."
+			groups = append(groups[:len(groups):len(groups)], a.Key)
+		}
+		for _, ga := range group {
+			buf = h.appendAttr(buf, prefix, groups, ga)
+		}
+		return buf
+	}
+
+	buf = append(buf, ' ')
+	buf = h.colorize(buf, ansiCyan, prefix+a.Key+"=")
+	return appendPrettyValue(buf, a.Value)
+}
+
+// colorize appends s wrapped in the given color, if colors are enabled.
+func (h *prettyHandler) colorize(buf []byte, color, s string) []byte {
+	if !h.color {
+		return append(buf, s...)
+	}
+	buf = append(buf, color...)
+	buf = append(buf, s...)
+	return append(buf, ansiReset...)
+}
+
+// appendPrettyValue appends v, quoting strings that contain spaces or special characters.
+func appendPrettyValue(buf []byte, v slog.Value) []byte {
+	var s string
+	switch v.Kind() {
+	case slog.KindString:
+		s = v.String()
+	case slog.KindTime:
+		s = v.Time().Format("15:04:05.000")
+	case slog.KindAny:
+		if err, ok := v.Any().(error); ok {
+			s = err.Error()
+		} else {
+			s = fmt.Sprint(v.Any())
+		}
+	default:
+		return append(buf, v.String()...)
+	}
+	if needsQuoting(s) {
+		return strconv.AppendQuote(buf, s)
+	}
+	return append(buf, s...)
+}
+
+// needsQuoting reports whether s is empty or contains spaces, quotes, '=', or non-printable characters.
+func needsQuoting(s string) bool {
+	if s == "" {
+		return true
+	}
+	for _, r := range s {
+		if unicode.IsSpace(r) || r == '"' || r == '=' || !unicode.IsPrint(r) {
+			return true
+		}
+	}
+	return false
+}
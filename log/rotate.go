@@ -0,0 +1,295 @@
+package log
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RotateOptions configures a RotatingFile.
+type RotateOptions struct {
+	// MaxSizeMB is the size in megabytes at which the file is rotated. Defaults to 100.
+	MaxSizeMB int
+	// MaxBackups is the number of rotated files to keep. Zero keeps all of them.
+	MaxBackups int
+	// MaxAge removes rotated files older than this, and rotates the file once it has been
+	// open this long, so logs written slowly don't outlive it in the current file.
+	// Zero keeps them regardless of age.
+	MaxAge time.Duration
+	// Compress gzips rotated files.
+	Compress bool
+}
+
+// backupTimeFormat is the timestamp added to rotated file names. It sorts lexically.
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+// RotatingFile is an io.WriteCloser that writes to a file and rotates it once it
+// reaches a maximum size or age. Rotated files are renamed with a timestamp
+// (e.g., app-2024-01-02T15-04-05.000.log, with a -1, -2, ... suffix if rotated again
+// within the millisecond), optionally gzipped, and pruned by count and age in the
+// background.
+type RotatingFile struct {
+	path string
+	opts RotateOptions
+
+	mu       sync.Mutex
+	file     *os.File
+	size     int64
+	openedAt time.Time
+
+	wg   sync.WaitGroup // background compression and pruning
+	bgMu sync.Mutex     // serializes background work so pruning never races compression
+}
+
+// NewRotatingFile opens path for appending, creating it and its directory if needed.
+func NewRotatingFile(path string, opts RotateOptions) (*RotatingFile, error) {
+	if opts.MaxSizeMB <= 0 {
+		opts.MaxSizeMB = 100
+	}
+
+	f := &RotatingFile{path: path, opts: opts}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Write writes p to the file, rotating it first if p would exceed the maximum size or
+// the file is older than MaxAge. If rotation fails, p is written to the current file,
+// and rotation is retried on the next write.
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return 0, os.ErrClosed
+	}
+
+	if f.shouldRotate(len(p)) {
+		if err := f.rotate(); err != nil && f.file == nil {
+			return 0, err
+		}
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// Rotate rotates the file immediately.
+func (f *RotatingFile) Rotate() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return os.ErrClosed
+	}
+	return f.rotate()
+}
+
+// Close closes the file and waits for background compression and pruning to finish.
+func (f *RotatingFile) Close() error {
+	f.mu.Lock()
+	var err error
+	if f.file != nil {
+		err = f.file.Close()
+		f.file = nil
+	}
+	f.mu.Unlock()
+
+	f.wg.Wait()
+	return err
+}
+
+// shouldRotate reports whether the file should be rotated before writing n bytes.
+// Must be called with mu held.
+func (f *RotatingFile) shouldRotate(n int) bool {
+	if f.size == 0 {
+		return false
+	}
+	if f.size+int64(n) > f.maxSize() {
+		return true
+	}
+	return f.opts.MaxAge > 0 && time.Since(f.openedAt) >= f.opts.MaxAge
+}
+
+// maxSize returns the maximum file size in bytes.
+func (f *RotatingFile) maxSize() int64 {
+	return int64(f.opts.MaxSizeMB) * 1024 * 1024
+}
+
+// open opens the current file for appending. Must be called with mu held.
+func (f *RotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(f.path), 0o755); err != nil {
+		return fmt.Errorf("log: failed to create log directory: %w", err)
+	}
+
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("log: failed to open log file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return fmt.Errorf("log: failed to stat log file: %w", err)
+	}
+
+	f.file = file
+	f.size = info.Size()
+	f.openedAt = time.Now()
+	return nil
+}
+
+// rotate renames the current file to a backup and opens a new one. If that fails, the
+// current file is reopened, so writes continue, and the error is returned; f.file is nil
+// only if no file could be opened. Must be called with mu held.
+func (f *RotatingFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		f.file = nil
+		return errors.Join(fmt.Errorf("log: failed to close log file: %w", err), f.open())
+	}
+	f.file = nil
+
+	backup := f.backupName(time.Now())
+	if err := os.Rename(f.path, backup); err != nil {
+		return errors.Join(fmt.Errorf("log: failed to rotate log file: %w", err), f.open())
+	}
+	if err := f.open(); err != nil {
+		// Put the old file back rather than stop logging
+		if os.Rename(backup, f.path) == nil {
+			_ = f.open()
+		}
+		return err
+	}
+
+	f.wg.Add(1)
+	go func() {
+		defer f.wg.Done()
+		f.bgMu.Lock()
+		defer f.bgMu.Unlock()
+
+		if f.opts.Compress {
+			_ = compressFile(backup)
+		}
+		f.prune()
+	}()
+	return nil
+}
+
+// backupName returns an unused rotated file name for the given time, adding a counter
+// if a backup from the same millisecond exists, compressed or not.
+func (f *RotatingFile) backupName(t time.Time) string {
+	ext := filepath.Ext(f.path)
+	base := strings.TrimSuffix(f.path, ext) + "-" + t.Format(backupTimeFormat)
+	name := base + ext
+	for i := 1; exists(name) || exists(name+".gz"); i++ {
+		name = base + "-" + strconv.Itoa(i) + ext
+	}
+	return name
+}
+
+// exists reports whether a file exists at path.
+func exists(path string) bool {
+	_, err := os.Lstat(path)
+	return err == nil
+}
+
+// prune removes rotated files beyond MaxBackups or older than MaxAge.
+func (f *RotatingFile) prune() {
+	if f.opts.MaxBackups <= 0 && f.opts.MaxAge <= 0 {
+		return
+	}
+
+	ext := filepath.Ext(f.path)
+	prefix := filepath.Base(strings.TrimSuffix(f.path, ext)) + "-"
+	dir := filepath.Dir(f.path)
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+
+	type backup struct {
+		name string
+		time time.Time
+		seq  int // counter suffix of backups rotated within the same millisecond
+	}
+	var backups []backup
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		stamp := strings.TrimSuffix(strings.TrimSuffix(strings.TrimPrefix(name, prefix), ".gz"), ext)
+		if len(stamp) < len(backupTimeFormat) {
+			continue
+		}
+		t, err := time.ParseInLocation(backupTimeFormat, stamp[:len(backupTimeFormat)], time.Local)
+		if err != nil {
+			continue
+		}
+		var seq int
+		if rest := stamp[len(backupTimeFormat):]; rest != "" {
+			if seq, err = strconv.Atoi(strings.TrimPrefix(rest, "-")); err != nil || !strings.HasPrefix(rest, "-") {
+				continue
+			}
+		}
+		backups = append(backups, backup{name: name, time: t, seq: seq})
+	}
+
+	// Newest first
+	slices.SortFunc(backups, func(a, b backup) int {
+		if c := b.time.Compare(a.time); c != 0 {
+			return c
+		}
+		return b.seq - a.seq
+	})
+
+	cutoff := time.Now().Add(-f.opts.MaxAge)
+	for i, b := range backups {
+		tooMany := f.opts.MaxBackups > 0 && i >= f.opts.MaxBackups
+		tooOld := f.opts.MaxAge > 0 && b.time.Before(cutoff)
+		if tooMany || tooOld {
+			_ = os.Remove(filepath.Join(dir, b.name))
+		}
+	}
+}
+
+// compressFile gzips path into path.gz and removes the original.
+func compressFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = src.Close() }()
+
+	dst, err := os.OpenFile(path+".gz", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+
+	zw := gzip.NewWriter(dst)
+	if _, err := io.Copy(zw, src); err != nil {
+		_ = dst.Close()
+		_ = os.Remove(path + ".gz")
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		_ = dst.Close()
+		_ = os.Remove(path + ".gz")
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		_ = os.Remove(path + ".gz")
+		return err
+	}
+	return os.Remove(path)
+}
@@ -0,0 +1,187 @@
+package log
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// backups returns the names of the rotated files next to path.
+func backups(t *testing.T, path string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		if e.Name() != filepath.Base(path) {
+			names = append(names, e.Name())
+		}
+	}
+	return names
+}
+
+func TestRotatingFileSize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	f, err := NewRotatingFile(path, RotateOptions{MaxSizeMB: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	line := []byte(strings.Repeat("x", 1023) + "\n")
+	for range 1024 {
+		if _, err := f.Write(line); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := len(backups(t, path)); n != 0 {
+		t.Fatalf("expected no rotation at exactly the maximum size, got %d backups", n)
+	}
+
+	if _, err := f.Write(line); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(backups(t, path)); n != 1 {
+		t.Fatalf("expected 1 backup after exceeding the maximum size, got %d", n)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size() != int64(len(line)) {
+		t.Errorf("expected the new file to hold only the last write, got %d bytes", info.Size())
+	}
+}
+
+func TestRotatingFileBackupNamesUnique(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	f, err := NewRotatingFile(path, RotateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	// Rotations within the same millisecond must not overwrite each other
+	for i := range 5 {
+		if _, err := f.Write([]byte{byte('a' + i)}); err != nil {
+			t.Fatal(err)
+		}
+		if err := f.Rotate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	names := backups(t, path)
+	if len(names) != 5 {
+		t.Fatalf("expected 5 backups, got %v", names)
+	}
+	seen := make(map[string]bool)
+	for _, name := range names {
+		data, err := os.ReadFile(filepath.Join(filepath.Dir(path), name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		seen[string(data)] = true
+	}
+	if len(seen) != 5 {
+		t.Errorf("expected each backup to keep its own contents, got %v", seen)
+	}
+}
+
+func TestRotatingFileMaxBackups(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	f, err := NewRotatingFile(path, RotateOptions{MaxBackups: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for range 5 {
+		if _, err := f.Write([]byte("line\n")); err != nil {
+			t.Fatal(err)
+		}
+		if err := f.Rotate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Close waits for background pruning
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if names := backups(t, path); len(names) != 2 {
+		t.Errorf("expected 2 backups to be kept, got %v", names)
+	}
+}
+
+func TestRotatingFileMaxAge(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+
+	old := filepath.Join(dir, "app-"+time.Now().Add(-48*time.Hour).Format(backupTimeFormat)+".log")
+	if err := os.WriteFile(old, []byte("old\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := NewRotatingFile(path, RotateOptions{MaxAge: 24 * time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Write([]byte("first\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	// A file open longer than MaxAge is rotated on the next write, however small
+	f.mu.Lock()
+	f.openedAt = time.Now().Add(-25 * time.Hour)
+	f.mu.Unlock()
+	if _, err := f.Write([]byte("second\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	names := backups(t, path)
+	if len(names) != 1 || filepath.Join(dir, names[0]) == old {
+		t.Fatalf("expected only the new backup to be kept, got %v", names)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "second\n" {
+		t.Errorf("expected the current file to hold the write after rotation, got %q", data)
+	}
+}
+
+func TestRotatingFileRotateFailure(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	f, err := NewRotatingFile(path, RotateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	// Removing the file out from under it makes the rename fail
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Rotate(); err == nil {
+		t.Fatal("expected the rotation to fail")
+	}
+
+	if _, err := f.Write([]byte("still logging\n")); err != nil {
+		t.Fatalf("expected writes to continue after a failed rotation, got %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "still logging\n" {
+		t.Errorf("expected the write in the reopened file, got %q", data)
+	}
+}
@@ -0,0 +1,84 @@
+package log
+
+import (
+	"hash/fnv"
+	"log/slog"
+	"sync/atomic"
+	"time"
+)
+
+// SamplingOptions configures sampling of repeated log records.
+// Records are grouped by level and message. Within each Tick, the first Initial
+// records of a group are logged, then every Thereafter-th record.
+type SamplingOptions struct {
+	// Initial is the number of records per group logged in each tick.
+	Initial int
+	// Thereafter logs every Nth record of a group once Initial is exceeded.
+	// If zero, all further records in the tick are dropped.
+	Thereafter int
+	// Tick is the sampling window. Defaults to 1 second.
+	Tick time.Duration
+	// OnDrop is called for every dropped record, e.g., to count drops in a metric.
+	OnDrop func(level slog.Level, msg string)
+}
+
+// samplerBuckets is the number of counters records are hashed into.
+// Distinct messages that collide share a budget, which only matters under heavy load.
+const samplerBuckets = 4096
+
+// sampler decides which records to keep. It is shared by all handlers derived
+// from the same root handler, so WithAttrs doesn't reset the budget.
+type sampler struct {
+	opts   SamplingOptions
+	counts [samplerBuckets]samplerCounter
+}
+
+// samplerCounter counts records of one group within the current tick.
+type samplerCounter struct {
+	resetAt atomic.Int64 // unix nanos when the current tick ends
+	n       atomic.Uint64
+}
+
+// newSampler creates a sampler, applying defaults.
+func newSampler(opts SamplingOptions) *sampler {
+	if opts.Tick <= 0 {
+		opts.Tick = time.Second
+	}
+	return &sampler{opts: opts}
+}
+
+// allow reports whether a record should be logged, calling OnDrop if it isn't.
+func (s *sampler) allow(now time.Time, level slog.Level, msg string) bool {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte{byte(level)})
+	_, _ = h.Write([]byte(msg))
+	c := &s.counts[h.Sum32()%samplerBuckets]
+
+	n := c.inc(now.UnixNano(), int64(s.opts.Tick))
+	initial := uint64(max(s.opts.Initial, 0))
+	if n <= initial {
+		return true
+	}
+	if s.opts.Thereafter > 0 && (n-initial)%uint64(s.opts.Thereafter) == 0 {
+		return true
+	}
+
+	if s.opts.OnDrop != nil {
+		s.opts.OnDrop(level, msg)
+	}
+	return false
+}
+
+// inc increments the counter, starting a new tick if the current one has ended,
+// and returns the number of records seen in the tick so far.
+func (c *samplerCounter) inc(now, tick int64) uint64 {
+	resetAt := c.resetAt.Load()
+	if now > resetAt {
+		// Only the goroutine that moves the tick forward resets the count
+		if c.resetAt.CompareAndSwap(resetAt, now+tick) {
+			c.n.Store(1)
+			return 1
+		}
+	}
+	return c.n.Add(1)
+}
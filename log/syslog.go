@@ -0,0 +1,251 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Syslog severities (RFC 5424 section 6.2.1).
+const (
+	severityCritical = 2
+	severityError    = 3
+	severityWarning  = 4
+	severityInfo     = 6
+	severityDebug    = 7
+)
+
+// facilityUser is the syslog "user-level messages" facility.
+const facilityUser = 1
+
+// journaldSocket is the systemd-journald native protocol socket.
+const journaldSocket = "/run/systemd/journal/socket"
+
+// severity maps a slog level to a syslog severity.
+// Levels above ERROR (e.g., custom fatal levels) map to critical.
+func severity(level slog.Level) int {
+	switch {
+	case level > slog.LevelError:
+		return severityCritical
+	case level >= slog.LevelError:
+		return severityError
+	case level >= slog.LevelWarn:
+		return severityWarning
+	case level >= slog.LevelInfo:
+		return severityInfo
+	default:
+		return severityDebug
+	}
+}
+
+// leveledOutput is an output that needs the level of every record,
+// such as syslog or journald, which carry it as a priority.
+type leveledOutput interface {
+	writeRecord(level slog.Level, msg []byte) error
+}
+
+// leveledWriter adapts a leveledOutput to the io.Writer expected by slog handlers.
+// leveledHandler sets level under mu before the inner handler writes the record,
+// which slog handlers do with a single Write call.
+type leveledWriter struct {
+	mu    sync.Mutex
+	out   leveledOutput
+	level slog.Level
+}
+
+// Write writes a single formatted record with the current level.
+func (w *leveledWriter) Write(p []byte) (int, error) {
+	if err := w.out.writeRecord(w.level, bytes.TrimSuffix(p, []byte("\n"))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// leveledHandler passes the level of each record to its leveledWriter.
+type leveledHandler struct {
+	inner slog.Handler
+	w     *leveledWriter
+}
+
+func (h *leveledHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.inner.Enabled(ctx, level)
+}
+
+func (h *leveledHandler) Handle(ctx context.Context, r slog.Record) error {
+	h.w.mu.Lock()
+	defer h.w.mu.Unlock()
+	h.w.level = r.Level
+	return h.inner.Handle(ctx, r)
+}
+
+func (h *leveledHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &leveledHandler{inner: h.inner.WithAttrs(attrs), w: h.w}
+}
+
+func (h *leveledHandler) WithGroup(name string) slog.Handler {
+	return &leveledHandler{inner: h.inner.WithGroup(name), w: h.w}
+}
+
+// Timeouts and backoff of reconnectingConn.
+const (
+	dialTimeout         = 5 * time.Second
+	writeTimeout        = 5 * time.Second
+	minReconnectBackoff = 100 * time.Millisecond
+	maxReconnectBackoff = 30 * time.Second
+)
+
+// reconnectingConn connects lazily and reconnects after a failed write. Records are
+// written under the leveledWriter lock, so dials and writes time out, and after a failure
+// no attempts are made until a backoff doubling up to maxReconnectBackoff has passed,
+// dropping records rather than stalling every caller on an unreachable server.
+type reconnectingConn struct {
+	name    string // of the destination, for errors
+	network string
+	addr    string
+	conn    net.Conn
+	backoff time.Duration // after the last failure, zero once a write succeeds
+	retryAt time.Time
+}
+
+// write writes p, connecting first if needed.
+func (c *reconnectingConn) write(p []byte) error {
+	if c.conn == nil {
+		if time.Now().Before(c.retryAt) {
+			return fmt.Errorf("log: %s unavailable, reconnecting at %s", c.name, c.retryAt.Format(time.RFC3339Nano))
+		}
+		conn, err := net.DialTimeout(c.network, c.addr, dialTimeout)
+		if err != nil {
+			c.failed()
+			return fmt.Errorf("log: failed to connect to %s: %w", c.name, err)
+		}
+		c.conn = conn
+	}
+
+	_ = c.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	if _, err := c.conn.Write(p); err != nil {
+		_ = c.conn.Close()
+		c.conn = nil
+		c.failed()
+		return fmt.Errorf("log: failed to write to %s: %w", c.name, err)
+	}
+	c.backoff = 0
+	return nil
+}
+
+// failed backs off reconnecting after a failure.
+func (c *reconnectingConn) failed() {
+	c.backoff = min(max(2*c.backoff, minReconnectBackoff), maxReconnectBackoff)
+	c.retryAt = time.Now().Add(c.backoff)
+}
+
+// syslogOutput sends RFC 5424 messages to a syslog server.
+type syslogOutput struct {
+	app  string
+	host string
+	conn reconnectingConn
+}
+
+// newSyslogOutput creates a syslog output for an address such as "udp://host:514",
+// "tcp://host:601", or "unix:///dev/log". An empty address uses the local /dev/log socket.
+func newSyslogOutput(addr, app string) (*syslogOutput, error) {
+	network, address := "unixgram", "/dev/log"
+	if addr != "" {
+		scheme, rest, ok := strings.Cut(addr, "://")
+		if !ok {
+			return nil, fmt.Errorf("log: invalid syslog address %q", addr)
+		}
+		switch scheme {
+		case "udp", "tcp":
+			network = scheme
+		case "unix":
+			network = "unixgram"
+		default:
+			return nil, fmt.Errorf("log: unsupported syslog network %q", scheme)
+		}
+		address = rest
+	}
+
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "-"
+	}
+
+	return &syslogOutput{
+		app:  app,
+		host: host,
+		conn: reconnectingConn{name: "syslog", network: network, addr: address},
+	}, nil
+}
+
+// writeRecord formats msg as an RFC 5424 message and sends it.
+// Over TCP, messages are framed with octet counting (RFC 6587).
+func (s *syslogOutput) writeRecord(level slog.Level, msg []byte) error {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "<%d>1 %s %s %s %d - - ",
+		facilityUser*8+severity(level),
+		time.Now().Format(time.RFC3339Nano),
+		s.host, s.app, os.Getpid())
+	buf.Write(msg)
+
+	packet := buf.Bytes()
+	if s.conn.network == "tcp" {
+		packet = append([]byte(strconv.Itoa(len(packet))+" "), packet...)
+	}
+	return s.conn.write(packet)
+}
+
+// journaldOutput sends records to systemd-journald using its native protocol.
+type journaldOutput struct {
+	app  string
+	conn reconnectingConn
+}
+
+// newJournaldOutput creates a journald output.
+func newJournaldOutput(app string) *journaldOutput {
+	return &journaldOutput{
+		app:  app,
+		conn: reconnectingConn{name: "journald", network: "unixgram", addr: journaldSocket},
+	}
+}
+
+// writeRecord sends msg as the MESSAGE field with the level as PRIORITY.
+func (j *journaldOutput) writeRecord(level slog.Level, msg []byte) error {
+	var buf bytes.Buffer
+	writeJournalField(&buf, "PRIORITY", []byte(strconv.Itoa(severity(level))))
+	writeJournalField(&buf, "SYSLOG_IDENTIFIER", []byte(j.app))
+	writeJournalField(&buf, "MESSAGE", msg)
+	return j.conn.write(buf.Bytes())
+}
+
+// writeJournalField writes a field in the journald native format.
+// Values containing newlines use the length-prefixed binary form.
+func writeJournalField(buf *bytes.Buffer, name string, value []byte) {
+	buf.WriteString(name)
+	if bytes.IndexByte(value, '\n') < 0 {
+		buf.WriteByte('=')
+		buf.Write(value)
+		buf.WriteByte('\n')
+		return
+	}
+	buf.WriteByte('\n')
+	_ = binary.Write(buf, binary.LittleEndian, uint64(len(value)))
+	buf.Write(value)
+	buf.WriteByte('\n')
+}
+
+// defaultAppName returns the executable name, used as the syslog app name.
+func defaultAppName() string {
+	if len(os.Args) > 0 && os.Args[0] != "" {
+		return filepath.Base(os.Args[0])
+	}
+	return "-"
+}
@@ -0,0 +1,124 @@
+package log
+
+import (
+	"bytes"
+	"encoding/binary"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// listenUnixgram listens on a datagram socket in a temporary directory, short enough for
+// the socket path limit.
+func listenUnixgram(t *testing.T) (net.PacketConn, string) {
+	t.Helper()
+	dir, err := os.MkdirTemp("", "bedrock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+
+	path := filepath.Join(dir, "journal.sock")
+	conn, err := net.ListenPacket("unixgram", path)
+	if err != nil {
+		t.Skipf("unixgram not available: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	return conn, path
+}
+
+// readPacket reads a datagram from conn.
+func readPacket(t *testing.T, conn net.PacketConn) []byte {
+	t.Helper()
+	buf := make([]byte, 4096)
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf[:n]
+}
+
+func TestJournaldOutput(t *testing.T) {
+	conn, path := listenUnixgram(t)
+	out := newJournaldOutput("billing")
+	out.conn.addr = path
+
+	if err := out.writeRecord(slog.LevelWarn, []byte("level=WARN msg=\"disk almost full\"")); err != nil {
+		t.Fatal(err)
+	}
+	got := string(readPacket(t, conn))
+	for _, field := range []string{"PRIORITY=4\n", "SYSLOG_IDENTIFIER=billing\n", "MESSAGE=level=WARN msg=\"disk almost full\"\n"} {
+		if !strings.Contains(got, field) {
+			t.Errorf("expected %q in the journald packet, got %q", field, got)
+		}
+	}
+
+	// Messages with newlines use the length-prefixed form
+	msg := []byte("panic: boom\ngoroutine 1")
+	if err := out.writeRecord(slog.LevelError, msg); err != nil {
+		t.Fatal(err)
+	}
+	var want bytes.Buffer
+	want.WriteString("MESSAGE\n")
+	_ = binary.Write(&want, binary.LittleEndian, uint64(len(msg)))
+	want.Write(msg)
+	want.WriteByte('\n')
+	if got := readPacket(t, conn); !bytes.HasSuffix(got, want.Bytes()) {
+		t.Errorf("expected the length-prefixed message, got %q", got)
+	}
+}
+
+func TestReconnectingConnBackoff(t *testing.T) {
+	dir, err := os.MkdirTemp("", "bedrock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	c := &reconnectingConn{name: "journald", network: "unixgram", addr: filepath.Join(dir, "missing.sock")}
+	if err := c.write([]byte("first")); err == nil || !strings.Contains(err.Error(), "failed to connect") {
+		t.Fatalf("expected a connection error, got %v", err)
+	}
+	if c.backoff != minReconnectBackoff || !c.retryAt.After(time.Now()) {
+		t.Fatalf("expected a %v backoff, got %v until %v", minReconnectBackoff, c.backoff, c.retryAt)
+	}
+
+	// Within the backoff, records are dropped without dialing
+	conn, path := listenUnixgram(t)
+	c.addr = path
+	if err := c.write([]byte("dropped")); err == nil || !strings.Contains(err.Error(), "reconnecting at") {
+		t.Fatalf("expected the record to be dropped during the backoff, got %v", err)
+	}
+	if c.conn != nil {
+		t.Fatal("expected no connection during the backoff")
+	}
+
+	// The backoff doubles with each failure, up to the maximum
+	c.failed()
+	if c.backoff != 2*minReconnectBackoff {
+		t.Errorf("expected the backoff to double, got %v", c.backoff)
+	}
+	c.backoff = maxReconnectBackoff
+	c.failed()
+	if c.backoff != maxReconnectBackoff {
+		t.Errorf("expected the backoff to be capped at %v, got %v", maxReconnectBackoff, c.backoff)
+	}
+
+	// Once it has passed, the connection is retried and the backoff reset
+	c.retryAt = time.Now()
+	if err := c.write([]byte("second")); err != nil {
+		t.Fatal(err)
+	}
+	if got := readPacket(t, conn); string(got) != "second" {
+		t.Errorf("expected the record after reconnecting, got %q", got)
+	}
+	if c.backoff != 0 {
+		t.Errorf("expected the backoff to be reset, got %v", c.backoff)
+	}
+	_ = c.conn.Close()
+}
@@ -0,0 +1,61 @@
+package log
+
+import (
+	"context"
+	"log/slog"
+)
+
+// traceGate reports whether a context's trace is sampled. It is shared by a Handler
+// and its tracedDebugHandlers, so SetTraceSampledFunc reaches all of them.
+type traceGate struct {
+	sampled func(ctx context.Context) bool
+}
+
+func (g *traceGate) allow(ctx context.Context) bool {
+	return g.sampled != nil && g.sampled(ctx)
+}
+
+// tracedDebugHandler writes records that meet its level, plus Debug records below it
+// when the context's trace is sampled. Its inner handler is built at Debug level.
+type tracedDebugHandler struct {
+	inner slog.Handler
+	level slog.Leveler
+	gate  *traceGate
+}
+
+func (h *tracedDebugHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	if level < minLevel(h.level) && (level < slog.LevelDebug || !h.gate.allow(ctx)) {
+		return false
+	}
+	return h.inner.Enabled(ctx, level)
+}
+
+func (h *tracedDebugHandler) Handle(ctx context.Context, r slog.Record) error {
+	return h.inner.Handle(ctx, r)
+}
+
+func (h *tracedDebugHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &tracedDebugHandler{inner: h.inner.WithAttrs(attrs), level: h.level, gate: h.gate}
+}
+
+func (h *tracedDebugHandler) WithGroup(name string) slog.Handler {
+	return &tracedDebugHandler{inner: h.inner.WithGroup(name), level: h.level, gate: h.gate}
+}
+
+// debugFloor lowers a level to at most Debug, so the handler it configures
+// accepts the Debug records tracedDebugHandler lets through.
+type debugFloor struct {
+	level slog.Leveler
+}
+
+func (l debugFloor) Level() slog.Level {
+	return min(minLevel(l.level), slog.LevelDebug)
+}
+
+// minLevel returns the level of l, or Info if l is nil, as slog handlers do.
+func minLevel(l slog.Leveler) slog.Level {
+	if l == nil {
+		return slog.LevelInfo
+	}
+	return l.Level()
+}
@@ -0,0 +1,127 @@
+package log
+
+import (
+	"fmt"
+	"log/slog"
+	"unicode/utf8"
+)
+
+// truncatedSuffix marks attribute values shortened to fit HandlerOptions.MaxRecordSize.
+const truncatedSuffix = "...[truncated]"
+
+// limitNode is an attribute of a record being limited: a group of nodes, or a leaf value.
+type limitNode struct {
+	key   string
+	group []limitNode
+	value slog.Value
+	s     string // the string form of a string or any value, which may be truncated
+	long  bool   // value is a string or any value, so s may be truncated
+}
+
+// limitRecord returns r with its longest string and any values truncated, so the message
+// and attributes add up to about max bytes. Each value longer than a common cap is cut
+// to the cap and marked with truncatedSuffix; the cap is the largest that fits.
+// The message is only truncated if the attributes alone exceed max.
+func limitRecord(r slog.Record, max int) slog.Record {
+	var nodes []limitNode
+	r.Attrs(func(a slog.Attr) bool {
+		nodes = append(nodes, newLimitNode(a))
+		return true
+	})
+	msg := limitNode{value: slog.StringValue(r.Message), s: r.Message, long: true}
+
+	size, longest := limitSize(nodes, -1)
+	if size+len(r.Message) <= max {
+		return r
+	}
+
+	// Find the largest cap on value length that fits, by binary search
+	lo, hi := 0, longest
+	for lo < hi {
+		mid := (lo + hi + 1) / 2
+		if size, _ := limitSize(nodes, mid); size+len(r.Message) <= max {
+			lo = mid
+		} else {
+			hi = mid - 1
+		}
+	}
+
+	out := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	if size, _ := limitSize(nodes, lo); size+len(r.Message) > max {
+		// The attributes don't fit even when emptied, so shorten the message too
+		out.Message = truncate(msg, max-size).String()
+	}
+	out.AddAttrs(limitAttrs(nodes, lo)...)
+	return out
+}
+
+func newLimitNode(a slog.Attr) limitNode {
+	v := a.Value.Resolve()
+	n := limitNode{key: a.Key, value: v}
+	switch v.Kind() {
+	case slog.KindGroup:
+		for _, ga := range v.Group() {
+			n.group = append(n.group, newLimitNode(ga))
+		}
+	case slog.KindString:
+		n.s, n.long = v.String(), true
+	case slog.KindAny:
+		if err, ok := v.Any().(error); ok {
+			n.s = err.Error()
+		} else {
+			n.s = fmt.Sprint(v.Any())
+		}
+		n.long = true
+	default:
+		n.s = v.String()
+	}
+	return n
+}
+
+// limitSize returns the approximate encoded size of nodes with string and any values
+// capped at limit bytes (-1 for no cap), and the length of the longest such value.
+func limitSize(nodes []limitNode, limit int) (size, longest int) {
+	for _, n := range nodes {
+		size += len(n.key)
+		if n.group != nil {
+			s, l := limitSize(n.group, limit)
+			size += s
+			longest = max(longest, l)
+			continue
+		}
+		size += len(n.s)
+		if n.long {
+			longest = max(longest, len(n.s))
+			if limit >= 0 && len(n.s) > limit {
+				size += limit + len(truncatedSuffix) - len(n.s)
+			}
+		}
+	}
+	return size, longest
+}
+
+// limitAttrs converts nodes back to attributes, truncating values longer than limit.
+func limitAttrs(nodes []limitNode, limit int) []slog.Attr {
+	attrs := make([]slog.Attr, len(nodes))
+	for i, n := range nodes {
+		switch {
+		case n.group != nil:
+			attrs[i] = slog.Attr{Key: n.key, Value: slog.GroupValue(limitAttrs(n.group, limit)...)}
+		case n.long && len(n.s) > limit:
+			attrs[i] = slog.Attr{Key: n.key, Value: truncate(n, limit)}
+		default:
+			attrs[i] = slog.Attr{Key: n.key, Value: n.value}
+		}
+	}
+	return attrs
+}
+
+// truncate returns the string form of n cut to at most limit bytes, on a character
+// boundary, followed by truncatedSuffix.
+func truncate(n limitNode, limit int) slog.Value {
+	i := min(max(limit, 0), len(n.s))
+	for i > 0 && i < len(n.s) && !utf8.RuneStart(n.s[i]) {
+		i--
+	}
+	return slog.StringValue(n.s[:i] + truncatedSuffix)
+}
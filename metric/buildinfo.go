@@ -0,0 +1,125 @@
+package metric
+
+import (
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"sync"
+
+	"github.com/kzs0/bedrock/attr"
+)
+
+// BuildInfo describes how the running binary was built.
+type BuildInfo struct {
+	// Path is the main module path.
+	Path string
+	// Version is the main module version (e.g., "v1.2.3" or "(devel)").
+	Version string
+	// Revision is the VCS revision the binary was built from.
+	Revision string
+	// GoVersion is the Go toolchain version used to build the binary.
+	GoVersion string
+	// Dirty is true if the VCS working tree had uncommitted changes.
+	Dirty bool
+}
+
+// ReadBuildInfo returns the build information embedded in the running binary.
+// Fields that aren't available (e.g., when built without VCS stamping) are "unknown".
+func ReadBuildInfo() BuildInfo {
+	info := BuildInfo{
+		Path:      "unknown",
+		Version:   "unknown",
+		Revision:  "unknown",
+		GoVersion: runtime.Version(),
+	}
+
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+
+	if bi.Main.Path != "" {
+		info.Path = bi.Main.Path
+	}
+	if bi.Main.Version != "" {
+		info.Version = bi.Main.Version
+	}
+	if bi.GoVersion != "" {
+		info.GoVersion = bi.GoVersion
+	}
+
+	for _, s := range bi.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			info.Revision = s.Value
+		case "vcs.modified":
+			info.Dirty = s.Value == "true"
+		}
+	}
+
+	return info
+}
+
+// Attrs returns the build information as attributes.
+// These are suitable for use as static attributes.
+func (bi BuildInfo) Attrs() []attr.Attr {
+	return []attr.Attr{
+		attr.String("version", bi.Version),
+		attr.String("revision", bi.Revision),
+	}
+}
+
+// BuildInfoCollector exposes a build_info gauge with a constant value of 1
+// and labels describing how the binary was built.
+// It automatically includes static labels on all metrics.
+type BuildInfoCollector struct {
+	labels    []attr.Attr // static labels, then the build labels they don't set
+	buildInfo *Gauge
+
+	once sync.Once
+}
+
+// NewBuildInfoCollector creates a new build info collector.
+// The static labels are automatically applied to all metrics. A static label with the
+// name of a build label, such as "version" from BuildInfo.Attrs, replaces it, so the
+// label isn't defined twice.
+func NewBuildInfoCollector(registry *Registry, staticLabels ...attr.Attr) *BuildInfoCollector {
+	info := ReadBuildInfo()
+	static := attr.NewSet(staticLabels...)
+	labels := append([]attr.Attr(nil), static.Attrs()...)
+	for _, label := range []attr.Attr{
+		attr.String("path", info.Path),
+		attr.String("version", info.Version),
+		attr.String("revision", info.Revision),
+		attr.String("go_version", info.GoVersion),
+		attr.String("dirty", strconv.FormatBool(info.Dirty)),
+	} {
+		if !static.Has(label.Key) {
+			labels = append(labels, label)
+		}
+	}
+
+	labelNames := make([]string, 0, len(labels))
+	for _, label := range labels {
+		labelNames = append(labelNames, label.Key)
+	}
+
+	return &BuildInfoCollector{
+		labels:    labels,
+		buildInfo: registry.Gauge("build_info", "Build information about the running binary", labelNames...),
+	}
+}
+
+// Describe reports the build_info metric family.
+func (bc *BuildInfoCollector) Describe(describe func(Desc)) {
+	describe(bc.buildInfo.Desc())
+}
+
+// Collect sets the build_info gauge.
+// Build information never changes, so this only does work on the first call.
+func (bc *BuildInfoCollector) Collect(emit func(MetricFamily)) error {
+	bc.once.Do(func() {
+		bc.buildInfo.With(bc.labels...).Set(1)
+	})
+	return nil
+}
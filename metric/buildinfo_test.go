@@ -0,0 +1,74 @@
+package metric
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/kzs0/bedrock/attr"
+)
+
+func TestBuildInfoCollector(t *testing.T) {
+	r := NewRegistry("")
+	if err := r.RegisterCollector(NewBuildInfoCollector(r)); err != nil {
+		t.Fatalf("failed to register collector: %v", err)
+	}
+
+	families := r.Gather()
+
+	var buildInfo *MetricFamily
+	for i := range families {
+		if families[i].Name == "build_info" {
+			buildInfo = &families[i]
+			break
+		}
+	}
+
+	if buildInfo == nil {
+		t.Fatal("expected build_info metric")
+	}
+	if len(buildInfo.Metrics) != 1 {
+		t.Fatalf("expected 1 metric, got %d", len(buildInfo.Metrics))
+	}
+
+	m := buildInfo.Metrics[0]
+	if m.Value != 1 {
+		t.Errorf("expected build_info value 1, got %f", m.Value)
+	}
+
+	for _, label := range []string{"path", "version", "revision", "go_version", "dirty"} {
+		if !m.Labels.Has(label) {
+			t.Errorf("expected %s label on build_info metric", label)
+		}
+	}
+
+	goVersion, _ := m.Labels.Get("go_version")
+	if goVersion.AsString() != runtime.Version() {
+		t.Errorf("expected go_version %q, got %q", runtime.Version(), goVersion.AsString())
+	}
+}
+
+func TestBuildInfoCollectorStaticLabels(t *testing.T) {
+	r := NewRegistry("")
+	static := append(ReadBuildInfo().Attrs(), attr.String("env", "prod"), attr.String("version", "1.2.3"))
+	if err := r.RegisterCollector(NewBuildInfoCollector(r, static...)); err != nil {
+		t.Fatalf("failed to register collector: %v", err)
+	}
+
+	for _, fam := range r.Gather() {
+		if fam.Name != "build_info" {
+			continue
+		}
+		m := fam.Metrics[0]
+		if m.Labels.Len() != 6 {
+			t.Errorf("expected each label once, got %v", m.Labels.Keys())
+		}
+		if v, _ := m.Labels.Get("version"); v.String() != "1.2.3" {
+			t.Errorf("expected the static version to replace the build label, got %q", v.String())
+		}
+		if v, _ := m.Labels.Get("env"); v.String() != "prod" {
+			t.Errorf("expected the static env label, got %q", v.String())
+		}
+		return
+	}
+	t.Fatal("expected build_info metric")
+}
@@ -0,0 +1,160 @@
+package metric
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Desc describes a metric family produced by a collector.
+type Desc struct {
+	Name string
+	Help string
+	Type MetricType
+	Unit Unit
+}
+
+// Collector produces metrics on demand when the registry is gathered.
+//
+// Collectors come in two flavours, and may mix both:
+//   - Collectors that update metrics they created in the registry (e.g., RuntimeCollector).
+//     These families are gathered with the rest of the registry.
+//   - Collectors that build families themselves and pass them to emit.
+//
+// Describe must report every family the collector is responsible for, either way.
+// The registry uses the descriptors to detect conflicts and to remove the
+// collector's metrics when it is unregistered. Describe must not call back
+// into the registry.
+type Collector interface {
+	// Describe calls describe once for every metric family the collector produces.
+	Describe(describe func(Desc))
+	// Collect updates the collector's metrics and calls emit for any families
+	// it builds directly. A returned error is reported from GatherWithError;
+	// families emitted before the error are still gathered.
+	Collect(emit func(MetricFamily)) error
+}
+
+// ErrDuplicateCollector is returned when registering a collector twice,
+// or one that describes a metric family already described by another collector.
+var ErrDuplicateCollector = errors.New("metric: duplicate collector")
+
+// RegisterCollector adds a collector that will be called before gathering metrics.
+// This is useful for collectors that need to update metrics on-demand (e.g., runtime metrics).
+// It returns an error if the collector is already registered or describes a
+// metric family that another registered collector already describes.
+func (r *Registry) RegisterCollector(c Collector) error {
+	// Collectors are described without r.mu held, as they may take their own locks
+	// while collecting into the registry; collectorsMu keeps the check and the
+	// registration atomic instead.
+	r.collectorsMu.Lock()
+	defer r.collectorsMu.Unlock()
+
+	descs := describe(c)
+	for _, existing := range r.snapshotCollectors() {
+		if existing == c {
+			return ErrDuplicateCollector
+		}
+		var conflict string
+		existing.Describe(func(d Desc) {
+			for _, nd := range descs {
+				if nd.Name == d.Name {
+					conflict = d.Name
+				}
+			}
+		})
+		if conflict != "" {
+			return fmt.Errorf("%w: %q is already described by another collector", ErrDuplicateCollector, conflict)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.collectors = append(r.collectors, c)
+	return nil
+}
+
+// snapshotCollectors returns the registered collectors. The registry only appends to or
+// replaces the slice, never modifying its elements, so it may be used after r.mu is released.
+func (r *Registry) snapshotCollectors() []Collector {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.collectors
+}
+
+// UnregisterCollector removes a collector and every metric family it describes
+// from the registry. It returns false if the collector wasn't registered.
+func (r *Registry) UnregisterCollector(c Collector) bool {
+	r.collectorsMu.Lock()
+	defer r.collectorsMu.Unlock()
+
+	descs := describe(c)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	idx := -1
+	for i, existing := range r.collectors {
+		if existing == c {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 {
+		return false
+	}
+
+	collectors := make([]Collector, 0, len(r.collectors)-1)
+	collectors = append(collectors, r.collectors[:idx]...)
+	collectors = append(collectors, r.collectors[idx+1:]...)
+	r.collectors = collectors
+
+	for _, d := range descs {
+		delete(r.counters, d.Name)
+		delete(r.gauges, d.Name)
+		delete(r.histograms, d.Name)
+	}
+	return true
+}
+
+// Describe returns descriptors for every metric family in the registry,
+// including families described by registered collectors.
+func (r *Registry) Describe() []Desc {
+	r.mu.RLock()
+
+	seen := make(map[string]struct{})
+	descs := make([]Desc, 0, len(r.counters)+len(r.gauges)+len(r.histograms))
+	add := func(d Desc) {
+		if _, ok := seen[d.Name]; ok {
+			return
+		}
+		seen[d.Name] = struct{}{}
+		descs = append(descs, d)
+	}
+
+	for _, c := range r.counters {
+		add(c.Desc())
+	}
+	for _, g := range r.gauges {
+		add(g.Desc())
+	}
+	for _, h := range r.histograms {
+		add(h.Desc())
+	}
+	collectors := r.collectors
+	r.mu.RUnlock()
+
+	// Collectors are described without r.mu held, see RegisterCollector
+	for _, c := range collectors {
+		c.Describe(add)
+	}
+
+	return descs
+}
+
+// describe collects a collector's descriptors into a slice.
+func describe(c Collector) []Desc {
+	var descs []Desc
+	c.Describe(func(d Desc) {
+		descs = append(descs, d)
+	})
+	return descs
+}
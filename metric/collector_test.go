@@ -0,0 +1,146 @@
+package metric
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestRegistryCollectorEmit(t *testing.T) {
+	r := NewRegistry("")
+
+	mock := &mockCollector{
+		descs: []Desc{{Name: "external_value", Type: TypeGauge}},
+		families: []MetricFamily{{
+			Name:    "external_value",
+			Type:    TypeGauge,
+			Metrics: []Metric{{Value: 7}},
+		}},
+	}
+	if err := r.RegisterCollector(mock); err != nil {
+		t.Fatalf("failed to register collector: %v", err)
+	}
+
+	found := false
+	for _, fam := range r.Gather() {
+		if fam.Name == "external_value" {
+			found = true
+			if fam.Metrics[0].Value != 7 {
+				t.Errorf("expected value 7, got %f", fam.Metrics[0].Value)
+			}
+		}
+	}
+	if !found {
+		t.Error("expected emitted family to be gathered")
+	}
+}
+
+func TestRegistryCollectorError(t *testing.T) {
+	r := NewRegistry("")
+	r.Counter("requests_total", "Total requests").Inc()
+
+	collectErr := errors.New("collect failed")
+	if err := r.RegisterCollector(&mockCollector{err: collectErr}); err != nil {
+		t.Fatalf("failed to register collector: %v", err)
+	}
+
+	families, err := r.GatherWithError()
+	if !errors.Is(err, collectErr) {
+		t.Errorf("expected collector error, got %v", err)
+	}
+	if len(families) != 1 {
+		t.Errorf("expected registry families despite collector error, got %d", len(families))
+	}
+}
+
+func TestRegistryDuplicateCollector(t *testing.T) {
+	r := NewRegistry("")
+
+	mock := &mockCollector{descs: []Desc{{Name: "dup_metric", Type: TypeGauge}}}
+	if err := r.RegisterCollector(mock); err != nil {
+		t.Fatalf("failed to register collector: %v", err)
+	}
+
+	if err := r.RegisterCollector(mock); !errors.Is(err, ErrDuplicateCollector) {
+		t.Errorf("expected ErrDuplicateCollector for same collector, got %v", err)
+	}
+
+	other := &mockCollector{descs: []Desc{{Name: "dup_metric", Type: TypeGauge}}}
+	if err := r.RegisterCollector(other); !errors.Is(err, ErrDuplicateCollector) {
+		t.Errorf("expected ErrDuplicateCollector for conflicting descriptor, got %v", err)
+	}
+}
+
+func TestRegistryUnregisterCollector(t *testing.T) {
+	r := NewRegistry("")
+	collector := NewProcessCollector(r)
+	if err := r.RegisterCollector(collector); err != nil {
+		t.Fatalf("failed to register collector: %v", err)
+	}
+
+	if !r.UnregisterCollector(collector) {
+		t.Fatal("expected collector to be unregistered")
+	}
+	if r.UnregisterCollector(collector) {
+		t.Error("expected second unregister to report false")
+	}
+
+	for _, fam := range r.Gather() {
+		if fam.Name == "process_start_time_seconds" {
+			t.Error("expected collector metrics to be removed after unregistering")
+		}
+	}
+}
+
+func TestRegistryDescribe(t *testing.T) {
+	r := NewRegistry("")
+	r.CounterWithUnit("sent_total", "Bytes sent", UnitBytes)
+	if err := r.RegisterCollector(&mockCollector{descs: []Desc{{Name: "external_value", Type: TypeGauge}}}); err != nil {
+		t.Fatalf("failed to register collector: %v", err)
+	}
+
+	descs := make(map[string]Desc)
+	for _, d := range r.Describe() {
+		descs[d.Name] = d
+	}
+
+	if d, ok := descs["sent_bytes_total"]; !ok || d.Type != TypeCounter || d.Unit != UnitBytes {
+		t.Errorf("expected counter descriptor with bytes unit, got %+v", d)
+	}
+	if _, ok := descs["external_value"]; !ok {
+		t.Error("expected collector descriptor")
+	}
+}
+
+func TestRegistryCollectorConcurrentDescribe(t *testing.T) {
+	r := NewRegistry("")
+	rc := NewRuntimeCollector(r)
+	if err := r.RegisterCollector(rc); err != nil {
+		t.Fatal(err)
+	}
+
+	// Collecting creates gauges in the registry while describing walks the collectors;
+	// neither may wait on a lock the other holds
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range 20 {
+			_ = rc.Collect(func(MetricFamily) {})
+		}
+	}()
+	for i := range 20 {
+		r.Describe()
+		mock := &mockCollector{descs: []Desc{{Name: fmt.Sprintf("external_%d", i), Type: TypeGauge}}}
+		if err := r.RegisterCollector(mock); err != nil {
+			t.Fatal(err)
+		}
+		r.UnregisterCollector(mock)
+	}
+
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("collection deadlocked with Describe")
+	}
+}
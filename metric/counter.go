@@ -1,7 +1,8 @@
 package metric
 
 import (
-	"strings"
+	"fmt"
+	"math/rand/v2"
 	"sync"
 	"sync/atomic"
 
@@ -12,51 +13,78 @@ import (
 type Counter struct {
 	name       string
 	help       string
+	unit       Unit
 	labelNames map[string]struct{}
-	mu         sync.RWMutex
-	values     map[string]*counterValue
+	shards     int      // number of shards per series; 0 for unsharded counters
+	values     sync.Map // map[string]*counterValue; lock-free reads for hot paths and Gather
 }
 
 type counterValue struct {
 	labels attr.Set
+	vec    CounterVec // handle returned by With, so lookups don't allocate one
 	value  atomic.Uint64
+	shards []counterShard // spreads increments of hot series across cache lines
 }
 
-// With returns a CounterVec with the given label values.
-func (c *Counter) With(labels ...attr.Attr) *CounterVec {
-	labels_verified := make([]attr.Attr, 0, len(labels))
-	for _, label := range labels {
-		sanitized := sanitizeName(label.Key)
-		if _, ok := c.labelNames[sanitized]; !ok {
-			continue
-		}
-		label = label.WithKey(sanitized)
-		labels_verified = append(labels_verified, label)
+// counterShard is a counter cell padded to a cache line so that shards
+// updated from different CPUs don't contend with each other.
+type counterShard struct {
+	value atomic.Uint64
+	_     [56]byte
+}
+
+// add adds n to the series, spreading the increment across shards if sharded.
+func (cv *counterValue) add(n uint64) {
+	if len(cv.shards) == 0 {
+		cv.value.Add(n)
+		return
+	}
+	// rand.Uint32 uses per-P state, so picking a shard doesn't contend either
+	cv.shards[rand.Uint32()%uint32(len(cv.shards))].value.Add(n)
+}
+
+// load returns the current value of the series, summing shards if sharded.
+func (cv *counterValue) load() uint64 {
+	v := cv.value.Load()
+	for i := range cv.shards {
+		v += cv.shards[i].value.Load()
 	}
+	return v
+}
 
-	key := labelsKey(labels_verified)
+// With returns a CounterVec with the given label values.
+// Labels that aren't one of the counter's label names are dropped.
+func (c *Counter) With(labels ...attr.Attr) *CounterVec {
+	var stack [maxStackLabels]attr.Attr
+	resolved := resolveLabels(stack[:0], labels, c.labelNames)
 
-	c.mu.RLock()
-	cv, ok := c.values[key]
-	c.mu.RUnlock()
+	var buf [256]byte
+	key := appendLabelsKey(buf[:0], resolved)
 
-	if ok {
-		return &CounterVec{value: cv}
+	if v, ok := c.values.Load(string(key)); ok {
+		return &v.(*counterValue).vec
 	}
 
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	// Double-check after acquiring write lock
-	if cv, ok = c.values[key]; ok {
-		return &CounterVec{value: cv}
+	nv := &counterValue{
+		labels: attr.NewSet(resolved...),
+		shards: make([]counterShard, c.shards),
 	}
+	nv.vec = CounterVec{value: nv}
+	// LoadOrStore resolves races between concurrent first uses of a label set
+	v, _ := c.values.LoadOrStore(string(key), nv)
+	return &v.(*counterValue).vec
+}
 
-	cv = &counterValue{
-		labels: attr.NewSet(labels_verified...),
+// MustCurryWith returns the CounterVec for the given label values, for callers that
+// resolve a series once and keep the handle. Recording through a kept handle never
+// allocates, whereas With resolves the labels on every call.
+// It panics if a label isn't one of the counter's label names, since With would
+// silently drop it.
+func (c *Counter) MustCurryWith(labels ...attr.Attr) *CounterVec {
+	if key, ok := unknownLabel(labels, c.labelNames); ok {
+		panic(fmt.Sprintf("metric: %s has no label %q", c.name, key))
 	}
-	c.values[key] = cv
-	return &CounterVec{value: cv}
+	return c.With(labels...)
 }
 
 // Inc increments the counter by 1.
@@ -69,22 +97,33 @@ func (c *Counter) Add(v float64) {
 	c.With().Add(v)
 }
 
+// Desc returns the descriptor of the counter.
+func (c *Counter) Desc() Desc {
+	return Desc{
+		Name: c.name,
+		Help: c.help,
+		Type: TypeCounter,
+		Unit: c.unit,
+	}
+}
+
 // collect gathers all counter values for exposition.
+// Each series is snapshotted atomically without blocking concurrent recording.
 func (c *Counter) collect() MetricFamily {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
-
-	metrics := make([]Metric, 0, len(c.values))
-	for _, cv := range c.values {
+	var metrics []Metric
+	c.values.Range(func(_, v any) bool {
+		cv := v.(*counterValue)
 		metrics = append(metrics, Metric{
 			Labels: cv.labels,
-			Value:  float64FromUint64(cv.value.Load()),
+			Value:  float64FromUint64(cv.load()),
 		})
-	}
+		return true
+	})
 
 	return MetricFamily{
 		Name:    c.name,
 		Help:    c.help,
+		Unit:    c.unit,
 		Type:    TypeCounter,
 		Metrics: metrics,
 	}
@@ -97,7 +136,7 @@ type CounterVec struct {
 
 // Inc increments the counter by 1.
 func (cv *CounterVec) Inc() {
-	cv.value.value.Add(1)
+	cv.value.add(1)
 }
 
 // Add adds the given value to the counter.
@@ -106,26 +145,7 @@ func (cv *CounterVec) Add(v float64) {
 		return // Counters can only increase
 	}
 	// Store as uint64 bits for atomic operations
-	cv.value.value.Add(uint64(v))
-}
-
-// labelsKey creates a unique key from label values.
-func labelsKey(labels []attr.Attr) string {
-	if len(labels) == 0 {
-		return ""
-	}
-	set := attr.NewSet(labels...)
-	var sb strings.Builder
-	set.Range(func(a attr.Attr) bool {
-		if sb.Len() > 0 {
-			sb.WriteByte('|')
-		}
-		sb.WriteString(a.Key)
-		sb.WriteByte('=')
-		sb.WriteString(a.Value.String())
-		return true
-	})
-	return sb.String()
+	cv.value.add(uint64(v))
 }
 
 // float64FromUint64 converts a uint64 to float64.
@@ -1,6 +1,7 @@
 package metric
 
 import (
+	"fmt"
 	"math"
 	"sync"
 	"sync/atomic"
@@ -12,51 +13,49 @@ import (
 type Gauge struct {
 	name       string
 	help       string
+	unit       Unit
 	labelNames map[string]struct{}
-	mu         sync.RWMutex
-	values     map[string]*gaugeValue
+	values     sync.Map // map[string]*gaugeValue; lock-free reads for hot paths and Gather
 }
 
 type gaugeValue struct {
 	labels attr.Set
+	vec    GaugeVec      // handle returned by With, so lookups don't allocate one
 	bits   atomic.Uint64 // Stores float64 as uint64 bits
 }
 
 // With returns a GaugeVec with the given label values.
+// Labels that aren't one of the gauge's label names are dropped.
 func (g *Gauge) With(labels ...attr.Attr) *GaugeVec {
-	labels_verified := make([]attr.Attr, 0, len(labels))
-	for _, label := range labels {
-		sanitized := sanitizeName(label.Key)
-		if _, ok := g.labelNames[sanitized]; !ok {
-			continue
-		}
-		label = label.WithKey(sanitized)
-		labels_verified = append(labels_verified, label)
-	}
-
-	key := labelsKey(labels_verified)
+	var stack [maxStackLabels]attr.Attr
+	resolved := resolveLabels(stack[:0], labels, g.labelNames)
 
-	g.mu.RLock()
-	gv, ok := g.values[key]
-	g.mu.RUnlock()
+	var buf [256]byte
+	key := appendLabelsKey(buf[:0], resolved)
 
-	if ok {
-		return &GaugeVec{value: gv}
+	if v, ok := g.values.Load(string(key)); ok {
+		return &v.(*gaugeValue).vec
 	}
 
-	g.mu.Lock()
-	defer g.mu.Unlock()
-
-	// Double-check after acquiring write lock
-	if gv, ok = g.values[key]; ok {
-		return &GaugeVec{value: gv}
+	nv := &gaugeValue{
+		labels: attr.NewSet(resolved...),
 	}
+	nv.vec = GaugeVec{value: nv}
+	// LoadOrStore resolves races between concurrent first uses of a label set
+	v, _ := g.values.LoadOrStore(string(key), nv)
+	return &v.(*gaugeValue).vec
+}
 
-	gv = &gaugeValue{
-		labels: attr.NewSet(labels_verified...),
+// MustCurryWith returns the GaugeVec for the given label values, for callers that
+// resolve a series once and keep the handle. Recording through a kept handle never
+// allocates, whereas With resolves the labels on every call.
+// It panics if a label isn't one of the gauge's label names, since With would
+// silently drop it.
+func (g *Gauge) MustCurryWith(labels ...attr.Attr) *GaugeVec {
+	if key, ok := unknownLabel(labels, g.labelNames); ok {
+		panic(fmt.Sprintf("metric: %s has no label %q", g.name, key))
 	}
-	g.values[key] = gv
-	return &GaugeVec{value: gv}
+	return g.With(labels...)
 }
 
 // Set sets the gauge to the given value.
@@ -84,22 +83,33 @@ func (g *Gauge) Sub(v float64) {
 	g.With().Sub(v)
 }
 
+// Desc returns the descriptor of the gauge.
+func (g *Gauge) Desc() Desc {
+	return Desc{
+		Name: g.name,
+		Help: g.help,
+		Type: TypeGauge,
+		Unit: g.unit,
+	}
+}
+
 // collect gathers all gauge values for exposition.
+// Each series is snapshotted atomically without blocking concurrent recording.
 func (g *Gauge) collect() MetricFamily {
-	g.mu.RLock()
-	defer g.mu.RUnlock()
-
-	metrics := make([]Metric, 0, len(g.values))
-	for _, gv := range g.values {
+	var metrics []Metric
+	g.values.Range(func(_, v any) bool {
+		gv := v.(*gaugeValue)
 		metrics = append(metrics, Metric{
 			Labels: gv.labels,
 			Value:  math.Float64frombits(gv.bits.Load()),
 		})
-	}
+		return true
+	})
 
 	return MetricFamily{
 		Name:    g.name,
 		Help:    g.help,
+		Unit:    g.unit,
 		Type:    TypeGauge,
 		Metrics: metrics,
 	}
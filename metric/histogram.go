@@ -1,6 +1,7 @@
 package metric
 
 import (
+	"fmt"
 	"math"
 	"sync"
 	"sync/atomic"
@@ -12,55 +13,53 @@ import (
 type Histogram struct {
 	name       string
 	help       string
+	unit       Unit
 	buckets    []float64
 	labelNames map[string]struct{}
-	mu         sync.RWMutex
-	values     map[string]*histogramValue
+	values     sync.Map // map[string]*histogramValue; lock-free reads for hot paths and Gather
 }
 
 type histogramValue struct {
 	labels      attr.Set
+	vec         HistogramVec    // handle returned by With, so lookups don't allocate one
 	bucketCount []atomic.Uint64 // count for each bucket
 	count       atomic.Uint64   // total count
 	sumBits     atomic.Uint64   // sum stored as float64 bits
 }
 
 // With returns a HistogramVec with the given label values.
+// Labels that aren't one of the histogram's label names are dropped.
 func (h *Histogram) With(labels ...attr.Attr) *HistogramVec {
-	labels_verified := make([]attr.Attr, 0, len(labels))
-	for _, label := range labels {
-		sanitized := sanitizeName(label.Key)
-		if _, ok := h.labelNames[sanitized]; !ok {
-			continue
-		}
-		label = label.WithKey(sanitized)
-		labels_verified = append(labels_verified, label)
-	}
-
-	key := labelsKey(labels_verified)
+	var stack [maxStackLabels]attr.Attr
+	resolved := resolveLabels(stack[:0], labels, h.labelNames)
 
-	h.mu.RLock()
-	hv, ok := h.values[key]
-	h.mu.RUnlock()
+	var buf [256]byte
+	key := appendLabelsKey(buf[:0], resolved)
 
-	if ok {
-		return &HistogramVec{value: hv, buckets: h.buckets}
+	if v, ok := h.values.Load(string(key)); ok {
+		return &v.(*histogramValue).vec
 	}
 
-	h.mu.Lock()
-	defer h.mu.Unlock()
-
-	// Double-check after acquiring write lock
-	if hv, ok = h.values[key]; ok {
-		return &HistogramVec{value: hv, buckets: h.buckets}
+	nv := &histogramValue{
+		labels:      attr.NewSet(resolved...),
+		bucketCount: make([]atomic.Uint64, len(h.buckets)),
 	}
+	nv.vec = HistogramVec{value: nv, buckets: h.buckets}
+	// LoadOrStore resolves races between concurrent first uses of a label set
+	v, _ := h.values.LoadOrStore(string(key), nv)
+	return &v.(*histogramValue).vec
+}
 
-	hv = &histogramValue{
-		labels:      attr.NewSet(labels_verified...),
-		bucketCount: make([]atomic.Uint64, len(h.buckets)),
+// MustCurryWith returns the HistogramVec for the given label values, for callers that
+// resolve a series once and keep the handle. Recording through a kept handle never
+// allocates, whereas With resolves the labels on every call.
+// It panics if a label isn't one of the histogram's label names, since With would
+// silently drop it.
+func (h *Histogram) MustCurryWith(labels ...attr.Attr) *HistogramVec {
+	if key, ok := unknownLabel(labels, h.labelNames); ok {
+		panic(fmt.Sprintf("metric: %s has no label %q", h.name, key))
 	}
-	h.values[key] = hv
-	return &HistogramVec{value: hv, buckets: h.buckets}
+	return h.With(labels...)
 }
 
 // Observe adds a single observation to the histogram.
@@ -68,13 +67,22 @@ func (h *Histogram) Observe(v float64) {
 	h.With().Observe(v)
 }
 
+// Desc returns the descriptor of the histogram.
+func (h *Histogram) Desc() Desc {
+	return Desc{
+		Name: h.name,
+		Help: h.help,
+		Type: TypeHistogram,
+		Unit: h.unit,
+	}
+}
+
 // collect gathers all histogram values for exposition.
+// Each series is read without blocking concurrent observations.
 func (h *Histogram) collect() MetricFamily {
-	h.mu.RLock()
-	defer h.mu.RUnlock()
-
-	metrics := make([]Metric, 0, len(h.values))
-	for _, hv := range h.values {
+	var metrics []Metric
+	h.values.Range(func(_, v any) bool {
+		hv := v.(*histogramValue)
 		buckets := make([]Bucket, len(h.buckets))
 		var cumulative uint64
 		for i, bound := range h.buckets {
@@ -91,11 +99,13 @@ func (h *Histogram) collect() MetricFamily {
 			Count:   hv.count.Load(),
 			Sum:     math.Float64frombits(hv.sumBits.Load()),
 		})
-	}
+		return true
+	})
 
 	return MetricFamily{
 		Name:    h.name,
 		Help:    h.help,
+		Unit:    h.unit,
 		Type:    TypeHistogram,
 		Metrics: metrics,
 	}
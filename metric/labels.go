@@ -0,0 +1,112 @@
+package metric
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/kzs0/bedrock/attr"
+)
+
+// maxStackLabels is the number of labels that can be resolved without
+// allocating. Series with more labels still work, they just allocate.
+const maxStackLabels = 8
+
+// resolveLabels appends the labels whose sanitized key is one of names to dst,
+// sorted by key with duplicates removed (last value wins), matching attr.NewSet.
+// Unknown labels are dropped.
+func resolveLabels(dst, labels []attr.Attr, names map[string]struct{}) []attr.Attr {
+	for _, label := range labels {
+		sanitized := SanitizeLabelName(label.Key)
+		if _, ok := names[sanitized]; !ok {
+			continue
+		}
+		dst = append(dst, label.WithKey(sanitized))
+	}
+	return sortLabels(dst)
+}
+
+// sanitizeLabelKeys returns a copy of labels with their keys sanitized as label names.
+func sanitizeLabelKeys(labels []attr.Attr) []attr.Attr {
+	sanitized := make([]attr.Attr, len(labels))
+	for i, label := range labels {
+		sanitized[i] = label.WithKey(SanitizeLabelName(label.Key))
+	}
+	return sanitized
+}
+
+// unknownLabel returns the key of the first label whose sanitized key isn't one of names.
+func unknownLabel(labels []attr.Attr, names map[string]struct{}) (string, bool) {
+	for _, label := range labels {
+		if _, ok := names[SanitizeLabelName(label.Key)]; !ok {
+			return label.Key, true
+		}
+	}
+	return "", false
+}
+
+// sortLabels sorts labels by key in place and removes duplicate keys, keeping the last value.
+// Label sets are small, so a stable insertion sort avoids the allocations of sort.Slice.
+func sortLabels(labels []attr.Attr) []attr.Attr {
+	for i := 1; i < len(labels); i++ {
+		for j := i; j > 0 && labels[j].Key < labels[j-1].Key; j-- {
+			labels[j], labels[j-1] = labels[j-1], labels[j]
+		}
+	}
+
+	deduped := labels[:0]
+	for i, a := range labels {
+		if i > 0 && labels[i-1].Key == a.Key {
+			deduped[len(deduped)-1] = a
+		} else {
+			deduped = append(deduped, a)
+		}
+	}
+	return deduped
+}
+
+// labelsKey creates a unique key from label values.
+func labelsKey(labels []attr.Attr) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	var stack [maxStackLabels]attr.Attr
+	sorted := sortLabels(append(stack[:0], labels...))
+
+	var buf [256]byte
+	return string(appendLabelsKey(buf[:0], sorted))
+}
+
+// appendLabelsKey appends the key of already sorted and deduplicated labels to dst.
+func appendLabelsKey(dst []byte, labels []attr.Attr) []byte {
+	for i, a := range labels {
+		if i > 0 {
+			dst = append(dst, '|')
+		}
+		dst = append(dst, a.Key...)
+		dst = append(dst, '=')
+		dst = appendValue(dst, a.Value)
+	}
+	return dst
+}
+
+// appendValue appends the string form of v to dst, as returned by v.String.
+func appendValue(dst []byte, v attr.Value) []byte {
+	switch v.Kind() {
+	case attr.KindString:
+		return append(dst, v.AsString()...)
+	case attr.KindInt64:
+		return strconv.AppendInt(dst, v.AsInt64(), 10)
+	case attr.KindUint64:
+		return strconv.AppendUint(dst, v.AsUint64(), 10)
+	case attr.KindFloat64:
+		return strconv.AppendFloat(dst, v.AsFloat64(), 'g', -1, 64)
+	case attr.KindBool:
+		return strconv.AppendBool(dst, v.AsBool())
+	case attr.KindDuration:
+		return append(dst, v.AsDuration().String()...)
+	case attr.KindTime:
+		return v.AsTime().AppendFormat(dst, time.RFC3339Nano)
+	default:
+		return append(dst, v.String()...)
+	}
+}
@@ -1,6 +1,10 @@
 package metric
 
 import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
 	"testing"
 
 	"github.com/kzs0/bedrock/attr"
@@ -213,3 +217,272 @@ func TestRegistryNoPrefix(t *testing.T) {
 		t.Errorf("expected name 'requests_total' (no prefix), got '%s'", families[0].Name)
 	}
 }
+
+func TestRegistryWithPrefix(t *testing.T) {
+	r := NewRegistry("myapp")
+	db := r.WithPrefix("db")
+	pool := db.WithPrefix("pool")
+
+	db.Counter("queries_total", "Total queries").Inc()
+	pool.Gauge("connections", "Open connections").Set(3)
+	r.WithPrefix("").Counter("requests_total", "Total requests").Inc()
+
+	// Sub-registries share metrics with their parent
+	names := map[string]bool{}
+	for _, fam := range r.Gather() {
+		names[fam.Name] = true
+	}
+	for _, want := range []string{"myapp_db_queries_total", "myapp_db_pool_connections", "myapp_requests_total"} {
+		if !names[want] {
+			t.Errorf("expected %q to be gathered from the parent registry, got %v", want, names)
+		}
+	}
+
+	if db.Counter("queries_total", "Total queries") != r.Counter("db_queries_total", "Total queries") {
+		t.Error("expected sub-registry and parent to return the same counter")
+	}
+}
+
+func TestRegistryDefaultBuckets(t *testing.T) {
+	buckets := []float64{1, 2, 3}
+	r := NewRegistry("", WithDefaultBuckets(buckets))
+	other := NewRegistry("")
+
+	h := r.Histogram("latency", "Latency", nil)
+	if !slices.Equal(h.buckets, buckets) {
+		t.Errorf("expected registry default buckets %v, got %v", buckets, h.buckets)
+	}
+	if h := r.WithPrefix("db").Histogram("latency", "Latency", nil); !slices.Equal(h.buckets, buckets) {
+		t.Errorf("expected sub-registry to use registry default buckets, got %v", h.buckets)
+	}
+	if h := r.Histogram("size", "Size", []float64{10}); !slices.Equal(h.buckets, []float64{10}) {
+		t.Errorf("expected explicit buckets to win, got %v", h.buckets)
+	}
+	if h := other.Histogram("latency", "Latency", nil); !slices.Equal(h.buckets, DefaultBuckets) {
+		t.Errorf("expected package default buckets, got %v", h.buckets)
+	}
+}
+
+func TestRegistryUnit(t *testing.T) {
+	r := NewRegistry("")
+
+	r.HistogramWithUnit("request_duration", "Request duration", UnitSeconds, nil).Observe(0.5)
+	r.CounterWithUnit("bytes_sent_total", "Bytes sent", UnitBytes).Add(10)
+	r.GaugeWithUnit("cpu_usage_ratio", "CPU usage", UnitRatio).Set(0.25)
+
+	units := make(map[string]Unit)
+	for _, fam := range r.Gather() {
+		units[fam.Name] = fam.Unit
+	}
+
+	expected := map[string]Unit{
+		"request_duration_seconds": UnitSeconds,
+		"bytes_sent_bytes_total":   UnitBytes,
+		"cpu_usage_ratio":          UnitRatio,
+	}
+	for name, unit := range expected {
+		got, ok := units[name]
+		if !ok {
+			t.Errorf("expected metric %q, got %v", name, units)
+			continue
+		}
+		if got != unit {
+			t.Errorf("expected unit %q for %q, got %q", unit, name, got)
+		}
+	}
+}
+
+func TestWithUnitSuffix(t *testing.T) {
+	tests := []struct {
+		name     string
+		unit     Unit
+		expected string
+	}{
+		{"request_duration", UnitSeconds, "request_duration_seconds"},
+		{"request_duration_seconds", UnitSeconds, "request_duration_seconds"},
+		{"sent_bytes_total", UnitBytes, "sent_bytes_total"},
+		{"sent_total", UnitBytes, "sent_bytes_total"},
+		{"requests_total", UnitNone, "requests_total"},
+	}
+
+	for _, test := range tests {
+		result := withUnitSuffix(test.name, test.unit)
+		if result != test.expected {
+			t.Errorf("withUnitSuffix(%q, %q) = %q, expected %q", test.name, test.unit, result, test.expected)
+		}
+	}
+}
+
+func TestRegistryRegisterStrict(t *testing.T) {
+	r := NewRegistry("")
+
+	if _, err := r.RegisterCounter(Opts{Name: "http.requests"}); !errors.Is(err, ErrInvalidName) {
+		t.Errorf("expected ErrInvalidName for invalid metric name, got %v", err)
+	}
+	if _, err := r.RegisterGauge(Opts{Name: "temperature", LabelNames: []string{"room-id"}}); !errors.Is(err, ErrInvalidName) {
+		t.Errorf("expected ErrInvalidName for invalid label name, got %v", err)
+	}
+	if _, err := r.RegisterHistogram(Opts{Name: "1latency"}); !errors.Is(err, ErrInvalidName) {
+		t.Errorf("expected ErrInvalidName for leading digit, got %v", err)
+	}
+
+	h, err := r.RegisterHistogram(Opts{Name: "latency", Unit: UnitSeconds, LabelNames: []string{"method"}})
+	if err != nil {
+		t.Fatalf("expected valid histogram, got %v", err)
+	}
+	if h.Desc().Name != "latency_seconds" {
+		t.Errorf("expected name 'latency_seconds', got %q", h.Desc().Name)
+	}
+}
+
+func TestRegistryLabelNameColons(t *testing.T) {
+	r := NewRegistry("")
+
+	// Colons are valid in metric names but not in label names
+	if _, err := r.RegisterCounter(Opts{Name: "job:requests_total"}); err != nil {
+		t.Errorf("expected a colon to be valid in a metric name, got %v", err)
+	}
+	if _, err := r.RegisterCounter(Opts{Name: "requests_total", LabelNames: []string{"shard:id"}}); !errors.Is(err, ErrInvalidName) {
+		t.Errorf("expected ErrInvalidName for a label name with a colon, got %v", err)
+	}
+
+	c := r.Counter("ops_total", "Ops", "shard:id")
+	c.With(attr.String("shard:id", "3")).Inc()
+	for _, fam := range r.Gather() {
+		if fam.Name != "ops_total" {
+			continue
+		}
+		if v, ok := fam.Metrics[0].Labels.Get("shard_id"); !ok || v.String() != "3" {
+			t.Errorf("expected the label to be sanitized to shard_id, got %v", fam.Metrics[0].Labels)
+		}
+	}
+}
+
+func TestRegistryOnSanitize(t *testing.T) {
+	r := NewRegistry("")
+
+	var mappings [][2]string
+	r.OnSanitize(func(original, sanitized string) {
+		mappings = append(mappings, [2]string{original, sanitized})
+	})
+
+	r.Counter("http.requests", "HTTP requests", "http.method")
+	r.Counter("http.requests", "HTTP requests", "http.method")
+	r.Counter("valid_total", "Valid")
+
+	if len(mappings) != 2 {
+		t.Fatalf("expected 2 sanitization reports, got %v", mappings)
+	}
+	if mappings[0] != [2]string{"http.requests", "http_requests"} {
+		t.Errorf("unexpected metric name mapping %v", mappings[0])
+	}
+	if mappings[1] != [2]string{"http.method", "http_method"} {
+		t.Errorf("unexpected label name mapping %v", mappings[1])
+	}
+}
+
+func TestGatherConcurrentRecording(t *testing.T) {
+	r := NewRegistry("")
+	c := r.Counter("requests_total", "Total requests", "worker")
+	h := r.Histogram("latency", "Latency", nil, "worker")
+
+	var wg sync.WaitGroup
+	for i := range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			worker := attr.String("worker", fmt.Sprint(i))
+			for range 1000 {
+				c.With(worker).Inc()
+				h.With(worker).Observe(1)
+			}
+		}()
+	}
+
+	// Gather while recording is in progress
+	for range 10 {
+		_ = r.Gather()
+	}
+	wg.Wait()
+
+	var total float64
+	for _, fam := range r.Gather() {
+		if fam.Name != "requests_total" {
+			continue
+		}
+		for _, m := range fam.Metrics {
+			total += m.Value
+		}
+	}
+	if total != 4000 {
+		t.Errorf("expected total 4000, got %f", total)
+	}
+}
+
+func TestShardedCounter(t *testing.T) {
+	r := NewRegistry("")
+	c := r.ShardedCounter("hot_total", "Hot counter", "route")
+	if c.shards == 0 {
+		t.Fatal("expected counter to be sharded")
+	}
+
+	route := attr.String("route", "/")
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 1000 {
+				c.With(route).Inc()
+			}
+			c.With(route).Add(10)
+		}()
+	}
+	wg.Wait()
+
+	for _, fam := range r.Gather() {
+		if fam.Name != "hot_total" {
+			continue
+		}
+		if len(fam.Metrics) != 1 {
+			t.Fatalf("expected 1 series, got %d", len(fam.Metrics))
+		}
+		if fam.Metrics[0].Value != 8080 {
+			t.Errorf("expected 8080, got %f", fam.Metrics[0].Value)
+		}
+		return
+	}
+	t.Fatal("hot_total not gathered")
+}
+
+func TestRecordingAllocs(t *testing.T) {
+	r := NewRegistry("")
+	c := r.Counter("allocs_total", "Allocations", "method", "status")
+	labels := []attr.Attr{attr.String("status", "200"), attr.String("method", "GET")}
+
+	handle := c.MustCurryWith(labels...)
+	if allocs := testing.AllocsPerRun(100, func() { handle.Inc() }); allocs != 0 {
+		t.Errorf("expected recording on a handle not to allocate, got %v allocs", allocs)
+	}
+
+	// The lookup only allocates the key string passed to the series map
+	if allocs := testing.AllocsPerRun(100, func() { c.With(labels...).Inc() }); allocs > 1 {
+		t.Errorf("expected at most 1 alloc per With lookup, got %v", allocs)
+	}
+
+	if c.With(labels...) != handle {
+		t.Error("expected With to return the curried handle")
+	}
+}
+
+func TestMustCurryWithUnknownLabel(t *testing.T) {
+	r := NewRegistry("")
+	g := r.Gauge("temperature", "Temperature", "room")
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected MustCurryWith to panic on an unknown label")
+		}
+	}()
+	g.MustCurryWith(attr.String("floor", "1"))
+}
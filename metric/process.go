@@ -0,0 +1,198 @@
+package metric
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kzs0/bedrock/attr"
+)
+
+// processStartTime approximates the process start time when /proc is unavailable.
+var processStartTime = time.Now()
+
+// userHZ is the kernel clock tick rate used for CPU times in /proc/<pid>/stat.
+// This is 100 on virtually all Linux systems.
+const userHZ = 100
+
+// ProcessCollector collects process-level metrics. process_cpu_seconds_total is a
+// counter, and the rest are gauges. On Linux, values are read from /proc/self. On other platforms, only
+// process_start_time_seconds is reported, based on when the package was loaded.
+// It automatically includes static labels on all metrics.
+type ProcessCollector struct {
+	staticLabels []attr.Attr
+	procPath     string
+
+	cpuSeconds     Desc     // emitted, as registry counters can only be added to
+	cpuLabels      attr.Set // static labels, with sanitized keys
+	residentMemory *Gauge
+	virtualMemory  *Gauge
+	openFDs        *Gauge
+	maxFDs         *Gauge
+	startTime      *Gauge
+
+	mu sync.Mutex
+}
+
+// NewProcessCollector creates a new process metrics collector.
+// The static labels are automatically applied to all metrics.
+func NewProcessCollector(registry *Registry, staticLabels ...attr.Attr) *ProcessCollector {
+	// Extract label names from static labels
+	labelNames := make([]string, 0, len(staticLabels))
+	for _, label := range staticLabels {
+		labelNames = append(labelNames, label.Key)
+	}
+
+	pc := &ProcessCollector{
+		staticLabels: staticLabels,
+		procPath:     "/proc/self",
+		cpuSeconds: Desc{
+			Name: registry.qualify("process_cpu_seconds_total"),
+			Help: "Total user and system CPU time spent in seconds",
+			Type: TypeCounter,
+			Unit: UnitSeconds,
+		},
+		cpuLabels: attr.NewSet(sanitizeLabelKeys(staticLabels)...),
+	}
+
+	pc.residentMemory = registry.GaugeWithUnit("process_resident_memory_bytes", "Resident memory size in bytes", UnitBytes, labelNames...)
+	pc.virtualMemory = registry.GaugeWithUnit("process_virtual_memory_bytes", "Virtual memory size in bytes", UnitBytes, labelNames...)
+	pc.openFDs = registry.Gauge("process_open_fds", "Number of open file descriptors", labelNames...)
+	pc.maxFDs = registry.Gauge("process_max_fds", "Maximum number of open file descriptors", labelNames...)
+	pc.startTime = registry.GaugeWithUnit("process_start_time_seconds", "Start time of the process since unix epoch in seconds", UnitSeconds, labelNames...)
+
+	return pc
+}
+
+// Describe reports the process metric families.
+func (pc *ProcessCollector) Describe(describe func(Desc)) {
+	describe(pc.cpuSeconds)
+	for _, g := range []*Gauge{pc.residentMemory, pc.virtualMemory, pc.openFDs, pc.maxFDs, pc.startTime} {
+		describe(g.Desc())
+	}
+}
+
+// Collect updates all process metrics with current values.
+// Metrics that can't be read on the current platform are skipped rather than
+// reported as errors. The gauges live in the registry; the CPU time counter is emitted.
+func (pc *ProcessCollector) Collect(emit func(MetricFamily)) error {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	startTime := float64(processStartTime.UnixNano()) / 1e9
+
+	if stat, err := pc.readStat(); err == nil {
+		emit(MetricFamily{
+			Name:    pc.cpuSeconds.Name,
+			Help:    pc.cpuSeconds.Help,
+			Unit:    pc.cpuSeconds.Unit,
+			Type:    TypeCounter,
+			Metrics: []Metric{{Labels: pc.cpuLabels, Value: stat.cpuSeconds}},
+		})
+		pc.residentMemory.With(pc.staticLabels...).Set(stat.residentBytes)
+		pc.virtualMemory.With(pc.staticLabels...).Set(stat.virtualBytes)
+		if bootTime, err := readBootTime(); err == nil {
+			startTime = bootTime + stat.startTicks/userHZ
+		}
+	}
+
+	if entries, err := os.ReadDir(pc.procPath + "/fd"); err == nil {
+		pc.openFDs.With(pc.staticLabels...).Set(float64(len(entries)))
+	}
+
+	if limit, err := pc.readMaxFDs(); err == nil {
+		pc.maxFDs.With(pc.staticLabels...).Set(limit)
+	}
+
+	pc.startTime.With(pc.staticLabels...).Set(startTime)
+
+	return nil
+}
+
+// procStat holds the fields of /proc/self/stat used by the collector.
+type procStat struct {
+	cpuSeconds    float64
+	residentBytes float64
+	virtualBytes  float64
+	startTicks    float64
+}
+
+// readStat parses /proc/self/stat.
+func (pc *ProcessCollector) readStat() (procStat, error) {
+	data, err := os.ReadFile(pc.procPath + "/stat")
+	if err != nil {
+		return procStat{}, err
+	}
+
+	// The command name is in parentheses and may contain spaces,
+	// so fields are split after the last closing parenthesis.
+	i := bytes.LastIndexByte(data, ')')
+	if i < 0 {
+		return procStat{}, os.ErrInvalid
+	}
+	// fields[0] is field 3 (state) in proc(5) numbering
+	fields := strings.Fields(string(data[i+1:]))
+	if len(fields) < 22 {
+		return procStat{}, os.ErrInvalid
+	}
+
+	utime, _ := strconv.ParseFloat(fields[11], 64)
+	stime, _ := strconv.ParseFloat(fields[12], 64)
+	startTicks, _ := strconv.ParseFloat(fields[19], 64)
+	vsize, _ := strconv.ParseFloat(fields[20], 64)
+	rss, _ := strconv.ParseFloat(fields[21], 64)
+
+	return procStat{
+		cpuSeconds:    (utime + stime) / userHZ,
+		residentBytes: rss * float64(os.Getpagesize()),
+		virtualBytes:  vsize,
+		startTicks:    startTicks,
+	}, nil
+}
+
+// readMaxFDs parses the soft open files limit from /proc/self/limits.
+func (pc *ProcessCollector) readMaxFDs() (float64, error) {
+	f, err := os.Open(pc.procPath + "/limits")
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = f.Close() }()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "Max open files") {
+			continue
+		}
+		fields := strings.Fields(strings.TrimPrefix(line, "Max open files"))
+		if len(fields) == 0 {
+			break
+		}
+		if fields[0] == "unlimited" {
+			return 0, os.ErrInvalid
+		}
+		return strconv.ParseFloat(fields[0], 64)
+	}
+	return 0, os.ErrNotExist
+}
+
+// readBootTime returns the system boot time in seconds since epoch from /proc/stat.
+func readBootTime() (float64, error) {
+	f, err := os.Open("/proc/stat")
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = f.Close() }()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if v, ok := strings.CutPrefix(scanner.Text(), "btime "); ok {
+			return strconv.ParseFloat(strings.TrimSpace(v), 64)
+		}
+	}
+	return 0, os.ErrNotExist
+}
@@ -0,0 +1,86 @@
+package metric
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/kzs0/bedrock/attr"
+)
+
+func TestProcessCollector(t *testing.T) {
+	r := NewRegistry("")
+	if err := r.RegisterCollector(NewProcessCollector(r)); err != nil {
+		t.Fatalf("failed to register collector: %v", err)
+	}
+
+	values := make(map[string]float64)
+	for _, fam := range r.Gather() {
+		if len(fam.Metrics) > 0 {
+			values[fam.Name] = fam.Metrics[0].Value
+		}
+	}
+
+	start, ok := values["process_start_time_seconds"]
+	if !ok {
+		t.Fatal("expected process_start_time_seconds metric")
+	}
+	if now := float64(time.Now().Unix()); start <= 0 || start > now+1 {
+		t.Errorf("process start time %f seems unreasonable (now: %f)", start, now)
+	}
+
+	// The remaining metrics are only available with procfs
+	if _, err := os.Stat("/proc/self/stat"); err != nil {
+		t.Skip("procfs not available")
+	}
+
+	for _, name := range []string{
+		"process_cpu_seconds_total",
+		"process_resident_memory_bytes",
+		"process_virtual_memory_bytes",
+		"process_open_fds",
+	} {
+		v, ok := values[name]
+		if !ok {
+			t.Errorf("expected metric %q not found", name)
+			continue
+		}
+		if v < 0 {
+			t.Errorf("expected non-negative value for %q, got %f", name, v)
+		}
+	}
+
+	if values["process_resident_memory_bytes"] == 0 {
+		t.Error("expected non-zero resident memory")
+	}
+}
+
+func TestProcessCollectorCPUCounter(t *testing.T) {
+	if _, err := os.Stat("/proc/self/stat"); err != nil {
+		t.Skip("procfs not available")
+	}
+
+	r := NewRegistry("app")
+	if err := r.RegisterCollector(NewProcessCollector(r, attr.String("service.name", "billing"))); err != nil {
+		t.Fatalf("failed to register collector: %v", err)
+	}
+
+	var cpu *MetricFamily
+	for _, fam := range r.Gather() {
+		if fam.Name == "app_process_cpu_seconds_total" {
+			cpu = &fam
+		}
+	}
+	if cpu == nil {
+		t.Fatal("expected app_process_cpu_seconds_total metric")
+	}
+	if cpu.Type != TypeCounter || cpu.Unit != UnitSeconds {
+		t.Errorf("expected a counter in seconds, got %s in %q", cpu.Type, cpu.Unit)
+	}
+	if len(cpu.Metrics) != 1 {
+		t.Fatalf("expected one series, got %d", len(cpu.Metrics))
+	}
+	if v, _ := cpu.Metrics[0].Labels.Get("service_name"); v.String() != "billing" {
+		t.Errorf("expected the sanitized static label, got %v", cpu.Metrics[0].Labels)
+	}
+}
@@ -35,6 +35,12 @@ func Encode(w io.Writer, families []metric.MetricFamily) error {
 		// Write TYPE line
 		fmt.Fprintf(buf, "# TYPE %s %s\n", fam.Name, fam.Type)
 
+		// Write UNIT line. The unit must be a suffix of the name, which a counter's
+		// "_total" name doesn't end in, so the line is left out for those.
+		if fam.Unit != metric.UnitNone && strings.HasSuffix(fam.Name, "_"+string(fam.Unit)) {
+			fmt.Fprintf(buf, "# UNIT %s %s\n", fam.Name, fam.Unit)
+		}
+
 		// Write metric values
 		for _, m := range fam.Metrics {
 			labelPairs := attrsToLabels(m.Labels)
@@ -0,0 +1,33 @@
+package prometheus
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/kzs0/bedrock/metric"
+)
+
+func TestEncodeUnit(t *testing.T) {
+	r := metric.NewRegistry("")
+	r.HistogramWithUnit("request_duration", "Request duration", metric.UnitSeconds, nil).Observe(0.5)
+	r.CounterWithUnit("sent_total", "Bytes sent", metric.UnitBytes).Add(10)
+	r.Counter("requests_total", "Requests").Inc()
+
+	var buf bytes.Buffer
+	if err := Encode(&buf, r.Gather()); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+
+	if !strings.Contains(out, "# UNIT request_duration_seconds seconds\n") {
+		t.Errorf("expected a UNIT line for the histogram, got:\n%s", out)
+	}
+	// The unit isn't a suffix of a counter's "_total" name
+	if strings.Contains(out, "# UNIT sent_bytes_total") {
+		t.Errorf("expected no UNIT line for the counter, got:\n%s", out)
+	}
+	if strings.Count(out, "# UNIT") != 1 {
+		t.Errorf("expected a single UNIT line, got:\n%s", out)
+	}
+}
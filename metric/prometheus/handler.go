@@ -9,7 +9,11 @@ import (
 // Handler returns an HTTP handler that serves metrics in Prometheus format.
 func Handler(registry *metric.Registry) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		families := registry.Gather()
+		families, err := registry.GatherWithError()
+		if err != nil {
+			http.Error(w, "error gathering metrics: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
 
 		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
 
@@ -1,189 +1,410 @@
 package metric
 
 import (
+	"errors"
+	"fmt"
+	"runtime"
 	"strings"
 	"sync"
 
 	"github.com/kzs0/bedrock/attr"
 )
 
-// Collector is an interface for collecting metrics before gathering.
-// Implementations should update their metrics when Collect is called.
-type Collector interface {
-	Collect()
-}
-
 // Registry is a thread-safe registry for metrics.
 type Registry struct {
+	*registryStore
+	prefix string
+}
+
+// registryStore holds the metrics of a registry and of every registry derived from it with WithPrefix.
+type registryStore struct {
 	mu         sync.RWMutex
-	prefix     string
 	counters   map[string]*Counter
 	gauges     map[string]*Gauge
 	histograms map[string]*Histogram
 	collectors []Collector
+
+	collectorsMu sync.Mutex // serializes RegisterCollector and UnregisterCollector
+
+	defaultBuckets []float64 // buckets for histograms created without any; DefaultBuckets if nil
+
+	onSanitize func(original, sanitized string)
+	sanitized  map[string]struct{} // original names already reported to onSanitize
+}
+
+// RegistryOption configures a Registry.
+type RegistryOption func(*Registry)
+
+// WithDefaultBuckets sets the buckets used for histograms created without any,
+// instead of the package-level DefaultBuckets. Empty buckets are ignored.
+func WithDefaultBuckets(buckets []float64) RegistryOption {
+	return func(r *Registry) {
+		r.defaultBuckets = buckets
+	}
 }
 
 // NewRegistry creates a new metric registry with an optional prefix.
 // The prefix is prepended to all metric names (e.g., prefix="myapp" creates "myapp_metric_name").
 // If prefix is empty, no prefix is added.
-func NewRegistry(prefix string) *Registry {
+func NewRegistry(prefix string, opts ...RegistryOption) *Registry {
+	r := &Registry{
+		registryStore: &registryStore{
+			counters:   make(map[string]*Counter),
+			gauges:     make(map[string]*Gauge),
+			histograms: make(map[string]*Histogram),
+			sanitized:  make(map[string]struct{}),
+		},
+		prefix: prefix,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// WithPrefix returns a registry that adds prefix to the names of metrics it creates,
+// after this registry's own prefix (e.g., "myapp" then "db" creates "myapp_db_metric_name").
+// This lets libraries create metrics under their own namespace or subsystem without
+// concatenating names at every call site.
+//
+// The returned registry shares its metrics and collectors with this one, so gathering
+// either registry gathers the metrics of both.
+func (r *Registry) WithPrefix(prefix string) *Registry {
+	if prefix != "" {
+		prefix = r.qualify(prefix)
+	} else {
+		prefix = r.prefix
+	}
 	return &Registry{
-		prefix:     prefix,
-		counters:   make(map[string]*Counter),
-		gauges:     make(map[string]*Gauge),
-		histograms: make(map[string]*Histogram),
+		registryStore: r.registryStore,
+		prefix:        prefix,
 	}
 }
 
+// Opts describes a metric to register with RegisterCounter, RegisterGauge, or RegisterHistogram.
+type Opts struct {
+	// Name is the metric name. The registry prefix and unit suffix are added to it.
+	Name string
+	// Help describes the metric.
+	Help string
+	// Unit is the unit of measurement, if any.
+	Unit Unit
+	// LabelNames are the label names the metric accepts.
+	LabelNames []string
+	// Buckets are the histogram bucket upper bounds. Ignored for counters and gauges.
+	Buckets []float64
+	// Sharded spreads each counter series across per-CPU shards that are summed at
+	// collection time. Ignored for gauges and histograms. See ShardedCounter.
+	Sharded bool
+}
+
+// OnSanitize sets a function that is called whenever a metric or label name
+// has to be rewritten to be a valid Prometheus name (e.g., "http.requests" -> "http_requests").
+// It is called at most once per distinct original name.
+func (r *Registry) OnSanitize(fn func(original, sanitized string)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.onSanitize = fn
+}
+
 // Counter returns or creates a counter with the given name.
 func (r *Registry) Counter(name, help string, labelNames ...string) *Counter {
+	return r.CounterWithUnit(name, help, UnitNone, labelNames...)
+}
+
+// CounterWithUnit returns or creates a counter with the given name and unit.
+// The unit is appended to the name if it doesn't already end with it.
+func (r *Registry) CounterWithUnit(name, help string, unit Unit, labelNames ...string) *Counter {
+	c, _ := r.counter(Opts{Name: name, Help: help, Unit: unit, LabelNames: labelNames}, false)
+	return c
+}
+
+// ShardedCounter returns or creates a counter whose series are sharded across CPUs.
+// Use it for extremely hot counters (millions of increments per second on one label set),
+// where atomic contention on a single series shows up in profiles.
+// Sharded series use more memory and are slightly slower to collect.
+// If a counter with the same name already exists, it is returned as-is.
+func (r *Registry) ShardedCounter(name, help string, labelNames ...string) *Counter {
+	c, _ := r.counter(Opts{Name: name, Help: help, LabelNames: labelNames, Sharded: true}, false)
+	return c
+}
+
+// RegisterCounter returns or creates a counter, without sanitizing its name or labels.
+// It returns an error wrapping ErrInvalidName if any of them isn't a valid Prometheus name.
+func (r *Registry) RegisterCounter(opts Opts) (*Counter, error) {
+	return r.counter(opts, true)
+}
+
+// counter returns or creates a counter, validating names when strict or sanitizing them otherwise.
+func (r *Registry) counter(opts Opts, strict bool) (*Counter, error) {
+	var notify []string
+	defer func() { r.notifySanitized(notify) }()
+
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	// Prepend prefix if configured
-	if r.prefix != "" {
-		name = r.prefix + "_" + name
+	name, unit, labelNames, err := r.resolveNames(opts, strict, &notify)
+	if err != nil {
+		return nil, err
 	}
 
-	// Sanitize metric name for Prometheus compatibility
-	name = sanitizeName(name)
-
 	if c, ok := r.counters[name]; ok {
-		return c
-	}
-
-	// Sanitize label names
-	sanitizedLabels := make(map[string]struct{}, len(labelNames))
-	for _, label := range labelNames {
-		sanitizedLabels[sanitizeName(label)] = struct{}{}
+		return c, nil
 	}
 
 	c := &Counter{
 		name:       name,
-		help:       help,
-		labelNames: sanitizedLabels,
-		values:     make(map[string]*counterValue),
+		help:       opts.Help,
+		unit:       unit,
+		labelNames: labelNames,
+	}
+	if opts.Sharded {
+		c.shards = runtime.GOMAXPROCS(0)
 	}
 	r.counters[name] = c
-	return c
+	return c, nil
 }
 
 // Gauge returns or creates a gauge with the given name.
 func (r *Registry) Gauge(name, help string, labelNames ...string) *Gauge {
+	return r.GaugeWithUnit(name, help, UnitNone, labelNames...)
+}
+
+// GaugeWithUnit returns or creates a gauge with the given name and unit.
+// The unit is appended to the name if it doesn't already end with it.
+func (r *Registry) GaugeWithUnit(name, help string, unit Unit, labelNames ...string) *Gauge {
+	g, _ := r.gauge(Opts{Name: name, Help: help, Unit: unit, LabelNames: labelNames}, false)
+	return g
+}
+
+// RegisterGauge returns or creates a gauge, without sanitizing its name or labels.
+// It returns an error wrapping ErrInvalidName if any of them isn't a valid Prometheus name.
+func (r *Registry) RegisterGauge(opts Opts) (*Gauge, error) {
+	return r.gauge(opts, true)
+}
+
+// gauge returns or creates a gauge, validating names when strict or sanitizing them otherwise.
+func (r *Registry) gauge(opts Opts, strict bool) (*Gauge, error) {
+	var notify []string
+	defer func() { r.notifySanitized(notify) }()
+
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	// Prepend prefix if configured
-	if r.prefix != "" {
-		name = r.prefix + "_" + name
+	name, unit, labelNames, err := r.resolveNames(opts, strict, &notify)
+	if err != nil {
+		return nil, err
 	}
 
-	// Sanitize metric name for Prometheus compatibility
-	name = sanitizeName(name)
-
 	if g, ok := r.gauges[name]; ok {
-		return g
-	}
-
-	// Sanitize label names
-	sanitizedLabels := make(map[string]struct{}, len(labelNames))
-	for _, label := range labelNames {
-		sanitizedLabels[sanitizeName(label)] = struct{}{}
+		return g, nil
 	}
 
 	g := &Gauge{
 		name:       name,
-		help:       help,
-		labelNames: sanitizedLabels,
-		values:     make(map[string]*gaugeValue),
+		help:       opts.Help,
+		unit:       unit,
+		labelNames: labelNames,
 	}
 	r.gauges[name] = g
-	return g
+	return g, nil
 }
 
 // Histogram returns or creates a histogram with the given name.
 func (r *Registry) Histogram(name, help string, buckets []float64, labelNames ...string) *Histogram {
+	return r.HistogramWithUnit(name, help, UnitNone, buckets, labelNames...)
+}
+
+// HistogramWithUnit returns or creates a histogram with the given name and unit.
+// The unit is appended to the name if it doesn't already end with it.
+func (r *Registry) HistogramWithUnit(name, help string, unit Unit, buckets []float64, labelNames ...string) *Histogram {
+	h, _ := r.histogram(Opts{Name: name, Help: help, Unit: unit, LabelNames: labelNames, Buckets: buckets}, false)
+	return h
+}
+
+// RegisterHistogram returns or creates a histogram, without sanitizing its name or labels.
+// It returns an error wrapping ErrInvalidName if any of them isn't a valid Prometheus name.
+func (r *Registry) RegisterHistogram(opts Opts) (*Histogram, error) {
+	return r.histogram(opts, true)
+}
+
+// histogram returns or creates a histogram, validating names when strict or sanitizing them otherwise.
+func (r *Registry) histogram(opts Opts, strict bool) (*Histogram, error) {
+	var notify []string
+	defer func() { r.notifySanitized(notify) }()
+
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	// Prepend prefix if configured
-	if r.prefix != "" {
-		name = r.prefix + "_" + name
+	name, unit, labelNames, err := r.resolveNames(opts, strict, &notify)
+	if err != nil {
+		return nil, err
 	}
 
-	// Sanitize metric name for Prometheus compatibility
-	name = sanitizeName(name)
-
 	if h, ok := r.histograms[name]; ok {
-		return h
+		return h, nil
 	}
 
+	buckets := opts.Buckets
 	if len(buckets) == 0 {
-		buckets = DefaultBuckets
+		buckets = r.defaultBuckets
 	}
-
-	// Sanitize label names
-	sanitizedLabels := make(map[string]struct{}, len(labelNames))
-	for _, label := range labelNames {
-		sanitizedLabels[sanitizeName(label)] = struct{}{}
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
 	}
 
 	h := &Histogram{
 		name:       name,
-		help:       help,
+		help:       opts.Help,
+		unit:       unit,
 		buckets:    buckets,
-		labelNames: sanitizedLabels,
-		values:     make(map[string]*histogramValue),
+		labelNames: labelNames,
 	}
 	r.histograms[name] = h
-	return h
+	return h, nil
 }
 
-// RegisterCollector adds a collector that will be called before gathering metrics.
-// This is useful for collectors that need to update metrics on-demand (e.g., runtime metrics).
-func (r *Registry) RegisterCollector(c Collector) {
-	r.mu.Lock()
-	defer r.mu.Unlock()
-	r.collectors = append(r.collectors, c)
+// resolveNames computes the full metric name, unit, and label name set for opts.
+// When strict, invalid names are reported as errors. Otherwise they are sanitized
+// for Prometheus compatibility, and names that changed are appended to notify
+// (pairs of original and sanitized names) if they haven't been reported before.
+// Must be called with r.mu held.
+func (r *Registry) resolveNames(opts Opts, strict bool, notify *[]string) (string, Unit, map[string]struct{}, error) {
+	name := r.qualify(opts.Name)
+
+	resolve := func(n string, label bool) (string, error) {
+		if strict && label {
+			return n, ValidateLabelName(n)
+		}
+		if strict {
+			return n, ValidateName(n)
+		}
+		sanitized := sanitize(n, !label)
+		if sanitized != n {
+			if _, ok := r.sanitized[n]; !ok && r.onSanitize != nil {
+				r.sanitized[n] = struct{}{}
+				*notify = append(*notify, n, sanitized)
+			}
+		}
+		return sanitized, nil
+	}
+
+	unit, err := resolve(string(opts.Unit), false)
+	if err != nil && opts.Unit != UnitNone {
+		return "", "", nil, err
+	}
+
+	// Sanitize metric name for Prometheus compatibility
+	name, err = resolve(withUnitSuffix(name, Unit(unit)), false)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	// Sanitize label names
+	labelNames := make(map[string]struct{}, len(opts.LabelNames))
+	for _, label := range opts.LabelNames {
+		label, err := resolve(label, true)
+		if err != nil {
+			return "", "", nil, err
+		}
+		labelNames[label] = struct{}{}
+	}
+
+	return name, Unit(unit), labelNames, nil
+}
+
+// qualify prepends the registry prefix, if configured, to a metric name.
+func (r *Registry) qualify(name string) string {
+	if r.prefix != "" {
+		return r.prefix + "_" + name
+	}
+	return name
+}
+
+// notifySanitized reports sanitized names to the OnSanitize function.
+// It is called after the registry lock is released so the callback may use the registry.
+func (r *Registry) notifySanitized(pairs []string) {
+	if len(pairs) == 0 {
+		return
+	}
+	r.mu.RLock()
+	fn := r.onSanitize
+	r.mu.RUnlock()
+	for i := 0; i+1 < len(pairs); i += 2 {
+		fn(pairs[i], pairs[i+1])
+	}
 }
 
 // Gather collects all metrics for exposition.
 // It first calls all registered collectors, then gathers all metric families.
+// Collector errors are ignored; use GatherWithError to observe them.
 func (r *Registry) Gather() []MetricFamily {
+	families, _ := r.GatherWithError()
+	return families
+}
+
+// GatherWithError collects all metrics for exposition and reports collector errors.
+// Families from collectors that succeeded, and from the registry itself, are
+// always returned, even if some collectors failed.
+func (r *Registry) GatherWithError() ([]MetricFamily, error) {
 	// Call all registered collectors first (outside the read lock)
 	r.mu.RLock()
 	collectors := r.collectors
 	r.mu.RUnlock()
 
+	var emitted []MetricFamily
+	emit := func(f MetricFamily) {
+		emitted = append(emitted, f)
+	}
+
+	var errs []error
 	for _, c := range collectors {
-		c.Collect()
+		if err := c.Collect(emit); err != nil {
+			errs = append(errs, err)
+		}
 	}
 
-	// Now gather all metrics
+	// Snapshot the metrics under the read lock, then collect them without it
+	// so a slow scrape never blocks metric creation.
 	r.mu.RLock()
-	defer r.mu.RUnlock()
+	counters := make([]*Counter, 0, len(r.counters))
+	for _, c := range r.counters {
+		counters = append(counters, c)
+	}
+	gauges := make([]*Gauge, 0, len(r.gauges))
+	for _, g := range r.gauges {
+		gauges = append(gauges, g)
+	}
+	histograms := make([]*Histogram, 0, len(r.histograms))
+	for _, h := range r.histograms {
+		histograms = append(histograms, h)
+	}
+	r.mu.RUnlock()
 
-	families := make([]MetricFamily, 0, len(r.counters)+len(r.gauges)+len(r.histograms))
+	families := make([]MetricFamily, 0, len(counters)+len(gauges)+len(histograms)+len(emitted))
 
-	for _, c := range r.counters {
+	for _, c := range counters {
 		families = append(families, c.collect())
 	}
-	for _, g := range r.gauges {
+	for _, g := range gauges {
 		families = append(families, g.collect())
 	}
-	for _, h := range r.histograms {
+	for _, h := range histograms {
 		families = append(families, h.collect())
 	}
+	families = append(families, emitted...)
 
-	return families
+	return families, errors.Join(errs...)
 }
 
 // MetricFamily represents a collection of metrics with the same name.
 type MetricFamily struct {
-	Name    string
-	Help    string
-	Type    MetricType
-	Metrics []Metric
+	Name        string
+	Help        string
+	Unit        Unit
+	Type        MetricType
+	Temporality Temporality // Cumulative unless converted with a DeltaConverter
+	Metrics     []Metric
 }
 
 // MetricType is the type of a metric.
@@ -213,15 +434,62 @@ type Bucket struct {
 // DefaultBuckets are the default histogram buckets.
 var DefaultBuckets = []float64{.5, 1, 2.5, 5, 10, 25, 50, 100, 250, 500, 1000}
 
-// sanitizeName converts metric/label names to valid Prometheus names.
-// Prometheus metric and label names must match [a-zA-Z_:][a-zA-Z0-9_:]*.
+// DefaultSecondsBuckets are the default buckets for durations measured in seconds.
+// They match the Prometheus client defaults.
+var DefaultSecondsBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// ErrInvalidName is returned when a metric or label name isn't a valid Prometheus name.
+var ErrInvalidName = errors.New("metric: invalid name")
+
+// ValidateName reports whether name is a valid Prometheus metric or label name.
+// Valid names match [a-zA-Z_:][a-zA-Z0-9_:]*.
+func ValidateName(name string) error {
+	if name == "" {
+		return fmt.Errorf("%w: empty name", ErrInvalidName)
+	}
+	for i, r := range name {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || r == '_' || r == ':' || (i > 0 && r >= '0' && r <= '9') {
+			continue
+		}
+		return fmt.Errorf("%w: %q (would be sanitized to %q)", ErrInvalidName, name, sanitizeName(name))
+	}
+	return nil
+}
+
+// ValidateLabelName reports whether name is a valid Prometheus label name.
+// Valid names match [a-zA-Z_][a-zA-Z0-9_]*; unlike metric names, they can't contain colons.
+func ValidateLabelName(name string) error {
+	if err := ValidateName(name); err != nil {
+		return err
+	}
+	if strings.Contains(name, ":") {
+		return fmt.Errorf("%w: %q (would be sanitized to %q)", ErrInvalidName, name, SanitizeLabelName(name))
+	}
+	return nil
+}
+
+// SanitizeLabelName converts name to a valid Prometheus label name, as the registry does
+// for label names and attribute keys, replacing dots, colons, and other invalid
+// characters with underscores (e.g., "http.method" becomes "http_method").
+func SanitizeLabelName(name string) string {
+	return sanitize(name, false)
+}
+
+// sanitizeName converts metric names to valid Prometheus names.
+// Prometheus metric names must match [a-zA-Z_:][a-zA-Z0-9_:]*.
 // This replaces dots and other invalid characters with underscores.
 func sanitizeName(name string) string {
+	return sanitize(name, true)
+}
+
+// sanitize replaces dots and other invalid characters in name with underscores,
+// keeping colons only if colon is set.
+func sanitize(name string, colon bool) string {
 	// Replace dots with underscores
 	name = strings.ReplaceAll(name, ".", "_")
-	// Replace any other non-alphanumeric characters (except underscores and colons)
+	// Replace any other non-alphanumeric characters (except underscores, and colons if allowed)
 	return strings.Map(func(r rune) rune {
-		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '_' || r == ':' {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '_' || (colon && r == ':') {
 			return r
 		}
 		return '_'
@@ -1,9 +1,13 @@
 package metric
 
 import (
+	"math"
+	"math/rand/v2"
 	"runtime"
 	"runtime/metrics"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/kzs0/bedrock/attr"
 )
@@ -35,13 +39,25 @@ type RuntimeCollector struct {
 	gcNumForcedGC    *Gauge
 	cpuClasses       map[string]*Gauge
 	memoryClasses    map[string]*Gauge
+	histograms       map[string]Desc // runtime/metrics histograms, keyed by runtime name
 
+	// mu guards memoryClasses and histograms. It is never held while calling into the
+	// registry, which calls Describe with its own lock held.
 	mu sync.Mutex
+
+	// Background collection, see Start
+	loopMu sync.Mutex
+	stop   chan struct{}
+	done   chan struct{}
 }
 
 // NewRuntimeCollector creates a new runtime metrics collector.
 // The static labels are automatically applied to all metrics.
 func NewRuntimeCollector(registry *Registry, staticLabels ...attr.Attr) *RuntimeCollector {
+	// Sanitize the static label keys up front, as the runtime histograms are emitted
+	// directly rather than through the registry
+	staticLabels = sanitizeLabelKeys(staticLabels)
+
 	// Extract label names from static labels
 	labelNames := make([]string, 0, len(staticLabels))
 	for _, label := range staticLabels {
@@ -53,6 +69,7 @@ func NewRuntimeCollector(registry *Registry, staticLabels ...attr.Attr) *Runtime
 		staticLabels: staticLabels,
 		cpuClasses:   make(map[string]*Gauge),
 		memoryClasses: make(map[string]*Gauge),
+		histograms:   make(map[string]Desc),
 	}
 
 	// Create gauges for basic runtime metrics
@@ -61,33 +78,54 @@ func NewRuntimeCollector(registry *Registry, staticLabels ...attr.Attr) *Runtime
 	rc.threads = registry.Gauge("go_threads", "Number of OS threads created", labelNames...)
 
 	// Memory metrics
-	rc.heapAllocBytes = registry.Gauge("go_memstats_heap_alloc_bytes", "Number of heap bytes allocated and still in use", labelNames...)
-	rc.heapIdleBytes = registry.Gauge("go_memstats_heap_idle_bytes", "Number of heap bytes waiting to be used", labelNames...)
-	rc.heapInuseBytes = registry.Gauge("go_memstats_heap_inuse_bytes", "Number of heap bytes that are in use", labelNames...)
+	rc.heapAllocBytes = registry.GaugeWithUnit("go_memstats_heap_alloc_bytes", "Number of heap bytes allocated and still in use", UnitBytes, labelNames...)
+	rc.heapIdleBytes = registry.GaugeWithUnit("go_memstats_heap_idle_bytes", "Number of heap bytes waiting to be used", UnitBytes, labelNames...)
+	rc.heapInuseBytes = registry.GaugeWithUnit("go_memstats_heap_inuse_bytes", "Number of heap bytes that are in use", UnitBytes, labelNames...)
 	rc.heapObjects = registry.Gauge("go_memstats_heap_objects", "Number of allocated objects", labelNames...)
-	rc.heapReleasedBytes = registry.Gauge("go_memstats_heap_released_bytes", "Number of heap bytes released to OS", labelNames...)
-	rc.stackInuseBytes = registry.Gauge("go_memstats_stack_inuse_bytes", "Number of bytes in use by the stack allocator", labelNames...)
-	rc.stackSysBytes = registry.Gauge("go_memstats_stack_sys_bytes", "Number of bytes obtained from system for stack allocator", labelNames...)
+	rc.heapReleasedBytes = registry.GaugeWithUnit("go_memstats_heap_released_bytes", "Number of heap bytes released to OS", UnitBytes, labelNames...)
+	rc.stackInuseBytes = registry.GaugeWithUnit("go_memstats_stack_inuse_bytes", "Number of bytes in use by the stack allocator", UnitBytes, labelNames...)
+	rc.stackSysBytes = registry.GaugeWithUnit("go_memstats_stack_sys_bytes", "Number of bytes obtained from system for stack allocator", UnitBytes, labelNames...)
 	rc.mallocs = registry.Gauge("go_memstats_mallocs_total", "Total number of mallocs", labelNames...)
 	rc.frees = registry.Gauge("go_memstats_frees_total", "Total number of frees", labelNames...)
 
 	// GC metrics
-	rc.gcSysBytes = registry.Gauge("go_memstats_gc_sys_bytes", "Number of bytes used for garbage collection system metadata", labelNames...)
-	rc.gcNextBytes = registry.Gauge("go_memstats_next_gc_bytes", "Number of heap bytes when next garbage collection will take place", labelNames...)
-	rc.gcLastNanos = registry.Gauge("go_memstats_last_gc_time_seconds", "Time of last garbage collection in seconds since epoch", labelNames...)
-	rc.gcPauseTotalNanos = registry.Gauge("go_gc_duration_seconds_total", "Total garbage collection pause time in seconds", labelNames...)
+	rc.gcSysBytes = registry.GaugeWithUnit("go_memstats_gc_sys_bytes", "Number of bytes used for garbage collection system metadata", UnitBytes, labelNames...)
+	rc.gcNextBytes = registry.GaugeWithUnit("go_memstats_next_gc_bytes", "Number of heap bytes when next garbage collection will take place", UnitBytes, labelNames...)
+	rc.gcLastNanos = registry.GaugeWithUnit("go_memstats_last_gc_time_seconds", "Time of last garbage collection in seconds since epoch", UnitSeconds, labelNames...)
+	rc.gcPauseTotalNanos = registry.GaugeWithUnit("go_gc_duration_seconds_total", "Total garbage collection pause time in seconds", UnitSeconds, labelNames...)
 	rc.gcNumGC = registry.Gauge("go_gc_cycles_total", "Total number of completed GC cycles", labelNames...)
 	rc.gcNumForcedGC = registry.Gauge("go_gc_cycles_forced_total", "Total number of forced GC cycles", labelNames...)
 
 	return rc
 }
 
-// Collect updates all runtime metrics with current values.
-// This should be called periodically or before scraping metrics.
-func (rc *RuntimeCollector) Collect() {
+// Describe reports the runtime metric families.
+// Families derived from runtime/metrics are only described once they've been collected.
+func (rc *RuntimeCollector) Describe(describe func(Desc)) {
 	rc.mu.Lock()
 	defer rc.mu.Unlock()
 
+	for _, g := range []*Gauge{
+		rc.goInfo, rc.goroutines, rc.threads,
+		rc.heapAllocBytes, rc.heapIdleBytes, rc.heapInuseBytes, rc.heapObjects, rc.heapReleasedBytes,
+		rc.stackInuseBytes, rc.stackSysBytes, rc.mallocs, rc.frees,
+		rc.gcSysBytes, rc.gcNextBytes, rc.gcLastNanos, rc.gcPauseTotalNanos, rc.gcNumGC, rc.gcNumForcedGC,
+	} {
+		describe(g.Desc())
+	}
+	for _, g := range rc.memoryClasses {
+		describe(g.Desc())
+	}
+	for _, d := range rc.histograms {
+		describe(d)
+	}
+}
+
+// Collect updates all runtime metrics with current values.
+// This should be called periodically or before scraping metrics.
+// Most metrics live in the registry; runtime/metrics duration histograms
+// (e.g., scheduler latencies and GC pauses) are emitted directly.
+func (rc *RuntimeCollector) Collect(emit func(MetricFamily)) error {
 	// Set Go version info
 	versionLabels := append(rc.staticLabels, attr.String("version", runtime.Version()))
 	rc.goInfo.With(versionLabels...).Set(1)
@@ -117,11 +155,70 @@ func (rc *RuntimeCollector) Collect() {
 	rc.gcNumForcedGC.With(rc.staticLabels...).Set(float64(memStats.NumForcedGC))
 
 	// Read runtime/metrics for additional data
-	rc.collectRuntimeMetrics()
+	rc.collectRuntimeMetrics(emit)
+
+	return nil
+}
+
+// Start collects runtime metrics in the background every interval until Stop is called,
+// so push-based exporters and long scrape intervals still see fresh values.
+// Each wait is extended by a random duration of up to jitter, so that many instances
+// don't collect in lockstep. Gathering the registry still collects on demand.
+// Calling Start while background collection is running has no effect.
+func (rc *RuntimeCollector) Start(interval, jitter time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	rc.loopMu.Lock()
+	defer rc.loopMu.Unlock()
+
+	if rc.stop != nil {
+		return
+	}
+	rc.stop = make(chan struct{})
+	rc.done = make(chan struct{})
+
+	go rc.loop(interval, jitter, rc.stop, rc.done)
+}
+
+// Stop stops background collection started with Start and waits for it to exit.
+func (rc *RuntimeCollector) Stop() {
+	rc.loopMu.Lock()
+	defer rc.loopMu.Unlock()
+
+	if rc.stop == nil {
+		return
+	}
+	close(rc.stop)
+	<-rc.done
+	rc.stop, rc.done = nil, nil
+}
+
+// loop runs background collection until stop is closed.
+func (rc *RuntimeCollector) loop(interval, jitter time.Duration, stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+
+	for {
+		wait := interval
+		if jitter > 0 {
+			wait += rand.N(jitter)
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-stop:
+			timer.Stop()
+			return
+		case <-timer.C:
+			// Emitted histograms are built on demand during Gather, so they're dropped here
+			_ = rc.Collect(func(MetricFamily) {})
+		}
+	}
 }
 
 // collectRuntimeMetrics collects metrics from the runtime/metrics package.
-func (rc *RuntimeCollector) collectRuntimeMetrics() {
+func (rc *RuntimeCollector) collectRuntimeMetrics(emit func(MetricFamily)) {
 	// Define the metrics we want to read
 	descs := metrics.All()
 	samples := make([]metrics.Sample, len(descs))
@@ -142,8 +239,10 @@ func (rc *RuntimeCollector) collectRuntimeMetrics() {
 		case metrics.KindFloat64:
 			rc.setRuntimeMetric(name, value.Float64())
 		case metrics.KindFloat64Histogram:
-			// Skip histograms for now - they require more complex handling
-			continue
+			// Only duration histograms have meaningful fixed buckets
+			if strings.HasSuffix(name, ":seconds") {
+				emit(rc.runtimeHistogram(name, value.Float64Histogram()))
+			}
 		case metrics.KindBad:
 			continue
 		}
@@ -157,7 +256,9 @@ func (rc *RuntimeCollector) setRuntimeMetric(name string, value float64) {
 	promName := sanitizeRuntimeMetricName(name)
 
 	// Check if we already have this gauge in our memory classes map
+	rc.mu.Lock()
 	gauge, ok := rc.memoryClasses[name]
+	rc.mu.Unlock()
 	if !ok {
 		// Extract label names from static labels
 		labelNames := make([]string, 0, len(rc.staticLabels))
@@ -165,14 +266,84 @@ func (rc *RuntimeCollector) setRuntimeMetric(name string, value float64) {
 			labelNames = append(labelNames, label.Key)
 		}
 
-		// Create the gauge
+		// Create the gauge outside rc.mu; concurrent collections get the same gauge back
 		gauge = rc.registry.Gauge(promName, "Go runtime metric: "+name, labelNames...)
+		rc.mu.Lock()
 		rc.memoryClasses[name] = gauge
+		rc.mu.Unlock()
 	}
 
 	gauge.With(rc.staticLabels...).Set(value)
 }
 
+// runtimeHistogramBuckets are the upper bounds, in seconds, that runtime/metrics
+// duration histograms are re-bucketed into. The runtime's own buckets are far
+// too fine-grained to expose directly.
+var runtimeHistogramBuckets = []float64{1e-6, 1e-5, 1e-4, 2.5e-4, 5e-4, 1e-3, 2.5e-3, 5e-3, 1e-2, 2.5e-2, 5e-2, .1, .25, .5, 1}
+
+// runtimeHistogram converts a runtime/metrics histogram into a histogram family.
+// Each runtime bucket is counted in the first bound that contains its upper edge.
+// The runtime doesn't track the sum of observations, so it is estimated from
+// the bucket midpoints.
+func (rc *RuntimeCollector) runtimeHistogram(name string, h *metrics.Float64Histogram) MetricFamily {
+	rc.mu.Lock()
+	d, ok := rc.histograms[name]
+	if !ok {
+		d = Desc{
+			Name: rc.registry.qualify(sanitizeRuntimeMetricName(name)),
+			Help: "Go runtime metric: " + name,
+			Type: TypeHistogram,
+			Unit: UnitSeconds,
+		}
+		rc.histograms[name] = d
+	}
+	rc.mu.Unlock()
+
+	buckets := make([]Bucket, len(runtimeHistogramBuckets))
+	for i, bound := range runtimeHistogramBuckets {
+		buckets[i].UpperBound = bound
+	}
+
+	var count uint64
+	var sum float64
+	for i, c := range h.Counts {
+		if c == 0 {
+			continue
+		}
+		lo, hi := h.Buckets[i], h.Buckets[i+1]
+		count += c
+
+		switch {
+		case math.IsInf(lo, -1):
+			sum += float64(c) * hi
+		case math.IsInf(hi, 1):
+			sum += float64(c) * lo
+		default:
+			sum += float64(c) * (lo + hi) / 2
+		}
+
+		// Buckets are cumulative, so count the runtime bucket in every bound above it
+		for j := range buckets {
+			if hi <= buckets[j].UpperBound {
+				buckets[j].Count += c
+			}
+		}
+	}
+
+	return MetricFamily{
+		Name: d.Name,
+		Help: d.Help,
+		Unit: d.Unit,
+		Type: d.Type,
+		Metrics: []Metric{{
+			Labels:  attr.NewSet(rc.staticLabels...),
+			Buckets: buckets,
+			Count:   count,
+			Sum:     sum,
+		}},
+	}
+}
+
 // sanitizeRuntimeMetricName converts a runtime/metrics name to a Prometheus-compatible name.
 // e.g., "/gc/heap/allocs:bytes" -> "go_runtime_gc_heap_allocs_bytes"
 func sanitizeRuntimeMetricName(name string) string {
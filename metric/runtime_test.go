@@ -3,6 +3,7 @@ package metric
 import (
 	"runtime"
 	"testing"
+	"time"
 
 	"github.com/kzs0/bedrock/attr"
 )
@@ -12,7 +13,7 @@ func TestRuntimeCollector(t *testing.T) {
 	collector := NewRuntimeCollector(r)
 
 	// Trigger collection
-	collector.Collect()
+	_ = collector.Collect(func(MetricFamily) {})
 
 	// Gather metrics
 	families := r.Gather()
@@ -58,7 +59,7 @@ func TestRuntimeCollectorWithStaticLabels(t *testing.T) {
 	collector := NewRuntimeCollector(r, staticLabels...)
 
 	// Trigger collection
-	collector.Collect()
+	_ = collector.Collect(func(MetricFamily) {})
 
 	// Gather metrics
 	families := r.Gather()
@@ -98,7 +99,7 @@ func TestRuntimeCollectorGoroutineCount(t *testing.T) {
 	r := NewRegistry("")
 	collector := NewRuntimeCollector(r)
 
-	collector.Collect()
+	_ = collector.Collect(func(MetricFamily) {})
 
 	families := r.Gather()
 
@@ -131,7 +132,7 @@ func TestRuntimeCollectorGoInfo(t *testing.T) {
 	r := NewRegistry("")
 	collector := NewRuntimeCollector(r)
 
-	collector.Collect()
+	_ = collector.Collect(func(MetricFamily) {})
 
 	families := r.Gather()
 
@@ -170,7 +171,9 @@ func TestRuntimeCollectorGoInfo(t *testing.T) {
 func TestRuntimeCollectorRegisteredWithRegistry(t *testing.T) {
 	r := NewRegistry("")
 	collector := NewRuntimeCollector(r)
-	r.RegisterCollector(collector)
+	if err := r.RegisterCollector(collector); err != nil {
+		t.Fatalf("failed to register collector: %v", err)
+	}
 
 	// Gather should automatically call Collect
 	families := r.Gather()
@@ -215,7 +218,9 @@ func TestRegistryCollectorInterface(t *testing.T) {
 	collected := false
 	mock := &mockCollector{collectFunc: func() { collected = true }}
 
-	r.RegisterCollector(mock)
+	if err := r.RegisterCollector(mock); err != nil {
+		t.Fatalf("failed to register collector: %v", err)
+	}
 
 	// Gather should trigger the collector
 	_ = r.Gather()
@@ -227,8 +232,125 @@ func TestRegistryCollectorInterface(t *testing.T) {
 
 type mockCollector struct {
 	collectFunc func()
+	descs       []Desc
+	families    []MetricFamily
+	err         error
+}
+
+func (m *mockCollector) Describe(describe func(Desc)) {
+	for _, d := range m.descs {
+		describe(d)
+	}
 }
 
-func (m *mockCollector) Collect() {
-	m.collectFunc()
+func (m *mockCollector) Collect(emit func(MetricFamily)) error {
+	if m.collectFunc != nil {
+		m.collectFunc()
+	}
+	for _, f := range m.families {
+		emit(f)
+	}
+	return m.err
+}
+
+func TestRuntimeCollectorHistograms(t *testing.T) {
+	r := NewRegistry("")
+	collector := NewRuntimeCollector(r, attr.String("env", "test"))
+
+	runtime.GC()
+	families := map[string]MetricFamily{}
+	_ = collector.Collect(func(f MetricFamily) {
+		families[f.Name] = f
+	})
+
+	for _, name := range []string{"go_runtime__sched_latencies_seconds", "go_runtime__gc_pauses_seconds"} {
+		fam, ok := families[name]
+		if !ok {
+			t.Errorf("expected histogram %q to be emitted", name)
+			continue
+		}
+		if fam.Type != TypeHistogram {
+			t.Errorf("%s: expected histogram type, got %s", name, fam.Type)
+		}
+		if len(fam.Metrics) != 1 {
+			t.Fatalf("%s: expected 1 metric, got %d", name, len(fam.Metrics))
+		}
+
+		m := fam.Metrics[0]
+		if _, ok := m.Labels.Get("env"); !ok {
+			t.Errorf("%s: expected static label env", name)
+		}
+		if len(m.Buckets) != len(runtimeHistogramBuckets) {
+			t.Fatalf("%s: expected %d buckets, got %d", name, len(runtimeHistogramBuckets), len(m.Buckets))
+		}
+		var prev uint64
+		for _, b := range m.Buckets {
+			if b.Count < prev {
+				t.Errorf("%s: expected cumulative buckets, got %d after %d", name, b.Count, prev)
+			}
+			prev = b.Count
+		}
+		if m.Count < prev {
+			t.Errorf("%s: expected count %d >= last bucket %d", name, m.Count, prev)
+		}
+	}
+
+	// Emitted histograms are described once collected
+	described := map[string]bool{}
+	collector.Describe(func(d Desc) { described[d.Name] = true })
+	if !described["go_runtime__sched_latencies_seconds"] {
+		t.Error("expected scheduler latency histogram to be described")
+	}
+}
+
+func TestRuntimeCollectorBackground(t *testing.T) {
+	r := NewRegistry("")
+	collector := NewRuntimeCollector(r)
+
+	collector.Start(time.Millisecond, time.Millisecond)
+	collector.Start(time.Millisecond, 0) // no-op while running
+	defer collector.Stop()
+
+	// Read the gauge directly, since Gather would collect on demand
+	deadline := time.Now().Add(time.Second)
+	for len(collector.goroutines.collect().Metrics) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("expected background collection to update go_goroutines")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	collector.Stop()
+	collector.Stop() // no-op once stopped
+}
+
+func TestRuntimeCollectorSanitizesStaticLabels(t *testing.T) {
+	r := NewRegistry("")
+	collector := NewRuntimeCollector(r, attr.String("deploy.env", "prod"))
+
+	var histograms int
+	_ = collector.Collect(func(f MetricFamily) {
+		if f.Type != TypeHistogram {
+			return
+		}
+		histograms++
+		labels := f.Metrics[0].Labels
+		if labels.Has("deploy.env") {
+			t.Errorf("%s: expected no raw static label key", f.Name)
+		}
+		if v, _ := labels.Get("deploy_env"); v.String() != "prod" {
+			t.Errorf("%s: expected static label deploy_env=prod, got %q", f.Name, v.String())
+		}
+	})
+	if histograms == 0 {
+		t.Fatal("expected runtime histograms to be emitted")
+	}
+
+	for _, fam := range r.Gather() {
+		for _, m := range fam.Metrics {
+			if m.Labels.Has("deploy.env") {
+				t.Errorf("%s: expected no raw static label key", fam.Name)
+			}
+		}
+	}
 }
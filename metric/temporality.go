@@ -0,0 +1,112 @@
+package metric
+
+import "sync"
+
+// Temporality describes whether metric values accumulate since process start
+// or only cover the interval since the previous export.
+type Temporality int
+
+const (
+	// TemporalityCumulative values accumulate since the metric was created.
+	// This is what the registry produces and what Prometheus expects.
+	TemporalityCumulative Temporality = iota
+	// TemporalityDelta values cover only the interval since the previous export.
+	// Some push backends (e.g., Dynatrace, StatsD) require delta counters.
+	TemporalityDelta
+)
+
+// String returns the name of the temporality.
+func (t Temporality) String() string {
+	switch t {
+	case TemporalityDelta:
+		return "delta"
+	default:
+		return "cumulative"
+	}
+}
+
+// DeltaConverter converts cumulative metric families into delta families for push exporters.
+// It remembers the last value of every counter and histogram series it has seen,
+// so a single converter must be used per export destination.
+// Gauges are passed through unchanged since they have no temporality.
+type DeltaConverter struct {
+	mu   sync.Mutex
+	last map[string]Metric
+}
+
+// NewDeltaConverter creates a new delta converter.
+func NewDeltaConverter() *DeltaConverter {
+	return &DeltaConverter{
+		last: make(map[string]Metric),
+	}
+}
+
+// Convert returns the per-interval values of the given cumulative families.
+// The first time a series is seen its full cumulative value is reported.
+// If a series' value decreases (e.g., after a process restart) it is treated as a reset.
+func (d *DeltaConverter) Convert(families []MetricFamily) []MetricFamily {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	result := make([]MetricFamily, 0, len(families))
+	for _, fam := range families {
+		if fam.Type == TypeGauge || fam.Temporality == TemporalityDelta {
+			result = append(result, fam)
+			continue
+		}
+
+		metrics := make([]Metric, len(fam.Metrics))
+		for i, m := range fam.Metrics {
+			key := fam.Name + "{" + labelsKey(m.Labels.Attrs()) + "}"
+			prev, ok := d.last[key]
+			d.last[key] = m
+
+			if !ok {
+				metrics[i] = m
+				continue
+			}
+
+			switch fam.Type {
+			case TypeCounter:
+				metrics[i] = counterDelta(m, prev)
+			case TypeHistogram:
+				metrics[i] = histogramDelta(m, prev)
+			}
+		}
+
+		fam.Metrics = metrics
+		fam.Temporality = TemporalityDelta
+		result = append(result, fam)
+	}
+
+	return result
+}
+
+// counterDelta returns the counter increase between prev and m.
+func counterDelta(m, prev Metric) Metric {
+	if m.Value < prev.Value {
+		return m // counter reset
+	}
+	m.Value -= prev.Value
+	return m
+}
+
+// histogramDelta returns the histogram observations recorded between prev and m.
+func histogramDelta(m, prev Metric) Metric {
+	if m.Count < prev.Count || len(m.Buckets) != len(prev.Buckets) {
+		return m // histogram reset
+	}
+
+	buckets := make([]Bucket, len(m.Buckets))
+	for i, b := range m.Buckets {
+		buckets[i] = Bucket{
+			UpperBound: b.UpperBound,
+			Count:      b.Count - prev.Buckets[i].Count,
+		}
+	}
+
+	m.Buckets = buckets
+	m.Count -= prev.Count
+	m.Sum -= prev.Sum
+	return m
+}
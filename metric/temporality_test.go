@@ -0,0 +1,72 @@
+package metric
+
+import (
+	"testing"
+
+	"github.com/kzs0/bedrock/attr"
+)
+
+func TestDeltaConverterCounter(t *testing.T) {
+	r := NewRegistry("")
+	c := r.Counter("requests_total", "Total requests", "method")
+	d := NewDeltaConverter()
+
+	c.With(attr.String("method", "GET")).Add(5)
+	families := d.Convert(r.Gather())
+	if families[0].Temporality != TemporalityDelta {
+		t.Errorf("expected delta temporality, got %v", families[0].Temporality)
+	}
+	if v := families[0].Metrics[0].Value; v != 5 {
+		t.Errorf("expected first delta 5, got %f", v)
+	}
+
+	c.With(attr.String("method", "GET")).Add(3)
+	families = d.Convert(r.Gather())
+	if v := families[0].Metrics[0].Value; v != 3 {
+		t.Errorf("expected second delta 3, got %f", v)
+	}
+
+	families = d.Convert(r.Gather())
+	if v := families[0].Metrics[0].Value; v != 0 {
+		t.Errorf("expected zero delta with no increments, got %f", v)
+	}
+}
+
+func TestDeltaConverterHistogram(t *testing.T) {
+	r := NewRegistry("")
+	h := r.Histogram("latency", "Latency", []float64{1, 10})
+	d := NewDeltaConverter()
+
+	h.Observe(0.5)
+	h.Observe(5)
+	_ = d.Convert(r.Gather())
+
+	h.Observe(5)
+	families := d.Convert(r.Gather())
+	m := families[0].Metrics[0]
+	if m.Count != 1 {
+		t.Errorf("expected delta count 1, got %d", m.Count)
+	}
+	if m.Sum != 5 {
+		t.Errorf("expected delta sum 5, got %f", m.Sum)
+	}
+	if m.Buckets[0].Count != 0 || m.Buckets[1].Count != 1 {
+		t.Errorf("expected delta buckets [0 1], got [%d %d]", m.Buckets[0].Count, m.Buckets[1].Count)
+	}
+}
+
+func TestDeltaConverterGaugePassthrough(t *testing.T) {
+	r := NewRegistry("")
+	g := r.Gauge("queue_depth", "Queue depth")
+	d := NewDeltaConverter()
+
+	g.Set(10)
+	_ = d.Convert(r.Gather())
+	families := d.Convert(r.Gather())
+	if families[0].Temporality != TemporalityCumulative {
+		t.Errorf("expected gauge temporality to be unchanged, got %v", families[0].Temporality)
+	}
+	if v := families[0].Metrics[0].Value; v != 10 {
+		t.Errorf("expected gauge value 10, got %f", v)
+	}
+}
@@ -0,0 +1,40 @@
+package metric
+
+import "strings"
+
+// Unit is the unit of measurement for a metric.
+// Units are exposed as OpenMetrics UNIT metadata so dashboards don't have to
+// guess them from metric names. The metadata is left out for counters, whose
+// "_total" names don't end in the unit as OpenMetrics requires.
+type Unit string
+
+const (
+	UnitNone    Unit = ""
+	UnitSeconds Unit = "seconds"
+	UnitBytes   Unit = "bytes"
+	UnitRatio   Unit = "ratio"
+	UnitCelsius Unit = "celsius"
+	UnitMeters  Unit = "meters"
+	UnitVolts   Unit = "volts"
+	UnitAmperes Unit = "amperes"
+	UnitJoules  Unit = "joules"
+	UnitGrams   Unit = "grams"
+)
+
+// withUnitSuffix returns name with the unit suffix appended if it is missing.
+// OpenMetrics requires metrics with a unit to have the unit as a name suffix,
+// optionally followed by "_total" for counters.
+// e.g., ("request_duration", UnitSeconds) -> "request_duration_seconds"
+func withUnitSuffix(name string, unit Unit) string {
+	if unit == UnitNone {
+		return name
+	}
+	suffix := "_" + string(unit)
+	if strings.HasSuffix(name, suffix) || strings.HasSuffix(name, suffix+"_total") {
+		return name
+	}
+	if base, ok := strings.CutSuffix(name, "_total"); ok {
+		return base + suffix + "_total"
+	}
+	return name + suffix
+}
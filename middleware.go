@@ -1,12 +1,22 @@
 package bedrock
 
 import (
+	"bufio"
 	"context"
 	"fmt"
+	"io"
+	"net"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/kzs0/bedrock/attr"
+	"github.com/kzs0/bedrock/internal"
+	"github.com/kzs0/bedrock/trace"
 	httpProp "github.com/kzs0/bedrock/trace/http"
+	"github.com/kzs0/bedrock/trace/w3c"
+	"github.com/kzs0/bedrock/transport"
 )
 
 // HTTPMiddleware wraps an HTTP handler with bedrock operations.
@@ -24,15 +34,32 @@ import (
 //	http.ListenAndServe(":8080", handler)
 func HTTPMiddleware(ctx context.Context, handler http.Handler, opts ...MiddlewareOption) http.Handler {
 	cfg := applyMiddlewareOptions(opts)
+	baseBedrock := cfg.bedrock
+	if baseBedrock == nil {
+		baseBedrock = bedrockFromContext(ctx)
+	}
 
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Build initial attributes
+		// Add bedrock to request context if not present (preserves other context values)
+		reqCtx := r.Context()
+		if bedrockFromContext(reqCtx).isNoop && !baseBedrock.isNoop {
+			reqCtx = WithBedrock(reqCtx, baseBedrock)
+		}
+
+		// Pass filtered requests through uninstrumented, with bedrock still in their context
+		if !cfg.instrument(r) {
+			handler.ServeHTTP(w, r.WithContext(reqCtx))
+			return
+		}
+
+		// Build initial attributes, named by the semantic conventions if configured
+		key := bedrockFromContext(reqCtx).attrName
 		attrs := []attr.Attr{
-			attr.String("http.method", r.Method),
-			attr.String("http.path", r.URL.Path),
-			attr.String("http.scheme", r.URL.Scheme),
-			attr.String("http.host", r.Host),
-			attr.String("http.user_agent", r.UserAgent()),
+			attr.String(key("http.method"), r.Method),
+			attr.String(key("http.path"), r.URL.Path),
+			attr.String(key("http.scheme"), r.URL.Scheme),
+			attr.String(key("http.host"), r.Host),
+			attr.String(key("http.user_agent"), r.UserAgent()),
 		}
 
 		// Add custom attributes if provided
@@ -40,25 +67,19 @@ func HTTPMiddleware(ctx context.Context, handler http.Handler, opts ...Middlewar
 			attrs = append(attrs, cfg.additionalAttrs(r)...)
 		}
 
-		// Build metric labels
-		labels := []string{"http.method", "http.path", "http.status_code"}
+		// Build metric labels. The raw path is left out, as it would explode cardinality
+		labels := []string{key("http.method"), "http.route", key("http.status_code")}
 		labels = append(labels, cfg.additionalLabels...)
 
-		// Start operation with the request context
-		// Add bedrock from base context if not already present
-		reqCtx := r.Context()
-		baseBedrock := bedrockFromContext(ctx)
-
-		// Add bedrock to request context if not present (preserves other context values)
-		if bedrockFromContext(reqCtx).isNoop && baseBedrock != nil && !baseBedrock.isNoop {
-			reqCtx = WithBedrock(reqCtx, baseBedrock)
-		}
-
 		// Extract W3C Trace Context from headers if trace propagation is enabled
 		var opOpts []OperationOption
 		opOpts = append(opOpts, Attrs(attrs...))
 		opOpts = append(opOpts, MetricLabels(labels...))
 
+		if cfg.healthCheckSampler != nil && cfg.isHealthCheck(r) {
+			opOpts = append(opOpts, WithTraceSampler(cfg.healthCheckSampler))
+		}
+
 		if cfg.tracePropagation {
 			prop := &httpProp.Propagator{}
 			remoteCtx, err := prop.Extract(r.Header)
@@ -69,7 +90,13 @@ func HTTPMiddleware(ctx context.Context, handler http.Handler, opts ...Middlewar
 		}
 
 		op, opCtx := Operation(reqCtx, cfg.operationName, opOpts...)
-		defer op.Done()
+
+		// Attach the request ID to the operation, logs, and response
+		if cfg.requestID {
+			id := requestID(r.Header.Get(RequestIDHeader))
+			opCtx = SetValue(opCtx, requestIDAttr, attr.StringValue(id))
+			w.Header().Set(RequestIDHeader, id)
+		}
 
 		// Wrap response writer to capture status code
 		rw := &responseWriter{
@@ -77,27 +104,116 @@ func HTTPMiddleware(ctx context.Context, handler http.Handler, opts ...Middlewar
 			status:         http.StatusOK,
 			wroteHeader:    false,
 		}
+		if cfg.serverTiming {
+			rw.beforeHeader = func(h http.Header) {
+				h.Add("Server-Timing", serverTiming(op.state, cfg.serverTimingTraceparent))
+			}
+		}
+		next := r.WithContext(opCtx)
 
-		// Call next handler with operation context
-		handler.ServeHTTP(rw, r.WithContext(opCtx))
+		// Count the request body as it's read if its length isn't known upfront
+		var body *countingReader
+		if r.ContentLength < 0 && r.Body != nil && r.Body != http.NoBody {
+			body = &countingReader{ReadCloser: r.Body}
+			next.Body = body
+		}
+
+		defer func() {
+			// Recover a panic in the handler, so the operation fails with it and the client
+			// gets a 500. If the response has started, it's aborted with http.ErrAbortHandler
+			// instead, so the client doesn't take a truncated response for a complete one.
+			// http.ErrAbortHandler deliberately aborts the response, so it's kept.
+			p := recover()
+			panicked := p != nil && p != http.ErrAbortHandler
+			abort := panicked && rw.wroteHeader
+			if panicked && !rw.wroteHeader {
+				http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			}
+			// The server writes the header of an empty response after the middleware returns
+			if !rw.wroteHeader && rw.beforeHeader != nil {
+				rw.beforeHeader(rw.Header())
+			}
 
-		// Add status code as attribute
-		op.Register(opCtx, attr.Int("http.status_code", rw.status))
+			// Routers match the route while handling the request, so it's only known now
+			route := cfg.routeFunc(next)
+			if route != "" {
+				op.Register(opCtx, attr.String("http.route", route))
+				if op.state.span != nil {
+					op.state.span.SetName(r.Method + " " + route)
+				}
+			}
 
-		// Register failure if error status
-		if cfg.successStatusCodes != nil {
-			if !cfg.successStatusCodes[rw.status] {
-				op.Register(opCtx, attr.Error(fmt.Errorf("HTTP %d", rw.status)))
+			// Add status code and sizes as attributes
+			requestSize := max(r.ContentLength, 0)
+			if body != nil {
+				requestSize = body.n
 			}
-		} else {
-			// Default: 4xx and 5xx are failures
-			if rw.status >= 400 {
-				op.Register(opCtx, attr.Error(fmt.Errorf("HTTP %d", rw.status)))
+			op.Register(opCtx,
+				attr.Int(key("http.status_code"), rw.status),
+				attr.Int64(key("http.request_size"), requestSize),
+				attr.Int64(key("http.response_size"), rw.written),
+			)
+			sizeLabels := []attr.Attr{attr.String(key("http.method"), r.Method), attr.String("http.route", route)}
+			b := bedrockFromContext(opCtx)
+			b.histogram("http_request_size_bytes", "Size of HTTP request bodies in bytes", sizeBuckets, key("http.method"), "http.route").
+				With(sizeLabels...).Observe(float64(requestSize))
+			b.histogram("http_response_size_bytes", "Size of HTTP response bodies in bytes", sizeBuckets, key("http.method"), "http.route").
+				With(sizeLabels...).Observe(float64(rw.written))
+
+			switch {
+			case panicked:
+				op.donePanicked(p, applyEndOptions(nil))
+				if abort {
+					panic(http.ErrAbortHandler)
+				}
+				return
+			case p != nil:
+				op.Done()
+				panic(p)
 			}
-		}
+
+			// Register failure if error status, unless the handler already registered its error
+			if cfg.successStatusCodes != nil {
+				if !cfg.successStatusCodes[rw.status] && op.Succeeded() {
+					op.Register(opCtx, attr.Error(fmt.Errorf("HTTP %d", rw.status)))
+				}
+			} else {
+				// Default: 4xx and 5xx are failures
+				if rw.status >= 400 && op.Succeeded() {
+					op.Register(opCtx, attr.Error(fmt.Errorf("HTTP %d", rw.status)))
+				}
+			}
+			op.Done()
+		}()
+
+		// Call next handler with operation context
+		handler.ServeHTTP(rw.wrap(), next)
 	})
 }
 
+// Middleware returns HTTPMiddleware in the standard middleware form, for chi's Use, alice,
+// and similar chains. Operations use the bedrock in each request's context, or the
+// instance given with WithInstance if the request context has none.
+//
+// Usage:
+//
+//	router := chi.NewRouter()
+//	router.Use(bedrock.Middleware(bedrock.WithInstance(bedrock.FromContext(ctx))))
+func Middleware(opts ...MiddlewareOption) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return HTTPMiddleware(context.Background(), next, opts...)
+	}
+}
+
+// serveMuxRoute returns the path of the ServeMux pattern that matched r, such as "/users/{id}"
+// for "GET example.com/users/{id}", without its method and host, which are recorded separately.
+func serveMuxRoute(r *http.Request) string {
+	if i := strings.IndexByte(r.Pattern, '/'); i >= 0 {
+		return r.Pattern[i:]
+	}
+	return ""
+}
+
 // MiddlewareOption configures the HTTP middleware.
 type MiddlewareOption func(*middlewareConfig)
 
@@ -108,6 +224,32 @@ type middlewareConfig struct {
 	additionalAttrs    func(*http.Request) []attr.Attr
 	successStatusCodes map[int]bool
 	tracePropagation   bool
+	routeFunc          func(*http.Request) string
+	skipPaths          map[string]bool
+	filters            []func(*http.Request) bool
+
+	serverTiming            bool
+	serverTimingTraceparent bool
+	requestID               bool
+
+	healthCheckSampler    trace.Sampler
+	healthCheckPaths      map[string]bool
+	healthCheckUserAgents []string
+
+	bedrock *Bedrock // used instead of the bedrock in the middleware's context, if set
+}
+
+// instrument reports whether r passes the skip paths and filters, and so gets an operation.
+func (cfg *middlewareConfig) instrument(r *http.Request) bool {
+	if cfg.skipPaths[r.URL.Path] {
+		return false
+	}
+	for _, filter := range cfg.filters {
+		if !filter(r) {
+			return false
+		}
+	}
+	return true
 }
 
 // WithOperationName sets a custom operation name (default: "http.request").
@@ -118,7 +260,7 @@ func WithOperationName(name string) MiddlewareOption {
 }
 
 // WithAdditionalLabels adds extra metric label names beyond the defaults.
-// Default labels are: method, path, status_code
+// Default labels are: http.method, http.route, http.status_code
 func WithAdditionalLabels(labels ...string) MiddlewareOption {
 	return func(cfg *middlewareConfig) {
 		cfg.additionalLabels = append(cfg.additionalLabels, labels...)
@@ -151,6 +293,173 @@ func WithTracePropagation(enable bool) MiddlewareOption {
 	}
 }
 
+// WithRouteFunc sets the function that returns the route template matched by a request,
+// such as "/users/{id}", for the http.route label and the span name. It is called after
+// the handler returns, with the request passed to it; an empty route is not recorded.
+// Default: the pattern matched by a http.ServeMux.
+//
+// Usage with chi, installed as router middleware so the route context is in the request:
+//
+//	router.Use(func(next http.Handler) http.Handler {
+//	    return bedrock.HTTPMiddleware(ctx, next, bedrock.WithRouteFunc(func(r *http.Request) string {
+//	        return chi.RouteContext(r.Context()).RoutePattern()
+//	    }))
+//	})
+func WithRouteFunc(fn func(*http.Request) string) MiddlewareOption {
+	return func(cfg *middlewareConfig) {
+		cfg.routeFunc = fn
+	}
+}
+
+// WithSkipPaths excludes requests for the given exact paths, such as health checks and
+// metrics scrapes, from instrumentation: they get no operation, span, or metric series.
+//
+// Usage:
+//
+//	bedrock.HTTPMiddleware(ctx, mux, bedrock.WithSkipPaths("/healthz", "/metrics"))
+func WithSkipPaths(paths ...string) MiddlewareOption {
+	return func(cfg *middlewareConfig) {
+		if cfg.skipPaths == nil {
+			cfg.skipPaths = make(map[string]bool, len(paths))
+		}
+		for _, path := range paths {
+			cfg.skipPaths[path] = true
+		}
+	}
+}
+
+// WithFilter adds a filter that reports whether a request should be instrumented.
+// Requests for which any filter returns false get no operation, span, or metric series.
+//
+// Usage:
+//
+//	bedrock.HTTPMiddleware(ctx, mux, bedrock.WithFilter(func(r *http.Request) bool {
+//	    return !strings.HasPrefix(r.UserAgent(), "kube-probe/")
+//	}))
+func WithFilter(fn func(*http.Request) bool) MiddlewareOption {
+	return func(cfg *middlewareConfig) {
+		cfg.filters = append(cfg.filters, fn)
+	}
+}
+
+// WithServerTiming adds a Server-Timing header with the operation's duration up to when the
+// response header is written, so browser devtools and CDN logs can see backend timing.
+// With traceparent, the header also carries the request's W3C traceparent.
+//
+// Example header:
+//
+//	Server-Timing: http.request;dur=12.345, traceparent;desc="00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+func WithServerTiming(traceparent bool) MiddlewareOption {
+	return func(cfg *middlewareConfig) {
+		cfg.serverTiming = true
+		cfg.serverTimingTraceparent = traceparent
+	}
+}
+
+// serverTiming returns the Server-Timing header value for the operation so far.
+func serverTiming(op *operationState, traceparent bool) string {
+	ms := float64(time.Since(op.startTime).Microseconds()) / 1000
+	value := op.name + ";dur=" + strconv.FormatFloat(ms, 'f', -1, 64)
+	if traceparent && op.span != nil {
+		value += `, traceparent;desc="` + w3c.FormatTraceparent(op.span.TraceID(), op.span.SpanID(), op.span.IsSampled()) + `"`
+	}
+	return value
+}
+
+// WithRequestID reads the request ID from the X-Request-ID header, or generates one if the
+// header is missing or invalid, and attaches it to the operation and its logs as a
+// request.id attribute and to the response's X-Request-ID header. Handlers get it with
+// RequestID, and Config.ForwardRequestID forwards it on HTTP client requests.
+//
+// Incoming IDs are kept if they are up to 128 printable ASCII characters.
+func WithRequestID() MiddlewareOption {
+	return func(cfg *middlewareConfig) {
+		cfg.requestID = true
+	}
+}
+
+// requestID returns the incoming request ID header if it's valid, or a new random ID.
+func requestID(header string) string {
+	valid := header != "" && len(header) <= 128
+	for i := 0; valid && i < len(header); i++ {
+		valid = header[i] > ' ' && header[i] <= '~'
+	}
+	if valid {
+		return header
+	}
+	return internal.NewTraceID().String()
+}
+
+// RequestIDHeader is the header the middleware's WithRequestID reads request IDs from and
+// returns them in, and that Config.ForwardRequestID forwards them in.
+const RequestIDHeader = transport.RequestIDHeader
+
+// requestIDAttr is the attribute key and SetValue key of the request ID.
+const requestIDAttr = "request.id"
+
+// RequestID returns the ID of the request being handled, from the middleware's
+// WithRequestID, or "" if there is none.
+func RequestID(ctx context.Context) string {
+	if v, ok := Value(ctx, requestIDAttr); ok {
+		return v.AsString()
+	}
+	return ""
+}
+
+// defaultHealthCheckUserAgents are the User-Agent prefixes of Kubernetes probes and the
+// AWS and Google Cloud load balancers' health checks.
+var defaultHealthCheckUserAgents = []string{"kube-probe/", "ELB-HealthChecker/", "GoogleHC/"}
+
+// WithHealthCheckSampling traces health checks at rate, from 0 to 1, instead of the
+// configured trace sample rate, so frequent probes don't crowd out other traces. Health
+// checks are requests for paths, and requests whose User-Agent starts with one of those
+// of Kubernetes probes and the AWS and Google Cloud load balancers, or of those set with
+// WithHealthCheckUserAgents. Their metrics and logs are unaffected.
+//
+// Usage:
+//
+//	handler := bedrock.HTTPMiddleware(ctx, mux,
+//	    bedrock.WithHealthCheckSampling(0.001, "/healthz", "/readyz"),
+//	)
+func WithHealthCheckSampling(rate float64, paths ...string) MiddlewareOption {
+	return func(cfg *middlewareConfig) {
+		cfg.healthCheckSampler = trace.NewRatioSampler(rate)
+		for _, path := range paths {
+			cfg.healthCheckPaths[path] = true
+		}
+	}
+}
+
+// WithHealthCheckUserAgents replaces the User-Agent prefixes that identify health checks
+// for WithHealthCheckSampling.
+func WithHealthCheckUserAgents(prefixes ...string) MiddlewareOption {
+	return func(cfg *middlewareConfig) {
+		cfg.healthCheckUserAgents = prefixes
+	}
+}
+
+// isHealthCheck reports whether r is a health check, for WithHealthCheckSampling.
+func (cfg *middlewareConfig) isHealthCheck(r *http.Request) bool {
+	if cfg.healthCheckPaths[r.URL.Path] {
+		return true
+	}
+	userAgent := r.UserAgent()
+	for _, prefix := range cfg.healthCheckUserAgents {
+		if strings.HasPrefix(userAgent, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// WithInstance sets the bedrock instance used for requests whose context has none,
+// instead of the one in the context passed to HTTPMiddleware. A nil b is ignored.
+func WithInstance(b *Bedrock) MiddlewareOption {
+	return func(cfg *middlewareConfig) {
+		cfg.bedrock = b
+	}
+}
+
 // applyMiddlewareOptions applies middleware options.
 func applyMiddlewareOptions(opts []MiddlewareOption) middlewareConfig {
 	cfg := middlewareConfig{
@@ -158,6 +467,10 @@ func applyMiddlewareOptions(opts []MiddlewareOption) middlewareConfig {
 		additionalLabels:   make([]string, 0),
 		successStatusCodes: nil,
 		tracePropagation:   true, // Default: enabled
+		routeFunc:          serveMuxRoute,
+
+		healthCheckPaths:      make(map[string]bool),
+		healthCheckUserAgents: defaultHealthCheckUserAgents,
 	}
 	for _, opt := range opts {
 		opt(&cfg)
@@ -170,10 +483,16 @@ type responseWriter struct {
 	http.ResponseWriter
 	status      int
 	wroteHeader bool
+	written     int64 // response body bytes written
+
+	beforeHeader func(http.Header) // called before the header is written, if set
 }
 
 func (rw *responseWriter) WriteHeader(code int) {
 	if !rw.wroteHeader {
+		if rw.beforeHeader != nil {
+			rw.beforeHeader(rw.Header())
+		}
 		rw.status = code
 		rw.wroteHeader = true
 		rw.ResponseWriter.WriteHeader(code)
@@ -184,5 +503,196 @@ func (rw *responseWriter) Write(b []byte) (int, error) {
 	if !rw.wroteHeader {
 		rw.WriteHeader(http.StatusOK)
 	}
-	return rw.ResponseWriter.Write(b)
+	n, err := rw.ResponseWriter.Write(b)
+	rw.written += int64(n)
+	return n, err
+}
+
+// Unwrap returns the wrapped http.ResponseWriter, so http.ResponseController can reach
+// the optional interfaces the wrapped writer implements.
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// wrap returns rw implementing each of http.Flusher, http.Hijacker, http.Pusher, and
+// io.ReaderFrom only if the wrapped writer does, so handlers behind the middleware can
+// still stream, upgrade connections, and use sendfile, and those checking for an
+// interface see what the connection actually supports.
+func (rw *responseWriter) wrap() http.ResponseWriter {
+	const (
+		flush = 1 << iota
+		hijack
+		push
+		readFrom
+	)
+	var supported int
+	if _, ok := rw.ResponseWriter.(http.Flusher); ok {
+		supported |= flush
+	}
+	if _, ok := rw.ResponseWriter.(http.Hijacker); ok {
+		supported |= hijack
+	}
+	if _, ok := rw.ResponseWriter.(http.Pusher); ok {
+		supported |= push
+	}
+	if _, ok := rw.ResponseWriter.(io.ReaderFrom); ok {
+		supported |= readFrom
+	}
+
+	f, h, p, r := flusher{rw}, hijacker{rw}, pusher{rw}, readerFrom{rw}
+	switch supported {
+	case flush:
+		return struct {
+			*responseWriter
+			flusher
+		}{rw, f}
+	case hijack:
+		return struct {
+			*responseWriter
+			hijacker
+		}{rw, h}
+	case push:
+		return struct {
+			*responseWriter
+			pusher
+		}{rw, p}
+	case readFrom:
+		return struct {
+			*responseWriter
+			readerFrom
+		}{rw, r}
+	case flush | hijack:
+		return struct {
+			*responseWriter
+			flusher
+			hijacker
+		}{rw, f, h}
+	case flush | push:
+		return struct {
+			*responseWriter
+			flusher
+			pusher
+		}{rw, f, p}
+	case flush | readFrom:
+		return struct {
+			*responseWriter
+			flusher
+			readerFrom
+		}{rw, f, r}
+	case hijack | push:
+		return struct {
+			*responseWriter
+			hijacker
+			pusher
+		}{rw, h, p}
+	case hijack | readFrom:
+		return struct {
+			*responseWriter
+			hijacker
+			readerFrom
+		}{rw, h, r}
+	case push | readFrom:
+		return struct {
+			*responseWriter
+			pusher
+			readerFrom
+		}{rw, p, r}
+	case flush | hijack | push:
+		return struct {
+			*responseWriter
+			flusher
+			hijacker
+			pusher
+		}{rw, f, h, p}
+	case flush | hijack | readFrom:
+		return struct {
+			*responseWriter
+			flusher
+			hijacker
+			readerFrom
+		}{rw, f, h, r}
+	case flush | push | readFrom:
+		return struct {
+			*responseWriter
+			flusher
+			pusher
+			readerFrom
+		}{rw, f, p, r}
+	case hijack | push | readFrom:
+		return struct {
+			*responseWriter
+			hijacker
+			pusher
+			readerFrom
+		}{rw, h, p, r}
+	case flush | hijack | push | readFrom:
+		return struct {
+			*responseWriter
+			flusher
+			hijacker
+			pusher
+			readerFrom
+		}{rw, f, h, p, r}
+	}
+	return rw
+}
+
+// flusher implements http.Flusher for a responseWriter whose wrapped writer does.
+type flusher struct{ rw *responseWriter }
+
+// Flush writes the header first if it hasn't been.
+func (f flusher) Flush() {
+	if !f.rw.wroteHeader {
+		f.rw.WriteHeader(http.StatusOK)
+	}
+	f.rw.ResponseWriter.(http.Flusher).Flush()
+}
+
+// hijacker implements http.Hijacker for a responseWriter whose wrapped writer does.
+type hijacker struct{ rw *responseWriter }
+
+// Hijack records a hijacked response with status 101 unless the handler wrote one,
+// since the server no longer writes it.
+func (h hijacker) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, buf, err := h.rw.ResponseWriter.(http.Hijacker).Hijack()
+	if err == nil && !h.rw.wroteHeader {
+		h.rw.status = http.StatusSwitchingProtocols
+		h.rw.wroteHeader = true
+	}
+	return conn, buf, err
+}
+
+// pusher implements http.Pusher for a responseWriter whose wrapped writer does.
+type pusher struct{ rw *responseWriter }
+
+func (p pusher) Push(target string, opts *http.PushOptions) error {
+	return p.rw.ResponseWriter.(http.Pusher).Push(target, opts)
+}
+
+// readerFrom implements io.ReaderFrom for a responseWriter whose wrapped writer does,
+// so it can use sendfile.
+type readerFrom struct{ rw *responseWriter }
+
+func (r readerFrom) ReadFrom(src io.Reader) (int64, error) {
+	if !r.rw.wroteHeader {
+		r.rw.WriteHeader(http.StatusOK)
+	}
+	n, err := r.rw.ResponseWriter.(io.ReaderFrom).ReadFrom(src)
+	r.rw.written += n
+	return n, err
+}
+
+// sizeBuckets are the histogram buckets for request and response sizes in bytes, 64B to 16MiB.
+var sizeBuckets = []float64{64, 256, 1 << 10, 4 << 10, 16 << 10, 64 << 10, 256 << 10, 1 << 20, 4 << 20, 16 << 20}
+
+// countingReader wraps a request body to count the bytes read from it.
+type countingReader struct {
+	io.ReadCloser
+	n int64
+}
+
+func (cr *countingReader) Read(p []byte) (int, error) {
+	n, err := cr.ReadCloser.Read(p)
+	cr.n += int64(n)
+	return n, err
 }
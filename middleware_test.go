@@ -1,12 +1,22 @@
 package bedrock
 
 import (
+	"bufio"
+	"bytes"
 	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"regexp"
+	"strings"
 	"testing"
 
 	"github.com/kzs0/bedrock/attr"
+	"github.com/kzs0/bedrock/log/logtest"
 )
 
 type testContextKey string
@@ -298,6 +308,54 @@ func TestHTTPMiddleware_AdditionalAttrs(t *testing.T) {
 	}
 }
 
+func TestHTTPMiddleware_ServeMuxRoute(t *testing.T) {
+	ctx, close := Init(context.Background(),
+		WithConfig(Config{Service: "test-service"}),
+	)
+	defer close()
+
+	var opState *operationState
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		opState = operationStateFromContext(r.Context())
+		w.WriteHeader(http.StatusOK)
+	})
+
+	wrappedHandler := HTTPMiddleware(ctx, mux)
+
+	for _, path := range []string{"/users/1", "/users/2"} {
+		req := httptest.NewRequest("GET", path, nil)
+		wrappedHandler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	if v, _ := opState.attrs.Get("http.route"); v.AsString() != "/users/{id}" {
+		t.Errorf("expected http.route '/users/{id}', got %q", v.AsString())
+	}
+	if v, _ := opState.attrs.Get("http.path"); v.AsString() != "/users/2" {
+		t.Errorf("expected http.path '/users/2', got %q", v.AsString())
+	}
+	if name := opState.span.Name(); name != "GET /users/{id}" {
+		t.Errorf("expected span name 'GET /users/{id}', got %q", name)
+	}
+
+	for _, fam := range FromContext(ctx).Metrics().Gather() {
+		if fam.Name != "http_request_count" {
+			continue
+		}
+		if len(fam.Metrics) != 1 {
+			t.Fatalf("expected one series for both paths, got %d", len(fam.Metrics))
+		}
+		if v, _ := fam.Metrics[0].Labels.Get("http_route"); v.AsString() != "/users/{id}" {
+			t.Errorf("expected http_route label '/users/{id}', got %q", v.AsString())
+		}
+		if _, ok := fam.Metrics[0].Labels.Get("http_path"); ok {
+			t.Error("expected no http_path label")
+		}
+		return
+	}
+	t.Error("expected http_request_count to be registered")
+}
+
 func TestHTTPMiddleware_MiddlewareChain(t *testing.T) {
 	// This test simulates a realistic middleware chain:
 	// 1. Auth middleware (sets user_id)
@@ -367,3 +425,612 @@ func TestHTTPMiddleware_MiddlewareChain(t *testing.T) {
 		t.Error("expected real bedrock, not noop")
 	}
 }
+
+func TestHTTPMiddleware_RouteFunc(t *testing.T) {
+	ctx, close := Init(context.Background(),
+		WithConfig(Config{Service: "test-service"}),
+	)
+	defer close()
+
+	var opState *operationState
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		opState = operationStateFromContext(r.Context())
+		w.WriteHeader(http.StatusOK)
+	})
+
+	wrappedHandler := HTTPMiddleware(ctx, handler,
+		WithRouteFunc(func(r *http.Request) string {
+			if strings.HasPrefix(r.URL.Path, "/orders/") {
+				return "/orders/:id"
+			}
+			return ""
+		}),
+	)
+
+	req := httptest.NewRequest("POST", "/orders/42", nil)
+	wrappedHandler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if v, _ := opState.attrs.Get("http.route"); v.AsString() != "/orders/:id" {
+		t.Errorf("expected http.route '/orders/:id', got %q", v.AsString())
+	}
+	if name := opState.span.Name(); name != "POST /orders/:id" {
+		t.Errorf("expected span name 'POST /orders/:id', got %q", name)
+	}
+
+	req = httptest.NewRequest("GET", "/other", nil)
+	wrappedHandler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if opState.attrs.Has("http.route") {
+		t.Error("expected no http.route for an unmatched request")
+	}
+	if name := opState.span.Name(); name != "http.request" {
+		t.Errorf("expected span name 'http.request', got %q", name)
+	}
+}
+
+func TestHTTPMiddleware_SkipPathsAndFilter(t *testing.T) {
+	ctx, close := Init(context.Background(),
+		WithConfig(Config{Service: "test-service"}),
+	)
+	defer close()
+
+	var opState *operationState
+	var capturedBedrock *Bedrock
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		opState = operationStateFromContext(r.Context())
+		capturedBedrock = FromContext(r.Context())
+		w.WriteHeader(http.StatusOK)
+	})
+
+	wrappedHandler := HTTPMiddleware(ctx, handler,
+		WithSkipPaths("/healthz", "/metrics"),
+		WithFilter(func(r *http.Request) bool {
+			return r.Header.Get("X-Probe") == ""
+		}),
+	)
+
+	for _, tc := range []struct {
+		path       string
+		probe      bool
+		instrument bool
+	}{
+		{path: "/healthz"},
+		{path: "/metrics"},
+		{path: "/users", probe: true},
+		{path: "/users", instrument: true},
+	} {
+		req := httptest.NewRequest("GET", tc.path, nil)
+		if tc.probe {
+			req.Header.Set("X-Probe", "1")
+		}
+		rr := httptest.NewRecorder()
+		wrappedHandler.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusOK {
+			t.Errorf("%s: expected handler to be called, got status %d", tc.path, rr.Code)
+		}
+		if capturedBedrock == nil || capturedBedrock.isNoop {
+			t.Errorf("%s: expected bedrock in request context", tc.path)
+		}
+		if got := opState != nil; got != tc.instrument {
+			t.Errorf("%s (probe %v): expected operation %v, got %v", tc.path, tc.probe, tc.instrument, got)
+		}
+	}
+
+	for _, fam := range FromContext(ctx).Metrics().Gather() {
+		if fam.Name == "http_request_count" && len(fam.Metrics) == 1 && fam.Metrics[0].Value != 1 {
+			t.Errorf("expected 1 instrumented request, got %v", fam.Metrics[0].Value)
+		}
+	}
+}
+
+func TestHTTPMiddleware_RecoversPanic(t *testing.T) {
+	ctx, close := Init(context.Background(),
+		WithConfig(Config{Service: "test-service", LogHandler: logtest.NewRecorder()}),
+	)
+	defer close()
+
+	var opState *operationState
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		opState = operationStateFromContext(r.Context())
+		panic("boom")
+	})
+
+	wrappedHandler := HTTPMiddleware(ctx, handler)
+
+	req := httptest.NewRequest("GET", "/users", nil)
+	rr := httptest.NewRecorder()
+	wrappedHandler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusInternalServerError {
+		t.Errorf("expected status 500, got %d", rr.Code)
+	}
+	if opState.success || opState.failure == nil || opState.failure.Error() != "panic: boom" {
+		t.Errorf("expected failure from the panic, got success=%v failure=%v", opState.success, opState.failure)
+	}
+	if v, _ := opState.attrs.Get("http.status_code"); v.AsInt64() != http.StatusInternalServerError {
+		t.Errorf("expected http.status_code 500, got %d", v.AsInt64())
+	}
+	if opState.span.IsRecording() {
+		t.Error("expected span to be ended")
+	}
+
+	var failures float64
+	for _, fam := range FromContext(ctx).Metrics().Gather() {
+		if fam.Name == "http_request_failures" {
+			for _, m := range fam.Metrics {
+				failures += m.Value
+			}
+		}
+	}
+	if failures != 1 {
+		t.Errorf("expected 1 failure, got %v", failures)
+	}
+}
+
+func TestHTTPMiddleware_AbortHandlerRepanics(t *testing.T) {
+	ctx, close := Init(context.Background(),
+		WithConfig(Config{Service: "test-service"}),
+	)
+	defer close()
+
+	var opState *operationState
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		opState = operationStateFromContext(r.Context())
+		panic(http.ErrAbortHandler)
+	})
+
+	defer func() {
+		if r := recover(); r != http.ErrAbortHandler {
+			t.Errorf("expected http.ErrAbortHandler to propagate, got %v", r)
+		}
+		if opState.span.IsRecording() {
+			t.Error("expected span to be ended")
+		}
+	}()
+	HTTPMiddleware(ctx, handler).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+}
+
+func TestHTTPMiddleware_PanicAfterHeaderAborts(t *testing.T) {
+	ctx, close := Init(context.Background(),
+		WithConfig(Config{Service: "test-service", LogHandler: logtest.NewRecorder()}),
+	)
+	defer close()
+
+	var opState *operationState
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		opState = operationStateFromContext(r.Context())
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("partial"))
+		panic("boom")
+	})
+
+	rr := httptest.NewRecorder()
+	defer func() {
+		if r := recover(); r != http.ErrAbortHandler {
+			t.Errorf("expected http.ErrAbortHandler to abort the response, got %v", r)
+		}
+		if opState.success || opState.failure == nil || opState.failure.Error() != "panic: boom" {
+			t.Errorf("expected failure from the panic, got success=%v failure=%v", opState.success, opState.failure)
+		}
+		if opState.span.IsRecording() {
+			t.Error("expected span to be ended")
+		}
+		if rr.Body.String() != "partial" {
+			t.Errorf("expected no error body after the response started, got %q", rr.Body.String())
+		}
+	}()
+	HTTPMiddleware(ctx, handler).ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+}
+
+func TestHTTPMiddleware_Sizes(t *testing.T) {
+	ctx, close := Init(context.Background(),
+		WithConfig(Config{Service: "test-service"}),
+	)
+	defer close()
+
+	var opState *operationState
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		opState = operationStateFromContext(r.Context())
+		_, _ = io.Copy(io.Discard, r.Body)
+		_, _ = w.Write([]byte("hello, world"))
+	})
+
+	wrappedHandler := HTTPMiddleware(ctx, handler)
+
+	// Known Content-Length
+	req := httptest.NewRequest("POST", "/upload", strings.NewReader("12345"))
+	wrappedHandler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if v, _ := opState.attrs.Get("http.request_size"); v.AsInt64() != 5 {
+		t.Errorf("expected http.request_size 5, got %d", v.AsInt64())
+	}
+	if v, _ := opState.attrs.Get("http.response_size"); v.AsInt64() != 12 {
+		t.Errorf("expected http.response_size 12, got %d", v.AsInt64())
+	}
+
+	// Unknown Content-Length, counted as the handler reads it
+	req = httptest.NewRequest("POST", "/upload", strings.NewReader("1234567"))
+	req.ContentLength = -1
+	wrappedHandler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if v, _ := opState.attrs.Get("http.request_size"); v.AsInt64() != 7 {
+		t.Errorf("expected counted http.request_size 7, got %d", v.AsInt64())
+	}
+
+	found := map[string]float64{}
+	for _, fam := range FromContext(ctx).Metrics().Gather() {
+		if fam.Name == "http_request_size_bytes" || fam.Name == "http_response_size_bytes" {
+			for _, m := range fam.Metrics {
+				found[fam.Name] += m.Sum
+			}
+		}
+	}
+	if found["http_request_size_bytes"] != 12 {
+		t.Errorf("expected 12 request bytes observed, got %v", found["http_request_size_bytes"])
+	}
+	if found["http_response_size_bytes"] != 24 {
+		t.Errorf("expected 24 response bytes observed, got %v", found["http_response_size_bytes"])
+	}
+}
+
+func TestHTTPMiddleware_SizesStrictNames(t *testing.T) {
+	var buf bytes.Buffer
+	ctx, close := Init(context.Background(),
+		WithConfig(Config{
+			Service:           "test-service",
+			LogOutput:         &buf,
+			MetricStrictNames: true,
+		}),
+	)
+	defer close()
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("hello, world"))
+	})
+	HTTPMiddleware(ctx, handler).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/upload", strings.NewReader("12345")))
+
+	// The size histograms are the middleware's own, so their dotted labels are sanitized, not rejected
+	if bytes.Contains(buf.Bytes(), []byte("_size_bytes")) {
+		t.Errorf("expected the size histograms not to be rejected, got: %s", buf.String())
+	}
+	found := map[string]float64{}
+	for _, fam := range FromContext(ctx).Metrics().Gather() {
+		if fam.Name == "http_request_size_bytes" || fam.Name == "http_response_size_bytes" {
+			for _, m := range fam.Metrics {
+				found[fam.Name] += m.Sum
+				if v, _ := m.Labels.Get("http_method"); v.String() != "POST" {
+					t.Errorf("expected the http_method label on %s, got %v", fam.Name, m.Labels)
+				}
+			}
+		}
+	}
+	if found["http_request_size_bytes"] != 5 || found["http_response_size_bytes"] != 12 {
+		t.Errorf("expected the sizes to be observed, got %v", found)
+	}
+}
+
+func TestHTTPMiddleware_ServerTiming(t *testing.T) {
+	ctx, close := Init(context.Background(),
+		WithConfig(Config{Service: "test-service"}),
+	)
+	defer close()
+
+	var opState *operationState
+	mux := http.NewServeMux()
+	mux.HandleFunc("/write", func(w http.ResponseWriter, r *http.Request) {
+		opState = operationStateFromContext(r.Context())
+		_, _ = w.Write([]byte("ok"))
+	})
+	mux.HandleFunc("/empty", func(w http.ResponseWriter, r *http.Request) {})
+
+	timing := regexp.MustCompile(`^http\.request;dur=[0-9.]+$`)
+
+	rr := httptest.NewRecorder()
+	HTTPMiddleware(ctx, mux, WithServerTiming(false)).ServeHTTP(rr, httptest.NewRequest("GET", "/empty", nil))
+	if got := rr.Header().Get("Server-Timing"); !timing.MatchString(got) {
+		t.Errorf("expected Server-Timing with the operation duration, got %q", got)
+	}
+
+	rr = httptest.NewRecorder()
+	HTTPMiddleware(ctx, mux, WithServerTiming(true)).ServeHTTP(rr, httptest.NewRequest("GET", "/write", nil))
+	traceparent := fmt.Sprintf(`traceparent;desc="00-%s-%s-01"`, opState.span.TraceID(), opState.span.SpanID())
+	got := rr.Header().Get("Server-Timing")
+	if !strings.HasPrefix(got, "http.request;dur=") || !strings.HasSuffix(got, ", "+traceparent) {
+		t.Errorf("expected Server-Timing with duration and %s, got %q", traceparent, got)
+	}
+
+	rr = httptest.NewRecorder()
+	HTTPMiddleware(ctx, mux).ServeHTTP(rr, httptest.NewRequest("GET", "/write", nil))
+	if got := rr.Header().Get("Server-Timing"); got != "" {
+		t.Errorf("expected no Server-Timing by default, got %q", got)
+	}
+}
+
+// hijackRecorder is a ResponseRecorder that can be hijacked.
+type hijackRecorder struct {
+	*httptest.ResponseRecorder
+	hijacked bool
+}
+
+func (h *hijackRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h.hijacked = true
+	return nil, nil, nil
+}
+
+// readFromRecorder is a ResponseRecorder that implements io.ReaderFrom.
+type readFromRecorder struct {
+	*httptest.ResponseRecorder
+}
+
+func (r *readFromRecorder) ReadFrom(src io.Reader) (int64, error) {
+	return io.Copy(r.ResponseRecorder, src)
+}
+
+func TestHTTPMiddleware_ResponseWriterInterfaces(t *testing.T) {
+	ctx, close := Init(context.Background(),
+		WithConfig(Config{Service: "test-service"}),
+	)
+	defer close()
+
+	var opState *operationState
+	var flushed, hijackable, readFrom bool
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		opState = operationStateFromContext(r.Context())
+		switch r.URL.Path {
+		case "/stream":
+			if f, ok := w.(http.Flusher); ok {
+				_, _ = w.Write([]byte("data: 1\n\n"))
+				f.Flush()
+				flushed = true
+			}
+			if rf, ok := w.(io.ReaderFrom); ok {
+				_, _ = rf.ReadFrom(strings.NewReader("data: 2\n\n"))
+				readFrom = true
+			}
+		case "/ws":
+			if h, ok := w.(http.Hijacker); ok {
+				_, _, err := h.Hijack()
+				hijackable = err == nil
+			}
+		}
+	})
+
+	wrappedHandler := HTTPMiddleware(ctx, handler)
+
+	rr := &readFromRecorder{ResponseRecorder: httptest.NewRecorder()}
+	wrappedHandler.ServeHTTP(rr, httptest.NewRequest("GET", "/stream", nil))
+	if !flushed || !rr.Flushed {
+		t.Error("expected Flush to reach the underlying writer")
+	}
+	if !readFrom || rr.Body.String() != "data: 1\n\ndata: 2\n\n" {
+		t.Errorf("expected ReadFrom to write to the underlying writer, got %q", rr.Body.String())
+	}
+	if v, _ := opState.attrs.Get("http.response_size"); v.AsInt64() != 18 {
+		t.Errorf("expected http.response_size 18, got %d", v.AsInt64())
+	}
+
+	hr := &hijackRecorder{ResponseRecorder: httptest.NewRecorder()}
+	wrappedHandler.ServeHTTP(hr, httptest.NewRequest("GET", "/ws", nil))
+	if !hijackable || !hr.hijacked {
+		t.Error("expected Hijack to reach the underlying writer")
+	}
+	if v, _ := opState.attrs.Get("http.status_code"); v.AsInt64() != http.StatusSwitchingProtocols {
+		t.Errorf("expected http.status_code 101 for a hijacked connection, got %d", v.AsInt64())
+	}
+
+	// The wrapper only implements the interfaces the underlying writer does
+	hijackable = false
+	wrappedHandler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/ws", nil))
+	if hijackable {
+		t.Error("expected no Hijacker without an underlying Hijacker")
+	}
+	HTTPMiddleware(ctx, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := w.(http.Flusher); !ok {
+			t.Error("expected a Flusher for a ResponseRecorder")
+		}
+		if _, ok := w.(http.Pusher); ok {
+			t.Error("expected no Pusher without an underlying Pusher")
+		}
+		if _, ok := w.(io.ReaderFrom); ok {
+			t.Error("expected no ReaderFrom without an underlying ReaderFrom")
+		}
+	})).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+	if _, ok := any(&responseWriter{}).(interface{ Unwrap() http.ResponseWriter }); !ok {
+		t.Error("expected Unwrap for http.ResponseController")
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	ctx, close := Init(context.Background(),
+		WithConfig(Config{Service: "test-service"}),
+	)
+	defer close()
+	b := FromContext(ctx)
+
+	var opState *operationState
+	var capturedBedrock *Bedrock
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		opState = operationStateFromContext(r.Context())
+		capturedBedrock = FromContext(r.Context())
+		w.WriteHeader(http.StatusOK)
+	})
+
+	// Bedrock from the request context
+	req := httptest.NewRequest("GET", "/users", nil)
+	req = req.WithContext(WithBedrock(req.Context(), b))
+	Middleware(WithOperationName("api.request"))(handler).ServeHTTP(httptest.NewRecorder(), req)
+
+	if opState == nil || opState.name != "api.request" {
+		t.Fatalf("expected api.request operation, got %+v", opState)
+	}
+	if capturedBedrock != b {
+		t.Error("expected the request context's bedrock")
+	}
+
+	// Bedrock from a provided instance
+	opState, capturedBedrock = nil, nil
+	Middleware(WithInstance(b))(handler).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users", nil))
+
+	if opState == nil || opState.name != "http.request" {
+		t.Fatalf("expected http.request operation, got %+v", opState)
+	}
+	if capturedBedrock != b {
+		t.Error("expected the provided bedrock instance")
+	}
+}
+
+func TestHTTPMiddleware_KeepsRegisteredError(t *testing.T) {
+	ctx, close := Init(context.Background(),
+		WithConfig(Config{Service: "test-service"}),
+	)
+	defer close()
+
+	errNotFound := errors.New("user not found")
+	var opState *operationState
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		opState = operationStateFromContext(r.Context())
+		op, _ := OperationFromContext(r.Context())
+		op.Register(r.Context(), attr.Error(errNotFound))
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	HTTPMiddleware(ctx, handler).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/1", nil))
+
+	if opState.success || !errors.Is(opState.failure, errNotFound) {
+		t.Errorf("expected the handler's error to be kept, got success=%v failure=%v", opState.success, opState.failure)
+	}
+}
+
+func TestHTTPMiddleware_SemConv(t *testing.T) {
+	ctx, close := Init(context.Background(),
+		WithConfig(Config{Service: "test-service", SemConv: true}),
+	)
+	defer close()
+
+	var opState *operationState
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		opState = operationStateFromContext(r.Context())
+		w.WriteHeader(http.StatusOK)
+	})
+
+	req := httptest.NewRequest("GET", "/users/42", nil)
+	HTTPMiddleware(ctx, mux).ServeHTTP(httptest.NewRecorder(), req)
+
+	for key, want := range map[string]string{
+		"http.request.method":       "GET",
+		"url.path":                  "/users/42",
+		"server.address":            "example.com",
+		"http.route":                "/users/{id}",
+		"http.response.status_code": "200",
+	} {
+		if v, ok := opState.attrs.Get(key); !ok || v.String() != want {
+			t.Errorf("expected %s = %q, got %q", key, want, v.String())
+		}
+	}
+	for _, key := range []string{"http.method", "http.path", "http.status_code"} {
+		if _, ok := opState.attrs.Get(key); ok {
+			t.Errorf("expected no %s attribute in semconv mode", key)
+		}
+	}
+
+	var labeled bool
+	for _, fam := range FromContext(ctx).Metrics().Gather() {
+		if fam.Name == "http_request_count" {
+			_, labeled = fam.Metrics[0].Labels.Get("http_request_method")
+		}
+	}
+	if !labeled {
+		t.Error("expected http_request_count to have an http_request_method label")
+	}
+}
+
+func TestHTTPMiddleware_RequestID(t *testing.T) {
+	recorder := logtest.NewRecorder()
+	ctx, close := Init(context.Background(),
+		WithConfig(Config{Service: "test-service", LogHandler: recorder}),
+	)
+	defer close()
+
+	var gotID string
+	var opState *operationState
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotID = RequestID(r.Context())
+		opState = operationStateFromContext(r.Context())
+		Info(r.Context(), "handling")
+	})
+	wrappedHandler := HTTPMiddleware(ctx, handler, WithRequestID())
+
+	// An incoming ID is kept
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(RequestIDHeader, "req-123")
+	rec := httptest.NewRecorder()
+	wrappedHandler.ServeHTTP(rec, req)
+
+	if gotID != "req-123" {
+		t.Errorf("expected RequestID req-123, got %q", gotID)
+	}
+	if h := rec.Header().Get(RequestIDHeader); h != "req-123" {
+		t.Errorf("expected response header req-123, got %q", h)
+	}
+	if v, _ := opState.attrs.Get("request.id"); v.String() != "req-123" {
+		t.Errorf("expected request.id attribute req-123, got %q", v.String())
+	}
+	if !recorder.HasRecord(slog.LevelInfo, "handling", attr.String("request.id", "req-123")) {
+		t.Error("expected the handler's log to carry request.id")
+	}
+
+	// A missing or invalid ID is replaced with a generated one
+	for _, header := range []string{"", "bad id", strings.Repeat("x", 129)} {
+		req = httptest.NewRequest("GET", "/", nil)
+		if header != "" {
+			req.Header.Set(RequestIDHeader, header)
+		}
+		rec = httptest.NewRecorder()
+		wrappedHandler.ServeHTTP(rec, req)
+
+		if !regexp.MustCompile(`^[0-9a-f]{32}$`).MatchString(gotID) {
+			t.Errorf("expected a generated ID for header %q, got %q", header, gotID)
+		}
+		if h := rec.Header().Get(RequestIDHeader); h != gotID {
+			t.Errorf("expected response header %q, got %q", gotID, h)
+		}
+	}
+}
+
+func TestHTTPMiddleware_HealthCheckSampling(t *testing.T) {
+	ctx, close := Init(context.Background(),
+		WithConfig(Config{Service: "test-service"}),
+	)
+	defer close()
+
+	var sampled, childSampled bool
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sampled = operationStateFromContext(r.Context()).span.IsSampled()
+		step := Step(r.Context(), "check.db")
+		childSampled = step.span.IsSampled()
+		step.Done()
+	})
+	wrappedHandler := HTTPMiddleware(ctx, handler, WithHealthCheckSampling(0, "/healthz"))
+
+	tests := []struct {
+		path, userAgent string
+		wantSampled     bool
+	}{
+		{"/healthz", "", false},
+		{"/", "kube-probe/1.29", false},
+		{"/", "ELB-HealthChecker/2.0", false},
+		{"/", "curl/8.0", true},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", tt.path, nil)
+		req.Header.Set("User-Agent", tt.userAgent)
+		wrappedHandler.ServeHTTP(httptest.NewRecorder(), req)
+
+		if sampled != tt.wantSampled || childSampled != tt.wantSampled {
+			t.Errorf("%s from %q: expected sampled %v, got %v (step %v)", tt.path, tt.userAgent, tt.wantSampled, sampled, childSampled)
+		}
+	}
+}
@@ -3,9 +3,11 @@ package bedrock
 import (
 	"io"
 	"log/slog"
+	"math"
 	"sync"
 
 	"github.com/kzs0/bedrock/attr"
+	"github.com/kzs0/bedrock/health"
 	bloglog "github.com/kzs0/bedrock/log"
 	"github.com/kzs0/bedrock/metric"
 	"github.com/kzs0/bedrock/trace"
@@ -30,13 +32,16 @@ func noopBedrock() *Bedrock {
 			config: Config{
 				Service: "noop",
 			},
-			logger:     slog.New(handler),
-			logBridge:  bloglog.NewBridge(slog.New(handler)),
-			tracer:     trace.NewTracer(trace.TracerConfig{ServiceName: "noop"}),
-			metrics:    metric.NewRegistry(""),
-			staticAttr: attr.NewSet(),
-			isNoop:     true,
+			logger:    slog.New(handler),
+			logBridge: bloglog.NewBridge(slog.New(handler)),
+			tracer:    trace.NewTracer(trace.TracerConfig{ServiceName: "noop"}),
+			metrics:   metric.NewRegistry(""),
+			readiness: health.NewRegistry(),
+			liveness:  health.NewRegistry(),
+			isNoop:    true,
 		}
+		noopInstance.static.Store(noopInstance.newStaticState(attr.NewSet()))
+		noopInstance.sampleRate.Store(math.Float64bits(1))
 	})
 	return noopInstance
 }
@@ -2,22 +2,31 @@ package bedrock
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"slices"
 	"sync"
 	"time"
 
 	"github.com/kzs0/bedrock/attr"
+	blog "github.com/kzs0/bedrock/log"
+	"github.com/kzs0/bedrock/metric"
 	"github.com/kzs0/bedrock/trace"
 )
 
 // OpStep is a handle to a step within an operation.
 // Steps contribute their attributes to the parent operation.
 type OpStep struct {
-	name   string
-	span   *trace.Span
-	attrs  attr.Set
-	parent *operationState
-	ctx    context.Context
+	name      string
+	span      *trace.Span
+	attrs     attr.Set
+	parent    *operationState
+	ctx       context.Context
+	startTime time.Time
+	duration  time.Duration // set by Done, guarded by the parent's mutex
+	metrics   bool          // record step metrics
 }
 
 // operationState is the internal state of an operation.
@@ -31,23 +40,54 @@ type operationState struct {
 	startTime    time.Time
 	attrs        attr.Set
 	metricLabels []string // defined label names (upfront registration)
+	noMetrics    bool     // skip automatic metrics
+	metricSample int      // record metrics for 1 in metricSample invocations, if > 1
 	parent       *operationState
 	success      bool
 	failure      error
+	ctx          context.Context // checked at the end if failOnCancel is set
+	failOnCancel bool
+	slo          time.Duration // target duration, if positive
+	sloBreached  bool
+
+	onDone []func() // from OperationInterceptors, run in reverse order when done
 
 	// Child tracking
-	steps []*OpStep
+	steps           []*OpStep
+	children        []childSummary // child operations, added as they end if the canonical log includes them
+	childrenDropped int            // child operations beyond maxCanonicalChildren
+	events          []trace.Event  // events, kept apart from the span if the canonical log includes them
+	eventsDropped   int            // events beyond maxCanonicalEvents
+}
+
+// maxCanonicalChildren is the number of child operations kept for an operation's canonical
+// log, so long-lived operations such as worker loops don't grow without bound.
+const maxCanonicalChildren = 100
+
+// maxCanonicalEvents is the number of events kept for an operation's canonical log.
+const maxCanonicalEvents = 100
+
+// childSummary summarizes a completed child operation for the canonical log.
+type childSummary struct {
+	name     string
+	duration time.Duration
+	success  bool
 }
 
 // newOperationState creates a new operation state.
-func newOperationState(b *Bedrock, span *trace.Span, name string, cfg operationConfig, parent *operationState) *operationState {
+func newOperationState(ctx context.Context, b *Bedrock, span *trace.Span, name string, attrs attr.Set, cfg operationConfig, parent *operationState) *operationState {
 	return &operationState{
+		ctx:          ctx,
+		failOnCancel: cfg.failOnCancel || b.config.OperationFailOnCancel,
+		slo:          cfg.slo,
 		bedrock:      b,
 		span:         span,
 		name:         name,
 		startTime:    time.Now(),
-		attrs:        attr.NewSet(cfg.attrs...),
-		metricLabels: cfg.metricLabels,
+		attrs:        attrs,
+		metricLabels: b.config.filterMetricLabels(cfg.metricLabels),
+		noMetrics:    cfg.noMetrics,
+		metricSample: cfg.metricSample,
 		parent:       parent,
 		success:      true, // Default to success
 		steps:        make([]*OpStep, 0),
@@ -68,14 +108,133 @@ func (op *operationState) setAttr(attrs ...attr.Attr) {
 	for _, a := range attrs {
 		if a.Key == "error" && a.Value.AsString() != "" {
 			op.success = false
-			op.failure = fmt.Errorf("%s", a.Value.AsString())
-			if op.span != nil {
-				op.span.RecordError(op.failure)
+			op.failure = a.Value.Err()
+			if op.failure == nil {
+				op.failure = fmt.Errorf("%s", a.Value.AsString())
 			}
+			op.recordError(op.failure)
 		}
 	}
 }
 
+// addEvent adds an event to the operation's span, and keeps it for the canonical log,
+// which includes it even if the span isn't recorded.
+func (op *operationState) addEvent(name string, attrs ...attr.Attr) {
+	op.mu.Lock()
+	defer op.mu.Unlock()
+
+	now := time.Now()
+	if op.span != nil {
+		op.span.AddEventAt(name, now, attrs...)
+	}
+	op.keepEvent(name, now, attrs...)
+}
+
+// recordError records err as an exception event, on the span and for the canonical log.
+// The caller holds op.mu.
+func (op *operationState) recordError(err error) {
+	if op.span != nil {
+		op.span.RecordError(err, errorStackAttrs(err)...)
+	}
+	op.keepEvent("exception", time.Now(), append([]attr.Attr{
+		attr.String("exception.type", "error"),
+		attr.String("exception.message", err.Error()),
+	}, errorStackAttrs(err)...)...)
+}
+
+// keepEvent keeps an event for the canonical log, if it includes events.
+// The caller holds op.mu.
+func (op *operationState) keepEvent(name string, t time.Time, attrs ...attr.Attr) {
+	if !op.logsEvents() {
+		return
+	}
+	if len(op.events) < maxCanonicalEvents {
+		op.events = append(op.events, trace.Event{Name: name, Time: t, Attrs: attr.NewSet(attrs...)})
+	} else {
+		op.eventsDropped++
+	}
+}
+
+// fail marks the operation as failed with err, recording it on the span.
+func (op *operationState) fail(err error) {
+	op.mu.Lock()
+	defer op.mu.Unlock()
+
+	op.success = false
+	op.failure = err
+	var msg string
+	if err != nil {
+		op.recordError(err)
+		msg = err.Error()
+	}
+	// RecordError does nothing for a nil err, so the status is set either way
+	if op.span != nil {
+		op.span.SetStatus(trace.StatusError, msg)
+	}
+}
+
+// succeed marks the operation as successful, clearing any failure.
+func (op *operationState) succeed() {
+	op.mu.Lock()
+	defer op.mu.Unlock()
+
+	op.success = true
+	op.failure = nil
+	if op.span != nil {
+		op.span.SetStatus(trace.StatusOK, "")
+	}
+}
+
+// failIfCanceled fails a successful operation whose context is done, classifying
+// the failure in an "error.type" attribute.
+func (op *operationState) failIfCanceled() {
+	err := op.ctx.Err()
+	if err == nil {
+		return
+	}
+	op.mu.Lock()
+	success := op.success
+	op.mu.Unlock()
+	if !success {
+		return
+	}
+
+	errorType := "canceled"
+	if errors.Is(err, context.DeadlineExceeded) {
+		errorType = "deadline_exceeded"
+	}
+	op.setAttr(attr.String("error.type", errorType))
+	op.fail(err)
+}
+
+// classifyError sets the "error.type" attribute of a failed operation that doesn't
+// have one, using Config.ErrorClassifier.
+func (op *operationState) classifyError() {
+	op.mu.Lock()
+	success, failure := op.success, op.failure
+	_, classified := op.attrs.Get("error.type")
+	op.mu.Unlock()
+	if success || classified {
+		return
+	}
+
+	classify := op.bedrock.config.ErrorClassifier
+	if classify == nil {
+		classify = ClassifyError
+	}
+	op.setAttr(attr.String("error.type", classify(failure)))
+}
+
+// errorStackAttrs returns the exception.stacktrace span event attribute for errors
+// recorded with attr.ErrorWithStack.
+func errorStackAttrs(err error) []attr.Attr {
+	var se *attr.StackError
+	if !errors.As(err, &se) {
+		return nil
+	}
+	return []attr.Attr{attr.String("exception.stacktrace", se.Stack().String())}
+}
+
 // buildMetricLabels builds the metric labels from registered names.
 // If a label name was registered but no attribute with that key exists, uses "_".
 // Static attributes are automatically included as labels.
@@ -84,12 +243,9 @@ func (op *operationState) buildMetricLabels() []attr.Attr {
 	defer op.mu.Unlock()
 
 	// Start with static attributes
-	labels := make([]attr.Attr, 0, len(op.metricLabels)+op.bedrock.staticAttr.Len())
-
-	op.bedrock.staticAttr.Range(func(a attr.Attr) bool {
-		labels = append(labels, a)
-		return true
-	})
+	_, static := op.bedrock.staticLabels()
+	labels := make([]attr.Attr, 0, len(op.metricLabels)+len(static))
+	labels = append(labels, static...)
 
 	// Add operation-specific labels (search operation attrs first, then step attrs)
 	for _, labelName := range op.metricLabels {
@@ -132,21 +288,29 @@ func (op *operationState) buildMetricLabels() []attr.Attr {
 }
 
 // recordMetrics records all automatic metrics for this operation.
-func (op *operationState) recordMetrics() {
-	if op.bedrock.isNoop {
+func (op *operationState) recordMetrics(duration time.Duration) {
+	if op.bedrock.isNoop || op.noMetrics {
+		return
+	}
+
+	// A sampled invocation stands in for metricSample invocations
+	weight := 1.0
+	if op.metricSample > 1 {
+		if rand.IntN(op.metricSample) != 0 {
+			return
+		}
+		weight = float64(op.metricSample)
+	}
+
+	if op.bedrock.config.MetricConsolidatedOperations {
+		op.recordConsolidatedMetrics(duration, weight)
 		return
 	}
 
-	duration := time.Since(op.startTime)
 	labels := op.buildMetricLabels()
 
 	// Build combined label names (static + operation-specific)
-	staticLabelNames := make([]string, 0, op.bedrock.staticAttr.Len())
-	op.bedrock.staticAttr.Range(func(a attr.Attr) bool {
-		staticLabelNames = append(staticLabelNames, a.Key)
-		return true
-	})
-
+	staticLabelNames, _ := op.bedrock.staticLabels()
 	allLabelNames := append(staticLabelNames, op.metricLabels...)
 
 	// Record count
@@ -155,7 +319,7 @@ func (op *operationState) recordMetrics() {
 		"Total count of "+op.name+" operations",
 		allLabelNames...,
 	)
-	counter.With(labels...).Inc()
+	counter.With(labels...).Add(weight)
 
 	// Record success or failure
 	if op.success {
@@ -164,68 +328,224 @@ func (op *operationState) recordMetrics() {
 			"Successful "+op.name+" operations",
 			allLabelNames...,
 		)
-		successCounter.With(labels...).Inc()
+		successCounter.With(labels...).Add(weight)
 	} else {
+		failureLabelNames, failureLabels := op.withErrorType(allLabelNames, labels)
 		failureCounter := op.bedrock.metrics.Counter(
 			op.name+"_failures",
 			"Failed "+op.name+" operations",
-			allLabelNames...,
+			failureLabelNames...,
 		)
-		failureCounter.With(labels...).Inc()
+		failureCounter.With(failureLabels...).Add(weight)
 	}
 
-	// Record duration in milliseconds
-	histogram := op.bedrock.metrics.Histogram(
-		op.name+"_duration_ms",
-		"Duration of "+op.name+" operations in milliseconds",
+	if op.sloBreached {
+		op.bedrock.metrics.Counter(
+			op.name+"_slo_breaches",
+			op.name+" operations slower than their SLO",
+			allLabelNames...,
+		).With(labels...).Add(weight)
+	}
+
+	// Record duration
+	op.bedrock.durationHistogram(op.name, op.name+" operations", allLabelNames).With(labels...).Observe(op.bedrock.durationValue(duration))
+}
+
+// recordConsolidatedMetrics records the operation into the shared operation_* metrics,
+// labeled by operation name and static attributes, adding weight to each counter.
+func (op *operationState) recordConsolidatedMetrics(duration time.Duration, weight float64) {
+	labelNames, labels := op.bedrock.staticLabels()
+	labelNames = append(labelNames, "operation")
+	labels = append(labels, attr.String("operation", op.name))
+
+	op.bedrock.metrics.Counter(
+		"operation_count",
+		"Total count of operations",
+		labelNames...,
+	).With(labels...).Add(weight)
+
+	if !op.success {
+		failureLabelNames, failureLabels := op.withErrorType(labelNames, labels)
+		op.bedrock.metrics.Counter(
+			"operation_failures",
+			"Failed operations",
+			failureLabelNames...,
+		).With(failureLabels...).Add(weight)
+	}
+	if op.sloBreached {
+		op.bedrock.metrics.Counter(
+			"operation_slo_breaches",
+			"Operations slower than their SLO",
+			labelNames...,
+		).With(labels...).Add(weight)
+	}
+
+	op.bedrock.durationHistogram("operation", "operations", labelNames).With(labels...).Observe(op.bedrock.durationValue(duration))
+}
+
+// withErrorType adds the operation's "error.type" to the failures counter's labels if
+// Config.OperationErrorTypes is set and the operation doesn't already have it as a label.
+func (op *operationState) withErrorType(labelNames []string, labels []attr.Attr) ([]string, []attr.Attr) {
+	if !op.bedrock.config.OperationErrorTypes || slices.Contains(labelNames, "error.type") {
+		return labelNames, labels
+	}
+
+	op.mu.Lock()
+	errorType, _ := op.attrs.Get("error.type")
+	op.mu.Unlock()
+
+	return append(slices.Clip(labelNames), "error.type"), append(slices.Clip(labels), attr.String("error.type", errorType.AsString()))
+}
+
+// durationHistogram returns the duration histogram for name: name_duration_ms by default,
+// or name_duration_seconds if Config.MetricDurationSeconds is set.
+func (b *Bedrock) durationHistogram(name, subject string, labelNames []string) *metric.Histogram {
+	if b.config.MetricDurationSeconds {
+		return b.metrics.HistogramWithUnit(
+			name+"_duration",
+			"Duration of "+subject+" in seconds",
+			metric.UnitSeconds,
+			metric.DefaultSecondsBuckets,
+			labelNames...,
+		)
+	}
+	return b.metrics.Histogram(
+		name+"_duration_ms",
+		"Duration of "+subject+" in milliseconds",
 		nil, // Use default buckets
-		allLabelNames...,
+		labelNames...,
 	)
-	histogram.With(labels...).Observe(float64(duration.Milliseconds()))
+}
+
+// durationValue converts duration to the unit of durationHistogram.
+func (b *Bedrock) durationValue(duration time.Duration) float64 {
+	if b.config.MetricDurationSeconds {
+		return duration.Seconds()
+	}
+	return float64(duration.Milliseconds())
+}
+
+// logsChildren reports whether the operation's canonical log includes its child operations.
+func (op *operationState) logsChildren() bool {
+	cfg := op.bedrock.config
+	return cfg.LogCanonical && !op.bedrock.isNoop && cfg.canonicalField("children")
+}
+
+// logsEvents reports whether the operation's canonical log includes its events.
+func (op *operationState) logsEvents() bool {
+	cfg := op.bedrock.config
+	return cfg.LogCanonical && !op.bedrock.isNoop && cfg.canonicalField("events")
+}
+
+// finalize settles the outcome before the interceptors see it: failing the operation if
+// its context is done, with FailOnCancel, and classifying its failure.
+func (op *operationState) finalize() {
+	if op.failOnCancel {
+		op.failIfCanceled()
+	}
+	if op.bedrock.config.OperationErrorTypes {
+		op.classifyError()
+	}
 }
 
 // end finishes the operation.
 func (op *operationState) end() {
+	// Classify a failure from an interceptor too
+	if op.bedrock.config.OperationErrorTypes {
+		op.classifyError()
+	}
+
+	duration := time.Since(op.startTime)
+	if op.slo > 0 {
+		op.checkSLO(duration)
+	}
+
+	if op.parent != nil && op.parent.logsChildren() {
+		op.mu.Lock()
+		child := childSummary{name: op.name, duration: duration, success: op.success}
+		op.mu.Unlock()
+
+		op.parent.mu.Lock()
+		if len(op.parent.children) < maxCanonicalChildren {
+			op.parent.children = append(op.parent.children, child)
+		} else {
+			op.parent.childrenDropped++
+		}
+		op.parent.mu.Unlock()
+	}
+
 	// End the span
 	if op.span != nil {
 		op.span.End()
 	}
 
 	// Record metrics
-	op.recordMetrics()
+	op.recordMetrics(duration)
 
 	// Canonical log if enabled
 	if op.bedrock.config.LogCanonical && !op.bedrock.isNoop {
-		op.logCanonical()
+		op.logCanonical(duration)
 	}
 }
 
-// logCanonical writes a structured log of the complete operation.
-func (op *operationState) logCanonical() {
+// checkSLO records whether the operation took longer than its SLO in an "slo_breached"
+// attribute. The span of a breaching operation is sampled, if it was recorded unsampled.
+func (op *operationState) checkSLO(duration time.Duration) {
+	breached := duration > op.slo
+	op.setAttr(attr.Bool("slo_breached", breached))
+
 	op.mu.Lock()
-	defer op.mu.Unlock()
+	op.sloBreached = breached
+	op.mu.Unlock()
 
-	duration := time.Since(op.startTime)
+	if breached && op.span != nil {
+		op.span.Sample()
+	}
+}
 
-	// Collect attributes
-	attrs := make(map[string]any)
+// operationLogAttrs appends the name and attributes of the operation in ctx to attrs,
+// for inclusion in log records written within it. Keys already in skip are left out.
+func operationLogAttrs(ctx context.Context, attrs []slog.Attr, skip attr.Set) []slog.Attr {
+	op := operationStateFromContext(ctx)
+	if op == nil {
+		return attrs
+	}
+
+	op.mu.Lock()
+	defer op.mu.Unlock()
+
+	if !skip.Has("operation") {
+		attrs = append(attrs, slog.String("operation", op.name))
+	}
 	op.attrs.Range(func(a attr.Attr) bool {
-		attrs[a.Key] = a.Value.AsAny()
+		if !skip.Has(a.Key) {
+			attrs = append(attrs, blog.AttrToSlog(a))
+		}
 		return true
 	})
+	return attrs
+}
 
-	// Collect step information
-	steps := make([]map[string]any, len(op.steps))
-	for i, step := range op.steps {
-		stepAttrs := make(map[string]any)
-		step.attrs.Range(func(a attr.Attr) bool {
-			stepAttrs[a.Key] = a.Value.AsAny()
-			return true
-		})
-		steps[i] = map[string]any{
-			"name":       step.name,
-			"attributes": stepAttrs,
-		}
+// logCanonical writes a structured log of the complete operation.
+// Its level, message, and optional fields are set by the LogCanonical* config options.
+func (op *operationState) logCanonical(duration time.Duration) {
+	cfg := op.bedrock.config
+
+	op.mu.Lock()
+	defer op.mu.Unlock()
+
+	if op.success && !cfg.sampleCanonical() {
+		return
+	}
+
+	// Log with the operation's span, so the line is correlated with its trace
+	ctx := context.Background()
+	if op.span != nil {
+		ctx = trace.ContextWithSpan(ctx, op.span)
+	}
+	level := cfg.canonicalLevel(op.success)
+	if !op.bedrock.logger.Enabled(ctx, level) {
+		return
 	}
 
 	// Build log fields
@@ -236,18 +556,77 @@ func (op *operationState) logCanonical() {
 	}
 
 	if op.failure != nil {
-		logFields = append(logFields, "error", op.failure.Error())
+		logFields = append(logFields, "error", op.failure)
 	}
 
-	if len(attrs) > 0 {
-		logFields = append(logFields, "attributes", attrs)
+	if cfg.canonicalField("attributes") {
+		attrs := make(map[string]any)
+		op.attrs.Range(func(a attr.Attr) bool {
+			if cfg.canonicalAttr(a.Key) {
+				attrs[a.Key] = a.Value.AsAny()
+			}
+			return true
+		})
+		if len(attrs) > 0 {
+			logFields = append(logFields, "attributes", attrs)
+		}
 	}
 
-	if len(steps) > 0 {
+	if cfg.canonicalField("steps") && len(op.steps) > 0 {
+		steps := make([]map[string]any, len(op.steps))
+		for i, step := range op.steps {
+			stepAttrs := make(map[string]any)
+			step.attrs.Range(func(a attr.Attr) bool {
+				if cfg.canonicalAttr(a.Key) {
+					stepAttrs[a.Key] = a.Value.AsAny()
+				}
+				return true
+			})
+			steps[i] = map[string]any{
+				"name":        step.name,
+				"duration_ms": step.duration.Milliseconds(),
+				"attributes":  stepAttrs,
+			}
+		}
 		logFields = append(logFields, "steps", steps)
 	}
 
-	op.bedrock.logger.Info("operation.complete", logFields...)
+	if cfg.canonicalField("children") && len(op.children) > 0 {
+		children := make([]map[string]any, len(op.children))
+		for i, child := range op.children {
+			children[i] = map[string]any{
+				"name":        child.name,
+				"duration_ms": child.duration.Milliseconds(),
+				"success":     child.success,
+			}
+		}
+		logFields = append(logFields, "children", children)
+		if op.childrenDropped > 0 {
+			logFields = append(logFields, "children_dropped", op.childrenDropped)
+		}
+	}
+
+	if cfg.canonicalField("events") && len(op.events) > 0 {
+		events := make([]map[string]any, len(op.events))
+		for i, event := range op.events {
+			eventAttrs := make(map[string]any)
+			event.Attrs.Range(func(a attr.Attr) bool {
+				eventAttrs[a.Key] = a.Value.AsAny()
+				return true
+			})
+			events[i] = map[string]any{
+				"name":       event.Name,
+				"time":       event.Time,
+				"attributes": eventAttrs,
+			}
+		}
+		logFields = append(logFields, "events", events)
+		if op.eventsDropped > 0 {
+			logFields = append(logFields, "events_dropped", op.eventsDropped)
+		}
+	}
+
+	op.bedrock.logger.Log(ctx, level, cfg.canonicalMessage(), logFields...)
 }
 
 // StepFromContext creates a lightweight step within an operation for tracing without full operation metrics.
@@ -276,15 +655,18 @@ func StepFromContext(ctx context.Context, name string, opts ...StepOption) *OpSt
 			parentCtx = ctx
 		}
 
-		_, span = b.tracer.Start(parentCtx, name, trace.WithAttrs(cfg.attrs...))
+		attrs := attr.NewSet(cfg.attrs...)
+		_, span = b.tracer.Start(parentCtx, name, trace.WithAttrSet(attrs))
 	}
 
 	step := &OpStep{
-		name:   name,
-		span:   span,
-		attrs:  attr.NewSet(cfg.attrs...),
-		parent: parent,
-		ctx:    ctx,
+		name:      name,
+		span:      span,
+		attrs:     attr.NewSet(cfg.attrs...),
+		parent:    parent,
+		ctx:       ctx,
+		startTime: time.Now(),
+		metrics:   cfg.metrics,
 	}
 
 	// Track step in parent
@@ -332,7 +714,43 @@ func (s *OpStep) Register(ctx context.Context, items ...attr.Registrable) {
 
 // Done ends the step.
 func (s *OpStep) Done() {
+	duration := time.Since(s.startTime)
+	if s.parent != nil {
+		s.parent.mu.Lock()
+		s.duration = duration
+		s.parent.mu.Unlock()
+	} else {
+		s.duration = duration
+	}
+
 	if s.span != nil {
 		s.span.End()
 	}
+	if s.metrics {
+		s.recordMetrics(duration)
+	}
+}
+
+// recordMetrics records the step's count and duration, labeled with static attributes
+// and the parent operation's name ("_" if there is none).
+func (s *OpStep) recordMetrics(duration time.Duration) {
+	b := bedrockFromContext(s.ctx)
+	if b.isNoop {
+		return
+	}
+
+	labelNames, labels := b.staticLabels()
+	operation := "_"
+	if s.parent != nil {
+		operation = s.parent.name
+	}
+	labelNames = append(labelNames, "operation")
+	labels = append(labels, attr.String("operation", operation))
+
+	b.metrics.Counter(
+		s.name+"_count",
+		"Total count of "+s.name+" steps",
+		labelNames...,
+	).With(labels...).Inc()
+	b.durationHistogram(s.name, s.name+" steps", labelNames).With(labels...).Observe(b.durationValue(duration))
 }
@@ -1,10 +1,31 @@
 package bedrock
 
 import (
+	"context"
+	"sync"
+	"time"
+
 	"github.com/kzs0/bedrock/attr"
 	"github.com/kzs0/bedrock/trace"
 )
 
+// OperationInterceptor is called with every operation when it starts, with the
+// operation's context. The function it returns, if not nil, is called when the
+// operation is done, after its outcome is set, including by FailOnCancel and
+// Config.OperationErrorTypes, and before its span ends and its metrics and canonical
+// log are recorded, so it can still register attributes or change the outcome. See
+// Config.OperationInterceptors.
+//
+// Usage:
+//
+//	func quota(ctx context.Context, op *bedrock.Op) func() {
+//	    tenant := tenantFromContext(ctx)
+//	    return func() {
+//	        quotas.Charge(tenant, op.Name(), op.Succeeded())
+//	    }
+//	}
+type OperationInterceptor func(ctx context.Context, op *Op) func()
+
 // OperationOption configures an operation.
 type OperationOption interface {
 	applyToOperation(*operationConfig)
@@ -71,6 +92,11 @@ type operationConfig struct {
 	failure      error              // error if operation failed
 	remoteParent *trace.SpanContext // remote parent from W3C Trace Context
 	noTrace      bool               // if true, skip tracing for this operation and children
+	noMetrics    bool               // if true, skip automatic metrics for this operation
+	failOnCancel bool               // if true, fail the operation if its context is done before Done
+	slo          time.Duration      // target duration, if positive
+	metricSample int                // record metrics for 1 in metricSample invocations, if > 1
+	traceSampler trace.Sampler      // samples the operation's trace instead of the tracer's sampler, if set
 }
 
 // MetricLabels defines the label names for this operation's metrics upfront.
@@ -82,6 +108,50 @@ func MetricLabels(labelNames ...string) operationOnlyOption {
 	}}
 }
 
+// NoMetrics disables automatic metrics for this operation.
+// Use this for extremely hot, fine-grained operations where per-operation metric
+// families would cost too much. Tracing and canonical logs are unaffected, and
+// child operations still record metrics.
+func NoMetrics() operationOnlyOption {
+	return operationOnlyOption{fn: func(cfg *operationConfig) {
+		cfg.noMetrics = true
+	}}
+}
+
+// SampleMetrics records automatic metrics for about 1 in n invocations of the operation,
+// chosen at random, adding n to its counters each time so their rates stay accurate.
+// Duration histograms observe only the sampled invocations, so their counts are about
+// 1/n of the true count. Use it for code paths hot enough that metric updates show in
+// profiles. Tracing and canonical logs are unaffected. Values of n below 2 record every
+// invocation.
+func SampleMetrics(n int) operationOnlyOption {
+	return operationOnlyOption{fn: func(cfg *operationConfig) {
+		cfg.metricSample = n
+	}}
+}
+
+// FailOnCancel marks the operation as failed if its context is canceled or its deadline
+// is exceeded before Done, instead of recording it as a success. The failure is the
+// context's error, and the operation gets an "error.type" attribute of "canceled" or
+// "deadline_exceeded", which can be added to MetricLabels. Config.OperationFailOnCancel
+// enables this for all operations.
+func FailOnCancel() operationOnlyOption {
+	return operationOnlyOption{fn: func(cfg *operationConfig) {
+		cfg.failOnCancel = true
+	}}
+}
+
+// WithSLO sets a target duration for the operation. When it completes, the operation gets
+// an "slo_breached" attribute, and operations slower than target are counted in
+// <name>_slo_breaches (operation_slo_breaches with MetricConsolidatedOperations), an input
+// for burn-rate alerts. With Config.OperationSLOSampleBreaches, a breaching operation's
+// span is exported even if the trace sampler dropped it.
+func WithSLO(target time.Duration) operationOnlyOption {
+	return operationOnlyOption{fn: func(cfg *operationConfig) {
+		cfg.slo = target
+	}}
+}
+
 // Success marks the operation as successful (affects auto-generated success/failure metrics).
 func Success() operationOnlyOption {
 	return operationOnlyOption{fn: func(cfg *operationConfig) {
@@ -104,6 +174,15 @@ func WithRemoteParent(parent trace.SpanContext) operationOnlyOption {
 	}}
 }
 
+// WithTraceSampler samples the operation's span with sampler instead of the configured
+// trace sampler, such as to trace health checks at a lower rate than other requests.
+// Its child operations and steps follow its sampling decision.
+func WithTraceSampler(sampler trace.Sampler) operationOnlyOption {
+	return operationOnlyOption{fn: func(cfg *operationConfig) {
+		cfg.traceSampler = sampler
+	}}
+}
+
 // EndOption configures how an operation ends.
 type EndOption func(*endConfig)
 
@@ -112,9 +191,10 @@ type endConfig struct {
 	success bool
 	failure error
 	hasOpts bool // whether any options were provided
+	repanic bool // whether DoneRecover re-panics
 }
 
-// EndSuccess marks the operation as successful when ending.
+// EndSuccess marks the operation as successful when ending, as Op.Succeed does.
 func EndSuccess() EndOption {
 	return func(cfg *endConfig) {
 		cfg.success = true
@@ -122,7 +202,8 @@ func EndSuccess() EndOption {
 	}
 }
 
-// EndFailure marks the operation as failed when ending.
+// EndFailure marks the operation as failed with err when ending, as Op.Fail does.
+// If err is nil, the outcome is left unchanged.
 func EndFailure(err error) EndOption {
 	return func(cfg *endConfig) {
 		cfg.success = false
@@ -131,6 +212,24 @@ func EndFailure(err error) EndOption {
 	}
 }
 
+// Repanic makes Op.DoneRecover panic again with the recovered value once the operation
+// is complete, so the panic still crashes the program or reaches an outer recover.
+// It has no effect on Done.
+func Repanic() EndOption {
+	return func(cfg *endConfig) {
+		cfg.repanic = true
+	}
+}
+
+// applyEndOptions applies options to create an end config.
+func applyEndOptions(opts []EndOption) endConfig {
+	var cfg endConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
 // applyOperationOptions applies options to create an operation config.
 func applyOperationOptions(name string, opts []OperationOption) operationConfig {
 	cfg := operationConfig{
@@ -153,6 +252,19 @@ type sourceConfig struct {
 	name         string
 	attrs        attr.Set
 	metricLabels []string // defined metric label names for operations from this source
+	aggLabels    []string // defined label names for the source's own aggregates
+
+	names *sync.Map // operation name -> name prefixed with the source's name, precomputed
+}
+
+// operationName returns name prefixed with the source's name, caching the result.
+func (cfg *sourceConfig) operationName(name string) string {
+	if full, ok := cfg.names.Load(name); ok {
+		return full.(string)
+	}
+	full := cfg.name + "." + name
+	cfg.names.Store(name, full)
+	return full
 }
 
 // SourceAttrs adds attributes to a source.
@@ -171,6 +283,16 @@ func SourceMetricLabels(labelNames ...string) SourceOption {
 	}
 }
 
+// SourceAggregateLabels defines the label names for the source's own aggregates.
+// Aggregations supply values with attr.Labeled, as in
+// attr.Labeled(attr.Sum("messages", 1), attr.String("topic", topic)). A registered
+// label an aggregation doesn't provide is set to "_"; unregistered labels are ignored.
+func SourceAggregateLabels(labelNames ...string) SourceOption {
+	return func(cfg *sourceConfig) {
+		cfg.aggLabels = append(cfg.aggLabels, labelNames...)
+	}
+}
+
 // applySourceOptions applies options to create a source config.
 func applySourceOptions(name string, opts []SourceOption) sourceConfig {
 	cfg := sourceConfig{
@@ -181,13 +303,35 @@ func applySourceOptions(name string, opts []SourceOption) sourceConfig {
 	for _, opt := range opts {
 		opt(&cfg)
 	}
+
+	cfg.names = &sync.Map{}
 	return cfg
 }
 
+// stepOnlyOption is an option that only works on steps.
+type stepOnlyOption struct {
+	fn func(*stepConfig)
+}
+
+func (o stepOnlyOption) applyToStep(c *stepConfig) {
+	o.fn(c)
+}
+
+// WithStepMetrics records a count and duration histogram for the step, <name>_count and
+// <name>_duration_ms, labeled with static attributes and the parent operation's name.
+// Use it for steps that matter on their own, such as "cache.lookup", without promoting
+// them to operations.
+func WithStepMetrics() stepOnlyOption {
+	return stepOnlyOption{fn: func(cfg *stepConfig) {
+		cfg.metrics = true
+	}}
+}
+
 // stepConfig holds configuration for a step.
 type stepConfig struct {
 	attrs   []attr.Attr
 	noTrace bool // if true, skip tracing for this step
+	metrics bool // if true, record step metrics
 }
 
 // applyStepOptions applies options to create a step config.
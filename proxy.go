@@ -0,0 +1,67 @@
+package bedrock
+
+import (
+	"net/http"
+	"net/http/httputil"
+	"time"
+
+	"github.com/kzs0/bedrock/attr"
+)
+
+// InstrumentReverseProxy instruments proxy for serving behind HTTPMiddleware or Middleware.
+// Each upstream request gets a client span under the inbound request's operation, and its
+// traceparent is sent upstream in place of the inbound one, so the upstream's spans join
+// the trace under the proxy's. The operation gets the upstream's host, status code, and
+// latency as proxy.upstream, proxy.upstream_status_code, and proxy.upstream_duration_ms,
+// and upstream requests are counted in the HTTP client metrics.
+//
+// Errors reaching the upstream are registered on the operation before proxy's
+// ErrorHandler runs, or, if it has none, the client gets a 502 as from ReverseProxy.
+//
+// Usage:
+//
+//	proxy := bedrock.InstrumentReverseProxy(httputil.NewSingleHostReverseProxy(upstream))
+//	http.ListenAndServe(":8080", bedrock.HTTPMiddleware(ctx, proxy))
+func InstrumentReverseProxy(proxy *httputil.ReverseProxy) *httputil.ReverseProxy {
+	proxy.Transport = &proxyTransport{base: proxy.Transport}
+
+	errorHandler := proxy.ErrorHandler
+	proxy.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+		ctx := r.Context()
+		if op, ok := OperationFromContext(ctx); ok {
+			op.Register(ctx, attr.Error(err))
+		}
+		if errorHandler != nil {
+			errorHandler(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusBadGateway)
+	}
+	return proxy
+}
+
+// proxyTransport instruments upstream requests as instrumentedTransport does, and
+// records the upstream's response on the inbound request's operation.
+type proxyTransport struct {
+	base http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *proxyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+
+	start := time.Now()
+	resp, err := clientTransport(ctx, t.base).RoundTrip(req)
+	duration := time.Since(start)
+
+	if op, ok := OperationFromContext(ctx); ok {
+		op.Register(ctx,
+			attr.String("proxy.upstream", req.URL.Host),
+			attr.Float64("proxy.upstream_duration_ms", float64(duration.Microseconds())/1000),
+		)
+		if resp != nil {
+			op.Register(ctx, attr.Int("proxy.upstream_status_code", resp.StatusCode))
+		}
+	}
+	return resp, err
+}
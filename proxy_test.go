@@ -0,0 +1,96 @@
+package bedrock
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"testing"
+
+	"github.com/kzs0/bedrock/trace/w3c"
+)
+
+func TestInstrumentReverseProxy(t *testing.T) {
+	var upstreamTraceparent string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamTraceparent = r.Header.Get("traceparent")
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer upstream.Close()
+
+	ctx, close := Init(context.Background(),
+		WithConfig(Config{Service: "test-proxy"}),
+	)
+	defer close()
+
+	target, _ := url.Parse(upstream.URL)
+	proxy := InstrumentReverseProxy(httputil.NewSingleHostReverseProxy(target))
+
+	var state *operationState
+	handler := HTTPMiddleware(ctx, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		state = operationStateFromContext(r.Context())
+		proxy.ServeHTTP(w, r)
+	}))
+
+	inbound := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	req := httptest.NewRequest("GET", "/users", nil)
+	req.Header.Set("traceparent", inbound)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected the upstream's 201, got %d", rec.Code)
+	}
+
+	// The upstream joins the trace under the proxy's client span, not the inbound parent
+	traceID, parentID, _, err := w3c.ParseTraceparent(upstreamTraceparent)
+	if err != nil {
+		t.Fatalf("expected a traceparent upstream, got %q: %v", upstreamTraceparent, err)
+	}
+	if traceID.String() != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("expected the inbound trace ID upstream, got %s", traceID)
+	}
+	if parentID.String() == "00f067aa0ba902b7" || parentID == state.span.SpanID() {
+		t.Error("expected the upstream's parent to be the proxy's client span")
+	}
+
+	if v, _ := state.attrs.Get("proxy.upstream"); v.String() != target.Host {
+		t.Errorf("expected proxy.upstream %s, got %v", target.Host, v)
+	}
+	if v, _ := state.attrs.Get("proxy.upstream_status_code"); v.String() != "201" {
+		t.Errorf("expected proxy.upstream_status_code 201, got %v", v)
+	}
+	if _, ok := state.attrs.Get("proxy.upstream_duration_ms"); !ok {
+		t.Error("expected proxy.upstream_duration_ms")
+	}
+}
+
+func TestInstrumentReverseProxyError(t *testing.T) {
+	ctx, close := Init(context.Background(),
+		WithConfig(Config{Service: "test-proxy"}),
+	)
+	defer close()
+
+	target, _ := url.Parse("http://127.0.0.1:1")
+	proxy := InstrumentReverseProxy(httputil.NewSingleHostReverseProxy(target))
+
+	var state *operationState
+	handler := HTTPMiddleware(ctx, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		state = operationStateFromContext(r.Context())
+		proxy.ServeHTTP(w, r)
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+
+	if rec.Code != http.StatusBadGateway {
+		t.Errorf("expected 502, got %d", rec.Code)
+	}
+	if state.success {
+		t.Error("expected the operation to fail")
+	}
+	if _, ok := state.attrs.Get("proxy.upstream_status_code"); ok {
+		t.Error("expected no upstream status code without a response")
+	}
+}
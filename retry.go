@@ -0,0 +1,125 @@
+package bedrock
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+
+	"github.com/kzs0/bedrock/attr"
+)
+
+// RetryPolicy configures Retry.
+type RetryPolicy struct {
+	// Attempts is the maximum number of attempts, including the first.
+	// Values below 1 mean a single attempt.
+	Attempts int
+	// Backoff is the delay before the second attempt.
+	Backoff time.Duration
+	// Multiplier scales the delay after each further attempt. Values below 1 mean 2.
+	Multiplier float64
+	// MaxBackoff caps the delay between attempts. Zero means no cap.
+	MaxBackoff time.Duration
+	// Jitter randomly shortens each delay by up to this fraction of it, from 0 to 1,
+	// so clients retrying together spread out.
+	Jitter float64
+	// Retryable reports whether an attempt's error should be retried.
+	// If nil, every error is retried.
+	Retryable func(error) bool
+}
+
+// delay returns the delay after the given attempt, counted from 1.
+func (p RetryPolicy) delay(attempt int) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 2
+	}
+
+	d := float64(p.Backoff)
+	for range attempt - 1 {
+		d *= multiplier
+		if p.MaxBackoff > 0 && d >= float64(p.MaxBackoff) {
+			break
+		}
+	}
+	if p.MaxBackoff > 0 && d > float64(p.MaxBackoff) {
+		d = float64(p.MaxBackoff)
+	}
+	if p.Jitter > 0 {
+		d -= d * min(p.Jitter, 1) * rand.Float64()
+	}
+	return time.Duration(d)
+}
+
+// Retry runs fn as an operation named name, as Run does, retrying it according to policy
+// until it succeeds, returns an error policy.Retryable rejects, or runs out of attempts.
+// It returns fn's last error, or ctx's error if ctx is done while waiting to retry.
+//
+// Each attempt is a step named name+".attempt" with a "retry.attempt" attribute, and its
+// error is recorded on the step's span. The operation gets a "retry.attempts" attribute,
+// and "retry.exhausted" if it ran out of attempts. Retries and exhaustions are counted
+// in <name>_retries and <name>_retries_exhausted.
+//
+// Usage:
+//
+//	err := bedrock.Retry(ctx, "payments.charge", bedrock.RetryPolicy{
+//	    Attempts: 3,
+//	    Backoff:  100 * time.Millisecond,
+//	    Jitter:   0.2,
+//	}, func(ctx context.Context) error {
+//	    return client.Charge(ctx, req)
+//	})
+func Retry(ctx context.Context, name string, policy RetryPolicy, fn func(context.Context) error, opts ...OperationOption) error {
+	attempts := max(policy.Attempts, 1)
+
+	return Run(ctx, name, func(ctx context.Context) error {
+		op, _ := OperationFromContext(ctx)
+
+		var err error
+		attempt := 1
+		for ; ; attempt++ {
+			step := Step(ctx, name+".attempt", Attrs(attr.Int("retry.attempt", attempt)))
+			err = fn(ctx)
+			if err != nil && step.span != nil {
+				step.span.RecordError(err)
+			}
+			step.Done()
+
+			if err == nil || attempt == attempts || (policy.Retryable != nil && !policy.Retryable(err)) {
+				break
+			}
+
+			timer := time.NewTimer(policy.delay(attempt))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				op.Register(ctx, attr.Int("retry.attempts", attempt))
+				recordRetries(ctx, name, attempt, false)
+				return ctx.Err()
+			case <-timer.C:
+			}
+		}
+
+		exhausted := err != nil && attempt == attempts && attempts > 1
+		op.Register(ctx, attr.Int("retry.attempts", attempt))
+		if exhausted {
+			op.Register(ctx, attr.Bool("retry.exhausted", true))
+		}
+		recordRetries(ctx, name, attempt, exhausted)
+		return err
+	}, opts...)
+}
+
+// recordRetries counts the retries after the first attempt, and whether they were exhausted.
+func recordRetries(ctx context.Context, name string, attempts int, exhausted bool) {
+	b := bedrockFromContext(ctx)
+	if b.isNoop {
+		return
+	}
+
+	if attempts > 1 {
+		b.counter(name+"_retries", "Retried attempts of "+name).Add(float64(attempts - 1))
+	}
+	if exhausted {
+		b.counter(name+"_retries_exhausted", name+" calls that failed on every attempt").Inc()
+	}
+}
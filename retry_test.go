@@ -0,0 +1,149 @@
+package bedrock
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRetry(t *testing.T) {
+	ctx, close := Init(context.Background(),
+		WithConfig(Config{Service: "test-service"}),
+	)
+	defer close()
+
+	errTransient := errors.New("transient")
+	policy := RetryPolicy{Attempts: 3, Backoff: time.Millisecond}
+
+	var state *operationState
+	calls := 0
+	err := Retry(ctx, "charge", policy, func(ctx context.Context) error {
+		state = operationStateFromContext(ctx)
+		calls++
+		if calls < 2 {
+			return errTransient
+		}
+		return nil
+	})
+	if err != nil || calls != 2 {
+		t.Fatalf("expected success on the second attempt, got err=%v calls=%d", err, calls)
+	}
+	if !state.success {
+		t.Error("expected the operation to succeed")
+	}
+	if v, _ := state.attrs.Get("retry.attempts"); v.AsInt64() != 2 {
+		t.Errorf("expected retry.attempts=2, got %v", v)
+	}
+	if len(state.steps) != 2 {
+		t.Fatalf("expected a step per attempt, got %d", len(state.steps))
+	}
+	if v, _ := state.steps[1].attrs.Get("retry.attempt"); v.AsInt64() != 2 {
+		t.Errorf("expected second step to have retry.attempt=2, got %v", v)
+	}
+	if events := state.steps[0].span.Events(); len(events) != 1 || events[0].Name != "exception" {
+		t.Errorf("expected the first attempt's error on its span, got %v", events)
+	}
+
+	calls = 0
+	err = Retry(ctx, "charge", policy, func(ctx context.Context) error {
+		state = operationStateFromContext(ctx)
+		calls++
+		return errTransient
+	})
+	if err != errTransient || calls != 3 {
+		t.Errorf("expected the last error after 3 attempts, got err=%v calls=%d", err, calls)
+	}
+	if v, _ := state.attrs.Get("retry.exhausted"); !v.AsBool() {
+		t.Error("expected retry.exhausted on the operation")
+	}
+	if state.success {
+		t.Error("expected the operation to fail")
+	}
+
+	errPermanent := errors.New("permanent")
+	calls = 0
+	policy.Retryable = func(err error) bool { return !errors.Is(err, errPermanent) }
+	err = Retry(ctx, "charge", policy, func(ctx context.Context) error {
+		calls++
+		return errPermanent
+	})
+	if err != errPermanent || calls != 1 {
+		t.Errorf("expected no retries of a non-retryable error, got err=%v calls=%d", err, calls)
+	}
+
+	metrics := make(map[string]float64)
+	for _, fam := range FromContext(ctx).Metrics().Gather() {
+		for _, m := range fam.Metrics {
+			metrics[fam.Name] += m.Value
+		}
+	}
+	if metrics["charge_retries"] != 3 {
+		t.Errorf("expected 3 retries, got %v", metrics["charge_retries"])
+	}
+	if metrics["charge_retries_exhausted"] != 1 {
+		t.Errorf("expected 1 exhausted call, got %v", metrics["charge_retries_exhausted"])
+	}
+}
+
+func TestRetryContextDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	err := Retry(ctx, "charge", RetryPolicy{Attempts: 5, Backoff: time.Hour}, func(ctx context.Context) error {
+		calls++
+		cancel()
+		return errors.New("transient")
+	})
+	if !errors.Is(err, context.Canceled) || calls != 1 {
+		t.Errorf("expected the context error while waiting to retry, got err=%v calls=%d", err, calls)
+	}
+}
+
+func TestRetryPolicyDelay(t *testing.T) {
+	p := RetryPolicy{Backoff: 100 * time.Millisecond, MaxBackoff: 300 * time.Millisecond}
+	for attempt, want := range map[int]time.Duration{1: 100 * time.Millisecond, 2: 200 * time.Millisecond, 3: 300 * time.Millisecond, 10: 300 * time.Millisecond} {
+		if got := p.delay(attempt); got != want {
+			t.Errorf("delay(%d) = %v, want %v", attempt, got, want)
+		}
+	}
+
+	p.Jitter = 0.5
+	for range 100 {
+		if d := p.delay(1); d < 50*time.Millisecond || d > 100*time.Millisecond {
+			t.Fatalf("expected jittered delay in [50ms, 100ms], got %v", d)
+		}
+	}
+}
+
+func TestRetryStrictNames(t *testing.T) {
+	var buf bytes.Buffer
+	ctx, close := Init(context.Background(),
+		WithConfig(Config{
+			Service:           "test-service",
+			LogOutput:         &buf,
+			MetricStrictNames: true,
+		}),
+	)
+	defer close()
+
+	// The retry counters are named after the operation, so they are sanitized like its metrics
+	errTransient := errors.New("transient")
+	_ = Retry(ctx, "payments.charge", RetryPolicy{Attempts: 2, Backoff: time.Millisecond}, func(ctx context.Context) error {
+		return errTransient
+	})
+
+	if bytes.Contains(buf.Bytes(), []byte(`"metric":"payments.charge_retries`)) {
+		t.Errorf("expected the retry counters not to be rejected, got: %s", buf.String())
+	}
+	metrics := map[string]float64{}
+	for _, fam := range FromContext(ctx).Metrics().Gather() {
+		for _, m := range fam.Metrics {
+			metrics[fam.Name] += m.Value
+		}
+	}
+	if metrics["payments_charge_retries"] != 1 || metrics["payments_charge_retries_exhausted"] != 1 {
+		t.Errorf("expected the sanitized retry counters, got retries %v and exhausted %v",
+			metrics["payments_charge_retries"], metrics["payments_charge_retries_exhausted"])
+	}
+}
@@ -1,15 +1,26 @@
 // Package server provides an HTTP server for observability endpoints.
-// This includes metrics, health checks, and pprof profiling endpoints.
+// This includes metrics, health checks, version, pprof profiling, and expvar endpoints.
 // Most users won't need to use this package directly as the observability server
 // is automatically started by bedrock.Init() when Config.ServerEnabled is true.
 package server
 
 import (
+	"bytes"
 	"context"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"expvar"
+	"fmt"
+	"io"
+	"log/slog"
 	"net"
 	"net/http"
+	"os"
+	"strconv"
 	"time"
 
+	"github.com/kzs0/bedrock/health"
 	"github.com/kzs0/bedrock/metric"
 	"github.com/kzs0/bedrock/metric/prometheus"
 	"github.com/kzs0/bedrock/profile"
@@ -21,6 +32,11 @@ type Server struct {
 	server          *http.Server
 	mux             *http.ServeMux
 	shutdownTimeout time.Duration
+
+	tls          bool
+	certFile     string
+	keyFile      string
+	clientCAFile string
 }
 
 // Config configures the observability HTTP server.
@@ -31,6 +47,49 @@ type Config struct {
 	EnableMetrics bool
 	// EnablePprof enables the /debug/pprof endpoints.
 	EnablePprof bool
+	// EnableExpvar enables the /debug/vars endpoint, serving the variables published with
+	// the standard expvar package as JSON, including cmdline and memstats.
+	EnableExpvar bool
+	// NamedMetrics looks up additional registries served at /metrics/{name} when
+	// EnableMetrics is set. Unknown names return 404. Registries are looked up on every
+	// request, so they can be created after the server starts.
+	NamedMetrics func(name string) (*metric.Registry, bool)
+
+	// Readiness and Liveness are the checks served at /ready and /health, responding with
+	// their JSON report and status 200 or 503. Without them, the endpoints always
+	// report healthy.
+	Readiness *health.Registry
+	Liveness  *health.Registry
+
+	// Version is served as JSON at /version, such as NewVersionInfo's. Without it, the
+	// endpoint isn't served.
+	Version *VersionInfo
+
+	// TLSCertFile and TLSKeyFile are the PEM-encoded certificate and private key to serve
+	// HTTPS with. The certificate file may hold the full chain, leaf first.
+	TLSCertFile string
+	TLSKeyFile  string
+	// TLSClientCAFile is a PEM bundle of the CAs that client certificates must be signed
+	// by. When set, clients must present a certificate it verifies, so scrapers and probes
+	// need one too, unless TLSConfig sets another ClientAuth.
+	TLSClientCAFile string
+	// TLSConfig is the TLS configuration to serve HTTPS with, for settings the file fields
+	// don't cover, such as certificates from a secret store or ClientAuth
+	// VerifyClientCertIfGiven. It is cloned, and TLSCertFile, TLSKeyFile, and
+	// TLSClientCAFile are added to the clone. The server serves HTTPS if it or
+	// TLSCertFile is set.
+	TLSConfig *tls.Config
+
+	// AdminToken enables the /admin endpoints when set. Requests must send it
+	// in an "Authorization: Bearer <token>" header.
+	AdminToken string
+	// LogLevel is changed by PUT /admin/loglevel and reported by GET /admin/loglevel.
+	// The endpoint is only served if AdminToken is also set.
+	LogLevel *slog.LevelVar
+	// Admin controls the trace sample rate, changed by PUT /admin/samplerate and
+	// reported by GET /admin/samplerate, and flushes telemetry on POST /admin/flush.
+	// The endpoints are only served if AdminToken is also set.
+	Admin Admin
 
 	// HTTP Protection Settings
 
@@ -65,6 +124,18 @@ type Config struct {
 	ShutdownTimeout time.Duration
 }
 
+// Admin is the runtime control of telemetry behind the /admin endpoints.
+// *bedrock.Bedrock implements it.
+type Admin interface {
+	// TraceSampleRate returns the fraction of new traces that are sampled, or false if
+	// a custom sampler is in use.
+	TraceSampleRate() (rate float64, ok bool)
+	// SetTraceSampleRate changes the fraction of new traces that are sampled.
+	SetTraceSampleRate(rate float64)
+	// Flush exports pending telemetry without shutting down.
+	Flush(ctx context.Context) error
+}
+
 // DefaultConfig returns a default server configuration with
 // production-grade security settings to protect against DoS attacks.
 func DefaultConfig() Config {
@@ -72,6 +143,7 @@ func DefaultConfig() Config {
 		Addr:          ":9090",
 		EnableMetrics: true,
 		EnablePprof:   true,
+		EnableExpvar:  true,
 
 		// DoS Protection Defaults
 		ReadTimeout:       10 * time.Second,  // Total request read timeout
@@ -98,23 +170,48 @@ func New(metrics *metric.Registry, cfg Config) *Server {
 
 	if cfg.EnableMetrics {
 		mux.Handle("/metrics", prometheus.Handler(metrics))
+
+		if lookup := cfg.NamedMetrics; lookup != nil {
+			mux.HandleFunc("/metrics/{name}", func(w http.ResponseWriter, r *http.Request) {
+				registry, ok := lookup(r.PathValue("name"))
+				if !ok {
+					http.NotFound(w, r)
+					return
+				}
+				prometheus.Handler(registry).ServeHTTP(w, r)
+			})
+		}
 	}
 
 	if cfg.EnablePprof {
 		profile.RegisterHandlers(mux)
 	}
 
-	// Health check endpoint
-	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-		_, _ = w.Write([]byte("ok"))
-	})
+	if cfg.EnableExpvar {
+		mux.Handle("/debug/vars", expvar.Handler())
+	}
 
-	// Ready check endpoint
-	mux.HandleFunc("/ready", func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-		_, _ = w.Write([]byte("ok"))
-	})
+	if cfg.AdminToken != "" && cfg.LogLevel != nil {
+		mux.Handle("/admin/loglevel", requireToken(cfg.AdminToken, logLevelHandler(cfg.LogLevel)))
+	}
+	if cfg.AdminToken != "" && cfg.Admin != nil {
+		mux.Handle("/admin/samplerate", requireToken(cfg.AdminToken, sampleRateHandler(cfg.Admin)))
+		mux.Handle("/admin/flush", requireToken(cfg.AdminToken, flushHandler(cfg.Admin)))
+	}
+
+	// Liveness and readiness check endpoints
+	if cfg.Liveness == nil {
+		cfg.Liveness = health.NewRegistry()
+	}
+	if cfg.Readiness == nil {
+		cfg.Readiness = health.NewRegistry()
+	}
+	mux.Handle("/health", cfg.Liveness.Handler())
+	mux.Handle("/ready", cfg.Readiness.Handler())
+
+	if cfg.Version != nil {
+		mux.Handle("/version", versionHandler(*cfg.Version))
+	}
 
 	// Apply timeout defaults if not set
 	if cfg.ReadTimeout == 0 {
@@ -140,6 +237,10 @@ func New(metrics *metric.Registry, cfg Config) *Server {
 		metrics:         metrics,
 		mux:             mux,
 		shutdownTimeout: cfg.ShutdownTimeout,
+		tls:             cfg.TLSConfig != nil || cfg.TLSCertFile != "",
+		certFile:        cfg.TLSCertFile,
+		keyFile:         cfg.TLSKeyFile,
+		clientCAFile:    cfg.TLSClientCAFile,
 		server: &http.Server{
 			Addr:    cfg.Addr,
 			Handler: mux,
@@ -150,18 +251,151 @@ func New(metrics *metric.Registry, cfg Config) *Server {
 			WriteTimeout:      cfg.WriteTimeout,
 			IdleTimeout:       cfg.IdleTimeout,
 			MaxHeaderBytes:    cfg.MaxHeaderBytes,
+			TLSConfig:         cfg.TLSConfig.Clone(),
 		},
 	}
 }
 
-// ListenAndServe starts the server.
+// requireToken rejects requests that don't carry the bearer token.
+func requireToken(token string, next http.Handler) http.Handler {
+	expected := []byte("Bearer " + token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got := []byte(r.Header.Get("Authorization"))
+		if subtle.ConstantTimeCompare(got, expected) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// logLevelHandler reports the log level on GET and changes it on PUT.
+// The PUT body is a level name such as "debug" or "WARN", as parsed by slog.Level.UnmarshalText.
+func logLevelHandler(level *slog.LevelVar) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut:
+			body, err := io.ReadAll(io.LimitReader(r.Body, 64))
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			var l slog.Level
+			if err := l.UnmarshalText(bytes.TrimSpace(body)); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			level.Set(l)
+		default:
+			w.Header().Set("Allow", "GET, PUT")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_, _ = w.Write([]byte(level.Level().String()))
+	})
+}
+
+// sampleRateHandler reports the trace sample rate on GET and changes it on PUT.
+// The PUT body is a number from 0 to 1, such as "0.25". GET reports "custom" if the rate
+// isn't known.
+func sampleRateHandler(admin Admin) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut:
+			body, err := io.ReadAll(io.LimitReader(r.Body, 64))
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			rate, err := strconv.ParseFloat(string(bytes.TrimSpace(body)), 64)
+			if err != nil || rate < 0 || rate > 1 {
+				http.Error(w, "sample rate must be a number from 0 to 1", http.StatusBadRequest)
+				return
+			}
+			admin.SetTraceSampleRate(rate)
+		default:
+			w.Header().Set("Allow", "GET, PUT")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		rate, ok := admin.TraceSampleRate()
+		if !ok {
+			_, _ = w.Write([]byte("custom"))
+			return
+		}
+		_, _ = w.Write([]byte(strconv.FormatFloat(rate, 'g', -1, 64)))
+	})
+}
+
+// flushHandler flushes pending telemetry on POST, responding once it is exported.
+func flushHandler(admin Admin) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", "POST")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if err := admin.Flush(r.Context()); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// ListenAndServe starts the server, serving HTTPS if TLS is configured.
 func (s *Server) ListenAndServe() error {
-	return s.server.ListenAndServe()
+	if !s.tls {
+		return s.server.ListenAndServe()
+	}
+	if err := s.configureClientCAs(); err != nil {
+		return err
+	}
+	return s.server.ListenAndServeTLS(s.certFile, s.keyFile)
 }
 
-// Serve starts the server on an existing listener.
+// Serve starts the server on an existing listener, serving HTTPS if TLS is configured.
 func (s *Server) Serve(ln net.Listener) error {
-	return s.server.Serve(ln)
+	if !s.tls {
+		return s.server.Serve(ln)
+	}
+	if err := s.configureClientCAs(); err != nil {
+		return err
+	}
+	return s.server.ServeTLS(ln, s.certFile, s.keyFile)
+}
+
+// configureClientCAs verifies client certificates with the CAs in the client CA file, if
+// one is configured, requiring them unless the TLS config sets another ClientAuth.
+func (s *Server) configureClientCAs() error {
+	if s.clientCAFile == "" {
+		return nil
+	}
+
+	pem, err := os.ReadFile(s.clientCAFile)
+	if err != nil {
+		return fmt.Errorf("reading client CA file: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return fmt.Errorf("no certificates in client CA file %s", s.clientCAFile)
+	}
+
+	if s.server.TLSConfig == nil {
+		s.server.TLSConfig = &tls.Config{}
+	}
+	s.server.TLSConfig.ClientCAs = pool
+	if s.server.TLSConfig.ClientAuth == tls.NoClientCert {
+		s.server.TLSConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return nil
 }
 
 // Shutdown gracefully shuts down the server.
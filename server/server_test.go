@@ -0,0 +1,222 @@
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kzs0/bedrock/metric"
+)
+
+// testCA is a certificate authority issuing certificates for tests.
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pool *x509.CertPool
+	pem  []byte
+}
+
+// newTestCA creates a self-signed CA.
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	return &testCA{
+		cert: cert,
+		key:  key,
+		pool: pool,
+		pem:  pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+	}
+}
+
+// issue returns a certificate signed by the CA for usage, valid for 127.0.0.1, with its
+// PEM-encoded certificate and key.
+func (ca *testCA) issue(t *testing.T, usage x509.ExtKeyUsage) (cert tls.Certificate, certPEM, keyPEM []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: "test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM = pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	cert, err = tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, certPEM, keyPEM
+}
+
+// writeFile writes data to a file named name in dir, returning its path.
+func writeFile(t *testing.T, dir, name string, data []byte) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// serveTLS starts a server for cfg with the CA's server certificate on a local listener,
+// returning its base URL.
+func serveTLS(t *testing.T, ca *testCA, cfg Config) string {
+	t.Helper()
+	dir := t.TempDir()
+	_, certPEM, keyPEM := ca.issue(t, x509.ExtKeyUsageServerAuth)
+	cfg.TLSCertFile = writeFile(t, dir, "tls.crt", certPEM)
+	cfg.TLSKeyFile = writeFile(t, dir, "tls.key", keyPEM)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := New(metric.NewRegistry(""), cfg)
+	go func() { _ = s.Serve(ln) }()
+	t.Cleanup(func() { _ = s.server.Close() })
+	return "https://" + ln.Addr().String()
+}
+
+// tlsClient returns a client trusting the CA, presenting certs to the server.
+func tlsClient(ca *testCA, certs ...tls.Certificate) *http.Client {
+	return &http.Client{
+		Timeout: 5 * time.Second,
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{RootCAs: ca.pool, Certificates: certs},
+		},
+	}
+}
+
+// get requests path, returning the response status.
+func get(client *http.Client, url string) (int, error) {
+	resp, err := client.Get(url)
+	if err != nil {
+		return 0, err
+	}
+	_ = resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+func TestServeTLS(t *testing.T) {
+	ca := newTestCA(t)
+	url := serveTLS(t, ca, Config{})
+
+	status, err := get(tlsClient(ca), url+"/health")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status != http.StatusOK {
+		t.Errorf("expected status 200 over HTTPS, got %d", status)
+	}
+
+	if _, err := get(&http.Client{Timeout: 5 * time.Second}, url+"/health"); err == nil {
+		t.Error("expected a client not trusting the CA to fail")
+	}
+}
+
+func TestServeTLSClientCA(t *testing.T) {
+	ca := newTestCA(t)
+	url := serveTLS(t, ca, Config{TLSClientCAFile: writeFile(t, t.TempDir(), "ca.crt", ca.pem)})
+
+	if _, err := get(tlsClient(ca), url+"/health"); err == nil {
+		t.Error("expected a client without a certificate to be rejected")
+	}
+
+	// A certificate from another CA isn't accepted either
+	other, _, _ := newTestCA(t).issue(t, x509.ExtKeyUsageClientAuth)
+	if _, err := get(tlsClient(ca, other), url+"/health"); err == nil {
+		t.Error("expected a client certificate from another CA to be rejected")
+	}
+
+	cert, _, _ := ca.issue(t, x509.ExtKeyUsageClientAuth)
+	status, err := get(tlsClient(ca, cert), url+"/health")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status != http.StatusOK {
+		t.Errorf("expected status 200 with a client certificate, got %d", status)
+	}
+}
+
+func TestServeTLSClientCAKeepsClientAuth(t *testing.T) {
+	ca := newTestCA(t)
+	url := serveTLS(t, ca, Config{
+		TLSClientCAFile: writeFile(t, t.TempDir(), "ca.crt", ca.pem),
+		TLSConfig:       &tls.Config{ClientAuth: tls.VerifyClientCertIfGiven},
+	})
+
+	status, err := get(tlsClient(ca), url+"/health")
+	if err != nil {
+		t.Fatalf("expected a client without a certificate to be accepted, got %v", err)
+	}
+	if status != http.StatusOK {
+		t.Errorf("expected status 200, got %d", status)
+	}
+
+	other, _, _ := newTestCA(t).issue(t, x509.ExtKeyUsageClientAuth)
+	if _, err := get(tlsClient(ca, other), url+"/health"); err == nil {
+		t.Error("expected a client certificate from another CA to be rejected")
+	}
+}
+
+func TestServeTLSClientCAFileInvalid(t *testing.T) {
+	s := New(metric.NewRegistry(""), Config{
+		TLSCertFile:     "tls.crt",
+		TLSClientCAFile: writeFile(t, t.TempDir(), "ca.crt", []byte("not a certificate")),
+	})
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	if err := s.Serve(ln); err == nil {
+		t.Error("expected an error for a client CA file without certificates")
+	}
+}
@@ -0,0 +1,45 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/kzs0/bedrock/metric"
+)
+
+// VersionInfo describes the running service, served as JSON at /version.
+type VersionInfo struct {
+	// Service is the service name.
+	Service string `json:"service"`
+	// Version is the service version, such as the main module version.
+	Version string `json:"version"`
+	// Revision is the VCS revision the binary was built from.
+	Revision string `json:"revision"`
+	// GoVersion is the Go toolchain version used to build the binary.
+	GoVersion string `json:"go_version"`
+	// StartTime is when the service started.
+	StartTime time.Time `json:"start_time"`
+}
+
+// NewVersionInfo returns the VersionInfo of service from the build information embedded
+// in the running binary, as read by metric.ReadBuildInfo, started now.
+func NewVersionInfo(service string) VersionInfo {
+	info := metric.ReadBuildInfo()
+	return VersionInfo{
+		Service:   service,
+		Version:   info.Version,
+		Revision:  info.Revision,
+		GoVersion: info.GoVersion,
+		StartTime: time.Now(),
+	}
+}
+
+// versionHandler responds with info as JSON.
+func versionHandler(info VersionInfo) http.Handler {
+	body, _ := json.Marshal(info)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(body)
+	})
+}
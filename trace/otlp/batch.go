@@ -115,6 +115,23 @@ func (bp *BatchProcessor) exportLocked() {
 	}()
 }
 
+// ForceFlush exports the queued spans now, returning when the export is done or ctx is.
+func (bp *BatchProcessor) ForceFlush(ctx context.Context) error {
+	bp.mu.Lock()
+	if bp.timer != nil {
+		bp.timer.Stop()
+		bp.timer = nil
+	}
+	spans := bp.queue
+	bp.queue = make([]*trace.Span, 0, bp.cfg.BatchSize)
+	bp.mu.Unlock()
+
+	if len(spans) == 0 {
+		return nil
+	}
+	return bp.exporter.ExportSpans(ctx, spans)
+}
+
 // Shutdown stops the processor and exports remaining spans.
 func (bp *BatchProcessor) Shutdown(ctx context.Context) error {
 	bp.mu.Lock()
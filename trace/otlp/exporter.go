@@ -34,8 +34,9 @@ type Exporter struct {
 	cfg    ExporterConfig
 	client *http.Client
 
-	mu      sync.Mutex
-	stopped bool
+	mu       sync.Mutex
+	stopped  bool
+	resource attr.Set // guarded by mu, initially cfg.Resource
 }
 
 // NewExporter creates a new OTLP exporter.
@@ -45,13 +46,21 @@ func NewExporter(cfg ExporterConfig) *Exporter {
 	}
 
 	return &Exporter{
-		cfg: cfg,
+		cfg:      cfg,
+		resource: cfg.Resource,
 		client: &http.Client{
 			Timeout: cfg.Timeout,
 		},
 	}
 }
 
+// SetResource replaces the resource attributes of spans exported afterwards.
+func (e *Exporter) SetResource(resource attr.Set) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.resource = resource
+}
+
 // ExportSpans exports spans to the OTLP endpoint.
 func (e *Exporter) ExportSpans(ctx context.Context, spans []*trace.Span) error {
 	e.mu.Lock()
@@ -59,6 +68,7 @@ func (e *Exporter) ExportSpans(ctx context.Context, spans []*trace.Span) error {
 		e.mu.Unlock()
 		return nil
 	}
+	resource := e.resource
 	e.mu.Unlock()
 
 	if len(spans) == 0 {
@@ -66,7 +76,7 @@ func (e *Exporter) ExportSpans(ctx context.Context, spans []*trace.Span) error {
 	}
 
 	// Encode spans
-	data, err := EncodeSpans(spans, e.cfg.ServiceName, e.cfg.Resource)
+	data, err := EncodeSpans(spans, e.cfg.ServiceName, resource)
 	if err != nil {
 		return fmt.Errorf("otlp: failed to encode spans: %w", err)
 	}
@@ -45,8 +45,12 @@ type Span struct {
 	statusMsg  string
 	tracestate string // W3C tracestate for propagation
 
-	tracer *Tracer
-	ended  bool
+	tracer  *Tracer
+	ended   bool
+	sampled bool // false for spans dropped by the sampler
+	// followed is set for spans started with their own sampler and their descendants,
+	// whose children follow their sampling decision rather than the tracer's sampler.
+	followed bool
 }
 
 // Event represents an event within a span.
@@ -73,9 +77,22 @@ func (s *Span) ParentID() internal.SpanID {
 
 // Name returns the span name.
 func (s *Span) Name() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	return s.name
 }
 
+// SetName renames the span, for names only known after it starts, such as a matched route.
+func (s *Span) SetName(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.ended {
+		return
+	}
+	s.name = name
+}
+
 // Kind returns the span kind.
 func (s *Span) Kind() SpanKind {
 	return s.kind
@@ -129,6 +146,12 @@ func (s *Span) SetAttr(attrs ...attr.Attr) {
 
 // AddEvent adds an event to the span.
 func (s *Span) AddEvent(name string, attrs ...attr.Attr) {
+	s.AddEventAt(name, time.Now(), attrs...)
+}
+
+// AddEventAt adds an event that happened at t to the span, for events recorded after
+// the fact, such as the last message of a stream.
+func (s *Span) AddEventAt(name string, t time.Time, attrs ...attr.Attr) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -137,7 +160,7 @@ func (s *Span) AddEvent(name string, attrs ...attr.Attr) {
 	}
 	s.events = append(s.events, Event{
 		Name:  name,
-		Time:  time.Now(),
+		Time:  t,
 		Attrs: attr.NewSet(attrs...),
 	})
 }
@@ -191,13 +214,27 @@ func (s *Span) End() {
 	}
 	s.endTime = time.Now()
 	s.ended = true
+	if s.tracer != nil && s.tracer.redactor != nil {
+		s.redact(s.tracer.redactor)
+	}
+	sampled := s.sampled
 	s.mu.Unlock()
 
-	if s.tracer != nil {
+	if s.tracer != nil && sampled {
 		s.tracer.export(s)
 	}
 }
 
+// redact masks sensitive attributes, event attributes, and the status message.
+// The caller must hold s.mu.
+func (s *Span) redact(r *attr.Redactor) {
+	s.attrs = r.RedactSet(s.attrs)
+	for i := range s.events {
+		s.events[i].Attrs = r.RedactSet(s.events[i].Attrs)
+	}
+	s.statusMsg = r.RedactString(s.statusMsg)
+}
+
 // IsRecording returns true if the span is recording events.
 func (s *Span) IsRecording() bool {
 	s.mu.Lock()
@@ -205,6 +242,23 @@ func (s *Span) IsRecording() bool {
 	return !s.ended
 }
 
+// IsSampled reports whether the span was sampled, rather than dropped by the tracer's sampler.
+func (s *Span) IsSampled() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sampled
+}
+
+// Sample promotes a span started with WithRecordUnsampled to sampled, so it is exported
+// when it ends, such as when it turns out to be interesting. It has no effect on ended spans.
+func (s *Span) Sample() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.ended && s.tracer != nil {
+		s.sampled = true
+	}
+}
+
 // Duration returns the span duration.
 func (s *Span) Duration() time.Duration {
 	s.mu.Lock()
@@ -3,6 +3,8 @@ package trace
 import (
 	"context"
 	"errors"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -105,6 +107,27 @@ func TestSpanEvents(t *testing.T) {
 	span.End()
 }
 
+func TestSpanAddEventAt(t *testing.T) {
+	tracer := NewTracer(TracerConfig{})
+
+	_, span := tracer.Start(context.Background(), "test")
+	defer span.End()
+
+	at := time.Now().Add(-time.Second)
+	span.AddEventAt("event1", at, attr.String("key", "value"))
+
+	events := span.Events()
+	if len(events) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(events))
+	}
+	if !events[0].Time.Equal(at) {
+		t.Errorf("expected event time %v, got %v", at, events[0].Time)
+	}
+	if v, _ := events[0].Attrs.Get("key"); v.String() != "value" {
+		t.Errorf("expected attribute key=value, got %q", v.String())
+	}
+}
+
 func TestSpanRecordError(t *testing.T) {
 	tracer := NewTracer(TracerConfig{})
 
@@ -133,6 +156,35 @@ func TestSpanRecordError(t *testing.T) {
 	span.End()
 }
 
+func TestSpanRedaction(t *testing.T) {
+	redactor, err := attr.NewRedactor([]string{"password"}, []string{`tok_[a-z0-9]+`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tracer := NewTracer(TracerConfig{Redactor: redactor})
+
+	_, span := tracer.Start(context.Background(), "login")
+	span.SetAttr(attr.String("password", "hunter2"), attr.String("user", "alice"))
+	span.RecordError(errors.New("invalid key tok_abc123"))
+	span.End()
+
+	attrs := span.Attrs()
+	if v, _ := attrs.Get("password"); v.AsString() != attr.Redacted {
+		t.Errorf("expected password redacted, got %q", v.AsString())
+	}
+	if v, _ := attrs.Get("user"); v.AsString() != "alice" {
+		t.Errorf("expected user unchanged, got %q", v.AsString())
+	}
+
+	events := span.Events()
+	if v, _ := events[0].Attrs.Get("exception.message"); v.AsString() != "invalid key [REDACTED]" {
+		t.Errorf("expected exception message redacted, got %q", v.AsString())
+	}
+	if _, msg := span.Status(); msg != "invalid key [REDACTED]" {
+		t.Errorf("expected status message redacted, got %q", msg)
+	}
+}
+
 func TestSpanKind(t *testing.T) {
 	tracer := NewTracer(TracerConfig{})
 
@@ -211,6 +263,150 @@ func TestParentBasedSampler(t *testing.T) {
 	}
 }
 
+func TestParentBasedSamplerDroppedParent(t *testing.T) {
+	tracer := NewTracer(TracerConfig{
+		ServiceName: "test-service",
+		Sampler:     NewParentBasedSampler(NeverSampler{}),
+	})
+
+	ctx, parent := tracer.Start(context.Background(), "parent")
+	if parent.IsSampled() {
+		t.Fatal("expected root span to be dropped")
+	}
+
+	_, child := tracer.Start(ctx, "child")
+	if child.IsSampled() {
+		t.Error("expected child of a dropped span to be dropped")
+	}
+	if SpanContextFromContext(ctx).Sampled {
+		t.Error("expected span context of a dropped span to be unsampled")
+	}
+}
+
+func TestWithSampler(t *testing.T) {
+	tracer := NewTracer(TracerConfig{
+		ServiceName: "test-service",
+		Sampler:     AlwaysSampler{},
+	})
+
+	ctx, health := tracer.Start(context.Background(), "health", WithSampler(NeverSampler{}))
+	if health.IsSampled() {
+		t.Fatal("expected the span's own sampler to drop it")
+	}
+
+	// Descendants follow its decision rather than the tracer's sampler
+	ctx, child := tracer.Start(ctx, "child")
+	_, grandchild := tracer.Start(ctx, "grandchild")
+	if child.IsSampled() || grandchild.IsSampled() {
+		t.Error("expected descendants of a dropped span with its own sampler to be dropped")
+	}
+
+	ctx, sampled := tracer.Start(context.Background(), "sampled", WithSampler(AlwaysSampler{}))
+	_, child = tracer.Start(ctx, "child")
+	if !sampled.IsSampled() || !child.IsSampled() {
+		t.Error("expected a sampled span with its own sampler and its child to be sampled")
+	}
+}
+
+// chanExporter sends exported spans to a channel.
+type chanExporter chan *Span
+
+func (e chanExporter) ExportSpans(_ context.Context, spans []*Span) error {
+	for _, s := range spans {
+		e <- s
+	}
+	return nil
+}
+
+func (e chanExporter) Shutdown(context.Context) error { return nil }
+
+func TestRecordUnsampled(t *testing.T) {
+	exported := make(chanExporter, 2)
+	tracer := NewTracer(TracerConfig{
+		ServiceName: "test-service",
+		Sampler:     NeverSampler{},
+		Exporter:    exported,
+	})
+
+	_, dropped := tracer.Start(context.Background(), "dropped", WithRecordUnsampled())
+	if !dropped.IsRecording() || dropped.IsSampled() {
+		t.Fatal("expected a recording, unsampled span")
+	}
+	dropped.End()
+
+	_, promoted := tracer.Start(context.Background(), "promoted", WithRecordUnsampled())
+	promoted.SetAttr(attr.Bool("slow", true))
+	promoted.Sample()
+	promoted.End()
+
+	select {
+	case span := <-exported:
+		if span != promoted {
+			t.Errorf("expected only the promoted span to be exported, got %q", span.Name())
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the promoted span to be exported")
+	}
+}
+
+func TestTracerSetSampler(t *testing.T) {
+	tracer := NewTracer(TracerConfig{ServiceName: "test-service", Sampler: NeverSampler{}})
+
+	_, span := tracer.Start(context.Background(), "before")
+	if span.IsSampled() {
+		t.Fatal("expected the span to be dropped")
+	}
+
+	tracer.SetSampler(AlwaysSampler{})
+	if _, ok := tracer.Sampler().(AlwaysSampler); !ok {
+		t.Errorf("expected AlwaysSampler, got %T", tracer.Sampler())
+	}
+	_, span = tracer.Start(context.Background(), "after")
+	if !span.IsSampled() {
+		t.Error("expected spans started after SetSampler to use the new sampler")
+	}
+}
+
+// blockingExporter blocks exports until release is closed.
+type blockingExporter struct {
+	release  chan struct{}
+	exported chan *Span
+}
+
+func (e blockingExporter) ExportSpans(_ context.Context, spans []*Span) error {
+	<-e.release
+	for _, s := range spans {
+		e.exported <- s
+	}
+	return nil
+}
+
+func (e blockingExporter) Shutdown(context.Context) error { return nil }
+
+func TestTracerFlush(t *testing.T) {
+	exporter := blockingExporter{release: make(chan struct{}), exported: make(chan *Span, 1)}
+	tracer := NewTracer(TracerConfig{ServiceName: "test-service", Exporter: exporter})
+
+	_, span := tracer.Start(context.Background(), "op")
+	span.End()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := tracer.Flush(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected Flush to stop when ctx is done, got %v", err)
+	}
+
+	close(exporter.release)
+	if err := tracer.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-exporter.exported:
+	default:
+		t.Error("expected the span to be exported when Flush returns")
+	}
+}
+
 func TestSpanContext(t *testing.T) {
 	sc := SpanContext{}
 	if sc.IsValid() {
@@ -223,3 +419,46 @@ func TestSpanContext(t *testing.T) {
 		t.Error("span context with IDs should be valid")
 	}
 }
+
+// countingExporter counts the spans it exports.
+type countingExporter struct {
+	exported atomic.Int64
+}
+
+func (e *countingExporter) ExportSpans(_ context.Context, spans []*Span) error {
+	e.exported.Add(int64(len(spans)))
+	return nil
+}
+
+func (e *countingExporter) Shutdown(context.Context) error { return nil }
+
+func TestTracerFlushConcurrent(t *testing.T) {
+	exporter := &countingExporter{}
+	tracer := NewTracer(TracerConfig{ServiceName: "test-service", Exporter: exporter})
+
+	// Flush while spans are ended from other goroutines, as shutdown does
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				_, span := tracer.Start(context.Background(), "op")
+				span.End()
+			}
+		}()
+	}
+	for range 100 {
+		if err := tracer.Flush(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wg.Wait()
+
+	if err := tracer.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if n := exporter.exported.Load(); n != 400 {
+		t.Errorf("expected 400 spans exported after Flush, got %d", n)
+	}
+}
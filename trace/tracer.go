@@ -2,6 +2,8 @@ package trace
 
 import (
 	"context"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/kzs0/bedrock/attr"
@@ -17,9 +19,17 @@ type Exporter interface {
 // Tracer creates spans and manages trace context.
 type Tracer struct {
 	serviceName string
+	resourceMu  sync.RWMutex
 	resource    attr.Set
-	sampler     Sampler
+	sampler     atomic.Pointer[Sampler]
 	exporter    Exporter
+	redactor    *attr.Redactor
+
+	// In-flight exports, and the channels of Flush calls waiting for them to finish,
+	// closed when there are none left
+	exportsMu sync.Mutex
+	exports   int
+	flushes   []chan struct{}
 }
 
 // TracerConfig configures the tracer.
@@ -28,6 +38,8 @@ type TracerConfig struct {
 	Resource    attr.Set
 	Sampler     Sampler
 	Exporter    Exporter
+	// Redactor, if set, masks sensitive span and event attributes before export.
+	Redactor *attr.Redactor
 }
 
 // NewTracer creates a new tracer.
@@ -37,20 +49,45 @@ func NewTracer(cfg TracerConfig) *Tracer {
 		sampler = AlwaysSampler{}
 	}
 
-	return &Tracer{
+	t := &Tracer{
 		serviceName: cfg.ServiceName,
 		resource:    cfg.Resource,
-		sampler:     sampler,
 		exporter:    cfg.Exporter,
+		redactor:    cfg.Redactor,
 	}
+	t.sampler.Store(&sampler)
+	return t
+}
+
+// Sampler returns the sampler that decides whether to sample spans.
+func (t *Tracer) Sampler() Sampler {
+	return *t.sampler.Load()
+}
+
+// SetSampler replaces the sampler for spans started afterwards, such as to raise the
+// sample rate during an incident. A nil sampler samples every span.
+func (t *Tracer) SetSampler(sampler Sampler) {
+	if sampler == nil {
+		sampler = AlwaysSampler{}
+	}
+	t.sampler.Store(&sampler)
 }
 
 // StartSpanOptions configures span creation.
 type StartSpanOptions struct {
-	Kind         SpanKind
-	Attrs        []attr.Attr
+	Kind  SpanKind
+	Attrs []attr.Attr
+	// AttrSet holds initial span attributes already in a Set, which the span shares
+	// instead of copying. Attrs override them if keys match.
+	AttrSet      attr.Set
 	Parent       *Span
 	RemoteParent *SpanContext // Remote parent from W3C Trace Context headers
+	// RecordUnsampled records the span even if the sampler drops it, so it can be
+	// promoted with Span.Sample before it ends. Unsampled spans are not exported.
+	RecordUnsampled bool
+	// Sampler decides whether to sample the span instead of the tracer's sampler, such as
+	// to sample health checks at a lower rate. Its descendants follow its decision.
+	Sampler Sampler
 }
 
 // Start creates a new span.
@@ -70,6 +107,7 @@ func (t *Tracer) Start(ctx context.Context, name string, opts ...StartSpanOption
 	var parentID internal.SpanID
 	var parentSampled bool
 	var tracestate string
+	followParent := false
 
 	// Remote parent takes precedence over local parent
 	if options.RemoteParent != nil && options.RemoteParent.IsValid() {
@@ -80,16 +118,29 @@ func (t *Tracer) Start(ctx context.Context, name string, opts ...StartSpanOption
 	} else if parent != nil {
 		traceID = parent.traceID
 		parentID = parent.spanID
-		parentSampled = true // If parent exists and wasn't dropped, it was sampled
+		parentSampled = parent.IsSampled()
 		// Inherit tracestate from parent span for propagation
 		tracestate = parent.tracestate
+		followParent = parent.followed
 	} else {
 		traceID = internal.NewTraceID()
 	}
 
-	// Check sampling decision
-	result := t.sampler.ShouldSample(traceID, name, parentSampled)
-	if result.Decision == SamplingDecisionDrop {
+	// Check sampling decision. Descendants of a span with its own sampler follow its decision.
+	var result SamplingResult
+	switch {
+	case options.Sampler != nil:
+		result = options.Sampler.ShouldSample(traceID, name, parentSampled)
+	case followParent && parentSampled:
+		result = SamplingResult{Decision: SamplingDecisionRecordAndSample}
+	case followParent:
+		result = SamplingResult{Decision: SamplingDecisionDrop}
+	default:
+		result = t.Sampler().ShouldSample(traceID, name, parentSampled)
+	}
+	followed := options.Sampler != nil || followParent
+
+	if result.Decision == SamplingDecisionDrop && !options.RecordUnsampled {
 		// Return a no-op span
 		noopSpan := &Span{
 			name:      name,
@@ -98,6 +149,7 @@ func (t *Tracer) Start(ctx context.Context, name string, opts ...StartSpanOption
 			parentID:  parentID,
 			startTime: time.Now(),
 			ended:     true, // Mark as ended so it's not exported
+			followed:  followed,
 		}
 		return ContextWithSpan(ctx, noopSpan), noopSpan
 	}
@@ -109,9 +161,11 @@ func (t *Tracer) Start(ctx context.Context, name string, opts ...StartSpanOption
 		parentID:   parentID,
 		kind:       options.Kind,
 		startTime:  time.Now(),
-		attrs:      attr.NewSet(options.Attrs...),
+		attrs:      options.AttrSet.Merge(options.Attrs...),
 		tracestate: tracestate,
 		tracer:     t,
+		sampled:    result.Decision != SamplingDecisionDrop,
+		followed:   followed,
 	}
 
 	return ContextWithSpan(ctx, span), span
@@ -122,12 +176,51 @@ func (t *Tracer) export(span *Span) {
 	if t.exporter == nil {
 		return
 	}
+	t.exportsMu.Lock()
+	t.exports++
+	t.exportsMu.Unlock()
+
 	// Export asynchronously to not block the caller
 	go func() {
+		defer t.exportDone()
 		_ = t.exporter.ExportSpans(context.Background(), []*Span{span})
 	}()
 }
 
+// exportDone counts an export as finished, releasing Flush calls once none are left.
+func (t *Tracer) exportDone() {
+	t.exportsMu.Lock()
+	defer t.exportsMu.Unlock()
+
+	t.exports--
+	if t.exports == 0 {
+		for _, flushed := range t.flushes {
+			close(flushed)
+		}
+		t.flushes = nil
+	}
+}
+
+// Flush waits for spans being exported to finish exporting, or for ctx to be done.
+// Spans may be ended concurrently; Flush returns once no exports are in flight.
+func (t *Tracer) Flush(ctx context.Context) error {
+	t.exportsMu.Lock()
+	if t.exports == 0 {
+		t.exportsMu.Unlock()
+		return nil
+	}
+	flushed := make(chan struct{})
+	t.flushes = append(t.flushes, flushed)
+	t.exportsMu.Unlock()
+
+	select {
+	case <-flushed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 // Shutdown shuts down the tracer and flushes any pending spans.
 func (t *Tracer) Shutdown(ctx context.Context) error {
 	if t.exporter != nil {
@@ -143,9 +236,18 @@ func (t *Tracer) ServiceName() string {
 
 // Resource returns the resource attributes.
 func (t *Tracer) Resource() attr.Set {
+	t.resourceMu.RLock()
+	defer t.resourceMu.RUnlock()
 	return t.resource
 }
 
+// SetResource replaces the resource attributes.
+func (t *Tracer) SetResource(resource attr.Set) {
+	t.resourceMu.Lock()
+	defer t.resourceMu.Unlock()
+	t.resource = resource
+}
+
 // StartSpanOption configures span creation.
 type StartSpanOption func(*StartSpanOptions)
 
@@ -163,6 +265,14 @@ func WithAttrs(attrs ...attr.Attr) StartSpanOption {
 	}
 }
 
+// WithAttrSet sets the initial span attributes from a Set, which the span shares
+// instead of copying.
+func WithAttrSet(attrs attr.Set) StartSpanOption {
+	return func(o *StartSpanOptions) {
+		o.AttrSet = attrs
+	}
+}
+
 // WithParent sets the parent span.
 func WithParent(parent *Span) StartSpanOption {
 	return func(o *StartSpanOptions) {
@@ -170,6 +280,22 @@ func WithParent(parent *Span) StartSpanOption {
 	}
 }
 
+// WithRecordUnsampled records the span even if the sampler drops it.
+// See StartSpanOptions.RecordUnsampled.
+func WithRecordUnsampled() StartSpanOption {
+	return func(o *StartSpanOptions) {
+		o.RecordUnsampled = true
+	}
+}
+
+// WithSampler sets the sampler that decides whether to sample the span.
+// See StartSpanOptions.Sampler.
+func WithSampler(sampler Sampler) StartSpanOption {
+	return func(o *StartSpanOptions) {
+		o.Sampler = sampler
+	}
+}
+
 // WithRemoteParent sets the remote parent from W3C Trace Context headers.
 func WithRemoteParent(parent SpanContext) StartSpanOption {
 	return func(o *StartSpanOptions) {
@@ -0,0 +1,142 @@
+package transport
+
+import (
+	"crypto/tls"
+	"net/http/httptrace"
+	"sync"
+	"time"
+
+	"github.com/kzs0/bedrock/attr"
+	"github.com/kzs0/bedrock/trace"
+)
+
+// clientTrace records the phases of a request as events on its span. The done event of
+// each phase carries its duration_ms, and the connection, request written, and first
+// byte events carry the elapsed_ms since the request started.
+type clientTrace struct {
+	span  *trace.Span
+	start time.Time
+
+	// Start times of the phases in progress. Connections to several addresses
+	// may be attempted concurrently, so connects are keyed by address.
+	mu       sync.Mutex
+	dns      time.Time
+	connects map[string]time.Time
+	tls      time.Time
+}
+
+// newClientTrace returns an httptrace.ClientTrace that records events on span.
+func newClientTrace(span *trace.Span) *httptrace.ClientTrace {
+	ct := &clientTrace{span: span, start: time.Now(), connects: make(map[string]time.Time)}
+	return &httptrace.ClientTrace{
+		GetConn:              ct.getConn,
+		GotConn:              ct.gotConn,
+		DNSStart:             ct.dnsStart,
+		DNSDone:              ct.dnsDone,
+		ConnectStart:         ct.connectStart,
+		ConnectDone:          ct.connectDone,
+		TLSHandshakeStart:    ct.tlsHandshakeStart,
+		TLSHandshakeDone:     ct.tlsHandshakeDone,
+		WroteRequest:         ct.wroteRequest,
+		GotFirstResponseByte: ct.gotFirstResponseByte,
+	}
+}
+
+func (ct *clientTrace) getConn(hostPort string) {
+	ct.span.AddEvent("http.get_conn", attr.String("net.peer", hostPort))
+}
+
+func (ct *clientTrace) gotConn(info httptrace.GotConnInfo) {
+	ct.span.AddEvent("http.got_conn",
+		attr.Bool("http.conn.reused", info.Reused),
+		attr.Bool("http.conn.was_idle", info.WasIdle),
+		attr.Duration("http.conn.idle_time", info.IdleTime),
+		attr.Float64("elapsed_ms", msSince(ct.start)),
+	)
+}
+
+func (ct *clientTrace) dnsStart(info httptrace.DNSStartInfo) {
+	ct.mu.Lock()
+	ct.dns = time.Now()
+	ct.mu.Unlock()
+	ct.span.AddEvent("http.dns.start", attr.String("net.host", info.Host))
+}
+
+func (ct *clientTrace) dnsDone(info httptrace.DNSDoneInfo) {
+	ct.mu.Lock()
+	start := ct.dns
+	ct.mu.Unlock()
+
+	attrs := []attr.Attr{
+		attr.Int("net.addrs", len(info.Addrs)),
+		attr.Float64("duration_ms", msSince(start)),
+	}
+	if info.Err != nil {
+		attrs = append(attrs, attr.String("error", info.Err.Error()))
+	}
+	ct.span.AddEvent("http.dns.done", attrs...)
+}
+
+func (ct *clientTrace) connectStart(network, addr string) {
+	ct.mu.Lock()
+	ct.connects[addr] = time.Now()
+	ct.mu.Unlock()
+	ct.span.AddEvent("http.connect.start", attr.String("net.transport", network), attr.String("net.peer", addr))
+}
+
+func (ct *clientTrace) connectDone(network, addr string, err error) {
+	ct.mu.Lock()
+	start := ct.connects[addr]
+	delete(ct.connects, addr)
+	ct.mu.Unlock()
+
+	attrs := []attr.Attr{
+		attr.String("net.transport", network),
+		attr.String("net.peer", addr),
+		attr.Float64("duration_ms", msSince(start)),
+	}
+	if err != nil {
+		attrs = append(attrs, attr.String("error", err.Error()))
+	}
+	ct.span.AddEvent("http.connect.done", attrs...)
+}
+
+func (ct *clientTrace) tlsHandshakeStart() {
+	ct.mu.Lock()
+	ct.tls = time.Now()
+	ct.mu.Unlock()
+	ct.span.AddEvent("http.tls.start")
+}
+
+func (ct *clientTrace) tlsHandshakeDone(state tls.ConnectionState, err error) {
+	ct.mu.Lock()
+	start := ct.tls
+	ct.mu.Unlock()
+
+	attrs := []attr.Attr{
+		attr.String("tls.version", tls.VersionName(state.Version)),
+		attr.Bool("tls.resumed", state.DidResume),
+		attr.Float64("duration_ms", msSince(start)),
+	}
+	if err != nil {
+		attrs = append(attrs, attr.String("error", err.Error()))
+	}
+	ct.span.AddEvent("http.tls.done", attrs...)
+}
+
+func (ct *clientTrace) wroteRequest(info httptrace.WroteRequestInfo) {
+	attrs := []attr.Attr{attr.Float64("elapsed_ms", msSince(ct.start))}
+	if info.Err != nil {
+		attrs = append(attrs, attr.String("error", info.Err.Error()))
+	}
+	ct.span.AddEvent("http.wrote_request", attrs...)
+}
+
+func (ct *clientTrace) gotFirstResponseByte() {
+	ct.span.AddEvent("http.first_byte", attr.Float64("elapsed_ms", msSince(ct.start)))
+}
+
+// msSince returns the milliseconds since start, with microsecond precision.
+func msSince(start time.Time) float64 {
+	return float64(time.Since(start).Microseconds()) / 1000
+}
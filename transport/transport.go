@@ -8,8 +8,12 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"time"
 
 	"github.com/kzs0/bedrock/attr"
+	"github.com/kzs0/bedrock/attr/semconv"
 	"github.com/kzs0/bedrock/trace"
 	httpProp "github.com/kzs0/bedrock/trace/http"
 )
@@ -19,11 +23,18 @@ type Tracer interface {
 	Start(ctx context.Context, name string, opts ...trace.StartSpanOption) (context.Context, *trace.Span)
 }
 
+// Metrics records HTTP client request metrics. Like Tracer, it avoids an import cycle
+// with the bedrock package, which implements it with its metric registry.
+type Metrics interface {
+	// RecordRequest records a completed request: its response or error, and how long it took.
+	RecordRequest(req *http.Request, resp *http.Response, err error, duration time.Duration)
+}
+
 // Transport is an http.RoundTripper that instruments HTTP requests with bedrock.
 // It automatically:
 // - Injects W3C Trace Context headers (traceparent, tracestate)
 // - Starts a client span for each request
-// - Records metrics for request duration and status
+// - Records metrics for request duration and status, if Metrics is set
 //
 // For typical usage, use bedrock.NewClient() or bedrock.Get/Post/Do() instead.
 // This type is exposed for advanced cases where you need direct RoundTripper control.
@@ -35,10 +46,62 @@ type Transport struct {
 	// Tracer is used to create spans. If nil, tracing is disabled.
 	// This is typically set by bedrock.NewClient() or provided via context.
 	Tracer Tracer
+
+	// Metrics records request metrics. If nil, metrics are disabled.
+	// Metrics are recorded whether or not tracing is enabled.
+	Metrics Metrics
+
+	// Attrs are added to the span of each request, after the standard HTTP attributes.
+	Attrs []attr.Attr
+
+	// URL returns the http.url attribute for a request's URL. If nil, SanitizeURL is used,
+	// so query strings and credentials are not recorded. Set it to record the query, or
+	// to template identifiers out of paths.
+	URL func(*url.URL) string
+
+	// Route returns the route template of a request, such as "/users/{id}", to name its
+	// span "GET /users/{id}" like the server's, and record it as http.route. If nil, the
+	// route set on the request's context with ContextWithRoute is used.
+	Route func(*http.Request) string
+
+	// RequestID returns the request ID to forward in the X-Request-ID header of requests
+	// that have none, or "" to forward none. If nil, request IDs are not forwarded.
+	RequestID func(context.Context) string
+
+	// SemConv names span attributes by the OpenTelemetry semantic conventions, such as
+	// http.request.method and url.path, rather than bedrock's names.
+	SemConv bool
+
+	// HTTPTrace records the phases of each request as events on its span, using
+	// net/http/httptrace: DNS lookup, connection setup, TLS handshake, getting a pooled
+	// or new connection, and the time to the first response byte.
+	HTTPTrace bool
 }
 
+// RequestIDHeader is the header request IDs are read from and forwarded in.
+const RequestIDHeader = "X-Request-ID"
+
 // RoundTrip implements http.RoundTripper.
 func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// Forward the request ID, on a copy so the caller's request is left as it was
+	if t.RequestID != nil && req.Header.Get(RequestIDHeader) == "" {
+		if id := t.RequestID(req.Context()); id != "" {
+			req = req.Clone(req.Context())
+			req.Header.Set(RequestIDHeader, id)
+		}
+	}
+
+	if t.Metrics != nil {
+		start := time.Now()
+		resp, err := t.roundTrip(req)
+		t.Metrics.RecordRequest(req, resp, err, time.Since(start))
+		return resp, err
+	}
+	return t.roundTrip(req)
+}
+
+// roundTrip executes the request, in a client span if there is a tracer.
+func (t *Transport) roundTrip(req *http.Request) (*http.Response, error) {
 	ctx := req.Context()
 
 	// Check if we have a tracer
@@ -47,18 +110,24 @@ func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
 		return t.base().RoundTrip(req)
 	}
 
-	// Start a client span for this request
+	// Start a client span for this request, named by its route template if it has one
 	spanName := fmt.Sprintf("HTTP %s", req.Method)
 
+	attrs := []attr.Attr{
+		attr.String(t.key("http.method"), req.Method),
+		attr.String(t.key("http.url"), t.urlAttr(req.URL)),
+		attr.String(t.key("http.host"), req.URL.Host),
+		attr.String(t.key("http.scheme"), req.URL.Scheme),
+		attr.String(t.key("http.target"), req.URL.Path),
+	}
+	if route := t.route(req); route != "" {
+		spanName = req.Method + " " + route
+		attrs = append(attrs, attr.String("http.route", route))
+	}
+	attrs = append(attrs, t.Attrs...)
 	spanCtx, span := t.Tracer.Start(ctx, spanName,
 		trace.WithSpanKind(trace.SpanKindClient),
-		trace.WithAttrs(
-			attr.String("http.method", req.Method),
-			attr.String("http.url", req.URL.String()),
-			attr.String("http.host", req.URL.Host),
-			attr.String("http.scheme", req.URL.Scheme),
-			attr.String("http.target", req.URL.Path),
-		),
+		trace.WithAttrs(attrs...),
 	)
 	defer span.End()
 
@@ -66,6 +135,10 @@ func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
 	prop := &httpProp.Propagator{}
 	_ = prop.Inject(spanCtx, req.Header)
 
+	if t.HTTPTrace {
+		spanCtx = httptrace.WithClientTrace(spanCtx, newClientTrace(span))
+	}
+
 	// Update request context to include span
 	req = req.WithContext(spanCtx)
 
@@ -80,7 +153,7 @@ func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
 	}
 
 	if resp != nil {
-		span.SetAttr(attr.Int("http.status_code", resp.StatusCode))
+		span.SetAttr(attr.Int(t.key("http.status_code"), resp.StatusCode))
 
 		// Mark as error if status code is 4xx or 5xx
 		if resp.StatusCode >= 400 {
@@ -93,6 +166,57 @@ func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
 	return resp, nil
 }
 
+// key returns the name of the span attribute key, as for SemConv.
+func (t *Transport) key(key string) string {
+	if t.SemConv {
+		return semconv.Name(key)
+	}
+	return key
+}
+
+// route returns the route template of req, or "" if it has none.
+func (t *Transport) route(req *http.Request) string {
+	if t.Route != nil {
+		return t.Route(req)
+	}
+	return RouteFromContext(req.Context())
+}
+
+// urlAttr returns the http.url attribute for u.
+func (t *Transport) urlAttr(u *url.URL) string {
+	if t.URL != nil {
+		return t.URL(u)
+	}
+	return SanitizeURL(u)
+}
+
+// SanitizeURL returns u without its user info, query string, and fragment, which can
+// carry credentials and tokens.
+func SanitizeURL(u *url.URL) string {
+	sanitized := *u
+	sanitized.User = nil
+	sanitized.RawQuery = ""
+	sanitized.ForceQuery = false
+	sanitized.Fragment = ""
+	sanitized.RawFragment = ""
+	return sanitized.String()
+}
+
+// routeKey is the context key for a request's route template.
+type routeKey struct{}
+
+// ContextWithRoute returns a context whose client requests are named by the route
+// template route, such as "/users/{id}", rather than only by their method.
+func ContextWithRoute(ctx context.Context, route string) context.Context {
+	return context.WithValue(ctx, routeKey{}, route)
+}
+
+// RouteFromContext returns the route template set with ContextWithRoute, or "".
+func RouteFromContext(ctx context.Context) string {
+	route, _ := ctx.Value(routeKey{}).(string)
+	return route
+}
+
 // base returns the base RoundTripper, defaulting to http.DefaultTransport.
 func (t *Transport) base() http.RoundTripper {
 	if t.Base != nil {